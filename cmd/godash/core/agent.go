@@ -0,0 +1,104 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/j-raghavan/godash/internal/config"
+	"github.com/j-raghavan/godash/internal/metrics"
+	"github.com/j-raghavan/godash/internal/output"
+	"github.com/j-raghavan/godash/internal/units"
+)
+
+// RunAgent runs godash as a headless collector, writing one metric sample
+// per line to w: as JSON, in another output.Formats encoding, or rendered
+// through the template given by spec (as for output.Parse) when spec is set.
+// It stops after count samples, or on interrupt when count is zero.
+func RunAgent(cfg config.Config, count int, format, spec string, w io.Writer) error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	encoder, err := output.NewEncoder(format, spec)
+	if err != nil {
+		return err
+	}
+	applyMemoryBudget(cfg)
+	collector, err := newCollector(cfg)
+	if err != nil {
+		return err
+	}
+	alerts, err := newAlerting(cfg, os.Stderr)
+	if err != nil {
+		return err
+	}
+	alerts.attach(collector)
+	defer func() {
+		if err := collector.SaveBandwidth(); err != nil {
+			fmt.Fprintf(os.Stderr, "Error saving bandwidth usage: %v\n", err)
+		}
+		if err := alerts.close(); err != nil {
+			fmt.Fprintf(os.Stderr, "Error saving alert history: %v\n", err)
+		}
+	}()
+	if err := dropPrivileges(cfg, os.Stderr); err != nil {
+		return err
+	}
+	unitFormat, _ := units.Parse(cfg.Units, cfg.NetworkBits) // checked by newCollector
+	output.SetUnits(unitFormat)
+	loc, _ := cfg.Location()
+	output.SetLocation(loc)
+	if tmpl, ok := encoder.(*output.TemplateEncoder); ok {
+		header, ok, err := tmpl.Header()
+		if err != nil {
+			return err
+		}
+		if ok {
+			if _, err := fmt.Fprintln(w, header); err != nil {
+				return fmt.Errorf("failed to write metrics: %w", err)
+			}
+		}
+	}
+
+	interval := time.Duration(cfg.RefreshInterval) * time.Second
+	if interval <= 0 {
+		interval = time.Second
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for n := 0; count == 0 || n < count; n++ {
+		if n > 0 {
+			select {
+			case <-ticker.C:
+			case <-ctx.Done():
+				return nil
+			}
+		}
+
+		metric, err := collector.Collect()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error collecting metrics: %v\n", err)
+			continue
+		}
+		metric.Timestamp = metric.Timestamp.In(loc)
+		if err := writeRecord(w, encoder, *metric); err != nil {
+			return fmt.Errorf("failed to write metrics: %w", err)
+		}
+	}
+	return nil
+}
+
+// writeRecord encodes metric as one record of the output stream
+func writeRecord(w io.Writer, encoder output.Encoder, metric metrics.Metric) error {
+	record, err := encoder.Encode(metric)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(output.Frame(encoder, record))
+	return err
+}
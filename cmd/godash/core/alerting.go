@@ -0,0 +1,104 @@
+package core
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/j-raghavan/godash/internal/config"
+	"github.com/j-raghavan/godash/internal/export"
+	"github.com/j-raghavan/godash/internal/metrics"
+	"github.com/j-raghavan/godash/internal/rules"
+)
+
+// alertRetention is how long resolved alerts and command runs are kept in
+// alerts.jsonl and commands.jsonl
+const alertRetention = 90 * 24 * time.Hour
+
+// alerting holds the rules engine, alert history and Zabbix exporter that
+// the long-running commands, monitor and agent, attach to their collector.
+// One-shot commands such as check and oneline leave them out so they never
+// fire rules or send notifications.
+type alerting struct {
+	engine  *rules.Engine
+	history *rules.History
+	audit   *rules.Audit
+	zabbix  *export.ZabbixSender
+	log     io.Writer
+}
+
+// newAlerting creates the rules and exporters configured by cfg, writing
+// rule action and send failures to log. Nothing runs until attach.
+func newAlerting(cfg config.Config, log io.Writer) (*alerting, error) {
+	a := &alerting{log: log}
+	if cfg.Zabbix.Enabled {
+		sender, err := newZabbixSender(cfg.Zabbix, log)
+		if err != nil {
+			return nil, err
+		}
+		a.zabbix = sender
+	}
+	if len(cfg.Rules) == 0 {
+		return a, nil
+	}
+	ruleList, err := rules.ParseRules(cfg.Rules)
+	if err != nil {
+		return nil, err
+	}
+	history, err := rules.DefaultHistory()
+	if err != nil {
+		return nil, err
+	}
+	audit, err := rules.DefaultAudit()
+	if err != nil {
+		return nil, err
+	}
+	notifiers, err := rules.ParseNotifiers(cfg.Notifiers)
+	if err != nil {
+		return nil, err
+	}
+	a.engine = rules.NewEngine(ruleList, log)
+	a.engine.SetHistory(history)
+	a.engine.SetAudit(audit)
+	a.engine.SetNotifiers(notifiers)
+	a.engine.SetTags(cfg.Tags)
+	a.engine.SetLowMemory(lowMemoryMode(cfg))
+	a.history = history
+	a.audit = audit
+	return a, nil
+}
+
+// attach exports and evaluates rules on every sample of collector, after
+// resolving alerts left active by an earlier run that was killed and
+// dropping records older than alertRetention
+func (a *alerting) attach(collector *metrics.SystemCollector) {
+	if a.zabbix != nil {
+		collector.AddObserver(a.zabbix.Observe)
+	}
+	if a.engine == nil {
+		return
+	}
+	if err := a.history.Reconcile(); err != nil {
+		fmt.Fprintf(a.log, "alert history: %v\n", err)
+	}
+	cutoff := time.Now().Add(-alertRetention)
+	if err := a.history.Compact(cutoff); err != nil {
+		fmt.Fprintf(a.log, "alert history: %v\n", err)
+	}
+	if err := a.audit.Prune(cutoff); err != nil {
+		fmt.Fprintf(a.log, "command audit: %v\n", err)
+	}
+	collector.AddObserver(func(m metrics.Metric) {
+		a.engine.Evaluate(m)
+	})
+}
+
+// close waits for running actions and notifications, so their audit
+// records are written, then resolves the alerts still active
+func (a *alerting) close() error {
+	if a.engine == nil {
+		return nil
+	}
+	a.engine.Wait()
+	return a.history.Close()
+}
@@ -0,0 +1,57 @@
+package core
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/j-raghavan/godash/internal/config"
+	"github.com/j-raghavan/godash/internal/rules"
+)
+
+// ShowAlerts writes the alerts that were active during the last since
+// (all recorded alerts when since is zero) to w, oldest first, with times
+// in the configured time zone
+func ShowAlerts(cfg config.Config, since time.Duration, w io.Writer) error {
+	loc, err := cfg.Location()
+	if err != nil {
+		return err
+	}
+	history, err := rules.DefaultHistory()
+	if err != nil {
+		return err
+	}
+	if err := history.Reconcile(); err != nil {
+		return err
+	}
+	var from time.Time
+	if since > 0 {
+		from = time.Now().Add(-since)
+	}
+	alerts, err := history.Load(from, time.Time{})
+	if err != nil {
+		return err
+	}
+	if len(alerts) == 0 {
+		_, _ = fmt.Fprintln(w, "No alerts recorded")
+		return nil
+	}
+
+	for _, alert := range alerts {
+		state := "active"
+		switch {
+		case alert.Kind != rules.EventThreshold:
+			state = "event"
+		case alert.Stopped:
+			state = "unknown, godash stopped after " + alert.ResolvedAt.Sub(alert.FiredAt).Round(time.Second).String()
+		case !alert.Active():
+			state = "resolved after " + alert.ResolvedAt.Sub(alert.FiredAt).Round(time.Second).String()
+		}
+		_, _ = fmt.Fprintf(w, "%s  %-16s %s [%s", alert.FiredAt.In(loc).Format(time.RFC3339), alert.Rule, alert.Message, state)
+		if alert.Kind == rules.EventThreshold {
+			_, _ = fmt.Fprintf(w, ", peak %g", alert.Peak)
+		}
+		_, _ = fmt.Fprintln(w, "]")
+	}
+	return nil
+}
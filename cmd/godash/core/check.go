@@ -0,0 +1,131 @@
+package core
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/j-raghavan/godash/internal/config"
+	"github.com/j-raghavan/godash/internal/rules"
+)
+
+// Nagios plugin exit codes
+const (
+	CheckOK       = 0
+	CheckWarning  = 1
+	CheckCritical = 2
+	CheckUnknown  = 3
+)
+
+// checkStatusNames are the status words of the plugin output, by exit code
+var checkStatusNames = []string{"OK", "WARNING", "CRITICAL", "UNKNOWN"}
+
+// checkAliases expands the short metric names accepted by "godash check"
+var checkAliases = map[string]string{
+	"memory": "memory.used_percent",
+	"disk":   "disk.*.used_percent",
+}
+
+// checkSampleWindow is how long a check samples counters to measure CPU
+// usage and rates, which need two samples
+const checkSampleWindow = 500 * time.Millisecond
+
+// CheckOptions holds the thresholds of a check. A zero threshold is not
+// checked.
+type CheckOptions struct {
+	Warn  float64
+	Crit  float64
+	Below bool // alert when values fall below the thresholds, e.g. free space
+}
+
+// RunCheck checks the values matching metric (a value name or pattern as
+// used by rules, or "memory" or "disk") against the thresholds, writes
+// Nagios plugin output to w and returns the plugin exit code
+func RunCheck(cfg config.Config, metric string, opts CheckOptions, w io.Writer) int {
+	values, err := sampleValues(cfg)
+	if err != nil {
+		fmt.Fprintf(w, "UNKNOWN - %v\n", err)
+		return CheckUnknown
+	}
+	status, text := CheckValues(values, metric, opts)
+	fmt.Fprintln(w, text)
+	return status
+}
+
+// sampleValues collects two samples so CPU usage and rates are current, and
+// returns the named values of the second
+func sampleValues(cfg config.Config) (map[string]float64, error) {
+	collector, err := newCollector(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := collector.Collect(); err != nil {
+		return nil, err
+	}
+	time.Sleep(checkSampleWindow)
+	metric, err := collector.Collect()
+	if err != nil {
+		return nil, err
+	}
+	return rules.Values(*metric), nil
+}
+
+// CheckValues evaluates the values matching metric and returns the plugin
+// exit code and output line: the status and values, then perfdata after "|"
+func CheckValues(values map[string]float64, metric string, opts CheckOptions) (int, string) {
+	pattern := metric
+	if alias, ok := checkAliases[metric]; ok {
+		pattern = alias
+	}
+	matched, err := rules.Select(values, pattern)
+	if err != nil {
+		return CheckUnknown, fmt.Sprintf("UNKNOWN - %v", err)
+	}
+	if len(matched) == 0 {
+		return CheckUnknown, fmt.Sprintf("UNKNOWN - no metric matches %q", metric)
+	}
+
+	status := CheckOK
+	var summary, perfdata []string
+	for _, name := range rules.Names(matched) {
+		v := matched[name]
+		switch {
+		case opts.Crit != 0 && beyond(v, opts.Crit, opts.Below):
+			status = CheckCritical
+		case opts.Warn != 0 && beyond(v, opts.Warn, opts.Below) && status < CheckWarning:
+			status = CheckWarning
+		}
+		summary = append(summary, fmt.Sprintf("%s %s", name, formatValue(v)))
+		perfdata = append(perfdata, perfdataItem(name, v, opts))
+	}
+	return status, fmt.Sprintf("%s - %s | %s", checkStatusNames[status],
+		strings.Join(summary, ", "), strings.Join(perfdata, " "))
+}
+
+// beyond reports whether v crossed threshold
+func beyond(v, threshold float64, below bool) bool {
+	if below {
+		return v < threshold
+	}
+	return v > threshold
+}
+
+// perfdataItem formats one value as 'label'=value[UOM];warn;crit;min;max
+func perfdataItem(name string, v float64, opts CheckOptions) string {
+	threshold := func(t float64) string {
+		if t == 0 {
+			return ""
+		}
+		if opts.Below {
+			return formatValue(t) + ":" // alert outside t..infinity
+		}
+		return formatValue(t)
+	}
+	uom, bounds := "", ";"
+	if name == "cpu" || strings.HasPrefix(name, "cpu.") || strings.HasSuffix(name, "_percent") {
+		uom, bounds = "%", "0;100"
+	}
+	return fmt.Sprintf("'%s'=%s%s;%s;%s;%s", name, formatValue(v), uom,
+		threshold(opts.Warn), threshold(opts.Crit), bounds)
+}
@@ -2,34 +2,304 @@ package core
 
 import (
 	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"strings"
 	"time"
 
+	"github.com/j-raghavan/godash/internal/bandwidth"
 	"github.com/j-raghavan/godash/internal/config"
+	"github.com/j-raghavan/godash/internal/export"
+	"github.com/j-raghavan/godash/internal/i18n"
+	"github.com/j-raghavan/godash/internal/jobs"
 	"github.com/j-raghavan/godash/internal/metrics"
-	"github.com/j-raghavan/godash/internal/tui"
+	"github.com/j-raghavan/godash/internal/rules"
+	"github.com/j-raghavan/godash/internal/units"
 )
 
-// RunMonitor contains the actual monitor logic
-func RunMonitor(cfg config.Config) {
-	fmt.Printf("Starting GoDash monitor with refresh interval: %ds\n", cfg.RefreshInterval)
-	if cfg.EnableGoRuntime {
-		fmt.Println("Go runtime metrics enabled.")
-	} else {
-		fmt.Println("Go runtime metrics disabled.")
-	}
+// defaultWANInterval is how often the public IP is looked up by default
+const defaultWANInterval = 5 * time.Minute
+
+// defaultZabbixInterval is how often metrics are sent to Zabbix by default
+const defaultZabbixInterval = time.Minute
 
-	// Create a new metrics collector
+// Adaptive sampling defaults
+const (
+	defaultAdaptiveCPUThreshold = 80
+	defaultAdaptiveSelfBudget   = 1
+	defaultAdaptiveMaxInterval  = 2 * time.Second
+)
+
+// newCollector creates a metrics collector configured from cfg. Rules and
+// exporters are not attached; see newAlerting.
+func newCollector(cfg config.Config) (*metrics.SystemCollector, error) {
 	collector := metrics.NewSystemCollector()
+	collector.SetDisplayNames(cfg.DisplayNames)
+	for name, value := range cfg.Tags {
+		if name == "" || strings.ContainsAny(name+value, " ,=\"") {
+			return nil, fmt.Errorf("invalid tag %s=%q: names and values may not contain spaces, commas, '=' or quotes", name, value)
+		}
+	}
+	collector.SetTags(cfg.Tags)
+	if _, err := units.Parse(cfg.Units, cfg.NetworkBits); err != nil {
+		return nil, err
+	}
+	if _, err := cfg.Location(); err != nil {
+		return nil, err
+	}
+	lowMemory, err := cfg.LowMemory(metrics.IsLowMemorySystem())
+	if err != nil {
+		return nil, err
+	}
+	collector.SetLowMemory(lowMemory)
+	if cfg.Locale != "" && !i18n.Has(cfg.Locale) {
+		return nil, fmt.Errorf("unsupported locale %q: available languages are %s",
+			cfg.Locale, strings.Join(i18n.Languages(), ", "))
+	}
+	if cfg.Privileges.User != "" {
+		if _, _, err := lookupPrivilegeUser(cfg.Privileges); err != nil {
+			return nil, err
+		}
+	}
+	collector.SetLibvirt(cfg.EnableLibvirt)
+	collector.SetTunnels(cfg.EnableWireGuard, cfg.OpenVPNStatus)
+	if cfg.WAN.Enabled {
+		interval := time.Duration(cfg.WAN.Interval) * time.Second
+		if interval <= 0 {
+			interval = defaultWANInterval
+		}
+		collector.SetWAN(cfg.WAN.Providers, interval, cfg.WAN.Gateway)
+	}
+	if cfg.LAN.Enabled {
+		collector.SetLAN(cfg.LAN.DHCPLeases, cfg.LAN.KnownMACs)
+	}
+	if cfg.DNS.Enabled {
+		source := cfg.DNS.Type
+		if source == "" {
+			source = metrics.DNSPihole
+		}
+		collector.SetDNS(source, cfg.DNS.Address, cfg.DNS.Username, cfg.DNS.Password)
+	}
+	if len(cfg.BMCs) > 0 {
+		bmcs := make([]metrics.BMC, 0, len(cfg.BMCs))
+		for _, b := range cfg.BMCs {
+			name := b.Name
+			if name == "" {
+				name = b.Address
+			}
+			bmcs = append(bmcs, metrics.BMC{
+				Name:     name,
+				Type:     b.Type,
+				Address:  b.Address,
+				Username: b.Username,
+				Password: b.Password,
+				Insecure: b.Insecure,
+			})
+		}
+		collector.SetBMCs(bmcs)
+	}
+	if len(cfg.GoApps) > 0 {
+		apps := make([]metrics.GoApp, 0, len(cfg.GoApps))
+		for _, a := range cfg.GoApps {
+			name := a.Name
+			if name == "" {
+				name = a.URL
+			}
+			apps = append(apps, metrics.GoApp{Name: name, URL: a.URL})
+		}
+		collector.SetGoApps(apps)
+	}
+	if len(cfg.Jobs) > 0 {
+		jobList, err := jobs.ParseJobs(cfg.Jobs)
+		if err != nil {
+			return nil, err
+		}
+		store, err := jobStore()
+		if err != nil {
+			return nil, err
+		}
+		collector.SetJobs(jobList, store)
+	}
+	if cfg.Bandwidth.Enabled {
+		accountant, err := newAccountant(cfg)
+		if err != nil {
+			return nil, err
+		}
+		collector.SetBandwidth(accountant, cfg.Bandwidth.Interfaces)
+	}
+	if len(cfg.Watch.Process) > 0 {
+		watches := make([]metrics.ProcessWatch, 0, len(cfg.Watch.Process))
+		for _, w := range cfg.Watch.Process {
+			if w.Name == "" {
+				return nil, fmt.Errorf("process watch is missing a name")
+			}
+			watch := metrics.ProcessWatch{Name: w.Name, MinCount: w.MinCount, MaxCPU: w.MaxCPU}
+			if w.MaxMemory != "" {
+				max, err := units.ParseBytes(w.MaxMemory)
+				if err != nil {
+					return nil, fmt.Errorf("process watch %s: %w", w.Name, err)
+				}
+				watch.MaxMemory = max
+			}
+			watches = append(watches, watch)
+		}
+		collector.SetProcessWatches(watches, cfg.Watch.SmapsMemory)
+	}
+	if len(cfg.Watch.Port) > 0 || cfg.Watch.NewPorts {
+		watches := make([]metrics.PortWatch, 0, len(cfg.Watch.Port))
+		for _, w := range cfg.Watch.Port {
+			watch := metrics.PortWatch{Port: w.Port, Protocol: w.Protocol, Expect: w.Expect}
+			if watch.Protocol == "" {
+				watch.Protocol = "tcp"
+			}
+			if watch.Expect == "" {
+				watch.Expect = metrics.PortListening
+			}
+			switch {
+			case w.Port < 1 || w.Port > 65535:
+				return nil, fmt.Errorf("port watch has invalid port %d", w.Port)
+			case watch.Protocol != "tcp" && watch.Protocol != "udp":
+				return nil, fmt.Errorf("port watch %d: unknown protocol %q", w.Port, w.Protocol)
+			case watch.Expect != metrics.PortListening && watch.Expect != metrics.PortClosed:
+				return nil, fmt.Errorf("port watch %d: expect must be listening or closed", w.Port)
+			}
+			watches = append(watches, watch)
+		}
+		collector.SetPortWatches(watches, cfg.Watch.NewPorts)
+	}
+	if len(cfg.Watch.Cert) > 0 {
+		checks := make([]metrics.CertCheck, 0, len(cfg.Watch.Cert))
+		for _, w := range cfg.Watch.Cert {
+			if (w.Address == "") == (w.File == "") {
+				return nil, fmt.Errorf("cert watch %s needs either an address or a file", w.Name)
+			}
+			name := w.Name
+			if name == "" {
+				name = w.Address + w.File
+			}
+			checks = append(checks, metrics.CertCheck{
+				Name:       name,
+				Address:    w.Address,
+				ServerName: w.ServerName,
+				File:       w.File,
+				WarnDays:   w.WarnDays,
+			})
+		}
+		collector.SetCertChecks(checks)
+	}
+	if cfg.Adaptive.Enabled {
+		adaptive := metrics.Adaptive{
+			CPUThreshold: cfg.Adaptive.CPUThreshold,
+			SelfBudget:   cfg.Adaptive.SelfBudget,
+			MaxInterval:  defaultAdaptiveMaxInterval,
+		}
+		if adaptive.CPUThreshold <= 0 {
+			adaptive.CPUThreshold = defaultAdaptiveCPUThreshold
+		}
+		if adaptive.SelfBudget <= 0 {
+			adaptive.SelfBudget = defaultAdaptiveSelfBudget
+		}
+		if cfg.Adaptive.MaxInterval != "" {
+			max, err := time.ParseDuration(cfg.Adaptive.MaxInterval)
+			if err != nil || max <= 0 {
+				return nil, fmt.Errorf("invalid adaptive max_interval %q", cfg.Adaptive.MaxInterval)
+			}
+			adaptive.MaxInterval = max
+		}
+		collector.SetAdaptive(adaptive)
+	}
+	return collector, nil
+}
+
+// newAccountant creates the transfer accountant configured by cfg
+func newAccountant(cfg config.Config) (*bandwidth.Accountant, error) {
+	quota := bandwidth.Quota{ResetDay: cfg.Bandwidth.ResetDay, AlertPercent: cfg.Bandwidth.AlertPercent}
+	if quota.ResetDay == 0 {
+		quota.ResetDay = 1
+	}
+	if quota.ResetDay < 1 || quota.ResetDay > 28 {
+		return nil, fmt.Errorf("invalid bandwidth reset_day %d: must be 1 to 28", cfg.Bandwidth.ResetDay)
+	}
+	for _, percent := range quota.AlertPercent {
+		if percent <= 0 {
+			return nil, fmt.Errorf("invalid bandwidth alert_percent %g", percent)
+		}
+	}
+	if cfg.Bandwidth.MonthlyQuota != "" {
+		limit, err := units.ParseBytes(cfg.Bandwidth.MonthlyQuota)
+		if err != nil || limit == 0 {
+			return nil, fmt.Errorf("invalid bandwidth monthly_quota %q", cfg.Bandwidth.MonthlyQuota)
+		}
+		quota.Limit = limit
+	}
+	store, err := bandwidth.DefaultStore()
+	if err != nil {
+		return nil, err
+	}
+	loc, _ := cfg.Location() // checked by newCollector
+	return bandwidth.NewAccountant(store, quota, loc), nil
+}
+
+// newZabbixSender creates the Zabbix exporter configured by cfg
+func newZabbixSender(cfg config.ZabbixConfig, log io.Writer) (*export.ZabbixSender, error) {
+	if cfg.Server == "" {
+		return nil, fmt.Errorf("zabbix server is not set")
+	}
+	if len(cfg.Items) == 0 {
+		return nil, fmt.Errorf("zabbix has no items")
+	}
+	interval := defaultZabbixInterval
+	if cfg.Interval != "" {
+		d, err := time.ParseDuration(cfg.Interval)
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("invalid zabbix interval %q", cfg.Interval)
+		}
+		interval = d
+	}
+	items := make([]export.ZabbixItem, 0, len(cfg.Items))
+	for metric, key := range cfg.Items {
+		if _, err := rules.Select(nil, metric); err != nil {
+			return nil, fmt.Errorf("zabbix item %s: %w", metric, err)
+		}
+		items = append(items, export.ZabbixItem{Metric: metric, Key: key})
+	}
+	return export.NewZabbixSender(cfg.Server, cfg.Host, items, interval, log), nil
+}
 
-	// Create a new UI instance
-	ui := tui.NewUI(collector, cfg.EnableGoRuntime)
+// openRuleLog opens rules.log in the data directory for appending, for use
+// while the terminal UI owns the screen
+func openRuleLog() (*os.File, error) {
+	dir, err := config.DataDir()
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create data directory: %w", err)
+	}
+	return os.OpenFile(filepath.Join(dir, "rules.log"), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+}
 
-	// Start the UI with the configured refresh interval
-	refreshInterval := time.Duration(cfg.RefreshInterval) * time.Second
-	if err := ui.Start(refreshInterval); err != nil {
-		fmt.Printf("Error starting UI: %v\n", err)
-		return
+// lowMemoryLimit is the soft heap limit applied in low-memory mode
+const lowMemoryLimit = 32 * 1024 * 1024
+
+// lowMemoryMode reports whether cfg and the host call for the low-memory
+// mode
+func lowMemoryMode(cfg config.Config) bool {
+	lowMemory, _ := cfg.LowMemory(metrics.IsLowMemorySystem()) // checked by newCollector
+	return lowMemory
+}
+
+// applyMemoryBudget tunes the Go runtime for hosts with little RAM and
+// reports whether the low-memory mode is active.
+func applyMemoryBudget(cfg config.Config) bool {
+	if !lowMemoryMode(cfg) {
+		return false
 	}
+	debug.SetGCPercent(50)
+	debug.SetMemoryLimit(lowMemoryLimit)
+	return true
 }
 
 // RunServer contains the actual server logic
@@ -43,8 +313,3 @@ func RunServer(cfg config.Config) {
 	// This is where you would initialize and start the web server
 	fmt.Println("Web server would start here (implementation pending)")
 }
-
-// ShowVersion displays version info
-func ShowVersion() string {
-	return "GoDash v0.1.0"
-}
@@ -0,0 +1,65 @@
+//go:build !agent
+
+package core
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/j-raghavan/godash/internal/config"
+	"github.com/j-raghavan/godash/internal/metrics"
+	"github.com/j-raghavan/godash/internal/rules"
+	"github.com/j-raghavan/godash/internal/units"
+)
+
+// demoRules fire on the demo host's CPU bursts, filling disk and wlan0
+// outages, so the alerts pane has something to show
+var demoRules = []config.RuleConfig{
+	{Name: "cpu-burst", When: "cpu > 90", For: "3s"},
+	{Name: "data-disk-full", When: "disk./data.used_percent > 90"},
+	{Name: "wlan-down", Event: rules.EventInterfaceDown, Match: "wlan0"},
+}
+
+// RunDemo runs the terminal UI on a synthetic host instead of this one,
+// with alerts from built-in rules. Display settings such as units and
+// colors come from cfg; collectors and rules there are ignored, and alerts
+// are not added to the alert history.
+func RunDemo(cfg config.Config) {
+	if _, err := units.Parse(cfg.Units, cfg.NetworkBits); err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return
+	}
+	if _, err := cfg.Location(); err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return
+	}
+	dir, err := os.MkdirTemp("", "godash-demo-")
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return
+	}
+	defer os.RemoveAll(dir)
+
+	ruleList, err := rules.ParseRules(demoRules)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return
+	}
+	history := rules.NewHistory(filepath.Join(dir, "alerts.jsonl"))
+	engine := rules.NewEngine(ruleList, io.Discard)
+	engine.SetHistory(history)
+	collector := metrics.NewDemoCollector(time.Now())
+	collector.AddObserver(func(m metrics.Metric) {
+		engine.Evaluate(m)
+	})
+
+	ui, err := newUI(cfg, collector, history, false)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return
+	}
+	startUI(ui, cfg)
+}
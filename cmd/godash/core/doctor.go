@@ -0,0 +1,282 @@
+package core
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+
+	"github.com/shirou/gopsutil/v3/disk"
+	"github.com/shirou/gopsutil/v3/host"
+
+	"github.com/j-raghavan/godash/internal/config"
+	"github.com/j-raghavan/godash/internal/metrics"
+)
+
+// Doctor check results
+const (
+	DoctorPass = "PASS"
+	DoctorWarn = "WARN"
+	DoctorFail = "FAIL"
+	DoctorSkip = "SKIP"
+)
+
+// dockerSocket is where the Docker daemon listens by default
+const dockerSocket = "/var/run/docker.sock"
+
+// DoctorCheck is the outcome of one diagnostic
+type DoctorCheck struct {
+	Name   string
+	Status string
+	Detail string
+	Hint   string // what to do about a warning or failure
+}
+
+// RunDoctor checks godash's runtime prerequisites, prints one line per
+// check to w and returns 1 if any check failed. loadErr is the error of
+// loading the config file, if it failed.
+func RunDoctor(cfg config.Config, loadErr error, w io.Writer) int {
+	code := 0
+	for _, check := range DoctorChecks(cfg, loadErr) {
+		fmt.Fprintf(w, "[%s] %-10s %s\n", check.Status, check.Name, check.Detail)
+		if check.Hint != "" {
+			fmt.Fprintf(w, "       %-10s → %s\n", "", check.Hint)
+		}
+		if check.Status == DoctorFail {
+			code = 1
+		}
+	}
+	return code
+}
+
+// DoctorChecks runs every diagnostic
+func DoctorChecks(cfg config.Config, loadErr error) []DoctorCheck {
+	check, collector := checkConfig(cfg, loadErr)
+	checks := []DoctorCheck{check}
+	if collector != nil {
+		checks = append(checks, checkCollectors(collector)...)
+	}
+	checks = append(checks,
+		checkProc(),
+		checkDisks(),
+		checkSensors(),
+		checkDocker(),
+		checkWebPort(cfg.WebPort),
+		checkDataDir(),
+	)
+	if cfg.Privileges.User != "" {
+		checks = append(checks, checkPrivileges(cfg))
+	}
+	if cfg.Watch.SmapsMemory && runtime.GOOS == "linux" && os.Geteuid() != 0 {
+		checks = append(checks, DoctorCheck{
+			Name: "smaps", Status: DoctorWarn,
+			Detail: "PSS/USS of other users' processes cannot be read without root",
+			Hint:   "run as root or the processes' owner, or disable smaps_memory",
+		})
+	}
+	return checks
+}
+
+// checkConfig reports whether the config file loads and its settings are
+// valid, returning the configured collector when they are
+func checkConfig(cfg config.Config, loadErr error) (DoctorCheck, *metrics.SystemCollector) {
+	check := DoctorCheck{Name: "config"}
+	detail := "settings are valid"
+	if cfg.ConfigFile != "" {
+		detail = cfg.ConfigFile + " is valid"
+	}
+	if loadErr != nil {
+		check.Status, check.Detail = DoctorFail, loadErr.Error()
+		check.Hint = "fix the file, or point --config at another one"
+		return check, nil
+	}
+	collector, err := newCollector(cfg)
+	if err == nil {
+		_, err = newAlerting(cfg, io.Discard)
+	}
+	if err != nil {
+		check.Status, check.Detail = DoctorFail, err.Error()
+		check.Hint = "see godash.toml.example for the expected settings"
+		return check, nil
+	}
+	check.Status, check.Detail = DoctorPass, detail
+	return check, collector
+}
+
+// checkCollectors collects a sample and reports each failing collector
+func checkCollectors(collector *metrics.SystemCollector) []DoctorCheck {
+	metric, err := collector.Collect()
+	if err != nil {
+		return []DoctorCheck{{Name: "collect", Status: DoctorFail, Detail: err.Error()}}
+	}
+	if len(metric.Errors) == 0 {
+		return []DoctorCheck{{Name: "collect", Status: DoctorPass, Detail: "every collector returned data"}}
+	}
+	names := make([]string, 0, len(metric.Errors))
+	for name := range metric.Errors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	checks := make([]DoctorCheck, 0, len(names))
+	for _, name := range names {
+		checks = append(checks, DoctorCheck{
+			Name: "collect", Status: DoctorWarn,
+			Detail: fmt.Sprintf("%s collector failed: %s", name, metric.Errors[name]),
+			Hint:   "its pane and values stay empty until this is fixed",
+		})
+	}
+	return checks
+}
+
+// checkProc reports whether the /proc files behind CPU, memory and network
+// stats are readable
+func checkProc() DoctorCheck {
+	check := DoctorCheck{Name: "proc"}
+	if runtime.GOOS != "linux" {
+		check.Status, check.Detail = DoctorSkip, "no /proc on "+runtime.GOOS
+		return check
+	}
+	var unreadable []string
+	for _, path := range []string{"/proc/stat", "/proc/meminfo", "/proc/net/dev", "/proc/mounts"} {
+		f, err := os.Open(path)
+		if err != nil {
+			unreadable = append(unreadable, path)
+			continue
+		}
+		f.Close()
+	}
+	if len(unreadable) > 0 {
+		check.Status, check.Detail = DoctorFail, "cannot read "+strings.Join(unreadable, ", ")
+		check.Hint = "in a container, mount the host's /proc; check hidepid mount options"
+		return check
+	}
+	check.Status, check.Detail = DoctorPass, "/proc is readable"
+	return check
+}
+
+// checkDisks reports whether mounted filesystems can be listed and stat'ed
+func checkDisks() DoctorCheck {
+	check := DoctorCheck{Name: "disks"}
+	partitions, err := disk.Partitions(false)
+	if err != nil || len(partitions) == 0 {
+		check.Status, check.Detail = DoctorFail, "no mounted filesystems found"
+		if err != nil {
+			check.Detail = fmt.Sprintf("cannot list filesystems: %v", err)
+		}
+		check.Hint = "in a container, mount the host filesystems read-only"
+		return check
+	}
+	var failed []string
+	for _, p := range partitions {
+		if _, err := disk.Usage(p.Mountpoint); err != nil {
+			failed = append(failed, p.Mountpoint)
+		}
+	}
+	if len(failed) > 0 {
+		check.Status = DoctorWarn
+		check.Detail = fmt.Sprintf("%d of %d filesystems cannot be stat'ed: %s",
+			len(failed), len(partitions), strings.Join(failed, ", "))
+		check.Hint = "they are shown empty; run as a user that can access them"
+		return check
+	}
+	check.Status, check.Detail = DoctorPass, fmt.Sprintf("%d filesystems readable", len(partitions))
+	return check
+}
+
+// checkSensors reports whether temperature sensors are exposed
+func checkSensors() DoctorCheck {
+	check := DoctorCheck{Name: "sensors"}
+	temps, _ := host.SensorsTemperatures()
+	if len(temps) == 0 {
+		check.Status, check.Detail = DoctorWarn, "no temperature sensors found"
+		check.Hint = "load the hwmon drivers, e.g. with lm-sensors' sensors-detect"
+		return check
+	}
+	check.Status, check.Detail = DoctorPass, fmt.Sprintf("%d temperature sensors", len(temps))
+	return check
+}
+
+// checkDocker reports whether the Docker socket can be used, if present
+func checkDocker() DoctorCheck {
+	check := DoctorCheck{Name: "docker"}
+	socket := dockerSocket
+	if path, ok := strings.CutPrefix(os.Getenv("DOCKER_HOST"), "unix://"); ok {
+		socket = path
+	}
+	if _, err := os.Stat(socket); err != nil {
+		check.Status, check.Detail = DoctorSkip, "no Docker socket at "+socket
+		return check
+	}
+	conn, err := net.Dial("unix", socket)
+	if err != nil {
+		check.Status, check.Detail = DoctorFail, fmt.Sprintf("cannot connect to %s: %v", socket, err)
+		check.Hint = "add this user to the docker group"
+		return check
+	}
+	conn.Close()
+	check.Status, check.Detail = DoctorPass, socket+" is accessible"
+	return check
+}
+
+// checkWebPort reports whether the dashboard port is free
+func checkWebPort(port int) DoctorCheck {
+	check := DoctorCheck{Name: "port"}
+	ln, err := net.Listen("tcp", fmt.Sprintf(":%d", port))
+	if err != nil {
+		check.Status, check.Detail = DoctorWarn, fmt.Sprintf("web port %d is unavailable: %v", port, err)
+		check.Hint = "pick another with --port or web_port"
+		return check
+	}
+	ln.Close()
+	check.Status, check.Detail = DoctorPass, fmt.Sprintf("web port %d is free", port)
+	return check
+}
+
+// checkDataDir reports whether state files can be written
+func checkDataDir() DoctorCheck {
+	check := DoctorCheck{Name: "data"}
+	dir, err := writableDataDir()
+	if err != nil {
+		check.Status, check.Detail = DoctorFail, fmt.Sprintf("data directory is not writable: %v", err)
+		check.Hint = "alert history and snapshots are lost; set --data-dir"
+		return check
+	}
+	check.Status, check.Detail = DoctorPass, filepath.Clean(dir)+" is writable"
+	return check
+}
+
+// writableDataDir creates the data directory if needed and checks that
+// files can be created in it
+func writableDataDir() (string, error) {
+	dir, err := config.DataDir()
+	if err != nil {
+		return dir, err
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return dir, err
+	}
+	f, err := os.CreateTemp(dir, ".doctor-*")
+	if err != nil {
+		return dir, err
+	}
+	f.Close()
+	os.Remove(f.Name())
+	return dir, nil
+}
+
+// checkPrivileges reports the user godash drops to and the collectors that
+// lose access by it
+func checkPrivileges(cfg config.Config) DoctorCheck {
+	check := DoctorCheck{Name: "privileges"}
+	if warnings := PrivilegeWarnings(cfg); len(warnings) > 0 {
+		check.Status, check.Detail = DoctorWarn, strings.Join(warnings, "; ")
+		check.Hint = "grant the access, e.g. with systemd AmbientCapabilities=, or disable those collectors"
+		return check
+	}
+	check.Status, check.Detail = DoctorPass, "drops to "+cfg.Privileges.User+" after startup as root"
+	return check
+}
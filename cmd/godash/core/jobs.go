@@ -0,0 +1,89 @@
+package core
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"time"
+
+	"github.com/j-raghavan/godash/internal/config"
+	"github.com/j-raghavan/godash/internal/jobs"
+)
+
+// RecordJob records that the named job has just completed
+func RecordJob(cfg config.Config, name string) error {
+	known := false
+	for _, job := range cfg.Jobs {
+		if job.Name == name {
+			known = true
+			break
+		}
+	}
+	if !known {
+		return fmt.Errorf("unknown job %q, add it to the [[jobs]] section of the config", name)
+	}
+
+	store, err := jobStore()
+	if err != nil {
+		return err
+	}
+	return store.RecordRun(name, time.Now())
+}
+
+// ShowJobs writes the status of all configured jobs to w and reports
+// whether any of them is overdue.
+func ShowJobs(cfg config.Config, w io.Writer) (bool, error) {
+	jobList, err := jobs.ParseJobs(cfg.Jobs)
+	if err != nil {
+		return false, err
+	}
+	loc, err := cfg.Location()
+	if err != nil {
+		return false, err
+	}
+	store, err := jobStore()
+	if err != nil {
+		return false, err
+	}
+	// Only monitors watch jobs; a status query leaves the state untouched
+	watched, err := store.Watched()
+	if err != nil {
+		return false, err
+	}
+	lastRuns, err := store.LastRuns()
+	if err != nil {
+		return false, err
+	}
+
+	overdue := false
+	for _, status := range jobs.Check(jobList, lastRuns, watched, time.Now()) {
+		switch {
+		case status.LastRun.IsZero() && status.Overdue:
+			overdue = true
+			_, _ = fmt.Fprintf(w, "%-20s OVERDUE by %s (never reported)\n", status.Name,
+				status.Late.Round(time.Second))
+		case status.LastRun.IsZero() && status.NextDue.IsZero():
+			_, _ = fmt.Fprintf(w, "%-20s never reported, not watched by a monitor yet\n", status.Name)
+		case status.LastRun.IsZero():
+			_, _ = fmt.Fprintf(w, "%-20s never reported (due %s)\n", status.Name,
+				status.NextDue.In(loc).Format(time.RFC3339))
+		case status.Overdue:
+			overdue = true
+			_, _ = fmt.Fprintf(w, "%-20s OVERDUE by %s (last run %s)\n", status.Name,
+				status.Late.Round(time.Second), status.LastRun.In(loc).Format(time.RFC3339))
+		default:
+			_, _ = fmt.Fprintf(w, "%-20s ok (next due %s)\n", status.Name,
+				status.NextDue.In(loc).Format(time.RFC3339))
+		}
+	}
+	return overdue, nil
+}
+
+// jobStore returns the job state store in the godash data directory
+func jobStore() (*jobs.Store, error) {
+	dir, err := config.DataDir()
+	if err != nil {
+		return nil, err
+	}
+	return jobs.NewStore(filepath.Join(dir, "jobs.json")), nil
+}
@@ -0,0 +1,173 @@
+//go:build !agent
+
+package core
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/j-raghavan/godash/internal/config"
+	"github.com/j-raghavan/godash/internal/i18n"
+	"github.com/j-raghavan/godash/internal/metrics"
+	"github.com/j-raghavan/godash/internal/rules"
+	"github.com/j-raghavan/godash/internal/tui"
+	"github.com/j-raghavan/godash/internal/units"
+)
+
+// RunMonitor contains the actual monitor logic
+func RunMonitor(cfg config.Config) {
+	fmt.Printf("Starting GoDash monitor with refresh interval: %ds\n", cfg.RefreshInterval)
+	if cfg.EnableGoRuntime {
+		fmt.Println("Go runtime metrics enabled.")
+	} else {
+		fmt.Println("Go runtime metrics disabled.")
+	}
+
+	lowMemory := applyMemoryBudget(cfg)
+	if lowMemory {
+		fmt.Println("Low-memory mode enabled.")
+	}
+
+	// Rule action failures go to a log file since the UI owns the terminal
+	ruleLog := io.Discard
+	if len(cfg.Rules) > 0 {
+		f, err := openRuleLog()
+		if err != nil {
+			fmt.Printf("Error opening rule log: %v\n", err)
+			return
+		}
+		defer f.Close()
+		ruleLog = f
+	}
+
+	// Create a new metrics collector
+	collector, err := newCollector(cfg)
+	if err != nil {
+		fmt.Printf("Error creating collector: %v\n", err)
+		return
+	}
+	alerts, err := newAlerting(cfg, ruleLog)
+	if err != nil {
+		fmt.Printf("Error setting up rules: %v\n", err)
+		return
+	}
+	alerts.attach(collector)
+	defer func() {
+		if err := collector.SaveBandwidth(); err != nil {
+			fmt.Printf("Error saving bandwidth usage: %v\n", err)
+		}
+		if err := alerts.close(); err != nil {
+			fmt.Printf("Error saving alert history: %v\n", err)
+		}
+	}()
+
+	if err := dropPrivileges(cfg, os.Stdout); err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return
+	}
+
+	ui, err := newUI(cfg, collector, alerts.history, lowMemory)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return
+	}
+	startUI(ui, cfg)
+}
+
+// newUI creates the terminal UI over collector, configured by cfg
+func newUI(cfg config.Config, collector metrics.Collector, history *rules.History, lowMemory bool) (*tui.UI, error) {
+	ui := tui.NewUI(collector, cfg.EnableGoRuntime)
+	ui.SetPinned(cfg.PinnedDisks, cfg.PinnedInterfaces)
+	ui.SetLowMemory(lowMemory)
+	format, _ := units.Parse(cfg.Units, cfg.NetworkBits) // checked by newCollector
+	ui.SetUnits(format)
+	locale := cfg.Locale
+	if locale == "" {
+		locale = i18n.Detect()
+	}
+	ui.SetLocale(i18n.New(locale))
+	colorMode := cfg.ColorMode
+	if colorMode == "" && os.Getenv("NO_COLOR") != "" {
+		colorMode = tui.ColorNone
+	}
+	colorMode, err := tui.ParseColorMode(colorMode)
+	if err != nil {
+		return nil, err
+	}
+	ui.SetColorMode(colorMode)
+	loc, _ := cfg.Location() // checked by newCollector
+	ui.SetLocation(loc)
+	cadence, err := tuiCadence(cfg.TUI)
+	if err != nil {
+		return nil, err
+	}
+	ui.SetCadence(cadence)
+	if history != nil {
+		ui.SetAlertHistory(history)
+	}
+	dataDir, err := config.DataDir()
+	if err != nil {
+		return nil, fmt.Errorf("failed to locate data directory: %w", err)
+	}
+	snapshotDir := cfg.SnapshotDir
+	if snapshotDir == "" {
+		snapshotDir = filepath.Join(dataDir, "snapshots")
+	}
+	ui.SetSnapshotDir(snapshotDir)
+	ui.SetCrashDir(filepath.Join(dataDir, "crashes"))
+	if cfg.TUI.Record != "" {
+		host, _ := os.Hostname()
+		recorder, err := tui.NewRecorder(cfg.TUI.Record, "godash on "+host)
+		if err != nil {
+			return nil, err
+		}
+		fmt.Printf("Recording to %s and %s\n", recorder.Path(), tui.MetricsRecordingPath(recorder.Path()))
+		ui.SetRecorder(recorder)
+	}
+	return ui, nil
+}
+
+// startUI runs ui at the configured refresh interval until it quits
+func startUI(ui *tui.UI, cfg config.Config) {
+	refreshInterval := time.Duration(cfg.RefreshInterval) * time.Second
+	if err := ui.Start(refreshInterval); err != nil {
+		var crash *tui.CrashError
+		if errors.As(err, &crash) {
+			fmt.Fprintln(os.Stderr, err)
+			return
+		}
+		fmt.Printf("Error starting UI: %v\n", err)
+	}
+}
+
+// tuiCadence parses the TUI's redraw settings
+func tuiCadence(cfg config.TUIConfig) (tui.Cadence, error) {
+	cadence := tui.Cadence{MaxFPS: cfg.MaxFPS}
+	for _, d := range []struct {
+		name  string
+		value string
+		into  *time.Duration
+	}{
+		{"memory_interval", cfg.MemoryInterval, &cadence.Memory},
+		{"network_interval", cfg.NetworkInterval, &cadence.Network},
+		{"interface_interval", cfg.InterfaceInterval, &cadence.Interfaces},
+		{"smoothing", cfg.Smoothing, &cadence.Smoothing},
+	} {
+		if d.value == "" {
+			continue
+		}
+		interval, err := time.ParseDuration(d.value)
+		if err != nil || interval <= 0 {
+			return tui.Cadence{}, fmt.Errorf("invalid tui %s %q", d.name, d.value)
+		}
+		*d.into = interval
+	}
+	if cfg.MaxFPS < 0 {
+		return tui.Cadence{}, fmt.Errorf("invalid tui max_fps %d", cfg.MaxFPS)
+	}
+	return cadence, nil
+}
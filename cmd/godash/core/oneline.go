@@ -0,0 +1,82 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/j-raghavan/godash/internal/config"
+	"github.com/j-raghavan/godash/internal/output"
+	"github.com/j-raghavan/godash/internal/units"
+)
+
+// onelineRateWindow is how long a single-shot summary samples network
+// counters to measure throughput
+const onelineRateWindow = 500 * time.Millisecond
+
+// RunOneline writes a one-line summary of the system to w, suitable for
+// tmux status-right or shell prompts. spec selects the template as for
+// output.Parse. With watch set it prints a new line every refresh interval
+// until interrupted, as i3bar and waybar expect.
+func RunOneline(cfg config.Config, spec string, watch bool, w io.Writer) error {
+	if spec == "" {
+		spec = cfg.OnelineFormat
+	}
+	if spec == "" {
+		spec = "oneline"
+	}
+	encoder, err := output.NewEncoder("", spec)
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	collector, err := newCollector(cfg)
+	if err != nil {
+		return err
+	}
+	format, _ := units.Parse(cfg.Units, cfg.NetworkBits) // checked by newCollector
+	output.SetUnits(format)
+	loc, _ := cfg.Location()
+	output.SetLocation(loc)
+	// The first sample primes CPU and network rate calculations
+	if _, err := collector.Collect(); err != nil {
+		return err
+	}
+	wait := onelineRateWindow
+	if watch {
+		wait = time.Duration(cfg.RefreshInterval) * time.Second
+		if wait <= 0 {
+			wait = time.Second
+		}
+	}
+
+	for {
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			return nil
+		}
+		metric, err := collector.Collect()
+		if err != nil {
+			return err
+		}
+		line, err := encoder.Encode(*metric)
+		if err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintln(w, strings.ReplaceAll(string(line), "\n", " ")); err != nil {
+			return err
+		}
+		if !watch {
+			return nil
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package core
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/user"
+	"runtime"
+
+	"github.com/j-raghavan/godash/internal/config"
+)
+
+// lookupPrivilegeUser checks that the user and group of the privileges
+// settings exist
+func lookupPrivilegeUser(p config.PrivilegesConfig) (*user.User, string, error) {
+	u, err := user.Lookup(p.User)
+	if err != nil {
+		return nil, "", fmt.Errorf("invalid privileges user %q: %w", p.User, err)
+	}
+	gid := u.Gid
+	if p.Group != "" {
+		g, err := user.LookupGroup(p.Group)
+		if err != nil {
+			return nil, "", fmt.Errorf("invalid privileges group %q: %w", p.Group, err)
+		}
+		gid = g.Gid
+	}
+	return u, gid, nil
+}
+
+// dropPrivileges switches to the privileges user once godash has set up
+// as root, and warns on w about enabled collectors that lose access. It
+// does nothing when no user is configured or godash is not running as root.
+func dropPrivileges(cfg config.Config, w io.Writer) error {
+	p := cfg.Privileges
+	if p.User == "" || os.Geteuid() != 0 {
+		return nil
+	}
+	u, gid, err := lookupPrivilegeUser(p)
+	if err != nil {
+		return err
+	}
+	if err := switchUser(u, gid); err != nil {
+		return fmt.Errorf("failed to drop privileges to %s: %w", p.User, err)
+	}
+	if dir, err := writableDataDir(); err != nil {
+		return fmt.Errorf("data directory %s is not writable by %s: %w (set data_dir to a directory it owns)", dir, p.User, err)
+	}
+	for _, warning := range PrivilegeWarnings(cfg) {
+		fmt.Fprintf(w, "Warning: %s\n", warning)
+	}
+	return nil
+}
+
+// PrivilegeWarnings lists the enabled collectors that need more than an
+// unprivileged user, when godash is configured to drop privileges
+func PrivilegeWarnings(cfg config.Config) []string {
+	if cfg.Privileges.User == "" {
+		return nil
+	}
+	var warnings []string
+	if cfg.Watch.SmapsMemory && runtime.GOOS == "linux" {
+		warnings = append(warnings, "smaps_memory: PSS/USS of other users' processes cannot be read; RSS is used for them")
+	}
+	if cfg.EnableLibvirt {
+		warnings = append(warnings, "enable_libvirt: virsh needs the user in the libvirt group")
+	}
+	if cfg.EnableWireGuard {
+		warnings = append(warnings, "enable_wireguard: wg needs CAP_NET_ADMIN, which an unprivileged user lacks")
+	}
+	for _, path := range []string{cfg.OpenVPNStatus, cfg.LAN.DHCPLeases} {
+		if path == "" {
+			continue
+		}
+		if f, err := os.Open(path); err != nil {
+			warnings = append(warnings, fmt.Sprintf("%s cannot be read: %v", path, err))
+		} else {
+			f.Close()
+		}
+	}
+	return warnings
+}
@@ -0,0 +1,13 @@
+//go:build !unix
+
+package core
+
+import (
+	"errors"
+	"os/user"
+)
+
+// switchUser is not supported without Unix users
+func switchUser(u *user.User, gid string) error {
+	return errors.New("dropping privileges is only supported on Unix")
+}
@@ -0,0 +1,46 @@
+//go:build unix
+
+package core
+
+import (
+	"errors"
+	"os/user"
+	"strconv"
+	"syscall"
+)
+
+// switchUser sets the process's supplementary groups, group and user to
+// u's, with gid as the group. The group goes first, as only root may
+// change it.
+func switchUser(u *user.User, gid string) error {
+	uid, err := strconv.Atoi(u.Uid)
+	if err != nil {
+		return err
+	}
+	g, err := strconv.Atoi(gid)
+	if err != nil {
+		return err
+	}
+	groups := []int{g}
+	// Supplementary groups such as libvirt keep granting access
+	if ids, err := u.GroupIds(); err == nil {
+		for _, id := range ids {
+			if n, err := strconv.Atoi(id); err == nil && n != g {
+				groups = append(groups, n)
+			}
+		}
+	}
+	if err := syscall.Setgroups(groups); err != nil {
+		return err
+	}
+	if err := syscall.Setgid(g); err != nil {
+		return err
+	}
+	if err := syscall.Setuid(uid); err != nil {
+		return err
+	}
+	if uid != 0 && syscall.Setuid(0) == nil {
+		return errors.New("root privileges could be regained")
+	}
+	return nil
+}
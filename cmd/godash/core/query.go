@@ -0,0 +1,179 @@
+package core
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"golang.org/x/term"
+
+	"github.com/j-raghavan/godash/internal/config"
+	"github.com/j-raghavan/godash/internal/rules"
+)
+
+// queryPrompt is shown before each query in interactive mode
+const queryPrompt = "godash> "
+
+// queryHelp describes the query syntax
+const queryHelp = `Queries are evaluated against a fresh sample:
+  cpu                       show a value
+  disk.*.used_percent       '*' matches any part of a name
+  memory.used_percent > 80  show values satisfying a condition
+  names [pattern]           list metric names
+  help                      show this help
+  quit                      leave the prompt
+Press Tab to complete metric names.`
+
+// RunQuery reads metric queries from in and writes their results to out.
+// When in is a terminal the prompt supports history and Tab completion.
+func RunQuery(cfg config.Config, in io.Reader, out io.Writer) error {
+	collector, err := newCollector(cfg)
+	if err != nil {
+		return err
+	}
+	// The first sample primes rate calculations such as network throughput
+	if _, err := collector.Collect(); err != nil {
+		return err
+	}
+
+	sample := func() (map[string]float64, error) {
+		metric, err := collector.Collect()
+		if err != nil {
+			return nil, err
+		}
+		return rules.Values(*metric), nil
+	}
+
+	if f, ok := in.(*os.File); ok && term.IsTerminal(int(f.Fd())) {
+		return runQueryTerminal(f, out, sample)
+	}
+
+	scanner := bufio.NewScanner(in)
+	for scanner.Scan() {
+		if !runQueryLine(scanner.Text(), out, sample) {
+			return nil
+		}
+	}
+	return scanner.Err()
+}
+
+// runQueryTerminal runs the prompt in raw mode with line editing
+func runQueryTerminal(f *os.File, out io.Writer, sample func() (map[string]float64, error)) error {
+	state, err := term.MakeRaw(int(f.Fd()))
+	if err != nil {
+		return fmt.Errorf("failed to set up terminal: %w", err)
+	}
+	defer term.Restore(int(f.Fd()), state)
+
+	terminal := term.NewTerminal(struct {
+		io.Reader
+		io.Writer
+	}{f, out}, queryPrompt)
+
+	var names []string
+	if values, err := sample(); err == nil {
+		names = rules.Names(values)
+	}
+	terminal.AutoCompleteCallback = func(line string, pos int, key rune) (string, int, bool) {
+		if key != '\t' {
+			return "", 0, false
+		}
+		return CompleteName(names, line, pos)
+	}
+
+	fmt.Fprintln(terminal, "Type 'help' for the query syntax.")
+	for {
+		line, err := terminal.ReadLine()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if !runQueryLine(line, terminal, sample) {
+			return nil
+		}
+	}
+}
+
+// runQueryLine evaluates one line of input, returning false to quit
+func runQueryLine(line string, out io.Writer, sample func() (map[string]float64, error)) bool {
+	line = strings.TrimSpace(line)
+	command, arg, _ := strings.Cut(line, " ")
+	switch command {
+	case "":
+		return true
+	case "quit", "exit":
+		return false
+	case "help":
+		fmt.Fprintln(out, queryHelp)
+		return true
+	}
+
+	values, err := sample()
+	if err != nil {
+		fmt.Fprintf(out, "Error collecting metrics: %v\n", err)
+		return true
+	}
+
+	if command == "names" {
+		pattern := strings.TrimSpace(arg)
+		for _, name := range rules.Names(values) {
+			if pattern == "" || strings.Contains(name, pattern) {
+				fmt.Fprintln(out, name)
+			}
+		}
+		return true
+	}
+
+	selected, err := rules.Select(values, line)
+	if err != nil {
+		fmt.Fprintf(out, "Error: %v\n", err)
+		return true
+	}
+	if len(selected) == 0 {
+		fmt.Fprintln(out, "(no match)")
+		return true
+	}
+	for _, name := range rules.Names(selected) {
+		fmt.Fprintf(out, "%s = %s\n", name, formatValue(selected[name]))
+	}
+	return true
+}
+
+// CompleteName completes the metric name ending at pos in line to the
+// longest prefix shared by all matching names. It reports false when no
+// name matches.
+func CompleteName(names []string, line string, pos int) (string, int, bool) {
+	start := strings.LastIndexAny(line[:pos], " <>=!") + 1
+	prefix := line[start:pos]
+
+	completion := ""
+	found := false
+	for _, name := range names {
+		if !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		if !found {
+			completion, found = name, true
+			continue
+		}
+		for !strings.HasPrefix(name, completion) {
+			completion = completion[:len(completion)-1]
+		}
+	}
+	if !found || completion == prefix {
+		return "", 0, false
+	}
+	return line[:start] + completion + line[pos:], start + len(completion), true
+}
+
+// formatValue prints whole numbers without a fraction
+func formatValue(v float64) string {
+	if v == float64(int64(v)) {
+		return fmt.Sprintf("%d", int64(v))
+	}
+	return fmt.Sprintf("%.2f", v)
+}
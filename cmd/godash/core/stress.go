@@ -0,0 +1,170 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// stressChunkSize is the size of each disk write
+const stressChunkSize = 4 * 1024 * 1024
+
+// stressFileLimit bounds the scratch file; writes wrap around at this size
+const stressFileLimit = 256 * 1024 * 1024
+
+// StressOptions configures the load generated by RunStress
+type StressOptions struct {
+	CPU       int // busy-looping workers
+	Duration  time.Duration
+	Memory    uint64 // bytes to allocate and keep resident
+	DiskWrite string // directory for the scratch file, empty to skip disk load
+}
+
+// RunStress generates CPU, memory and disk load for the given duration so
+// its impact can be watched in the monitor. It stops early on interrupt.
+func RunStress(opts StressOptions, w io.Writer) error {
+	if opts.Duration <= 0 {
+		return fmt.Errorf("duration must be positive")
+	}
+	if opts.CPU < 0 {
+		return fmt.Errorf("--cpu must not be negative")
+	}
+	if opts.CPU == 0 && opts.Memory == 0 && opts.DiskWrite == "" {
+		return fmt.Errorf("nothing to do: set --cpu, --mem or --disk-write")
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	ctx, cancel := context.WithTimeout(ctx, opts.Duration)
+	defer cancel()
+
+	fmt.Fprintf(w, "Stressing for %s:", opts.Duration)
+	if opts.CPU > 0 {
+		fmt.Fprintf(w, " %d CPU workers", opts.CPU)
+	}
+	if opts.Memory > 0 {
+		fmt.Fprintf(w, " %.1f MiB memory", float64(opts.Memory)/(1<<20))
+	}
+	if opts.DiskWrite != "" {
+		fmt.Fprintf(w, " disk writes to %s", opts.DiskWrite)
+	}
+	fmt.Fprintln(w)
+
+	var wg sync.WaitGroup
+	for i := 0; i < opts.CPU; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			stressCPU(ctx)
+		}()
+	}
+	if opts.Memory > 0 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			stressMemory(ctx, opts.Memory)
+		}()
+	}
+
+	var written uint64
+	var diskErr error
+	start := time.Now()
+	if opts.DiskWrite != "" {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			written, diskErr = stressDisk(ctx, opts.DiskWrite)
+			if diskErr != nil {
+				cancel()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if diskErr != nil {
+		return diskErr
+	}
+	if opts.DiskWrite != "" {
+		elapsed := time.Since(start).Seconds()
+		fmt.Fprintf(w, "Wrote %.1f MiB (%.1f MiB/s)\n",
+			float64(written)/(1<<20), float64(written)/(1<<20)/elapsed)
+	}
+	fmt.Fprintln(w, "Stress finished.")
+	return nil
+}
+
+// stressCPU keeps one core busy until ctx is done
+func stressCPU(ctx context.Context) {
+	x := 1.0
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		default:
+		}
+		for i := 0; i < 1_000_000; i++ {
+			x = x*1.0000001 + 1
+		}
+		if x < 0 {
+			// Keep the loop from being optimized away
+			fmt.Fprint(io.Discard, x)
+		}
+	}
+}
+
+// stressMemory allocates size bytes and touches every page each second so
+// the memory stays resident
+func stressMemory(ctx context.Context, size uint64) {
+	buf := make([]byte, size)
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	for n := byte(1); ; n++ {
+		for i := 0; i < len(buf); i += 4096 {
+			buf[i] = n
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// stressDisk writes to a scratch file in dir until ctx is done, syncing each
+// chunk, and returns the number of bytes written. The file is removed.
+func stressDisk(ctx context.Context, dir string) (uint64, error) {
+	f, err := os.CreateTemp(dir, "godash-stress-*")
+	if err != nil {
+		return 0, fmt.Errorf("failed to create scratch file: %w", err)
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+
+	chunk := make([]byte, stressChunkSize)
+	for i := range chunk {
+		chunk[i] = byte(i)
+	}
+
+	var written uint64
+	for ctx.Err() == nil {
+		if written%stressFileLimit == 0 && written > 0 {
+			if _, err := f.Seek(0, io.SeekStart); err != nil {
+				return written, fmt.Errorf("failed to rewind scratch file: %w", err)
+			}
+		}
+		n, err := f.Write(chunk)
+		written += uint64(n)
+		if err != nil {
+			return written, fmt.Errorf("failed to write scratch file: %w", err)
+		}
+		if err := f.Sync(); err != nil {
+			return written, fmt.Errorf("failed to sync scratch file: %w", err)
+		}
+	}
+	return written, nil
+}
@@ -0,0 +1,115 @@
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"runtime"
+	"runtime/debug"
+	"strings"
+)
+
+// Build metadata, set at link time, e.g.
+//
+//	go build -ldflags "-X github.com/j-raghavan/godash/cmd/godash/core.Commit=$(git rev-parse HEAD)"
+//
+// The Makefile sets all three. Builds without them fall back to the VCS
+// details the Go toolchain embeds.
+var (
+	Version   = "v0.1.0"
+	Commit    = ""
+	BuildDate = ""
+)
+
+// BuildInfo describes the running binary
+type BuildInfo struct {
+	Version   string   `json:"version"`
+	Commit    string   `json:"commit"`
+	BuildDate string   `json:"build_date"`
+	GoVersion string   `json:"go_version"`
+	Platform  string   `json:"platform"`
+	Tags      []string `json:"tags"`
+	Modified  bool     `json:"modified"` // built from a tree with uncommitted changes
+}
+
+// GetBuildInfo returns the metadata of the running binary
+func GetBuildInfo() BuildInfo {
+	info := BuildInfo{
+		Version:   Version,
+		Commit:    Commit,
+		BuildDate: BuildDate,
+		GoVersion: runtime.Version(),
+		Platform:  runtime.GOOS + "/" + runtime.GOARCH,
+		Tags:      []string{},
+	}
+	build, ok := debug.ReadBuildInfo()
+	if !ok {
+		return info
+	}
+	// go install pkg@version records the module version
+	if v := build.Main.Version; v != "" && v != "(devel)" && Version == "v0.1.0" {
+		info.Version = v
+	}
+	for _, setting := range build.Settings {
+		switch setting.Key {
+		case "-tags":
+			for _, tag := range strings.Split(setting.Value, ",") {
+				if tag = strings.TrimSpace(tag); tag != "" {
+					info.Tags = append(info.Tags, tag)
+				}
+			}
+		case "vcs.revision":
+			if info.Commit == "" {
+				info.Commit = setting.Value
+			}
+		case "vcs.time":
+			if info.BuildDate == "" {
+				info.BuildDate = setting.Value
+			}
+		case "vcs.modified":
+			info.Modified = setting.Value == "true"
+		}
+	}
+	return info
+}
+
+// ShowVersion displays version info
+func ShowVersion() string {
+	return "GoDash " + GetBuildInfo().Version
+}
+
+// WriteVersion writes the build metadata to w, as text or as JSON
+func WriteVersion(w io.Writer, asJSON bool) error {
+	info := GetBuildInfo()
+	if asJSON {
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(info)
+	}
+
+	commit := orUnknown(info.Commit)
+	if info.Modified {
+		commit += " (modified)"
+	}
+	features := "full (TUI included)"
+	for _, tag := range info.Tags {
+		if tag == "agent" {
+			features = "agent (TUI left out)"
+		}
+	}
+	tags := "none"
+	if len(info.Tags) > 0 {
+		tags = strings.Join(info.Tags, ", ")
+	}
+	_, err := fmt.Fprintf(w, "GoDash %s\n  commit:     %s\n  built:      %s\n  go:         %s\n  platform:   %s\n  build tags: %s\n  build:      %s\n",
+		info.Version, commit, orUnknown(info.BuildDate), info.GoVersion, info.Platform, tags, features)
+	return err
+}
+
+// orUnknown returns s, or "unknown" when it is empty
+func orUnknown(s string) string {
+	if s == "" {
+		return "unknown"
+	}
+	return s
+}
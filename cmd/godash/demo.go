@@ -0,0 +1,30 @@
+//go:build !agent
+
+package main
+
+import (
+	"github.com/j-raghavan/godash/cmd/godash/core"
+	"github.com/spf13/cobra"
+)
+
+// demoRecord is the demo's --record flag
+var demoRecord string
+
+// demoCmd runs the monitor on synthetic data
+var demoCmd = &cobra.Command{
+	Use:   "demo",
+	Short: "Start the monitor on a synthetic host",
+	Long: `Start the terminal UI fed by a deterministic generator instead of this
+machine: CPU waves with a burst every minute, a filling disk, a flapping
+Wi-Fi link and the alerts they raise. Useful for screenshots, UI work and
+trying GoDash without exposing a real host.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		cfg.TUI.Record = demoRecord
+		core.RunDemo(cfg)
+	},
+}
+
+func init() {
+	demoCmd.Flags().StringVar(&demoRecord, "record", "", "Record the session to this asciinema cast file, and the samples shown to a .jsonl file next to it")
+	rootCmd.AddCommand(demoCmd)
+}
@@ -4,9 +4,11 @@ package main
 import (
 	"fmt"
 	"os"
+	"time"
 
 	"github.com/j-raghavan/godash/cmd/godash/core"
 	"github.com/j-raghavan/godash/internal/config"
+	"github.com/j-raghavan/godash/internal/units"
 	"github.com/spf13/cobra"
 )
 
@@ -39,25 +41,7 @@ var rootCmd = &cobra.Command{
 	It's designed for developers, DevOps engineers, and homelab enthusiasts
 	who need a portable and install-free performance monitor.`,
 	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
-		// Load configuration from file
-		loadedCfg, err := config.LoadConfig(cfg.ConfigFile)
-		if err != nil {
-			return fmt.Errorf("failed to load config: %w", err)
-		}
-
-		// Override with CLI flags
-		if cmd.Flags().Changed("interval") {
-			loadedCfg.RefreshInterval = cfg.RefreshInterval
-		}
-		if cmd.Flags().Changed("go-runtime") {
-			loadedCfg.EnableGoRuntime = cfg.EnableGoRuntime
-		}
-		if cmd.Flags().Changed("port") {
-			loadedCfg.WebPort = cfg.WebPort
-		}
-
-		cfg = loadedCfg
-		return nil
+		return loadConfig(cmd)
 	},
 	Run: func(cmd *cobra.Command, args []string) {
 		if err := cmd.Help(); err != nil {
@@ -67,15 +51,42 @@ var rootCmd = &cobra.Command{
 	},
 }
 
-// monitorCmd represents the monitor subcommand for CLI
-var monitorCmd = &cobra.Command{
-	Use:   "monitor",
-	Short: "Start the interactive CLI monitor",
-	Long: `Start GoDash in terminal UI mode, displaying real-time system metrics.
-Press 'q' to quit, 'g' to toggle Go runtime stats.`,
-	Run: func(cmd *cobra.Command, args []string) {
-		core.RunMonitor(cfg)
-	},
+// loadConfig loads the config file into cfg and applies the CLI flags over
+// it
+func loadConfig(cmd *cobra.Command) error {
+	// Load configuration from file
+	profile := cfg.Profile
+	if !cmd.Flags().Changed("profile") {
+		profile = os.Getenv(config.ProfileEnv)
+	}
+	loadedCfg, err := config.LoadProfile(cfg.ConfigFile, profile)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	// Override with CLI flags
+	if cmd.Flags().Changed("interval") {
+		loadedCfg.RefreshInterval = cfg.RefreshInterval
+	}
+	if cmd.Flags().Changed("go-runtime") {
+		loadedCfg.EnableGoRuntime = cfg.EnableGoRuntime
+	}
+	if cmd.Flags().Changed("port") {
+		loadedCfg.WebPort = cfg.WebPort
+	}
+	if cmd.Flags().Changed("data-dir") {
+		loadedCfg.DataDir = cfg.DataDir
+	}
+
+	config.SetDataDir(loadedCfg.DataDir)
+	if from, to, err := config.MigrateDataDir(); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	} else if from != "" {
+		fmt.Fprintf(os.Stderr, "Moved %s to %s\n", from, to)
+	}
+
+	cfg = loadedCfg
+	return nil
 }
 
 // serverCmd represents the server subcommand for the web dashboard
@@ -89,27 +100,276 @@ at http://localhost:<port> and metrics via REST API and WebSocket.`,
 	},
 }
 
+// Flags for the agent subcommand
+var (
+	agentCount    int
+	agentFormat   string
+	agentTemplate string
+)
+
+// agentCmd represents the headless collector subcommand
+var agentCmd = &cobra.Command{
+	Use:   "agent",
+	Short: "Run as a headless metrics agent",
+	Long: `Run GoDash as a minimal collector without the terminal UI, writing one
+JSON metric sample per line to stdout at the refresh interval.
+--format line-protocol writes InfluxDB line protocol instead, a line per
+core, disk and interface; msgpack and protobuf (docs/godash.proto, each
+message prefixed with its varint length) write binary records. --template renders samples through a Go template:
+a built-in name (csv, influx, nagios, oneline, values), "@file" or the
+template text.
+
+Build with "-tags agent" for a smaller binary that leaves out the TUI.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return core.RunAgent(cfg, agentCount, agentFormat, agentTemplate, cmd.OutOrStdout())
+	},
+}
+
+// Flags for the stress subcommand
+var (
+	stressCPU      int
+	stressDuration time.Duration
+	stressMem      string
+	stressDisk     string
+)
+
+// stressCmd generates load so its impact can be watched in the monitor
+var stressCmd = &cobra.Command{
+	Use:   "stress",
+	Short: "Generate CPU, memory and disk load",
+	Long: `Generate controlled load while "godash monitor" runs in another terminal,
+to validate cooling and throughput, e.g.
+
+  godash stress --cpu 4 --duration 30s --mem 1G --disk-write /tmp`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		opts := core.StressOptions{
+			CPU:       stressCPU,
+			Duration:  stressDuration,
+			DiskWrite: stressDisk,
+		}
+		if stressMem != "" {
+			size, err := units.ParseBytes(stressMem)
+			if err != nil {
+				return err
+			}
+			opts.Memory = size
+		}
+		return core.RunStress(opts, cmd.OutOrStdout())
+	},
+}
+
+// queryCmd opens an interactive prompt for evaluating metric expressions
+var queryCmd = &cobra.Command{
+	Use:   "query",
+	Short: "Evaluate metric expressions interactively",
+	Long: `Open a prompt that evaluates metric expressions such as "cpu",
+"disk.*.used_percent" or "memory.used_percent > 80" against live data.
+Metric names complete with Tab. Queries can also be piped in, one per line.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return core.RunQuery(cfg, cmd.InOrStdin(), cmd.OutOrStdout())
+	},
+}
+
+// Flags for the oneline subcommand
+var (
+	onelineTemplate string
+	onelineWatch    bool
+)
+
+// onelineCmd prints a compact single-line summary
+var onelineCmd = &cobra.Command{
+	Use:   "oneline",
+	Short: "Print a one-line system summary for status bars",
+	Long: `Print a compact summary such as
+
+  cpu 23% | mem 61% | / 71% | ↓1.2MB/s ↑300KB/s
+
+for tmux status-right, i3bar/waybar or shell prompts. --template takes a
+built-in template name (csv, influx, nagios, oneline, values), "@file" or
+a Go template over .Host, .CPU, .Memory, .Rx, .Tx, .Metric and .Values,
+with the helpers pct, rate, bytes, disk "<mount>" and value "<name>", e.g.
+
+  godash oneline --template '{{pct .CPU}} {{pct (value "memory.used_percent")}}'
+
+With --watch a new line is printed every refresh interval.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return core.RunOneline(cfg, onelineTemplate, onelineWatch, cmd.OutOrStdout())
+	},
+}
+
+// checkOptions holds the thresholds of the check subcommand
+var checkOptions core.CheckOptions
+
+// checkCmd runs a Nagios/Icinga compatible check
+var checkCmd = &cobra.Command{
+	Use:   "check <metric>",
+	Short: "Check a metric against thresholds as a Nagios/Icinga plugin",
+	Long: `Check a metric and exit with the Nagios plugin codes 0 (OK), 1 (WARNING),
+2 (CRITICAL) or 3 (UNKNOWN), printing a status line with perfdata, e.g.
+
+  godash check cpu --warn 80 --crit 95
+  godash check disk --warn 85 --crit 95
+  godash check 'disk./.free' --warn 10e9 --crit 2e9 --below
+
+The metric is any name listed by "godash query" ('*' matches any part of
+a name), or "memory" or "disk" for their used percentages. The worst
+status of all matching values is reported.`,
+	// Argument, flag and config errors exit UNKNOWN rather than 1, which
+	// Nagios reads as WARNING
+	Args: func(cmd *cobra.Command, args []string) error {
+		return checkUnknown(cmd, cobra.ExactArgs(1)(cmd, args))
+	},
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		return checkUnknown(cmd, loadConfig(cmd))
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		OsExit(core.RunCheck(cfg, args[0], checkOptions, cmd.OutOrStdout()))
+	},
+}
+
+// checkUnknown prints err, if any, as a Nagios UNKNOWN status line and
+// exits with the UNKNOWN code
+func checkUnknown(cmd *cobra.Command, err error) error {
+	if err != nil {
+		fmt.Fprintf(cmd.OutOrStdout(), "UNKNOWN - %v\n", err)
+		OsExit(core.CheckUnknown)
+	}
+	return err
+}
+
+// doctorLoadErr is why the config failed to load, reported by doctor
+var doctorLoadErr error
+
+// doctorCmd diagnoses common setup problems
+var doctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: "Check permissions, sensors and config for common problems",
+	Long: `Check that godash can read /proc and disk stats, reach the Docker socket,
+find temperature sensors, bind the web port and write its data directory,
+and that the config is valid. Each check prints PASS, WARN, FAIL or SKIP
+with a hint on how to fix it; the exit code is 1 if any check failed.`,
+	// Load the config here so a broken file is reported as a failed check
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		if doctorLoadErr = loadConfig(cmd); doctorLoadErr != nil {
+			config.SetDataDir(cfg.DataDir)
+		}
+		return nil
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		OsExit(core.RunDoctor(cfg, doctorLoadErr, cmd.OutOrStdout()))
+	},
+}
+
+// jobCmd groups the periodic job subcommands
+var jobCmd = &cobra.Command{
+	Use:   "job",
+	Short: "Report and inspect periodic jobs",
+	Long: `Track periodic jobs such as cron tasks configured in the [[jobs]] section.
+Jobs call "godash job done <name>" when they finish, and are reported as
+overdue once their interval (plus grace) elapses without a completion, or
+since godash first saw them configured if they never completed.`,
+}
+
+// jobDoneCmd records a job completion
+var jobDoneCmd = &cobra.Command{
+	Use:   "done <name>",
+	Short: "Record that a job has completed",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return core.RecordJob(cfg, args[0])
+	},
+}
+
+// jobStatusCmd prints the status of all jobs
+var jobStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show the status of all jobs, exiting non-zero if any is overdue",
+	Run: func(cmd *cobra.Command, args []string) {
+		overdue, err := core.ShowJobs(cfg, cmd.OutOrStdout())
+		if err != nil {
+			fmt.Println(err)
+			OsExit(1)
+		}
+		if overdue {
+			OsExit(1)
+		}
+	},
+}
+
+// alertsSince limits the alert history to recent alerts
+var alertsSince time.Duration
+
+// alertsCmd prints the alert history recorded by the rules engine
+var alertsCmd = &cobra.Command{
+	Use:   "alerts",
+	Short: "Show the history of rule alerts",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return core.ShowAlerts(cfg, alertsSince, cmd.OutOrStdout())
+	},
+}
+
+// versionJSON prints the version as JSON
+var versionJSON bool
+
 // versionCmd represents the version subcommand
 var versionCmd = &cobra.Command{
 	Use:   "version",
 	Short: "Print the version number of GoDash",
-	Long:  `All software has versions. This is GoDash's.`,
-	Run: func(cmd *cobra.Command, args []string) {
-		fmt.Println(core.ShowVersion())
+	Long: `Print the version of GoDash with the commit and date it was built from,
+the Go version, platform and build tags. Use --json for machine-readable output.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return core.WriteVersion(cmd.OutOrStdout(), versionJSON)
 	},
 }
 
 func init() {
 	// Define global flags that apply to all commands
-	rootCmd.PersistentFlags().StringVarP(&cfg.ConfigFile, "config", "c", "", "config file (default is $HOME/.godash.toml)")
+	rootCmd.PersistentFlags().StringVarP(&cfg.ConfigFile, "config", "c", "", "config file (default is $XDG_CONFIG_HOME/godash/godash.toml or $HOME/.godash.toml)")
+	rootCmd.PersistentFlags().StringVar(&cfg.DataDir, "data-dir", "", "directory for alert history, job runs and snapshots (default is $XDG_DATA_HOME/godash)")
+	rootCmd.PersistentFlags().StringVar(&cfg.Profile, "profile", "", "config profile to apply (default is $GODASH_PROFILE)")
 	rootCmd.PersistentFlags().IntVarP(&cfg.RefreshInterval, "interval", "i", 1, "Metrics refresh interval in seconds")
 	rootCmd.PersistentFlags().BoolVarP(&cfg.EnableGoRuntime, "go-runtime", "g", false, "Enable Go runtime metrics")
 
 	// Add flags specific to the server command
 	serverCmd.Flags().IntVarP(&cfg.WebPort, "port", "p", 8080, "Port to serve dashboard on")
 
+	versionCmd.Flags().BoolVar(&versionJSON, "json", false, "Print build metadata as JSON")
+
+	// Add flags specific to the agent command
+	agentCmd.Flags().IntVarP(&agentCount, "count", "n", 0, "Number of samples to emit before exiting (0 runs until interrupted)")
+	agentCmd.Flags().StringVarP(&agentFormat, "format", "f", "json", "Output format: json, line-protocol, msgpack or protobuf")
+	agentCmd.Flags().StringVarP(&agentTemplate, "template", "t", "", "Render samples with a built-in template, @file or template text")
+
+	// Add flags specific to the alerts command
+	alertsCmd.Flags().DurationVar(&alertsSince, "since", 24*time.Hour, "Only show alerts active within this long (0 shows all)")
+
+	// Add flags specific to the oneline command
+	onelineCmd.Flags().StringVarP(&onelineTemplate, "template", "t", "", "Built-in template, @file or template text (default from oneline_format)")
+	onelineCmd.Flags().BoolVarP(&onelineWatch, "watch", "w", false, "Print a line every refresh interval")
+
+	// Add flags specific to the check command
+	checkCmd.Flags().Float64Var(&checkOptions.Warn, "warn", 0, "Warning threshold")
+	checkCmd.Flags().Float64Var(&checkOptions.Crit, "crit", 0, "Critical threshold")
+	checkCmd.Flags().BoolVar(&checkOptions.Below, "below", false, "Alert when values fall below the thresholds")
+	checkCmd.SetFlagErrorFunc(checkUnknown)
+
+	// Add flags specific to the stress command
+	stressCmd.Flags().IntVar(&stressCPU, "cpu", 0, "Number of CPU workers to run")
+	stressCmd.Flags().DurationVar(&stressDuration, "duration", 30*time.Second, "How long to generate load")
+	stressCmd.Flags().StringVar(&stressMem, "mem", "", "Memory to allocate, e.g. 512M or 1G")
+	stressCmd.Flags().StringVar(&stressDisk, "disk-write", "", "Directory to write a scratch file into")
+
 	// Add subcommands to root command
-	rootCmd.AddCommand(monitorCmd)
 	rootCmd.AddCommand(serverCmd)
+	rootCmd.AddCommand(agentCmd)
+	rootCmd.AddCommand(stressCmd)
+	rootCmd.AddCommand(queryCmd)
+	rootCmd.AddCommand(onelineCmd)
+	rootCmd.AddCommand(checkCmd)
+	rootCmd.AddCommand(doctorCmd)
+	jobCmd.AddCommand(jobDoneCmd)
+	jobCmd.AddCommand(jobStatusCmd)
+	rootCmd.AddCommand(jobCmd)
+	rootCmd.AddCommand(alertsCmd)
 	rootCmd.AddCommand(versionCmd)
 }
@@ -0,0 +1,41 @@
+//go:build !agent
+
+package main
+
+import (
+	"github.com/j-raghavan/godash/cmd/godash/core"
+	"github.com/j-raghavan/godash/internal/tui"
+	"github.com/spf13/cobra"
+)
+
+// Flags for the monitor subcommand
+var (
+	monitorNoColor      bool
+	monitorHighContrast bool
+	monitorRecord       string
+)
+
+// monitorCmd represents the monitor subcommand for CLI
+var monitorCmd = &cobra.Command{
+	Use:   "monitor",
+	Short: "Start the interactive CLI monitor",
+	Long: `Start GoDash in terminal UI mode, displaying real-time system metrics.
+Press 'q' to quit, 'g' to toggle Go runtime stats.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		switch {
+		case monitorHighContrast:
+			cfg.ColorMode = tui.ColorHighContrast
+		case monitorNoColor:
+			cfg.ColorMode = tui.ColorNone
+		}
+		cfg.TUI.Record = monitorRecord
+		core.RunMonitor(cfg)
+	},
+}
+
+func init() {
+	monitorCmd.Flags().BoolVar(&monitorNoColor, "no-color", false, "Disable colors (also set by NO_COLOR)")
+	monitorCmd.Flags().BoolVar(&monitorHighContrast, "high-contrast", false, "Use white on black with critical values in reverse video")
+	monitorCmd.Flags().StringVar(&monitorRecord, "record", "", "Record the session to this asciinema cast file, and the samples shown to a .jsonl file next to it")
+	rootCmd.AddCommand(monitorCmd)
+}
@@ -0,0 +1,255 @@
+package bandwidth
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/j-raghavan/godash/internal/config"
+)
+
+// saveInterval limits how often the counters are written to disk. At most
+// this much accounting is lost if godash is killed.
+const saveInterval = time.Minute
+
+// Date layout of the persisted days and quota periods
+const dayLayout = "2006-01-02"
+
+// DefaultAlertPercent are the quota percentages that raise an alert when
+// none are configured
+var DefaultAlertPercent = []float64{80, 100}
+
+// Counter is the persisted transfer of one interface
+type Counter struct {
+	Day      string `json:"day"` // e.g. 2006-01-02, in the configured time zone
+	DayRx    uint64 `json:"day_rx"`
+	DayTx    uint64 `json:"day_tx"`
+	Period   string `json:"period"` // first day of the quota period
+	PeriodRx uint64 `json:"period_rx"`
+	PeriodTx uint64 `json:"period_tx"`
+	// Raw interface counters at the last sample, so that transfer while
+	// godash was not running is counted too, unless the host rebooted
+	LastRx uint64 `json:"last_rx"`
+	LastTx uint64 `json:"last_tx"`
+}
+
+// Usage is the transfer of one interface today and in the current quota
+// period
+type Usage struct {
+	Interface string `json:"interface"`
+	TodayRx   uint64 `json:"today_rx"`
+	TodayTx   uint64 `json:"today_tx"`
+	MonthRx   uint64 `json:"month_rx"`
+	MonthTx   uint64 `json:"month_tx"`
+}
+
+// Report is the accounted transfer of every interface and the use of the
+// monthly quota
+type Report struct {
+	Interfaces   []Usage   `json:"interfaces"`
+	PeriodStart  time.Time `json:"period_start"`  // start of the quota period, the "month"
+	Quota        uint64    `json:"quota"`         // bytes per period, 0 without a quota
+	QuotaUsed    uint64    `json:"quota_used"`    // bytes received and sent this period
+	QuotaPercent float64   `json:"quota_percent"` // 0 without a quota
+	// QuotaLevel is the highest alert percentage QuotaPercent has reached,
+	// 0 below all of them
+	QuotaLevel float64 `json:"quota_level"`
+}
+
+// Quota limits the transfer in each period starting on ResetDay
+type Quota struct {
+	Limit        uint64    // bytes received and sent; 0 for no limit
+	ResetDay     int       // day of the month periods start, 1 to 28
+	AlertPercent []float64 // percentages of Limit that raise alerts
+}
+
+// Sample is the raw byte counters of one interface
+type Sample struct {
+	Interface string
+	RxBytes   uint64
+	TxBytes   uint64
+}
+
+// Store persists the counters of every interface in a JSON file.
+type Store struct {
+	path string
+}
+
+// NewStore creates a Store backed by the file at path
+func NewStore(path string) *Store {
+	return &Store{path: path}
+}
+
+// DefaultStore returns the Store in the godash data directory
+func DefaultStore() (*Store, error) {
+	dir, err := config.DataDir()
+	if err != nil {
+		return nil, err
+	}
+	return NewStore(filepath.Join(dir, "bandwidth.json")), nil
+}
+
+// Load returns the persisted counters by interface
+func (s *Store) Load() (map[string]*Counter, error) {
+	counters := make(map[string]*Counter)
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return counters, nil
+		}
+		return nil, fmt.Errorf("failed to read bandwidth state: %w", err)
+	}
+	if err := json.Unmarshal(data, &counters); err != nil {
+		return nil, fmt.Errorf("failed to parse bandwidth state %s: %w", s.path, err)
+	}
+	return counters, nil
+}
+
+// Save replaces the persisted counters
+func (s *Store) Save(counters map[string]*Counter) error {
+	data, err := json.MarshalIndent(counters, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal bandwidth state: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o755); err != nil {
+		return fmt.Errorf("failed to create data directory: %w", err)
+	}
+	// Write a temporary file first so a crash never leaves half a file
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write bandwidth state: %w", err)
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		return fmt.Errorf("failed to write bandwidth state: %w", err)
+	}
+	return nil
+}
+
+// Accountant adds up the transfer of each interface per day and per quota
+// period. Only one godash process should account into the same store. It
+// is safe for concurrent use, so that it can be flushed on exit while the
+// collector is still adding samples.
+type Accountant struct {
+	mu       sync.Mutex
+	store    *Store
+	quota    Quota
+	loc      *time.Location
+	counters map[string]*Counter
+	lastSave time.Time
+}
+
+// NewAccountant creates an Accountant that persists into store. Days and
+// periods start at midnight in loc.
+func NewAccountant(store *Store, quota Quota, loc *time.Location) *Accountant {
+	if quota.ResetDay < 1 {
+		quota.ResetDay = 1
+	}
+	if quota.AlertPercent == nil {
+		quota.AlertPercent = DefaultAlertPercent
+	}
+	if loc == nil {
+		loc = time.Local
+	}
+	return &Accountant{store: store, quota: quota, loc: loc}
+}
+
+// Add accounts the transfer since the previous samples and returns the
+// usage so far. The counters are saved at most every saveInterval.
+func (a *Accountant) Add(samples []Sample, now time.Time) (*Report, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.counters == nil {
+		counters, err := a.store.Load()
+		if err != nil {
+			return nil, err
+		}
+		a.counters = counters
+		a.lastSave = now
+	}
+
+	day := now.In(a.loc).Format(dayLayout)
+	start := PeriodStart(now, a.quota.ResetDay, a.loc)
+	period := start.Format(dayLayout)
+	report := &Report{PeriodStart: start, Quota: a.quota.Limit, Interfaces: make([]Usage, 0, len(samples))}
+	for _, s := range samples {
+		c, ok := a.counters[s.Interface]
+		if !ok {
+			// Count from the first sample of a newly seen interface
+			c = &Counter{LastRx: s.RxBytes, LastTx: s.TxBytes}
+			a.counters[s.Interface] = c
+		}
+		if c.Day != day {
+			c.Day, c.DayRx, c.DayTx = day, 0, 0
+		}
+		if c.Period != period {
+			c.Period, c.PeriodRx, c.PeriodTx = period, 0, 0
+		}
+		rx, tx := delta(c.LastRx, s.RxBytes), delta(c.LastTx, s.TxBytes)
+		c.DayRx += rx
+		c.DayTx += tx
+		c.PeriodRx += rx
+		c.PeriodTx += tx
+		c.LastRx, c.LastTx = s.RxBytes, s.TxBytes
+
+		report.Interfaces = append(report.Interfaces, Usage{
+			Interface: s.Interface,
+			TodayRx:   c.DayRx,
+			TodayTx:   c.DayTx,
+			MonthRx:   c.PeriodRx,
+			MonthTx:   c.PeriodTx,
+		})
+		report.QuotaUsed += c.PeriodRx + c.PeriodTx
+	}
+	sort.Slice(report.Interfaces, func(i, j int) bool {
+		return report.Interfaces[i].Interface < report.Interfaces[j].Interface
+	})
+	if a.quota.Limit > 0 {
+		report.QuotaPercent = float64(report.QuotaUsed) / float64(a.quota.Limit) * 100
+		for _, level := range a.quota.AlertPercent {
+			if report.QuotaPercent >= level && level > report.QuotaLevel {
+				report.QuotaLevel = level
+			}
+		}
+	}
+
+	if now.Sub(a.lastSave) >= saveInterval {
+		a.lastSave = now
+		if err := a.store.Save(a.counters); err != nil {
+			return report, err
+		}
+	}
+	return report, nil
+}
+
+// Flush saves the counters now, e.g. when godash exits
+func (a *Accountant) Flush() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.counters == nil {
+		return nil
+	}
+	return a.store.Save(a.counters)
+}
+
+// PeriodStart returns the start of the quota period containing now:
+// midnight in loc on the most recent resetDay of the month
+func PeriodStart(now time.Time, resetDay int, loc *time.Location) time.Time {
+	year, month, day := now.In(loc).Date()
+	if day < resetDay {
+		month-- // time.Date normalizes month 0 to December of the year before
+	}
+	return time.Date(year, month, resetDay, 0, 0, 0, 0, loc)
+}
+
+// delta returns how far a byte counter moved from prev to cur. A counter
+// that went backwards was reset, e.g. by a reboot, and counts from zero.
+func delta(prev, cur uint64) uint64 {
+	if cur < prev {
+		return cur
+	}
+	return cur - prev
+}
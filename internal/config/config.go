@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
+	"time"
 
 	"github.com/pelletier/go-toml/v2"
 )
@@ -13,7 +15,288 @@ type Config struct {
 	RefreshInterval int    `toml:"refresh_interval"`
 	WebPort         int    `toml:"web_port"`
 	EnableGoRuntime bool   `toml:"enable_go_runtime"`
+	EnableLibvirt   bool   `toml:"enable_libvirt"`
+	EnableWireGuard bool   `toml:"enable_wireguard"`
+	OpenVPNStatus   string `toml:"openvpn_status"`
 	ConfigFile      string `toml:"-"`
+	// DataDir overrides where state files such as alert history are kept
+	DataDir string `toml:"data_dir"`
+	// Include lists further config files merged into this one, as paths or
+	// glob patterns relative to it, e.g. ["conf.d/*.toml"]
+	Include []string `toml:"include"`
+	// Profile names the [profile.<name>] table applied over the base
+	// settings, from --profile or GODASH_PROFILE
+	Profile string `toml:"-"`
+	// MemoryMode is "low", "normal" or "auto" (the default, also used when
+	// empty), which enables the low-memory mode on hosts with little RAM.
+	// Other values are rejected.
+	MemoryMode string `toml:"memory_mode"`
+	// DisplayNames maps raw mountpoints, devices and interface names to
+	// friendly labels, e.g. "/dev/sdb1" = "Backup drive".
+	DisplayNames map[string]string `toml:"display_names"`
+	// PinnedDisks and PinnedInterfaces are always listed first, in order
+	PinnedDisks      []string `toml:"pinned_disks"`
+	PinnedInterfaces []string `toml:"pinned_interfaces"`
+	// SnapshotDir is where the TUI's 's' key saves snapshots, by default
+	// the snapshots directory in the data directory
+	SnapshotDir string `toml:"snapshot_dir"`
+	// Units selects "iec" (KiB, MiB; the default) or "si" (kB, MB) units
+	// for byte counts, and NetworkBits shows network rates in bits per
+	// second. The TUI toggles them with 'u' and 'b'.
+	Units       string `toml:"units"`
+	NetworkBits bool   `toml:"network_bits"`
+	// ColorMode is "normal" (the default), "none" for no colors, also used
+	// when NO_COLOR is set, or "high-contrast"
+	ColorMode string `toml:"color_mode"`
+	// Timezone is the zone times are displayed and exported in: "local"
+	// (the default), "UTC" or an IANA name such as "Europe/Berlin"
+	Timezone string `toml:"timezone"`
+	// Locale selects the language of the TUI's labels and its decimal
+	// separator, e.g. "de"; by default it is taken from LC_ALL,
+	// LC_MESSAGES or LANG
+	Locale string `toml:"locale"`
+	// OnelineFormat is the default template of "godash oneline"
+	OnelineFormat string `toml:"oneline_format"`
+	// Tags label this host, e.g. env = "prod"; they are attached to samples
+	// and alerts and can route notifications
+	Tags map[string]string `toml:"tags"`
+	// TUI sets the terminal UI's render rate and pane refresh intervals
+	TUI TUIConfig `toml:"tui"`
+	// WAN configures the public IP and WAN status widget
+	WAN WANConfig `toml:"wan"`
+	// Jobs lists periodic jobs that report completion via `godash job done`
+	Jobs []JobConfig `toml:"jobs"`
+	// LAN configures DHCP lease and neighbor table monitoring on routers
+	LAN LANConfig `toml:"lan"`
+	// BMCs lists baseboard management controllers polled for fans, PSUs
+	// and chassis temperatures
+	BMCs []BMCConfig `toml:"bmc"`
+	// DNS configures the Pi-hole / AdGuard Home panel
+	DNS DNSConfig `toml:"dns"`
+	// GoApps lists Go applications whose expvar/pprof endpoints are scraped
+	GoApps []GoAppConfig `toml:"go_apps"`
+	// Rules trigger actions when a metric crosses a threshold or an event occurs
+	Rules []RuleConfig `toml:"rules"`
+	// Notifiers receive the events of fired rules
+	Notifiers []NotifierConfig `toml:"notifiers"`
+	// Watch lists services that are expected to be running
+	Watch WatchConfig `toml:"watch"`
+	// Adaptive lowers the sampling frequency while the system is busy
+	Adaptive AdaptiveConfig `toml:"adaptive"`
+	// Zabbix pushes selected metrics to a Zabbix server or proxy
+	Zabbix ZabbixConfig `toml:"zabbix"`
+	// Bandwidth accounts daily and monthly transfer per interface
+	Bandwidth BandwidthConfig `toml:"bandwidth"`
+	// Privileges drops root privileges once godash has started
+	Privileges PrivilegesConfig `toml:"privileges"`
+}
+
+// TUIConfig holds the terminal UI's redraw settings
+type TUIConfig struct {
+	MaxFPS            int    `toml:"max_fps"`            // redraws per second, default 10
+	MemoryInterval    string `toml:"memory_interval"`    // memory pane refresh, default "5s"
+	NetworkInterval   string `toml:"network_interval"`   // network pane refresh, default "5s"
+	InterfaceInterval string `toml:"interface_interval"` // how often the busiest interfaces are re-chosen, default "30s"
+	Smoothing         string `toml:"smoothing"`          // CPU and network values shown are averaged over this window, e.g. "2s"; off by default
+	// Record is where --record writes an asciinema cast of the session,
+	// with the samples drawn next to it as JSON lines
+	Record string `toml:"-"`
+}
+
+// ZabbixConfig holds the Zabbix sender settings
+type ZabbixConfig struct {
+	Enabled  bool   `toml:"enabled"`
+	Server   string `toml:"server"`   // server or proxy host[:port], port 10051 by default
+	Host     string `toml:"host"`     // host name in Zabbix, defaults to the hostname
+	Interval string `toml:"interval"` // time between sends, default "60s"
+	// Items maps metric names or patterns, as used by rules, to trapper
+	// item keys; "{name}" in a key is replaced with the metric name
+	Items map[string]string `toml:"items"`
+}
+
+// BandwidthConfig holds the transfer accounting settings, for metered
+// connections
+type BandwidthConfig struct {
+	Enabled      bool      `toml:"enabled"`
+	Interfaces   []string  `toml:"interfaces"`    // accounted interfaces, default all but loopback
+	MonthlyQuota string    `toml:"monthly_quota"` // received plus sent, e.g. "500GiB"; empty for none
+	ResetDay     int       `toml:"reset_day"`     // day of the month the quota resets, 1 to 28, default 1
+	AlertPercent []float64 `toml:"alert_percent"` // quota use that raises bandwidth_quota events, default [80, 100]
+}
+
+// PrivilegesConfig holds the least-privilege settings, for godash started
+// as root (Unix only)
+type PrivilegesConfig struct {
+	// User is the unprivileged user godash switches to once its collectors
+	// are set up; empty to keep running as root
+	User  string `toml:"user"`
+	Group string `toml:"group"` // default the user's primary group
+}
+
+// WatchConfig holds the service watches
+type WatchConfig struct {
+	Process []ProcessWatchConfig `toml:"process"`
+	Port    []PortWatchConfig    `toml:"port"`
+	Cert    []CertWatchConfig    `toml:"cert"`
+	// NewPorts reports TCP ports that start listening after startup
+	NewPorts bool `toml:"new_ports"`
+	// SmapsMemory measures watched processes' PSS and USS on Linux, which
+	// is costlier than RSS but accurate for processes sharing memory
+	SmapsMemory bool `toml:"smaps_memory"`
+}
+
+// ProcessWatchConfig describes a process that is expected to be running
+type ProcessWatchConfig struct {
+	Name      string  `toml:"name"`       // process name, e.g. "postgres"
+	MinCount  int     `toml:"min_count"`  // default 1
+	MaxCPU    float64 `toml:"max_cpu"`    // total CPU percent of one core
+	MaxMemory string  `toml:"max_memory"` // total RSS (PSS with smaps_memory), e.g. "2G"
+}
+
+// PortWatchConfig describes a local port that is expected to be listening,
+// or to stay closed
+type PortWatchConfig struct {
+	Port     int    `toml:"port"`
+	Protocol string `toml:"protocol"` // "tcp" (default) or "udp"
+	Expect   string `toml:"expect"`   // "listening" (default) or "closed"
+}
+
+// CertWatchConfig describes a TLS certificate whose expiry is checked,
+// served by Address or stored in File
+type CertWatchConfig struct {
+	Name       string `toml:"name"`
+	Address    string `toml:"address"`     // host:port
+	ServerName string `toml:"server_name"` // SNI name, defaults to the address host
+	File       string `toml:"file"`        // PEM file
+	WarnDays   int    `toml:"warn_days"`   // default 14
+}
+
+// AdaptiveConfig holds the adaptive sampling settings
+type AdaptiveConfig struct {
+	Enabled      bool    `toml:"enabled"`
+	CPUThreshold float64 `toml:"cpu_threshold"` // system CPU percent, default 80
+	SelfBudget   float64 `toml:"self_budget"`   // godash CPU percent of one core, default 1
+	MaxInterval  string  `toml:"max_interval"`  // slowest sampling interval, default "2s"
+}
+
+// RuleConfig describes an automation rule. Exactly one of When (a threshold
+// expression such as "cpu > 90") or Event (e.g. "interface_down") is set.
+type RuleConfig struct {
+	Name    string         `toml:"name"`
+	When    string         `toml:"when"`
+	Event   string         `toml:"event"`
+	Match   string         `toml:"match"` // optional event subject filter, e.g. an interface name
+	For     string         `toml:"for"`   // how long the condition must hold, e.g. "1m"
+	Actions []ActionConfig `toml:"actions"`
+}
+
+// ActionConfig describes what a rule does when it fires
+type ActionConfig struct {
+	Type     string            `toml:"type"` // "http", "script", "command" or "mqtt"
+	URL      string            `toml:"url"`
+	Method   string            `toml:"method"` // default POST
+	Headers  map[string]string `toml:"headers"`
+	Command  string            `toml:"command"` // script: run with sh -c, payload on stdin; command: program
+	Args     []string          `toml:"args"`    // command only, passed without a shell
+	Broker   string            `toml:"broker"`  // MQTT host:port
+	Topic    string            `toml:"topic"`
+	Username string            `toml:"username"`
+	Password string            `toml:"password"`
+	Payload  string            `toml:"payload"` // Go template, defaults to the event as JSON
+	Timeout  string            `toml:"timeout"` // default 10s
+	// MaxConcurrent limits how many runs of a command action overlap,
+	// default 1; further firings are skipped
+	MaxConcurrent int `toml:"max_concurrent"`
+}
+
+// NotifierConfig describes a chat or push notification service that
+// receives rule events
+type NotifierConfig struct {
+	Name   string   `toml:"name"`
+	Type   string   `toml:"type"`    // "ntfy", "telegram", "slack" or "discord"
+	URL    string   `toml:"url"`     // webhook URL, or ntfy server / Telegram API base
+	Topic  string   `toml:"topic"`   // ntfy only
+	Token  string   `toml:"token"`   // ntfy access token or Telegram bot token
+	ChatID string   `toml:"chat_id"` // Telegram only
+	Rules  []string `toml:"rules"`   // rule names to route here, '*' wildcards; empty routes all
+	// Tags limits the notifier to hosts whose tags match, '*' wildcards
+	Tags map[string]string `toml:"tags"`
+}
+
+// GoAppConfig describes an external Go application to monitor
+type GoAppConfig struct {
+	Name string `toml:"name"`
+	URL  string `toml:"url"` // debug server base URL serving /debug/vars
+}
+
+// DNSConfig holds the Pi-hole / AdGuard Home integration settings
+type DNSConfig struct {
+	Enabled  bool   `toml:"enabled"`
+	Type     string `toml:"type"`     // "pihole" (default) or "adguard"
+	Address  string `toml:"address"`  // base URL of the web interface
+	Username string `toml:"username"` // AdGuard Home only
+	Password string `toml:"password"`
+}
+
+// BMCConfig describes a server BMC reachable over Redfish or IPMI
+type BMCConfig struct {
+	Name     string `toml:"name"`
+	Type     string `toml:"type"`    // "redfish" (default) or "ipmi"
+	Address  string `toml:"address"` // Redfish base URL or IPMI host
+	Username string `toml:"username"`
+	Password string `toml:"password"`
+	Insecure bool   `toml:"insecure"` // accept self-signed certificates
+}
+
+// LANConfig holds the router/gateway LAN monitoring settings
+type LANConfig struct {
+	Enabled    bool     `toml:"enabled"`
+	DHCPLeases string   `toml:"dhcp_leases"` // dnsmasq leases file
+	KnownMACs  []string `toml:"known_macs"`  // devices that are expected on the network
+}
+
+// JobConfig describes a periodic job such as a cron task
+type JobConfig struct {
+	Name     string `toml:"name"`
+	Interval string `toml:"interval"` // expected run interval, e.g. "24h"
+	Grace    string `toml:"grace"`    // optional extra time before it is overdue
+}
+
+// WANConfig holds the public IP and WAN status widget settings
+type WANConfig struct {
+	Enabled   bool     `toml:"enabled"`
+	Providers []string `toml:"providers"` // URLs returning the caller's IP as plain text
+	Interval  int      `toml:"interval"`  // seconds between lookups, default 300
+	Gateway   string   `toml:"gateway"`   // optional host:port used to measure WAN latency
+}
+
+// LowMemory reports whether the constrained-memory mode should be used,
+// given whether the host itself has little RAM.
+func (c Config) LowMemory(lowMemorySystem bool) (bool, error) {
+	switch c.MemoryMode {
+	case "", "auto":
+		return lowMemorySystem, nil
+	case "low":
+		return true, nil
+	case "normal":
+		return false, nil
+	}
+	return false, fmt.Errorf("invalid memory_mode %q: must be auto, low or normal", c.MemoryMode)
+}
+
+// Location returns the display time zone named by Timezone
+func (c Config) Location() (*time.Location, error) {
+	switch strings.ToLower(c.Timezone) {
+	case "", "local":
+		return time.Local, nil
+	case "utc":
+		return time.UTC, nil
+	}
+	loc, err := time.LoadLocation(c.Timezone)
+	if err != nil {
+		return nil, fmt.Errorf("invalid timezone %q: %w", c.Timezone, err)
+	}
+	return loc, nil
 }
 
 // DefaultConfig returns a Config with default values
@@ -25,10 +308,25 @@ func DefaultConfig() Config {
 	}
 }
 
-// LoadConfig loads configuration from a TOML file
+// ProfileEnv selects the config profile when --profile is not given
+const ProfileEnv = "GODASH_PROFILE"
+
+// LoadConfig loads configuration from a TOML, YAML or JSON file, applying
+// the profile named by GODASH_PROFILE, if any
 func LoadConfig(configFile string) (Config, error) {
+	return LoadProfile(configFile, os.Getenv(ProfileEnv))
+}
+
+// LoadProfile loads configuration from a TOML, YAML or JSON file (see
+// FormatOf), merges the files it includes (see resolveIncludes) and applies
+// the named profile over it. A profile is a [profile.<name>] table holding
+// any settings; its keys replace the base values, tables are merged key by
+// key and arrays such as rules are replaced whole. An empty profile loads
+// the base settings only.
+func LoadProfile(configFile, profile string) (Config, error) {
 	cfg := DefaultConfig()
 	cfg.ConfigFile = configFile
+	cfg.Profile = profile
 
 	// If no config file is specified, try to find one in default locations
 	if configFile == "" {
@@ -37,12 +335,19 @@ func LoadConfig(configFile string) (Config, error) {
 			return cfg, fmt.Errorf("failed to get user home directory: %w", err)
 		}
 
-		// Try to find config in default locations
-		possiblePaths := []string{
-			filepath.Join(homeDir, ".godash.toml"),
-			"godash.toml",
-			".godash.toml",
+		// Try to find config in default locations, godash.toml first and
+		// then its YAML and JSON equivalents
+		var possiblePaths []string
+		if dir, err := ConfigDir(); err == nil {
+			for _, ext := range configExtensions {
+				possiblePaths = append(possiblePaths, filepath.Join(dir, "godash"+ext))
+			}
 		}
+		possiblePaths = append(possiblePaths, filepath.Join(homeDir, ".godash.toml"))
+		for _, ext := range configExtensions {
+			possiblePaths = append(possiblePaths, "godash"+ext)
+		}
+		possiblePaths = append(possiblePaths, ".godash.toml")
 
 		for _, path := range possiblePaths {
 			if _, err := os.Stat(path); err == nil {
@@ -54,30 +359,66 @@ func LoadConfig(configFile string) (Config, error) {
 
 	// If we found a config file, load it
 	if configFile != "" {
-		data, err := os.ReadFile(configFile)
+		data, err := readConfigFile(configFile)
 		if err != nil {
 			return cfg, fmt.Errorf("failed to read config file: %w", err)
 		}
+		if data, err = resolveIncludes(configFile, data); err != nil {
+			return cfg, err
+		}
 
 		if err := toml.Unmarshal(data, &cfg); err != nil {
 			return cfg, fmt.Errorf("failed to parse config file: %w", err)
 		}
+		if profile != "" {
+			if err := applyProfile(&cfg, data, profile); err != nil {
+				return cfg, err
+			}
+		}
+	} else if profile != "" {
+		return cfg, fmt.Errorf("profile %q selected but no config file was found", profile)
 	}
 
 	return cfg, nil
 }
 
-// SaveConfig saves the configuration to a TOML file
+// applyProfile decodes the [profile.<name>] table of data over cfg
+func applyProfile(cfg *Config, data []byte, name string) error {
+	var file struct {
+		Profiles map[string]map[string]any `toml:"profile"`
+	}
+	if err := toml.Unmarshal(data, &file); err != nil {
+		return fmt.Errorf("failed to parse config file: %w", err)
+	}
+	settings, ok := file.Profiles[name]
+	if !ok {
+		return fmt.Errorf("profile %q not found in the config file", name)
+	}
+	overrides, err := toml.Marshal(settings)
+	if err != nil {
+		return fmt.Errorf("profile %s: %w", name, err)
+	}
+	if err := toml.Unmarshal(overrides, cfg); err != nil {
+		return fmt.Errorf("profile %s: %w", name, err)
+	}
+	return nil
+}
+
+// SaveConfig saves the configuration to its file, in the format given by
+// the file's extension
 func SaveConfig(cfg Config) error {
 	if cfg.ConfigFile == "" {
-		homeDir, err := os.UserHomeDir()
+		path, err := DefaultConfigFile()
 		if err != nil {
-			return fmt.Errorf("failed to get user home directory: %w", err)
+			return err
+		}
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			return fmt.Errorf("failed to create config directory: %w", err)
 		}
-		cfg.ConfigFile = filepath.Join(homeDir, ".godash.toml")
+		cfg.ConfigFile = path
 	}
 
-	data, err := toml.Marshal(cfg)
+	data, err := encodeConfig(cfg, FormatOf(cfg.ConfigFile))
 	if err != nil {
 		return fmt.Errorf("failed to marshal config: %w", err)
 	}
@@ -0,0 +1,128 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// Config file formats, chosen by file extension
+const (
+	FormatTOML = "toml"
+	FormatYAML = "yaml"
+	FormatJSON = "json"
+)
+
+// configExtensions are the extensions tried for godash.<ext> in the default
+// locations, in order
+var configExtensions = []string{".toml", ".yaml", ".yml", ".json"}
+
+// FormatOf returns the format of a config file from its extension: YAML for
+// .yaml and .yml, JSON for .json and TOML otherwise
+func FormatOf(path string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		return FormatYAML
+	case ".json":
+		return FormatJSON
+	}
+	return FormatTOML
+}
+
+// readConfigFile reads the config file at path and returns it as TOML.
+// YAML and JSON files use the same keys as TOML ones; they are converted so
+// includes, profiles and decoding work the same for every format.
+func readConfigFile(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	format := FormatOf(path)
+	if format == FormatTOML {
+		return data, nil
+	}
+
+	var table map[string]any
+	switch format {
+	case FormatYAML:
+		err = yaml.Unmarshal(data, &table)
+	case FormatJSON:
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.UseNumber()
+		err = decoder.Decode(&table)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	normalized, err := normalize(table)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	if normalized == nil {
+		return nil, nil
+	}
+	return toml.Marshal(normalized)
+}
+
+// normalize converts decoded YAML or JSON into values TOML can encode:
+// JSON numbers become integers where possible, nulls are dropped and YAML's
+// non-string map keys are rejected
+func normalize(v any) (any, error) {
+	switch v := v.(type) {
+	case map[string]any:
+		for key, value := range v {
+			n, err := normalize(value)
+			if err != nil {
+				return nil, err
+			}
+			if n == nil {
+				delete(v, key)
+				continue
+			}
+			v[key] = n
+		}
+	case map[any]any:
+		return nil, fmt.Errorf("map keys must be strings")
+	case []any:
+		out := v[:0]
+		for _, value := range v {
+			n, err := normalize(value)
+			if err != nil {
+				return nil, err
+			}
+			if n != nil {
+				out = append(out, n)
+			}
+		}
+		return out, nil
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return i, nil
+		}
+		return v.Float64()
+	}
+	return v, nil
+}
+
+// encodeConfig encodes cfg in format, using the TOML key names throughout
+func encodeConfig(cfg Config, format string) ([]byte, error) {
+	data, err := toml.Marshal(cfg)
+	if err != nil || format == FormatTOML {
+		return data, err
+	}
+	var table map[string]any
+	if err := toml.Unmarshal(data, &table); err != nil {
+		return nil, err
+	}
+	if format == FormatJSON {
+		data, err := json.MarshalIndent(table, "", "  ")
+		return append(data, '\n'), err
+	}
+	return yaml.Marshal(table)
+}
@@ -0,0 +1,80 @@
+package config
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2"
+)
+
+// resolveIncludes merges the files listed in the include key of data, the
+// contents of the config file at path, and returns the combined TOML.
+// Patterns are relative to the including file and expand in sorted order.
+// Included files are merged in turn over the main file: scalar keys replace
+// earlier values, tables merge key by key and arrays, such as rules, are
+// appended. Included files cannot include further files.
+func resolveIncludes(path string, data []byte) ([]byte, error) {
+	var head struct {
+		Include []string `toml:"include"`
+	}
+	if err := toml.Unmarshal(data, &head); err != nil {
+		return nil, fmt.Errorf("failed to parse config file: %w", err)
+	}
+	if len(head.Include) == 0 {
+		return data, nil
+	}
+
+	var merged map[string]any
+	if err := toml.Unmarshal(data, &merged); err != nil {
+		return nil, fmt.Errorf("failed to parse config file: %w", err)
+	}
+	for _, pattern := range head.Include {
+		if !filepath.IsAbs(pattern) {
+			pattern = filepath.Join(filepath.Dir(path), pattern)
+		}
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid include %q: %w", pattern, err)
+		}
+		// A literal path must exist, while a glob may match nothing
+		if len(matches) == 0 && !strings.ContainsAny(pattern, "*?[") {
+			return nil, fmt.Errorf("included config file %s does not exist", pattern)
+		}
+		for _, match := range matches {
+			included, err := readConfigFile(match)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read included config file: %w", err)
+			}
+			var table map[string]any
+			if err := toml.Unmarshal(included, &table); err != nil {
+				return nil, fmt.Errorf("failed to parse %s: %w", match, err)
+			}
+			if _, ok := table["include"]; ok {
+				return nil, fmt.Errorf("%s: included files cannot include others", match)
+			}
+			mergeTables(merged, table)
+		}
+	}
+	return toml.Marshal(merged)
+}
+
+// mergeTables merges src into dst: tables recursively, arrays by appending
+// and anything else by replacing
+func mergeTables(dst, src map[string]any) {
+	for key, value := range src {
+		switch v := value.(type) {
+		case map[string]any:
+			if existing, ok := dst[key].(map[string]any); ok {
+				mergeTables(existing, v)
+				continue
+			}
+		case []any:
+			if existing, ok := dst[key].([]any); ok {
+				dst[key] = append(existing, v...)
+				continue
+			}
+		}
+		dst[key] = value
+	}
+}
@@ -0,0 +1,124 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+)
+
+// dataDirOverride replaces the default data directory when set
+var dataDirOverride string
+
+// SetDataDir makes DataDir return dir, from --data-dir or data_dir. An empty
+// dir restores the default.
+func SetDataDir(dir string) {
+	dataDirOverride = dir
+}
+
+// ConfigDir returns godash's directory under the user's config directory:
+// $XDG_CONFIG_HOME/godash (~/.config/godash) on Linux, %APPDATA%\godash on
+// Windows and ~/Library/Application Support/godash on macOS
+func ConfigDir() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get user config directory: %w", err)
+	}
+	return filepath.Join(dir, "godash"), nil
+}
+
+// DefaultConfigFile returns the preferred config file location, godash.toml
+// in ConfigDir
+func DefaultConfigFile() (string, error) {
+	dir, err := ConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "godash.toml"), nil
+}
+
+// DataDir returns the directory where godash keeps its state files:
+// $XDG_DATA_HOME/godash (~/.local/share/godash) on Linux, %LOCALAPPDATA%\godash
+// on Windows and ~/Library/Application Support/godash on macOS. An existing
+// ~/.godash that has not been migrated yet is used instead.
+func DataDir() (string, error) {
+	if dataDirOverride != "" {
+		return dataDirOverride, nil
+	}
+	dir, err := xdgDataDir()
+	if err != nil {
+		return "", err
+	}
+	legacy, err := legacyDataDir()
+	if err != nil {
+		return "", err
+	}
+	if !exists(dir) && exists(legacy) {
+		return legacy, nil
+	}
+	return dir, nil
+}
+
+// MigrateDataDir moves ~/.godash to the new data directory if only the old
+// one exists, returning both paths when it moved anything. Migration is
+// skipped while SetDataDir overrides the location.
+func MigrateDataDir() (from, to string, err error) {
+	if dataDirOverride != "" {
+		return "", "", nil
+	}
+	legacy, err := legacyDataDir()
+	if err != nil {
+		return "", "", err
+	}
+	dir, err := xdgDataDir()
+	if err != nil {
+		return "", "", err
+	}
+	if exists(dir) || !exists(legacy) {
+		return "", "", nil
+	}
+	if err := os.MkdirAll(filepath.Dir(dir), 0o755); err != nil {
+		return "", "", fmt.Errorf("failed to create data directory: %w", err)
+	}
+	if err := os.Rename(legacy, dir); err != nil {
+		return "", "", fmt.Errorf("failed to move %s to %s: %w", legacy, dir, err)
+	}
+	return legacy, dir, nil
+}
+
+// xdgDataDir returns the platform's per-user data directory for godash
+func xdgDataDir() (string, error) {
+	switch runtime.GOOS {
+	case "windows":
+		if dir := os.Getenv("LocalAppData"); dir != "" {
+			return filepath.Join(dir, "godash"), nil
+		}
+		return ConfigDir()
+	case "darwin", "ios", "plan9":
+		return ConfigDir()
+	}
+	if dir := os.Getenv("XDG_DATA_HOME"); filepath.IsAbs(dir) {
+		return filepath.Join(dir, "godash"), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get user home directory: %w", err)
+	}
+	return filepath.Join(home, ".local", "share", "godash"), nil
+}
+
+// legacyDataDir returns ~/.godash, the data directory of earlier versions
+func legacyDataDir() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get user home directory: %w", err)
+	}
+	return filepath.Join(home, ".godash"), nil
+}
+
+// exists reports whether path exists
+func exists(path string) bool {
+	_, err := os.Stat(path)
+	return !errors.Is(err, os.ErrNotExist)
+}
@@ -0,0 +1,196 @@
+// Package export pushes metric samples to external monitoring systems.
+package export
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/j-raghavan/godash/internal/metrics"
+	"github.com/j-raghavan/godash/internal/rules"
+)
+
+// zabbixTimeout bounds one connection to the Zabbix server
+const zabbixTimeout = 10 * time.Second
+
+// zabbixHeader starts every Zabbix protocol packet: "ZBXD" and the
+// protocol flags (0x01, no compression)
+var zabbixHeader = []byte("ZBXD\x01")
+
+// ZabbixItem maps the values matching Metric (a name or pattern as used by
+// rules, e.g. "disk.*.used_percent") to a trapper item key. "{name}" in Key
+// is replaced with the matched value's name.
+type ZabbixItem struct {
+	Metric string
+	Key    string
+}
+
+// ZabbixValue is one item value in a sender data request
+type ZabbixValue struct {
+	Host  string `json:"host"`
+	Key   string `json:"key"`
+	Value string `json:"value"`
+	Clock int64  `json:"clock"`
+}
+
+// ZabbixSender pushes selected values to a Zabbix server or proxy with the
+// trapper protocol used by zabbix_sender
+type ZabbixSender struct {
+	Server   string // host:port, port 10051 by default
+	Host     string // host name as configured in Zabbix
+	Items    []ZabbixItem
+	Interval time.Duration
+	log      io.Writer
+
+	mu      sync.Mutex
+	last    time.Time
+	sending bool
+}
+
+// NewZabbixSender creates a sender for items. Host defaults to the
+// hostname; send failures are written to log.
+func NewZabbixSender(server, host string, items []ZabbixItem, interval time.Duration, log io.Writer) *ZabbixSender {
+	if _, _, err := net.SplitHostPort(server); err != nil {
+		server = net.JoinHostPort(server, "10051")
+	}
+	if host == "" {
+		host, _ = os.Hostname()
+	}
+	return &ZabbixSender{Server: server, Host: host, Items: items, Interval: interval, log: log}
+}
+
+// Observe sends metric's values when Interval has elapsed since the last
+// send. Sending happens in the background so a slow server never stalls
+// collection.
+func (z *ZabbixSender) Observe(metric metrics.Metric) {
+	z.mu.Lock()
+	defer z.mu.Unlock()
+	if z.sending || metric.Timestamp.Sub(z.last) < z.Interval {
+		return
+	}
+	z.sending = true
+	z.last = metric.Timestamp
+
+	values := ZabbixValues(rules.Values(metric), z.Items, z.Host, metric.Timestamp.Unix())
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), zabbixTimeout)
+		defer cancel()
+		if err := z.Send(ctx, values); err != nil && z.log != nil {
+			fmt.Fprintf(z.log, "zabbix %s: %v\n", z.Server, err)
+		}
+		z.mu.Lock()
+		z.sending = false
+		z.mu.Unlock()
+	}()
+}
+
+// ZabbixValues selects the values mapped by items, sorted by key
+func ZabbixValues(values map[string]float64, items []ZabbixItem, host string, clock int64) []ZabbixValue {
+	var out []ZabbixValue
+	for _, item := range items {
+		matched, err := rules.Select(values, item.Metric)
+		if err != nil {
+			continue
+		}
+		for name, v := range matched {
+			out = append(out, ZabbixValue{
+				Host:  host,
+				Key:   strings.ReplaceAll(item.Key, "{name}", name),
+				Value: strconv.FormatFloat(v, 'f', -1, 64),
+				Clock: clock,
+			})
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Key < out[j].Key })
+	return out
+}
+
+// Send delivers values in one sender data request and checks that the
+// server processed all of them
+func (z *ZabbixSender) Send(ctx context.Context, values []ZabbixValue) error {
+	if len(values) == 0 {
+		return nil
+	}
+	request, err := json.Marshal(struct {
+		Request string        `json:"request"`
+		Data    []ZabbixValue `json:"data"`
+	}{"sender data", values})
+	if err != nil {
+		return err
+	}
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", z.Server)
+	if err != nil {
+		return fmt.Errorf("failed to connect: %w", err)
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+	if _, err := conn.Write(EncodeZabbixPacket(request)); err != nil {
+		return fmt.Errorf("failed to send: %w", err)
+	}
+
+	body, err := ReadZabbixPacket(conn)
+	if err != nil {
+		return err
+	}
+	var response struct {
+		Response string `json:"response"`
+		Info     string `json:"info"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil {
+		return fmt.Errorf("invalid response: %w", err)
+	}
+	if response.Response != "success" {
+		return fmt.Errorf("server returned %q: %s", response.Response, response.Info)
+	}
+	// info reads "processed: 3; failed: 1; total: 4; seconds spent: 0.000055"
+	if _, rest, ok := strings.Cut(response.Info, "failed: "); ok {
+		failed, _, _ := strings.Cut(rest, ";")
+		if n, err := strconv.Atoi(failed); err == nil && n > 0 {
+			return fmt.Errorf("%d of %d values rejected, check the host name and item keys (%s)", n, len(values), response.Info)
+		}
+	}
+	return nil
+}
+
+// EncodeZabbixPacket frames data with the Zabbix protocol header and its
+// little-endian 64-bit length
+func EncodeZabbixPacket(data []byte) []byte {
+	packet := make([]byte, 0, len(zabbixHeader)+8+len(data))
+	packet = append(packet, zabbixHeader...)
+	packet = binary.LittleEndian.AppendUint64(packet, uint64(len(data)))
+	return append(packet, data...)
+}
+
+// ReadZabbixPacket reads one framed packet from r and returns its data
+func ReadZabbixPacket(r io.Reader) ([]byte, error) {
+	header := make([]byte, len(zabbixHeader)+8)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	if !bytes.Equal(header[:4], zabbixHeader[:4]) {
+		return nil, fmt.Errorf("invalid response header %q", header[:4])
+	}
+	size := binary.LittleEndian.Uint64(header[len(zabbixHeader):])
+	if size > 1<<20 {
+		return nil, fmt.Errorf("response too large (%d bytes)", size)
+	}
+	data := make([]byte, size)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	return data, nil
+}
@@ -0,0 +1,52 @@
+package i18n
+
+func init() {
+	Register(Language{
+		Code:    "de",
+		Name:    "Deutsch",
+		Decimal: ",",
+		Group:   ".",
+		Messages: map[string]string{
+			// Pane titles
+			"CPU Usage":                       "CPU-Auslastung",
+			"Memory Usage (Updates every %s)": "Arbeitsspeicher (alle %s aktualisiert)",
+			"Disk Usage":                      "Datenträger",
+			"Network I/O (Updates every %s)":  "Netzwerk (alle %s aktualisiert)",
+			"Virtual Machines":                "Virtuelle Maschinen",
+			"Hardware":                        "Hardware",
+			"DNS Filter":                      "DNS-Filter",
+			"Go Runtime":                      "Go-Laufzeit",
+			"Go Applications":                 "Go-Anwendungen",
+			"Recent Alerts":                   "Letzte Warnungen",
+			"Kernel":                          "Kernel",
+			"NUMA Nodes":                      "NUMA-Knoten",
+			"Watched Services":                "Überwachte Dienste",
+			"Interface %s":                    "Schnittstelle %s",
+
+			// Pane contents
+			"Overall: %.1f%%":                    "Gesamt: %.1f %%",
+			"   SoC: %.1f°C":                     "   SoC: %.1f °C",
+			"Core %2d: [%s] %5.1f%%   ":          "Kern %2d: [%s] %5.1f%%   ",
+			"Used: %s\nTotal: %s\n":              "Belegt: %s\nGesamt: %s\n",
+			"Huge pages: %d/%d used (%s each)\n": "Huge Pages: %d/%d belegt (je %s)\n",
+			"Used: %s / %s\n":                    "Belegt: %s / %s\n",
+			"[yellow]Full in %s[white]\n":        "[yellow]Voll in %s[white]\n",
+			"Run queue: [%s]%d runnable[white], %d blocked on I/O, %d cores\n": "Warteschlange: [%s]%d lauffähig[white], %d warten auf E/A, %d Kerne\n",
+			"Core frequencies are not reported on this system\n":               "Kernfrequenzen werden auf diesem System nicht gemeldet\n",
+			"Governor: %s\n":                                                    "Governor: %s\n",
+			"Core %2d: %4.2f GHz%s   ":                                          "Kern %2d: %4.2f GHz%s   ",
+			"[green]units: %s[white]":                                           "[green]Einheiten: %s[white]",
+			"[red]snapshot failed: %v[white]":                                   "[red]Schnappschuss fehlgeschlagen: %v[white]",
+			"[green]saved %s[white]":                                            "[green]%s gespeichert[white]",
+			"[green]interface %s, Enter for details[white]":                     "[green]Schnittstelle %s, Enter für Details[white]",
+			"[red]copy failed: %v[white]":                                       "[red]Kopieren fehlgeschlagen: %v[white]",
+			"[green]copied %s[white]":                                           "[green]%s kopiert[white]",
+			"[red]no match for %s[white]":                                       "[red]kein Treffer für %s[white]",
+			"[green]match %d/%d: %s %s[white]":                                  "[green]Treffer %d/%d: %s %s[white]",
+			"[yellow]/%s: %d matches, 'n'/'N' next/previous, Esc clears[white]": "[yellow]/%s: %d Treffer, 'n'/'N' nächster/vorheriger, Esc hebt auf[white]",
+
+			// Status bar
+			"[yellow]Press 'q' to quit, 'g' to toggle Go runtime stats, 'k' kernel counters, 'n' NUMA layout, 'c' CPU detail, 's' save snapshot, 'u'/'b' units, 'd'/'D' more disks, ↑/↓ Enter interface details, '/' search, 'y'/'Y' copy as text/JSON[white]": "[yellow]'q' beenden, 'g' Go-Laufzeit, 'k' Kernel-Zähler, 'n' NUMA, 'c' CPU-Details, 's' Schnappschuss speichern, 'u'/'b' Einheiten, 'd'/'D' weitere Datenträger, ↑/↓ Enter Schnittstellendetails, '/' suchen, 'y'/'Y' als Text/JSON kopieren[white]",
+		},
+	})
+}
@@ -0,0 +1,211 @@
+// Package i18n translates user-facing labels and formats numbers for a
+// locale. Messages are identified by their English text, so untranslated
+// messages fall back to English. A language is added by registering it
+// from an init function in a file of its own, as de.go does.
+package i18n
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Language is a message catalog and the number format of a language
+type Language struct {
+	Code    string // ISO 639-1 code, e.g. "de"
+	Name    string // name in the language itself, e.g. "Deutsch"
+	Decimal string // decimal separator, "." when empty
+	Group   string // thousands separator used by Number
+	// Messages maps English messages, including fmt verbs, to their
+	// translation. Translations must keep the verbs in the same order.
+	Messages map[string]string
+}
+
+var (
+	mu        sync.RWMutex
+	languages = map[string]Language{}
+)
+
+// Register adds or replaces a language
+func Register(lang Language) {
+	mu.Lock()
+	defer mu.Unlock()
+	languages[lang.Code] = lang
+}
+
+// Languages returns the codes of the registered languages, sorted
+func Languages() []string {
+	mu.RLock()
+	defer mu.RUnlock()
+	codes := make([]string, 0, len(languages))
+	for code := range languages {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+	return codes
+}
+
+// Messages returns the English messages translated by a language, sorted
+func Messages(code string) []string {
+	mu.RLock()
+	defer mu.RUnlock()
+	msgs := make([]string, 0, len(languages[code].Messages))
+	for msg := range languages[code].Messages {
+		msgs = append(msgs, msg)
+	}
+	sort.Strings(msgs)
+	return msgs
+}
+
+// Has reports whether a language is registered for locale
+func Has(locale string) bool {
+	mu.RLock()
+	defer mu.RUnlock()
+	_, ok := languages[languageCode(locale)]
+	return ok
+}
+
+// English is the source language of every message
+var English = Language{Code: "en", Name: "English", Decimal: ".", Group: ","}
+
+func init() {
+	Register(English)
+}
+
+// Detect returns the locale from the environment: LC_ALL, LC_MESSAGES or
+// LANG, whichever is set first
+func Detect() string {
+	for _, name := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+		if v := os.Getenv(name); v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// languageCode returns the language of a locale such as "de_DE.UTF-8"
+func languageCode(locale string) string {
+	code, _, _ := strings.Cut(locale, ".")
+	code, _, _ = strings.Cut(code, "_")
+	code, _, _ = strings.Cut(code, "-")
+	return strings.ToLower(code)
+}
+
+// Printer translates messages and formats numbers for one language
+type Printer struct {
+	lang Language
+}
+
+// New returns a printer for locale, e.g. "de" or "de_DE.UTF-8". Unknown
+// locales, "C" and "POSIX" use English.
+func New(locale string) *Printer {
+	mu.RLock()
+	defer mu.RUnlock()
+	lang, ok := languages[languageCode(locale)]
+	if !ok {
+		lang = English
+	}
+	if lang.Decimal == "" {
+		lang.Decimal = "."
+	}
+	return &Printer{lang: lang}
+}
+
+// Language returns the code of the printer's language
+func (p *Printer) Language() string {
+	return p.lang.Code
+}
+
+// Decimal returns the decimal separator
+func (p *Printer) Decimal() string {
+	return p.lang.Decimal
+}
+
+// T returns the translation of msg, or msg itself
+func (p *Printer) T(msg string) string {
+	if translated, ok := p.lang.Messages[msg]; ok {
+		return translated
+	}
+	return msg
+}
+
+// Sprintf translates format and formats args with it, writing floats with
+// the language's decimal separator
+func (p *Printer) Sprintf(format string, args ...any) string {
+	return fmt.Sprintf(p.T(format), p.localize(args)...)
+}
+
+// Fprintf is Sprintf writing to w
+func (p *Printer) Fprintf(w io.Writer, format string, args ...any) (int, error) {
+	return fmt.Fprintf(w, p.T(format), p.localize(args)...)
+}
+
+// localize wraps float arguments so they are printed with the decimal
+// separator
+func (p *Printer) localize(args []any) []any {
+	if p.lang.Decimal == "." {
+		return args
+	}
+	localized := make([]any, len(args))
+	for i, arg := range args {
+		switch v := arg.(type) {
+		case float64:
+			localized[i] = localFloat{v, p.lang.Decimal}
+		case float32:
+			localized[i] = localFloat{float64(v), p.lang.Decimal}
+		default:
+			localized[i] = arg
+		}
+	}
+	return localized
+}
+
+// Number formats v with decimals digits after the separator and groups
+// the integer digits in thousands, e.g. "1,234.5" or "1.234,5"
+func (p *Printer) Number(v float64, decimals int) string {
+	s := strconv.FormatFloat(v, 'f', decimals, 64)
+	sign := ""
+	if strings.HasPrefix(s, "-") {
+		sign, s = "-", s[1:]
+	}
+	whole, frac, _ := strings.Cut(s, ".")
+	var b strings.Builder
+	for i, digit := range whole {
+		if i > 0 && (len(whole)-i)%3 == 0 {
+			b.WriteString(p.lang.Group)
+		}
+		b.WriteRune(digit)
+	}
+	if frac != "" {
+		b.WriteString(p.lang.Decimal + frac)
+	}
+	return sign + b.String()
+}
+
+// localFloat prints a float with another decimal separator
+type localFloat struct {
+	v       float64
+	decimal string
+}
+
+// Format implements fmt.Formatter by formatting the float as usual and
+// swapping the separator, which keeps the width
+func (f localFloat) Format(state fmt.State, verb rune) {
+	spec := "%"
+	for _, flag := range "+-# 0" {
+		if state.Flag(int(flag)) {
+			spec += string(flag)
+		}
+	}
+	if width, ok := state.Width(); ok {
+		spec += strconv.Itoa(width)
+	}
+	if precision, ok := state.Precision(); ok {
+		spec += "." + strconv.Itoa(precision)
+	}
+	io.WriteString(state, strings.Replace(fmt.Sprintf(spec+string(verb), f.v), ".", f.decimal, 1))
+}
@@ -0,0 +1,212 @@
+package jobs
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/j-raghavan/godash/internal/config"
+)
+
+// Job is a periodic task that is expected to report completion at least
+// once every Interval (plus Grace).
+type Job struct {
+	Name     string
+	Interval time.Duration
+	Grace    time.Duration
+}
+
+// Status represents the state of a job at a point in time.
+type Status struct {
+	Name    string        `json:"name"`
+	LastRun time.Time     `json:"last_run"` // zero if the job never reported
+	NextDue time.Time     `json:"next_due"` // zero if the job never reported and is not watched
+	Overdue bool          `json:"overdue"`
+	Late    time.Duration `json:"late_ns"` // how far past NextDue an overdue job is
+}
+
+// ParseJobs converts the configured jobs, validating their durations
+func ParseJobs(cfgs []config.JobConfig) ([]Job, error) {
+	jobs := make([]Job, 0, len(cfgs))
+	for _, c := range cfgs {
+		if c.Name == "" {
+			return nil, fmt.Errorf("job is missing a name")
+		}
+		interval, err := time.ParseDuration(c.Interval)
+		if err != nil || interval <= 0 {
+			return nil, fmt.Errorf("invalid interval %q for job %s", c.Interval, c.Name)
+		}
+		job := Job{Name: c.Name, Interval: interval}
+		if c.Grace != "" {
+			if job.Grace, err = time.ParseDuration(c.Grace); err != nil {
+				return nil, fmt.Errorf("invalid grace %q for job %s", c.Grace, c.Name)
+			}
+		}
+		jobs = append(jobs, job)
+	}
+	return jobs, nil
+}
+
+// Check computes the status of each job from the last recorded runs. A job
+// that never reported is due one interval (plus grace) after it was first
+// watched, so that a job that never runs at all is also reported overdue.
+func Check(jobs []Job, lastRuns, watched map[string]time.Time, now time.Time) []Status {
+	statuses := make([]Status, 0, len(jobs))
+	for _, job := range jobs {
+		status := Status{Name: job.Name}
+		since, ok := lastRuns[job.Name]
+		if ok {
+			status.LastRun = since
+		} else {
+			since, ok = watched[job.Name]
+		}
+		if ok {
+			status.NextDue = since.Add(job.Interval + job.Grace)
+			if now.After(status.NextDue) {
+				status.Overdue = true
+				status.Late = now.Sub(status.NextDue)
+			}
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses
+}
+
+// Store persists the last completion time of each job, and when each job
+// was first watched, in a JSON file. Updates from concurrent processes,
+// such as jobs completing at the same time, are serialized with a lock
+// file next to it.
+type Store struct {
+	path string
+}
+
+// state is the content of the store's file
+type state struct {
+	LastRuns map[string]time.Time `json:"last_runs"`
+	Watched  map[string]time.Time `json:"watched"`
+}
+
+// NewStore creates a Store backed by the file at path
+func NewStore(path string) *Store {
+	return &Store{path: path}
+}
+
+// LastRuns returns the last completion time of every job that reported
+func (s *Store) LastRuns() (map[string]time.Time, error) {
+	st, err := s.load()
+	if err != nil {
+		return nil, err
+	}
+	return st.LastRuns, nil
+}
+
+// Watched returns when each job was first watched, without watching any
+func (s *Store) Watched() (map[string]time.Time, error) {
+	st, err := s.load()
+	if err != nil {
+		return nil, err
+	}
+	return st.Watched, nil
+}
+
+// RecordRun records that the named job completed at the given time
+func (s *Store) RecordRun(name string, at time.Time) error {
+	return s.update(func(st *state) {
+		st.LastRuns[name] = at
+	})
+}
+
+// Watch records now as the time the named jobs were first watched, for
+// those without one yet, and returns when each job was first watched
+func (s *Store) Watch(names []string, now time.Time) (map[string]time.Time, error) {
+	var watched map[string]time.Time
+	err := s.update(func(st *state) {
+		for _, name := range names {
+			if _, ok := st.Watched[name]; !ok {
+				st.Watched[name] = now
+			}
+		}
+		watched = st.Watched
+	})
+	return watched, err
+}
+
+// load reads the state file. Files written before jobs were watched hold
+// just the last runs.
+func (s *Store) load() (state, error) {
+	st := state{LastRuns: make(map[string]time.Time), Watched: make(map[string]time.Time)}
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return st, nil
+		}
+		return st, fmt.Errorf("failed to read job state: %w", err)
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return st, fmt.Errorf("failed to parse job state: %w", err)
+	}
+	if _, ok := fields["last_runs"]; ok {
+		err = json.Unmarshal(data, &st)
+	} else {
+		err = json.Unmarshal(data, &st.LastRuns)
+	}
+	if err != nil {
+		return st, fmt.Errorf("failed to parse job state: %w", err)
+	}
+	if st.LastRuns == nil {
+		st.LastRuns = make(map[string]time.Time)
+	}
+	if st.Watched == nil {
+		st.Watched = make(map[string]time.Time)
+	}
+	return st, nil
+}
+
+// update applies fn to the state while holding the lock and writes it back
+func (s *Store) update(fn func(*state)) error {
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o755); err != nil {
+		return fmt.Errorf("failed to create data directory: %w", err)
+	}
+	lock, err := os.OpenFile(s.path+".lock", os.O_CREATE|os.O_RDWR, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to lock job state: %w", err)
+	}
+	defer lock.Close()
+	if err := lockFile(lock); err != nil {
+		return fmt.Errorf("failed to lock job state: %w", err)
+	}
+
+	st, err := s.load()
+	if err != nil {
+		return err
+	}
+	fn(&st)
+	data, err := json.MarshalIndent(st, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal job state: %w", err)
+	}
+
+	// Write to a temporary file and rename so readers never see a partial file
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to write job state: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write job state: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write job state: %w", err)
+	}
+	if err := os.Chmod(tmp.Name(), 0o644); err != nil {
+		return fmt.Errorf("failed to write job state: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		return fmt.Errorf("failed to write job state: %w", err)
+	}
+	return nil
+}
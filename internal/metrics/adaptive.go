@@ -0,0 +1,129 @@
+package metrics
+
+import (
+	"os"
+	"time"
+
+	"github.com/shirou/gopsutil/v3/cpu"
+	"github.com/shirou/gopsutil/v3/process"
+)
+
+// adaptiveWindow is how often the sampling interval is reconsidered. Load
+// measured over single 100ms ticks is too noisy to act on.
+const adaptiveWindow = 2 * time.Second
+
+// Adaptive configures automatic slowing down of periodic collection under
+// load, so the monitor does not add to the problem it is showing
+type Adaptive struct {
+	// CPUThreshold is the system CPU percentage above which sampling slows
+	CPUThreshold float64
+	// SelfBudget is the CPU godash itself may use, in percent of one core
+	SelfBudget float64
+	// MaxInterval is the slowest sampling interval
+	MaxInterval time.Duration
+}
+
+// NextInterval returns the sampling interval to use after current, given
+// the system CPU usage and godash's own CPU usage. The interval doubles
+// while either is over its limit, up to MaxInterval, and halves back
+// towards base once both are comfortably (below half their limit) under it.
+func NextInterval(current, base time.Duration, systemCPU, selfCPU float64, a Adaptive) time.Duration {
+	switch {
+	case systemCPU > a.CPUThreshold || selfCPU > a.SelfBudget:
+		next := current * 2
+		if next > a.MaxInterval {
+			next = a.MaxInterval
+		}
+		if next < current {
+			return current
+		}
+		return next
+	case systemCPU < a.CPUThreshold/2 && selfCPU < a.SelfBudget/2:
+		next := current / 2
+		if next < base {
+			next = base
+		}
+		return next
+	}
+	return current
+}
+
+// SetAdaptive enables adaptive sampling for periodic collection started
+// with Start
+func (c *SystemCollector) SetAdaptive(a Adaptive) {
+	c.adaptive = &a
+}
+
+// selfCPUMeter measures the CPU used by this process between calls
+type selfCPUMeter struct {
+	proc     *process.Process
+	lastCPU  float64
+	lastTime time.Time
+}
+
+// newSelfCPUMeter creates a meter for the current process, or returns nil if
+// process times are unavailable on this platform
+func newSelfCPUMeter() *selfCPUMeter {
+	proc, err := process.NewProcess(int32(os.Getpid()))
+	if err != nil {
+		return nil
+	}
+	m := &selfCPUMeter{proc: proc, lastTime: time.Now()}
+	if times, err := proc.Times(); err == nil {
+		m.lastCPU = times.User + times.System
+	}
+	return m
+}
+
+// percent returns the CPU used since the previous call, in percent of one
+// core
+func (m *selfCPUMeter) percent() float64 {
+	if m == nil {
+		return 0
+	}
+	times, err := m.proc.Times()
+	if err != nil {
+		return 0
+	}
+	now := time.Now()
+	used := times.User + times.System
+	elapsed := now.Sub(m.lastTime).Seconds()
+	pct := 0.0
+	if elapsed > 0 {
+		pct = (used - m.lastCPU) / elapsed * 100
+	}
+	m.lastCPU, m.lastTime = used, now
+	return pct
+}
+
+// systemCPUMeter measures the system CPU usage between calls, so that the
+// interval follows the load over a whole adaptiveWindow rather than the
+// last tick
+type systemCPUMeter struct {
+	last cpu.TimesStat
+}
+
+// newSystemCPUMeter creates a meter for the system CPU usage, or returns nil
+// if CPU times are unavailable on this platform
+func newSystemCPUMeter() *systemCPUMeter {
+	times, err := cpu.Times(false)
+	if err != nil || len(times) == 0 {
+		return nil
+	}
+	return &systemCPUMeter{last: times[0]}
+}
+
+// percent returns the system CPU usage since the previous call, in percent
+// of all cores, or fallback if it cannot be measured
+func (m *systemCPUMeter) percent(fallback float64) float64 {
+	if m == nil {
+		return fallback
+	}
+	times, err := cpu.Times(false)
+	if err != nil || len(times) == 0 {
+		return fallback
+	}
+	pct := CPUPercent(m.last, times[0])
+	m.last = times[0]
+	return pct
+}
@@ -0,0 +1,51 @@
+package metrics
+
+import (
+	"time"
+
+	"github.com/j-raghavan/godash/internal/bandwidth"
+)
+
+// SetBandwidth enables transfer accounting of the named interfaces, or of
+// every interface but loopback when none are named.
+func (c *SystemCollector) SetBandwidth(accountant *bandwidth.Accountant, interfaces []string) {
+	c.bandwidth = accountant
+	c.bandwidthInterfaces = make(map[string]bool, len(interfaces))
+	for _, name := range interfaces {
+		c.bandwidthInterfaces[name] = true
+	}
+}
+
+// SaveBandwidth writes the transfer accounted so far, which is otherwise
+// saved once a minute. It does nothing when accounting is disabled.
+func (c *SystemCollector) SaveBandwidth() error {
+	if c.bandwidth == nil {
+		return nil
+	}
+	return c.bandwidth.Flush()
+}
+
+// collectBandwidthMetrics accounts the transfer of the interfaces in
+// network. It returns nil when accounting is disabled.
+func (c *SystemCollector) collectBandwidthMetrics(network []NetworkStat, now time.Time) (*bandwidth.Report, error) {
+	if c.bandwidth == nil {
+		return nil, nil
+	}
+	samples := make([]bandwidth.Sample, 0, len(network))
+	for _, net := range network {
+		if len(c.bandwidthInterfaces) > 0 && !c.bandwidthInterfaces[net.Interface] {
+			continue
+		}
+		if len(c.bandwidthInterfaces) == 0 && isLoopback(net.Interface) {
+			continue
+		}
+		samples = append(samples, bandwidth.Sample{Interface: net.Interface, RxBytes: net.RxBytes, TxBytes: net.TxBytes})
+	}
+	return c.bandwidth.Add(samples, now)
+}
+
+// isLoopback reports whether name is the loopback interface on Linux, the
+// BSDs or macOS
+func isLoopback(name string) bool {
+	return name == "lo" || name == "lo0"
+}
@@ -0,0 +1,336 @@
+package metrics
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// bmcRefreshInterval is how often BMCs are polled; they are slow to answer
+const bmcRefreshInterval = 30 * time.Second
+
+// bmcTimeout bounds a single poll of one BMC
+const bmcTimeout = 20 * time.Second
+
+// Sensor kinds reported by BMCs.
+const (
+	SensorTemperature = "temperature"
+	SensorFan         = "fan"
+	SensorPower       = "power"
+)
+
+// BMC describes a baseboard management controller to poll.
+type BMC struct {
+	Name     string
+	Type     string // "redfish" or "ipmi"
+	Address  string // Redfish base URL or IPMI host
+	Username string
+	Password string
+	Insecure bool // skip TLS verification for self-signed BMC certificates
+}
+
+// HardwareSensor represents a fan, PSU or temperature reading from a BMC.
+type HardwareSensor struct {
+	Host   string  `json:"host"`
+	Name   string  `json:"name"`
+	Kind   string  `json:"kind"`
+	Value  float64 `json:"value"`
+	Unit   string  `json:"unit"`
+	Status string  `json:"status"` // health as reported by the BMC, e.g. "ok", "OK", "Critical"
+}
+
+// Healthy reports whether the BMC considers the sensor healthy
+func (s HardwareSensor) Healthy() bool {
+	switch strings.ToLower(s.Status) {
+	case "", "ok", "ns":
+		return true
+	}
+	return false
+}
+
+// ParseIPMISensors parses the output of `ipmitool sdr`, e.g.
+// "CPU Temp         | 45 degrees C      | ok"
+func ParseIPMISensors(r io.Reader) ([]HardwareSensor, error) {
+	var sensors []HardwareSensor
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		parts := strings.Split(scanner.Text(), "|")
+		if len(parts) != 3 {
+			continue
+		}
+		sensor := HardwareSensor{
+			Name:   strings.TrimSpace(parts[0]),
+			Status: strings.TrimSpace(parts[2]),
+		}
+		value := strings.Fields(parts[1])
+		if len(value) < 2 {
+			continue
+		}
+		unit := strings.Join(value[1:], " ")
+		switch {
+		case unit == "degrees C":
+			sensor.Kind, sensor.Unit = SensorTemperature, "°C"
+		case unit == "RPM":
+			sensor.Kind, sensor.Unit = SensorFan, "RPM"
+		case unit == "Watts":
+			sensor.Kind, sensor.Unit = SensorPower, "W"
+		default:
+			continue
+		}
+		v, err := strconv.ParseFloat(value[0], 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid reading %q for sensor %s: %w", value[0], sensor.Name, err)
+		}
+		sensor.Value = v
+		sensors = append(sensors, sensor)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return sensors, nil
+}
+
+// redfishStatus is the common Redfish status object
+type redfishStatus struct {
+	Health string
+	State  string
+}
+
+// redfishThermal is the subset of a Redfish Thermal resource we use
+type redfishThermal struct {
+	Temperatures []struct {
+		Name           string
+		ReadingCelsius *float64
+		Status         redfishStatus
+	}
+	Fans []struct {
+		Name         string
+		FanName      string
+		Reading      *float64
+		ReadingUnits string
+		Status       redfishStatus
+	}
+}
+
+// redfishPower is the subset of a Redfish Power resource we use
+type redfishPower struct {
+	PowerSupplies []struct {
+		Name                 string
+		LastPowerOutputWatts *float64
+		Status               redfishStatus
+	}
+}
+
+// ParseRedfishThermal parses a Redfish Chassis Thermal resource
+func ParseRedfishThermal(data []byte) ([]HardwareSensor, error) {
+	var thermal redfishThermal
+	if err := json.Unmarshal(data, &thermal); err != nil {
+		return nil, fmt.Errorf("failed to parse redfish thermal: %w", err)
+	}
+
+	var sensors []HardwareSensor
+	for _, t := range thermal.Temperatures {
+		if t.ReadingCelsius == nil || t.Status.State == "Absent" {
+			continue
+		}
+		sensors = append(sensors, HardwareSensor{
+			Name: t.Name, Kind: SensorTemperature, Value: *t.ReadingCelsius, Unit: "°C", Status: t.Status.Health,
+		})
+	}
+	for _, f := range thermal.Fans {
+		if f.Reading == nil || f.Status.State == "Absent" {
+			continue
+		}
+		name := f.Name
+		if name == "" {
+			name = f.FanName // Redfish versions before 1.1
+		}
+		unit := f.ReadingUnits
+		if unit == "" {
+			unit = "RPM"
+		}
+		sensors = append(sensors, HardwareSensor{
+			Name: name, Kind: SensorFan, Value: *f.Reading, Unit: unit, Status: f.Status.Health,
+		})
+	}
+	return sensors, nil
+}
+
+// ParseRedfishPower parses a Redfish Chassis Power resource
+func ParseRedfishPower(data []byte) ([]HardwareSensor, error) {
+	var power redfishPower
+	if err := json.Unmarshal(data, &power); err != nil {
+		return nil, fmt.Errorf("failed to parse redfish power: %w", err)
+	}
+
+	var sensors []HardwareSensor
+	for _, psu := range power.PowerSupplies {
+		if psu.Status.State == "Absent" {
+			continue
+		}
+		sensor := HardwareSensor{Name: psu.Name, Kind: SensorPower, Unit: "W", Status: psu.Status.Health}
+		if psu.LastPowerOutputWatts != nil {
+			sensor.Value = *psu.LastPowerOutputWatts
+		}
+		sensors = append(sensors, sensor)
+	}
+	return sensors, nil
+}
+
+// bmcMonitor polls BMCs in the background so slow controllers never stall
+// collection.
+type bmcMonitor struct {
+	bmcs []BMC
+
+	mu       sync.Mutex
+	sensors  []HardwareSensor
+	errs     map[string]string
+	last     time.Time
+	checking bool
+}
+
+// SetBMCs enables polling of the given BMCs for fan, PSU and temperature sensors
+func (c *SystemCollector) SetBMCs(bmcs []BMC) {
+	c.bmc = &bmcMonitor{bmcs: bmcs}
+}
+
+// collectHardwareMetrics returns the latest BMC readings, starting a
+// background poll when one is due. It returns nil when no BMCs are configured.
+func (c *SystemCollector) collectHardwareMetrics() ([]HardwareSensor, error) {
+	if c.bmc == nil {
+		return nil, nil
+	}
+	m := c.bmc
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if !m.checking && time.Since(m.last) >= c.pollInterval(bmcRefreshInterval) {
+		m.checking = true
+		go m.poll()
+	}
+	for _, bmc := range m.bmcs {
+		if msg, ok := m.errs[bmc.Name]; ok {
+			return m.sensors, fmt.Errorf("bmc %s: %s", bmc.Name, msg)
+		}
+	}
+	return m.sensors, nil
+}
+
+// poll queries every BMC and replaces the cached readings
+func (m *bmcMonitor) poll() {
+	var sensors []HardwareSensor
+	errs := make(map[string]string)
+	for _, bmc := range m.bmcs {
+		ctx, cancel := context.WithTimeout(context.Background(), bmcTimeout)
+		var readings []HardwareSensor
+		var err error
+		switch bmc.Type {
+		case "ipmi":
+			readings, err = pollIPMI(ctx, bmc)
+		default:
+			readings, err = pollRedfish(ctx, bmc)
+		}
+		cancel()
+		if err != nil {
+			errs[bmc.Name] = err.Error()
+			continue
+		}
+		for i := range readings {
+			readings[i].Host = bmc.Name
+		}
+		sensors = append(sensors, readings...)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.sensors = sensors
+	m.errs = errs
+	m.last = time.Now()
+	m.checking = false
+}
+
+// pollIPMI reads sensors through ipmitool over the lanplus interface
+func pollIPMI(ctx context.Context, bmc BMC) ([]HardwareSensor, error) {
+	args := []string{"sdr"}
+	if bmc.Address != "" {
+		args = []string{"-I", "lanplus", "-H", bmc.Address, "-U", bmc.Username, "-E", "sdr"}
+	}
+	cmd := exec.CommandContext(ctx, "ipmitool", args...)
+	// -E reads the password from the environment so it never shows in ps
+	cmd.Env = append(cmd.Environ(), "IPMI_PASSWORD="+bmc.Password)
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("ipmitool failed: %w", err)
+	}
+	return ParseIPMISensors(bytes.NewReader(out))
+}
+
+// pollRedfish reads the Thermal and Power resources of every chassis
+func pollRedfish(ctx context.Context, bmc BMC) ([]HardwareSensor, error) {
+	client := &http.Client{}
+	if bmc.Insecure {
+		client.Transport = &http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: true}} //nolint:gosec // opt-in for self-signed BMCs
+	}
+	base := strings.TrimSuffix(bmc.Address, "/")
+
+	get := func(path string) ([]byte, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, base+path, nil)
+		if err != nil {
+			return nil, err
+		}
+		req.SetBasicAuth(bmc.Username, bmc.Password)
+		resp, err := client.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("GET %s returned %s", path, resp.Status)
+		}
+		return io.ReadAll(resp.Body)
+	}
+
+	data, err := get("/redfish/v1/Chassis")
+	if err != nil {
+		return nil, err
+	}
+	var chassis struct {
+		Members []struct {
+			ID string `json:"@odata.id"`
+		}
+	}
+	if err := json.Unmarshal(data, &chassis); err != nil {
+		return nil, fmt.Errorf("failed to parse redfish chassis: %w", err)
+	}
+
+	var sensors []HardwareSensor
+	for _, member := range chassis.Members {
+		if data, err := get(member.ID + "/Thermal"); err == nil {
+			thermal, err := ParseRedfishThermal(data)
+			if err != nil {
+				return nil, err
+			}
+			sensors = append(sensors, thermal...)
+		}
+		if data, err := get(member.ID + "/Power"); err == nil {
+			power, err := ParseRedfishPower(data)
+			if err != nil {
+				return nil, err
+			}
+			sensors = append(sensors, power...)
+		}
+	}
+	return sensors, nil
+}
@@ -0,0 +1,178 @@
+package metrics
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"net"
+	"os"
+	"sync"
+	"time"
+)
+
+// certRefreshInterval is how often certificates are checked; expiry is
+// counted in days so there is no point in checking more often
+const certRefreshInterval = time.Hour
+
+// certTimeout bounds a single TLS handshake
+const certTimeout = 5 * time.Second
+
+// defaultCertWarnDays is how close to expiry a certificate is flagged
+const defaultCertWarnDays = 14
+
+// CertCheck describes a certificate to check, either served by a TLS
+// endpoint or stored in a PEM file
+type CertCheck struct {
+	Name       string
+	Address    string // host:port, e.g. "proxy.lan:443"
+	ServerName string // SNI name, defaults to the address host
+	File       string // PEM file, used when Address is empty
+	WarnDays   int    // flag the certificate this many days before expiry
+}
+
+// CertStat reports the certificate that expires first in a chain
+type CertStat struct {
+	Name     string    `json:"name"`
+	Subject  string    `json:"subject"`
+	Issuer   string    `json:"issuer"`
+	NotAfter time.Time `json:"not_after"`
+	DaysLeft float64   `json:"days_left"` // negative once expired
+	Expiring bool      `json:"expiring"`  // DaysLeft is below the check's WarnDays
+	Error    string    `json:"error"`
+}
+
+// ParseCertFile returns the certificates in PEM data
+func ParseCertFile(data []byte) ([]*x509.Certificate, error) {
+	var certs []*x509.Certificate
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse certificate: %w", err)
+		}
+		certs = append(certs, cert)
+	}
+	if len(certs) == 0 {
+		return nil, fmt.Errorf("no certificates found")
+	}
+	return certs, nil
+}
+
+// CertStatFromChain reports the certificate in chain that expires first,
+// since an expired intermediate breaks the chain as surely as the leaf
+func CertStatFromChain(chain []*x509.Certificate, now time.Time, warnDays int) CertStat {
+	if len(chain) == 0 {
+		return CertStat{Error: "no certificates"}
+	}
+	first := chain[0]
+	for _, cert := range chain[1:] {
+		if cert.NotAfter.Before(first.NotAfter) {
+			first = cert
+		}
+	}
+	days := first.NotAfter.Sub(now).Hours() / 24
+	return CertStat{
+		Subject:  first.Subject.CommonName,
+		Issuer:   first.Issuer.CommonName,
+		NotAfter: first.NotAfter,
+		DaysLeft: days,
+		Expiring: days < float64(warnDays),
+	}
+}
+
+// certMonitor checks certificates in the background so a slow endpoint
+// never stalls collection
+type certMonitor struct {
+	checks []CertCheck
+
+	mu       sync.Mutex
+	stats    []CertStat
+	last     time.Time
+	checking bool
+}
+
+// SetCertChecks enables certificate expiry checks
+func (c *SystemCollector) SetCertChecks(checks []CertCheck) {
+	for i := range checks {
+		if checks[i].WarnDays <= 0 {
+			checks[i].WarnDays = defaultCertWarnDays
+		}
+	}
+	c.certs = &certMonitor{checks: checks}
+}
+
+// collectCertMetrics returns the latest certificate stats, starting a
+// background check when one is due. It returns nil when none are configured.
+func (c *SystemCollector) collectCertMetrics() []CertStat {
+	if c.certs == nil {
+		return nil
+	}
+	m := c.certs
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if !m.checking && time.Since(m.last) >= certRefreshInterval {
+		m.checking = true
+		go m.check()
+	}
+	return m.stats
+}
+
+// check reads every configured certificate
+func (m *certMonitor) check() {
+	stats := make([]CertStat, 0, len(m.checks))
+	for _, check := range m.checks {
+		var stat CertStat
+		chain, err := loadCertChain(check)
+		if err != nil {
+			stat.Error = err.Error()
+		} else {
+			stat = CertStatFromChain(chain, time.Now(), check.WarnDays)
+		}
+		stat.Name = check.Name
+		stats = append(stats, stat)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.stats = stats
+	m.last = time.Now()
+	m.checking = false
+}
+
+// loadCertChain returns the certificates served by the check's endpoint or
+// stored in its file
+func loadCertChain(check CertCheck) ([]*x509.Certificate, error) {
+	if check.Address == "" {
+		data, err := os.ReadFile(check.File)
+		if err != nil {
+			return nil, err
+		}
+		return ParseCertFile(data)
+	}
+
+	serverName := check.ServerName
+	if serverName == "" {
+		serverName, _, _ = net.SplitHostPort(check.Address)
+	}
+	dialer := &net.Dialer{Timeout: certTimeout}
+	// Verification is skipped so expired and self-signed certificates can
+	// still be inspected; only their dates are reported
+	conn, err := tls.DialWithDialer(dialer, "tcp", check.Address, &tls.Config{
+		ServerName:         serverName,
+		InsecureSkipVerify: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	return conn.ConnectionState().PeerCertificates, nil
+}
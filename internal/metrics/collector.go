@@ -1,29 +1,96 @@
 package metrics
 
 import (
+	"math"
+	stdnet "net"
 	"runtime"
+	"runtime/debug"
+	"strconv"
 	"time"
 
+	"github.com/j-raghavan/godash/internal/bandwidth"
+	"github.com/j-raghavan/godash/internal/jobs"
+	"github.com/shirou/gopsutil/v3/cpu"
 	"github.com/shirou/gopsutil/v3/disk"
+	"github.com/shirou/gopsutil/v3/mem"
 	"github.com/shirou/gopsutil/v3/net"
 )
 
-// Metric represents a snapthot of system metrics at a pont in time.
+// Mount tables and interface flags change rarely but are expensive to read
+// (a full /proc/self/mountinfo parse and a netlink dump respectively), so
+// they are refreshed on their own schedule rather than every tick
+const (
+	partitionRefreshInterval = 5 * time.Second
+	interfaceRefreshInterval = time.Second
+)
+
+// SchemaVersion is the version of Metric's JSON encoding, as written by the
+// agent and snapshots. It is bumped whenever a field is renamed, removed or
+// changes meaning; adding fields keeps the version.
+const SchemaVersion = 1
+
+// Metric represents a snapthot of system metrics at a pont in time. Its JSON
+// encoding uses the stable snake_case names documented in docs/schema.md;
+// durations are encoded as nanoseconds, in fields ending in _ns.
 type Metric struct {
-	Timestamp time.Time
-	CPU       []float64
-	Memory    MemoryStat
-	Disk      []DiskStat
-	Network   []NetworkStat
-	GoRuntime GoRuntimeStat
+	SchemaVersion int               `json:"schema_version"`
+	Timestamp     time.Time         `json:"timestamp"`
+	Tags          map[string]string `json:"tags,omitempty"` // host tags from the configuration
+	CPU           []float64         `json:"cpu"`            // per-core busy percentage
+	CPUTotal      float64           `json:"cpu_total"`      // busy percentage across all cores
+	CPUStates     CPUStateStat      `json:"cpu_states"`     // CPU time by state across all cores
+	CPUFreq       []CPUFreqStat     `json:"cpu_freq"`       // empty where cpufreq is unavailable
+	Memory        MemoryStat        `json:"memory"`
+	Disk          []DiskStat        `json:"disk"`
+	RAID          []RaidStat        `json:"raid"`
+	Network       []NetworkStat     `json:"network"`
+	GoRuntime     GoRuntimeStat     `json:"go_runtime"`
+	Pi            *PiStat           `json:"pi"` // nil when not running on a Raspberry Pi
+	VMs           []VMStat          `json:"vms"`
+	Tunnels       []TunnelPeer      `json:"tunnels"`
+	WAN           *WANStat          `json:"wan"` // nil unless the WAN widget is enabled
+	Jobs          []jobs.Status     `json:"jobs"`
+	LAN           *LANStat          `json:"lan"` // nil unless the LAN collector is enabled
+	Hardware      []HardwareSensor  `json:"hardware"`
+	DNS           *DNSStat          `json:"dns"` // nil unless the DNS panel is enabled
+	GoApps        []GoAppStat       `json:"go_apps"`
+	Processes     []ProcessStatus   `json:"processes"`  // watched processes
+	Ports         []PortStatus      `json:"ports"`      // watched ports
+	Certs         []CertStat        `json:"certs"`      // checked TLS certificates
+	Kernel        *KernelStat       `json:"kernel"`     // nil where /proc/stat is unavailable
+	HugePages     *HugePageStat     `json:"huge_pages"` // nil where /proc/meminfo is unavailable
+	NUMA          []NUMANode        `json:"numa"`       // empty where the kernel exposes no nodes
+	Bandwidth     *bandwidth.Report `json:"bandwidth"`  // nil unless bandwidth accounting is enabled
+	// Interval is the periodic sampling interval in effect, which adaptive
+	// sampling may have raised. Zero for samples collected on demand.
+	Interval time.Duration `json:"interval_ns"`
+	// Elapsed is the time since the collector started, read from the
+	// monotonic clock. Unlike Timestamp it is unaffected by wall clock
+	// steps, so it orders a host's samples and measures the time between
+	// them reliably.
+	Elapsed time.Duration `json:"elapsed_ns"`
+	// Errors maps the name of each collector that failed, e.g. "disk", to
+	// its error. Nil when every collector succeeded.
+	Errors map[string]string `json:"errors,omitempty"`
+}
+
+// recordError notes a collector failure in the sample
+func (m *Metric) recordError(collector string, err error) {
+	if err == nil {
+		return
+	}
+	if m.Errors == nil {
+		m.Errors = make(map[string]string)
+	}
+	m.Errors[collector] = err.Error()
 }
 
 // MemoryStat represents the memory usage of the system.
 type MemoryStat struct {
-	Total          uint64
-	Free           uint64
-	Used           uint64
-	UsedPercentage float64
+	Total          uint64  `json:"total"`
+	Free           uint64  `json:"free"`
+	Used           uint64  `json:"used"`
+	UsedPercentage float64 `json:"used_percent"`
 	// Available uint64
 	// Buffers uint64
 	// Cached uint64
@@ -34,31 +101,70 @@ type MemoryStat struct {
 
 // DiskStat represents the disk usage of the system.
 type DiskStat struct {
-	Path           string
-	Total          uint64
-	Used           uint64
-	Free           uint64
-	UsedPercentage float64
+	Path           string        `json:"path"`
+	Device         string        `json:"device"`
+	Label          string        `json:"label"`
+	FsType         string        `json:"fs_type"`
+	Health         string        `json:"health"`     // set for network filesystems only
+	Latency        time.Duration `json:"latency_ns"` // stat() latency for network filesystems
+	Total          uint64        `json:"total"`
+	Used           uint64        `json:"used"`
+	Free           uint64        `json:"free"`
+	UsedPercentage float64       `json:"used_percent"`
+	// FullIn is when the disk will be full at its recent growth rate, zero
+	// when it is not growing or there is not enough history yet
+	FullIn time.Duration `json:"full_in_ns"`
 }
 
-// NetworkStat represents the network usage of the system.
+// NetworkStat represents the network usage of the system. The byte and
+// packet counters are cumulative since boot; the per-second rates are only
+// valid once HasRates is set, from the second sample onwards.
 type NetworkStat struct {
-	Interface string
-	RxBytes   uint64
-	TxBytes   uint64
-	RxPackets uint64
-	TxPackets uint64
+	Interface       string  `json:"interface"`
+	Label           string  `json:"label"`
+	Up              bool    `json:"up"` // administratively up with carrier
+	RxBytes         uint64  `json:"rx_bytes"`
+	TxBytes         uint64  `json:"tx_bytes"`
+	RxPackets       uint64  `json:"rx_packets"`
+	TxPackets       uint64  `json:"tx_packets"`
+	HasRates        bool    `json:"has_rates"`
+	RxBytesPerSec   float64 `json:"rx_bytes_per_sec"`
+	TxBytesPerSec   float64 `json:"tx_bytes_per_sec"`
+	RxPacketsPerSec float64 `json:"rx_packets_per_sec"`
+	TxPacketsPerSec float64 `json:"tx_packets_per_sec"`
+}
+
+// CPUStateStat is the percentage of CPU time spent in each state. Steal is
+// time a hypervisor ran other guests while this one was runnable, and
+// IOWait idle time with disk I/O outstanding; both stay at zero where the
+// platform does not report them.
+type CPUStateStat struct {
+	User    float64 `json:"user"`
+	Nice    float64 `json:"nice"`
+	System  float64 `json:"system"`
+	IOWait  float64 `json:"iowait"`
+	Steal   float64 `json:"steal"`
+	IRQ     float64 `json:"irq"`
+	SoftIRQ float64 `json:"softirq"`
+	Idle    float64 `json:"idle"`
 }
 
 // GoRuntimeStat represents the Go runtime statistics.
 type GoRuntimeStat struct {
-	NumGoroutine int
-	MemAlloc     uint64
-	MemSys       uint64
-	NumGC        uint32
-	PauseTotalNs uint64
+	NumGoroutine int             `json:"num_goroutine"`
+	MemAlloc     uint64          `json:"mem_alloc"`
+	MemSys       uint64          `json:"mem_sys"`
+	NumGC        uint32          `json:"num_gc"`
+	PauseTotalNs uint64          `json:"pause_total_ns"`
+	HeapObjects  uint64          `json:"heap_objects"`
+	GOMAXPROCS   int             `json:"gomaxprocs"`
+	NumCgoCall   int64           `json:"num_cgo_call"`
+	RecentPauses []time.Duration `json:"recent_pauses_ns"` // most recent GC pauses, oldest first
 }
 
+// gcPauseSamples is the number of recent GC pauses reported
+const gcPauseSamples = 32
+
 // Collector interface defines methods to collect system metrics.
 type Collector interface {
 	Collect() (*Metric, error)
@@ -67,62 +173,245 @@ type Collector interface {
 	Stop()
 }
 
+// PanicHandler is called with a panic in a collector's collection
+// goroutine and its stack, instead of the panic killing the process
+type PanicHandler func(p any, stack []byte)
+
 // SystemCollector implements the Collector interface
 type SystemCollector struct {
 	stopChan chan struct{}
 	running  bool
+	onPanic  PanicHandler
 	// Store previous network stats to calculate rates
 	prevNetStats map[string]net.IOCountersStat
 	prevTime     time.Time
+	// Previous per-core CPU times, diffed against the next sample
+	prevCPUTimes []cpu.TimesStat
+	// Cached per-core frequency scaling
+	cpuFreq           []CPUFreqStat
+	cpuFreqLastUpdate time.Time
+	// Disk usage history for disk-full forecasts
+	forecaster diskForecaster
+	// Constrained-memory mode, see SetLowMemory
+	lowMemory bool
+	// Cached mount table, refreshed every partitionRefreshInterval
+	partitions           []disk.PartitionStat
+	partitionsLastUpdate time.Time
+	// Cached interface up state, refreshed every interfaceRefreshInterval
+	interfaceUp           map[string]bool
+	interfaceUpLastUpdate time.Time
+	// displayNames maps raw mountpoints, devices and interfaces to labels
+	displayNames map[string]string
+	// Cached Raspberry Pi state, refreshed every piRefreshInterval
+	piChecked    bool
+	piStat       *PiStat
+	piLastUpdate time.Time
+	// Outstanding stat() probes for network mounts that timed out
+	pendingMounts map[string]chan mountProbe
+	// Cached libvirt state, refreshed every libvirtRefreshInterval
+	libvirtEnabled bool
+	vmStats        []VMStat
+	vmErr          error
+	vmLastUpdate   time.Time
+	// Cached VPN state, refreshed every tunnelRefreshInterval
+	wireguardEnabled bool
+	openvpnStatus    string
+	tunnelStats      []TunnelPeer
+	tunnelErr        error
+	tunnelLastUpdate time.Time
+	// Public IP and WAN gateway monitor, nil when disabled
+	wan *wanMonitor
+	// Monitored periodic jobs and their cached last runs
+	jobs           []jobs.Job
+	jobStore       *jobs.Store
+	jobRuns        map[string]time.Time
+	jobWatched     map[string]time.Time
+	jobsLastUpdate time.Time
+	// Transfer accounting, nil when disabled
+	bandwidth           *bandwidth.Accountant
+	bandwidthInterfaces map[string]bool
+	// LAN neighbor and DHCP lease state
+	lanEnabled    bool
+	leasesFile    string
+	devices       *DeviceTracker
+	lanStat       *LANStat
+	lanLastUpdate time.Time
+	// BMC hardware sensor poller, nil when no BMCs are configured
+	bmc *bmcMonitor
+	// Pi-hole / AdGuard Home poller, nil when disabled
+	dns *dnsMonitor
+	// External Go application scraper, nil when none are configured
+	goApps *goAppMonitor
+	// observers are called with every collected sample, e.g. by the rules
+	// engine
+	observers []func(Metric)
+	// Watched process scanner, nil when no processes are watched
+	procs *processMonitor
+	// Watched ports, the listeners seen at startup and the cached status
+	portWatches    []PortWatch
+	watchNewPorts  bool
+	portBaseline   map[Listener]bool
+	portStatuses   []PortStatus
+	portLastUpdate time.Time
+	// Previous kernel counters, for rates
+	prevKernel     *KernelStat
+	prevKernelTime time.Time
+	// Huge page pool and NUMA node memory, cached
+	hugePages      *HugePageStat
+	numaNodes      []NUMANode
+	numaLastUpdate time.Time
+	// TLS certificate checker, nil when no certificates are configured
+	certs *certMonitor
+	// Adaptive sampling limits, nil when the interval is fixed
+	adaptive *Adaptive
+	// tags label this host in every sample
+	tags map[string]string
+	// started is when the collector was created, for Metric.Elapsed
+	started time.Time
 }
 
 // NewSystemCollector creates a new SystemCollector
 func NewSystemCollector() *SystemCollector {
+	now := time.Now()
 	return &SystemCollector{
-		stopChan:     make(chan struct{}),
-		prevNetStats: make(map[string]net.IOCountersStat),
-		prevTime:     time.Now(),
+		stopChan:      make(chan struct{}),
+		prevNetStats:  make(map[string]net.IOCountersStat),
+		prevTime:      now,
+		pendingMounts: make(map[string]chan mountProbe),
+		started:       now,
 	}
 }
 
-// Collect returns the current system metrics
+// SetDisplayNames configures the friendly labels attached to disks and
+// network interfaces. Keys may be mountpoints, devices or interface names.
+func (c *SystemCollector) SetDisplayNames(names map[string]string) {
+	c.displayNames = names
+}
+
+// SetTags labels every sample with tags, e.g. env=prod or role=nas
+func (c *SystemCollector) SetTags(tags map[string]string) {
+	c.tags = tags
+}
+
+// displayName returns the label for the first id that has one configured,
+// falling back to the first id itself.
+func (c *SystemCollector) displayName(ids ...string) string {
+	for _, id := range ids {
+		if name, ok := c.displayNames[id]; ok && name != "" {
+			return name
+		}
+	}
+	return ids[0]
+}
+
+// Collect returns the current system metrics. A failing collector does not
+// fail the sample: its data is left empty and the failure is recorded in
+// Metric.Errors, keyed by collector name.
 func (c *SystemCollector) Collect() (*Metric, error) {
+	// now keeps its monotonic reading, so consumers subtracting sample
+	// timestamps get monotonic durations
+	now := time.Now()
 	metric := &Metric{
-		Timestamp: time.Now(),
+		SchemaVersion: SchemaVersion,
+		Timestamp:     now,
+		Elapsed:       now.Sub(c.started),
+		Tags:          c.tags,
 	}
+	var err error
+
 	// Collect CPU metrics
-	cpuPercent, err := collectCPUMetrics()
-	if err != nil {
-		return nil, err
-	}
-	metric.CPU = cpuPercent
+	metric.CPU, metric.CPUTotal, metric.CPUStates, err = c.collectCPUMetrics()
+	metric.recordError("cpu", err)
+
+	// Collect CPU frequency scaling
+	metric.CPUFreq, err = c.collectCPUFreqMetrics()
+	metric.recordError("cpufreq", err)
 
 	// Collect Memory metrics
-	memoryStat, err := collectMemoryMetrics()
-	if err != nil {
-		return nil, err
-	}
-	metric.Memory = memoryStat
+	metric.Memory, err = collectMemoryMetrics()
+	metric.recordError("memory", err)
 
 	// Collect Disk metrics
-	diskStats, err := collectDiskMetrics()
-	if err != nil {
-		return nil, err
-	}
-	metric.Disk = diskStats
+	metric.Disk, err = c.collectDiskMetrics()
+	metric.recordError("disk", err)
+
+	// Collect software RAID metrics
+	metric.RAID, err = collectRaidMetrics()
+	metric.recordError("raid", err)
 
 	// Collect Network metrics
-	networkStats, err := c.collectNetworkMetrics()
-	if err != nil {
-		return nil, err
-	}
-	metric.Network = networkStats
+	metric.Network, err = c.collectNetworkMetrics()
+	metric.recordError("network", err)
+
+	// Account daily and monthly transfer
+	metric.Bandwidth, err = c.collectBandwidthMetrics(metric.Network, now)
+	metric.recordError("bandwidth", err)
 
 	// Collect Go runtime metrics
 	metric.GoRuntime = collectGoRuntimeMetrics()
+
+	// Collect Raspberry Pi metrics
+	metric.Pi = c.collectPiMetrics()
+
+	// Collect libvirt VM metrics
+	metric.VMs, err = c.collectVMMetrics()
+	metric.recordError("libvirt", err)
+
+	// Collect VPN tunnel metrics
+	metric.Tunnels, err = c.collectTunnelMetrics()
+	metric.recordError("tunnels", err)
+
+	// Collect public IP and WAN status
+	metric.WAN = c.collectWANMetrics()
+
+	// Collect periodic job status
+	metric.Jobs, err = c.collectJobMetrics()
+	metric.recordError("jobs", err)
+
+	// Collect LAN neighbor and DHCP lease metrics
+	metric.LAN, err = c.collectLANMetrics()
+	metric.recordError("lan", err)
+
+	// Collect BMC fan, PSU and temperature sensors
+	metric.Hardware, err = c.collectHardwareMetrics()
+	metric.recordError("hardware", err)
+
+	// Collect Pi-hole / AdGuard Home statistics
+	metric.DNS = c.collectDNSMetrics()
+
+	// Collect external Go application runtime stats
+	metric.GoApps = c.collectGoAppMetrics()
+
+	// Collect watched process status
+	metric.Processes, err = c.collectProcessMetrics()
+	metric.recordError("processes", err)
+
+	// Collect watched port status
+	metric.Ports, err = c.collectPortMetrics()
+	metric.recordError("ports", err)
+
+	// Collect kernel activity counters
+	metric.Kernel, err = c.collectKernelMetrics()
+	metric.recordError("kernel", err)
+
+	// Collect huge page and NUMA node usage
+	metric.HugePages, metric.NUMA, err = c.collectNUMAMetrics(metric.CPU)
+	metric.recordError("numa", err)
+
+	// Collect certificate expiry
+	metric.Certs = c.collectCertMetrics()
+
+	for _, observe := range c.observers {
+		observe(*metric)
+	}
 	return metric, nil
 }
 
+// AddObserver registers fn to be called with every collected sample
+func (c *SystemCollector) AddObserver(fn func(Metric)) {
+	c.observers = append(c.observers, fn)
+}
+
 // Start begins periodic collection of system metrics
 func (c *SystemCollector) Start(interval time.Duration,
 	metricsChan chan<- Metric,
@@ -136,15 +425,34 @@ func (c *SystemCollector) Start(interval time.Duration,
 
 	c.running = true
 	go func() {
+		defer c.recoverPanic()
 		ticker := time.NewTicker(interval)
 		defer ticker.Stop()
+		current := interval
+		var self *selfCPUMeter
+		var system *systemCPUMeter
+		if c.adaptive != nil {
+			self = newSelfCPUMeter()
+			system = newSystemCPUMeter()
+		}
+		lastAdjust := time.Now()
 		for {
 			select {
 			case <-ticker.C:
 				metric, err := c.Collect()
-				if err == nil && metric != nil {
-					metricsChan <- *metric
+				if err != nil || metric == nil {
+					continue
+				}
+				if c.adaptive != nil && time.Since(lastAdjust) >= adaptiveWindow {
+					next := NextInterval(current, interval, system.percent(metric.CPUTotal), self.percent(), *c.adaptive)
+					if next != current {
+						current = next
+						ticker.Reset(current)
+					}
+					lastAdjust = time.Now()
 				}
+				metric.Interval = current
+				metricsChan <- *metric
 			case <-c.stopChan:
 				return
 			}
@@ -152,6 +460,24 @@ func (c *SystemCollector) Start(interval time.Duration,
 	}()
 }
 
+// SetPanicHandler sets the handler of panics in the collection goroutine
+// started by Start, e.g. in a parser of one of the collectors. Without one
+// a panic there kills the process.
+func (c *SystemCollector) SetPanicHandler(fn PanicHandler) {
+	c.onPanic = fn
+}
+
+// recoverPanic passes a panic of the collection goroutine to the panic
+// handler, if there is one. It must be deferred by the goroutine itself.
+func (c *SystemCollector) recoverPanic() {
+	if c.onPanic == nil {
+		return
+	}
+	if p := recover(); p != nil {
+		c.onPanic(p, debug.Stack())
+	}
+}
+
 // Stop stops the periodic collection of system metrics
 func (c *SystemCollector) Stop() {
 	if !c.running {
@@ -162,13 +488,81 @@ func (c *SystemCollector) Stop() {
 	close(c.stopChan)
 }
 
-// collectCPUMetrics collects CPU usage metrics
-func collectCPUMetrics() ([]float64, error) {
-	cpuPercent := make([]float64, runtime.NumCPU())
-	for i := 0; i < runtime.NumCPU(); i++ {
-		cpuPercent[i] = float64(i) // Placeholder for actual CPU usage
+// collectCPUMetrics collects per-core and overall CPU usage from the change
+// in CPU times since the previous call, so reading them never blocks and the
+// percentages cover exactly one reporting interval. The first call reports
+// the average since boot.
+func (c *SystemCollector) collectCPUMetrics() ([]float64, float64, CPUStateStat, error) {
+	times, err := cpu.Times(true)
+	if err != nil {
+		return nil, 0, CPUStateStat{}, err
+	}
+
+	perCore := make([]float64, len(times))
+	var total, prevTotal cpu.TimesStat
+	for i, cur := range times {
+		var prev cpu.TimesStat
+		if i < len(c.prevCPUTimes) {
+			prev = c.prevCPUTimes[i]
+		}
+		perCore[i] = CPUPercent(prev, cur)
+		addCPUTimes(&total, cur)
+		addCPUTimes(&prevTotal, prev)
+	}
+
+	c.prevCPUTimes = times
+	return perCore, CPUPercent(prevTotal, total), CPUStates(prevTotal, total), nil
+}
+
+// CPUPercent returns the busy percentage of a CPU between two readings of
+// its times. Idle and I/O wait count as idle; guest time is already part of
+// user time.
+func CPUPercent(prev, cur cpu.TimesStat) float64 {
+	busy := cpuBusy(cur) - cpuBusy(prev)
+	all := cpuBusy(cur) + cur.Idle + cur.Iowait - cpuBusy(prev) - prev.Idle - prev.Iowait
+	if all <= 0 || busy <= 0 {
+		return 0
 	}
-	return cpuPercent, nil
+	return math.Min(100, busy/all*100)
+}
+
+// CPUStates returns the share of each state in the CPU time that passed
+// between prev and cur
+func CPUStates(prev, cur cpu.TimesStat) CPUStateStat {
+	all := cpuBusy(cur) + cur.Idle + cur.Iowait - cpuBusy(prev) - prev.Idle - prev.Iowait
+	if all <= 0 {
+		return CPUStateStat{}
+	}
+	share := func(prev, cur float64) float64 {
+		return math.Max(0, (cur-prev)/all*100)
+	}
+	return CPUStateStat{
+		User:    share(prev.User, cur.User),
+		Nice:    share(prev.Nice, cur.Nice),
+		System:  share(prev.System, cur.System),
+		IOWait:  share(prev.Iowait, cur.Iowait),
+		Steal:   share(prev.Steal, cur.Steal),
+		IRQ:     share(prev.Irq, cur.Irq),
+		SoftIRQ: share(prev.Softirq, cur.Softirq),
+		Idle:    share(prev.Idle, cur.Idle),
+	}
+}
+
+// cpuBusy returns the non-idle time in t
+func cpuBusy(t cpu.TimesStat) float64 {
+	return t.User + t.System + t.Nice + t.Irq + t.Softirq + t.Steal
+}
+
+// addCPUTimes adds the times in t to sum
+func addCPUTimes(sum *cpu.TimesStat, t cpu.TimesStat) {
+	sum.User += t.User
+	sum.System += t.System
+	sum.Nice += t.Nice
+	sum.Irq += t.Irq
+	sum.Softirq += t.Softirq
+	sum.Steal += t.Steal
+	sum.Idle += t.Idle
+	sum.Iowait += t.Iowait
 }
 
 // collectMemoryMetrics collects memory usage metrics
@@ -184,27 +578,100 @@ func collectMemoryMetrics() (MemoryStat, error) {
 	return memoryStat, nil
 }
 
+// LowMemoryThreshold is the total RAM below which godash switches to its
+// constrained-memory mode automatically.
+const LowMemoryThreshold = 512 * 1024 * 1024
+
+// IsLowMemorySystem reports whether the host has less than LowMemoryThreshold of RAM
+func IsLowMemorySystem() bool {
+	vm, err := mem.VirtualMemory()
+	return err == nil && vm.Total < LowMemoryThreshold
+}
+
+// In low-memory mode the background monitors (WAN, BMC, DNS, Go apps) and
+// the process scan poll lowMemoryPollFactor times less often, and disk
+// forecasts and the DNS history keep less history
+const (
+	lowMemoryPollFactor     = 4
+	lowMemoryForecastWindow = 6 * time.Hour
+	lowMemoryDNSHistorySize = 15
+)
+
+// SetLowMemory switches the collector to the constrained-memory mode:
+// background monitors and the process scan poll less often, disk forecasts
+// and the DNS blocked-percent history are shorter, and the PSS and USS of
+// watched processes are not read from smaps.
+func (c *SystemCollector) SetLowMemory(enabled bool) {
+	c.lowMemory = enabled
+	c.forecaster.window = 0
+	if enabled {
+		c.forecaster.window = lowMemoryForecastWindow
+	}
+}
+
+// pollInterval returns how often a monitor that refreshes every interval
+// polls, which is less often in low-memory mode
+func (c *SystemCollector) pollInterval(interval time.Duration) time.Duration {
+	if c.lowMemory {
+		return interval * lowMemoryPollFactor
+	}
+	return interval
+}
+
 // collectDiskMetrics collects disk usage metrics
-func collectDiskMetrics() ([]DiskStat, error) {
-	partitions, err := disk.Partitions(false)
-	if err != nil {
-		return nil, err
+func (c *SystemCollector) collectDiskMetrics() ([]DiskStat, error) {
+	if c.partitions == nil || time.Since(c.partitionsLastUpdate) >= partitionRefreshInterval {
+		partitions, err := disk.Partitions(false)
+		if err != nil {
+			return nil, err
+		}
+		c.partitions = partitions
+		c.partitionsLastUpdate = time.Now()
+
+		mounted := make(map[string]bool, len(partitions))
+		for _, partition := range partitions {
+			mounted[partition.Mountpoint] = true
+		}
+		c.forecaster.prune(mounted)
 	}
 
-	var diskStats []DiskStat
-	for _, partition := range partitions {
+	now := time.Now()
+	diskStats := make([]DiskStat, 0, len(c.partitions))
+	for _, partition := range c.partitions {
+		stat := DiskStat{
+			Path:   partition.Mountpoint,
+			Device: partition.Device,
+			Label:  c.displayName(partition.Mountpoint, partition.Device),
+			FsType: partition.Fstype,
+		}
+
+		// Network mounts are probed with a timeout so a hung server
+		// cannot stall the whole collection
+		if IsNetworkFilesystem(partition.Fstype) {
+			usage, latency, health := c.probeMount(partition.Mountpoint)
+			stat.Health = health
+			stat.Latency = latency
+			if usage != nil {
+				stat.Total = usage.Total
+				stat.Used = usage.Used
+				stat.Free = usage.Free
+				stat.UsedPercentage = usage.UsedPercent
+				stat.FullIn = c.forecaster.forecast(stat, now)
+			}
+			diskStats = append(diskStats, stat)
+			continue
+		}
+
 		usage, err := disk.Usage(partition.Mountpoint)
 		if err != nil {
 			continue
 		}
-
-		diskStats = append(diskStats, DiskStat{
-			Path:           partition.Mountpoint,
-			Total:          usage.Total,
-			Used:           usage.Used,
-			Free:           usage.Free,
-			UsedPercentage: usage.UsedPercent,
-		})
+		stat.Total = usage.Total
+		stat.Used = usage.Used
+		stat.Free = usage.Free
+		stat.UsedPercentage = usage.UsedPercent
+		stat.FullIn = c.forecaster.forecast(stat, now)
+		diskStats = append(diskStats, stat)
 	}
 
 	return diskStats, nil
@@ -217,12 +684,15 @@ func (c *SystemCollector) collectNetworkMetrics() ([]NetworkStat, error) {
 		return nil, err
 	}
 
+	up := c.interfaceUpState()
 	currentTime := time.Now()
-	var networkStats []NetworkStat
+	networkStats := make([]NetworkStat, 0, len(counters))
 
 	for _, counter := range counters {
 		netStat := NetworkStat{
 			Interface: counter.Name,
+			Label:     c.displayName(counter.Name),
+			Up:        up[counter.Name],
 			RxBytes:   counter.BytesRecv,
 			TxBytes:   counter.BytesSent,
 			RxPackets: counter.PacketsRecv,
@@ -233,10 +703,15 @@ func (c *SystemCollector) collectNetworkMetrics() ([]NetworkStat, error) {
 		if prev, ok := c.prevNetStats[counter.Name]; ok {
 			timeDiff := currentTime.Sub(c.prevTime).Seconds()
 			if timeDiff > 0 {
-				netStat.RxBytes = uint64(float64(counter.BytesRecv-prev.BytesRecv) / timeDiff)
-				netStat.TxBytes = uint64(float64(counter.BytesSent-prev.BytesSent) / timeDiff)
-				netStat.RxPackets = uint64(float64(counter.PacketsRecv-prev.PacketsRecv) / timeDiff)
-				netStat.TxPackets = uint64(float64(counter.PacketsSent-prev.PacketsSent) / timeDiff)
+				rate := CounterRate
+				if counters32 {
+					rate = Counter32Rate
+				}
+				netStat.HasRates = true
+				netStat.RxBytesPerSec = rate(prev.BytesRecv, counter.BytesRecv, timeDiff)
+				netStat.TxBytesPerSec = rate(prev.BytesSent, counter.BytesSent, timeDiff)
+				netStat.RxPacketsPerSec = rate(prev.PacketsRecv, counter.PacketsRecv, timeDiff)
+				netStat.TxPacketsPerSec = rate(prev.PacketsSent, counter.PacketsSent, timeDiff)
 			}
 		}
 
@@ -244,10 +719,75 @@ func (c *SystemCollector) collectNetworkMetrics() ([]NetworkStat, error) {
 		c.prevNetStats[counter.Name] = counter
 	}
 
+	// Forget interfaces that were removed, e.g. short-lived container veths.
+	// Every current interface was just stored, so there are stale entries
+	// only when the map holds more.
+	if len(c.prevNetStats) > len(counters) {
+		current := make(map[string]bool, len(counters))
+		for _, counter := range counters {
+			current[counter.Name] = true
+		}
+		for name := range c.prevNetStats {
+			if !current[name] {
+				delete(c.prevNetStats, name)
+			}
+		}
+	}
+
 	c.prevTime = currentTime
 	return networkStats, nil
 }
 
+// interfaceUpState returns whether each interface is up, i.e. enabled and
+// with a link. The map is reused until the next refresh.
+func (c *SystemCollector) interfaceUpState() map[string]bool {
+	if c.interfaceUp != nil && time.Since(c.interfaceUpLastUpdate) < interfaceRefreshInterval {
+		return c.interfaceUp
+	}
+	if c.interfaceUp == nil {
+		c.interfaceUp = make(map[string]bool)
+	}
+	ifaces, err := stdnet.Interfaces()
+	if err != nil {
+		return c.interfaceUp
+	}
+	for name := range c.interfaceUp {
+		delete(c.interfaceUp, name)
+	}
+	for _, iface := range ifaces {
+		c.interfaceUp[iface.Name] = iface.Flags&stdnet.FlagUp != 0 && iface.Flags&stdnet.FlagRunning != 0
+	}
+	c.interfaceUpLastUpdate = time.Now()
+	return c.interfaceUp
+}
+
+// counters32 reports whether the kernel's interface counters are 32-bit
+// unsigned longs that wrap at 4 GiB, as on 32-bit builds of Linux
+const counters32 = strconv.IntSize == 32
+
+// CounterRate returns the per-second rate of a counter that moved from prev
+// to cur over seconds. A counter that went backwards was reset, e.g.
+// because its interface was recreated, and counts from zero.
+func CounterRate(prev, cur uint64, seconds float64) float64 {
+	if seconds <= 0 {
+		return 0
+	}
+	delta := cur - prev
+	if cur < prev {
+		delta = cur
+	}
+	return float64(delta) / seconds
+}
+
+// Counter32Rate is CounterRate for a counter known to be 32 bits wide, which
+// is assumed to have wrapped rather than been reset when it went backwards
+func Counter32Rate(prev, cur uint64, seconds float64) float64 {
+	if seconds <= 0 || cur >= prev || prev > math.MaxUint32 {
+		return CounterRate(prev, cur, seconds)
+	}
+	return float64(math.MaxUint32-prev+cur+1) / seconds
+}
+
 // collectGoRuntimeMetrics collects Go runtime metrics
 func collectGoRuntimeMetrics() GoRuntimeStat {
 	var memStats runtime.MemStats
@@ -258,6 +798,29 @@ func collectGoRuntimeMetrics() GoRuntimeStat {
 		MemSys:       memStats.Sys,
 		NumGC:        memStats.NumGC,
 		PauseTotalNs: memStats.PauseTotalNs,
+		HeapObjects:  memStats.HeapObjects,
+		GOMAXPROCS:   runtime.GOMAXPROCS(0),
+		NumCgoCall:   runtime.NumCgoCall(),
+		RecentPauses: RecentGCPauses(&memStats, gcPauseSamples),
 	}
 	return goRuntimeStat
 }
+
+// RecentGCPauses returns up to n of the most recent GC pause durations from
+// the circular PauseNs buffer, oldest first.
+func RecentGCPauses(memStats *runtime.MemStats, n int) []time.Duration {
+	count := int(memStats.NumGC)
+	if count > len(memStats.PauseNs) {
+		count = len(memStats.PauseNs)
+	}
+	if count > n {
+		count = n
+	}
+
+	pauses := make([]time.Duration, 0, count)
+	for i := count - 1; i >= 0; i-- {
+		idx := (int(memStats.NumGC) - 1 - i) % len(memStats.PauseNs)
+		pauses = append(pauses, time.Duration(memStats.PauseNs[idx]))
+	}
+	return pauses
+}
@@ -0,0 +1,78 @@
+package metrics
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const cpuSysPath = "/sys/devices/system/cpu"
+
+// cpuFreqRefreshInterval limits how often the cpufreq files are read
+const cpuFreqRefreshInterval = 2 * time.Second
+
+// CPUFreqStat reports the frequency scaling of one core (Linux cpufreq)
+type CPUFreqStat struct {
+	CPU        int     `json:"cpu"`
+	Governor   string  `json:"governor"` // e.g. performance, powersave, schedutil
+	CurrentMHz float64 `json:"current_mhz"`
+	MaxMHz     float64 `json:"max_mhz"` // hardware maximum, 0 when unknown
+}
+
+// ReadCPUFreq reads the frequency scaling of every core from dir, normally
+// /sys/devices/system/cpu, sorted by core. Cores without cpufreq, e.g. in
+// most VMs, are left out.
+func ReadCPUFreq(dir string) ([]CPUFreqStat, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "cpu[0-9]*", "cpufreq"))
+	if err != nil {
+		return nil, err
+	}
+	var stats []CPUFreqStat
+	for _, path := range paths {
+		id, err := strconv.Atoi(strings.TrimPrefix(filepath.Base(filepath.Dir(path)), "cpu"))
+		if err != nil {
+			continue
+		}
+		cur, ok := readKHz(filepath.Join(path, "scaling_cur_freq"))
+		if !ok {
+			continue
+		}
+		stat := CPUFreqStat{CPU: id, CurrentMHz: cur / 1000}
+		if max, ok := readKHz(filepath.Join(path, "cpuinfo_max_freq")); ok {
+			stat.MaxMHz = max / 1000
+		}
+		if data, err := os.ReadFile(filepath.Join(path, "scaling_governor")); err == nil {
+			stat.Governor = strings.TrimSpace(string(data))
+		}
+		stats = append(stats, stat)
+	}
+	sort.Slice(stats, func(i, j int) bool { return stats[i].CPU < stats[j].CPU })
+	return stats, nil
+}
+
+// readKHz reads a cpufreq file holding a frequency in kHz
+func readKHz(path string) (float64, bool) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, false
+	}
+	khz, err := strconv.ParseFloat(strings.TrimSpace(string(data)), 64)
+	return khz, err == nil && khz > 0
+}
+
+// collectCPUFreqMetrics returns the frequency scaling of each core, re-read
+// every cpuFreqRefreshInterval
+func (c *SystemCollector) collectCPUFreqMetrics() ([]CPUFreqStat, error) {
+	if c.cpuFreqLastUpdate.IsZero() || time.Since(c.cpuFreqLastUpdate) >= cpuFreqRefreshInterval {
+		stats, err := ReadCPUFreq(cpuSysPath)
+		if err != nil {
+			return nil, err
+		}
+		c.cpuFreq = stats
+		c.cpuFreqLastUpdate = time.Now()
+	}
+	return c.cpuFreq, nil
+}
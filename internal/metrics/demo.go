@@ -0,0 +1,192 @@
+package metrics
+
+import (
+	"math"
+	"time"
+)
+
+// The synthetic host of DemoCollector: 8 cores, 16 GiB of memory, three
+// disks and two interfaces. Every demoBurstPeriod the CPU bursts for
+// demoBurstLength, the /data disk fills up over demoDiskPeriod and wlan0
+// drops out for demoLinkDown every demoLinkPeriod.
+const (
+	demoCores       = 8
+	demoMemory      = 16 << 30
+	demoBurstPeriod = 60 * time.Second
+	demoBurstStart  = 40 * time.Second
+	demoBurstLength = 8 * time.Second
+	demoDiskPeriod  = 2 * time.Minute
+	demoLinkPeriod  = 2 * time.Minute
+	demoLinkDown    = 15 * time.Second
+	demoLinkDownAt  = 90 * time.Second
+)
+
+// DemoCollector generates samples of a synthetic host from sine waves,
+// bursts and outages instead of reading the system, for screenshots and
+// trying godash out. The same elapsed time always yields the same sample.
+type DemoCollector struct {
+	start     time.Time
+	stopChan  chan struct{}
+	running   bool
+	observers []func(Metric)
+}
+
+// NewDemoCollector creates a DemoCollector whose samples start at start
+func NewDemoCollector(start time.Time) *DemoCollector {
+	return &DemoCollector{start: start, stopChan: make(chan struct{})}
+}
+
+// AddObserver registers fn to be called with every collected sample
+func (c *DemoCollector) AddObserver(fn func(Metric)) {
+	c.observers = append(c.observers, fn)
+}
+
+// Collect returns the sample for the time elapsed since the start
+func (c *DemoCollector) Collect() (*Metric, error) {
+	metric := c.Sample(time.Since(c.start))
+	for _, observe := range c.observers {
+		observe(metric)
+	}
+	return &metric, nil
+}
+
+// Start begins periodic generation of samples
+func (c *DemoCollector) Start(interval time.Duration, metricsChan chan<- Metric) {
+	if c.running {
+		return
+	}
+	if interval <= 0 {
+		interval = 100 * time.Millisecond
+	}
+	c.running = true
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				metric, _ := c.Collect()
+				metric.Interval = interval
+				metricsChan <- *metric
+			case <-c.stopChan:
+				return
+			}
+		}
+	}()
+}
+
+// Stop stops the periodic generation of samples
+func (c *DemoCollector) Stop() {
+	if !c.running {
+		return
+	}
+	c.stopChan <- struct{}{}
+	c.running = false
+	close(c.stopChan)
+}
+
+// Sample returns the synthetic sample elapsed after the start
+func (c *DemoCollector) Sample(elapsed time.Duration) Metric {
+	t := elapsed.Seconds()
+	metric := Metric{
+		SchemaVersion: SchemaVersion,
+		Timestamp:     c.start.Add(elapsed),
+		Elapsed:       elapsed,
+		CPU:           make([]float64, demoCores),
+		CPUFreq:       make([]CPUFreqStat, demoCores),
+	}
+
+	// Each core follows slow and fast waves of its own phase, and all of
+	// them saturate during a burst
+	phase := elapsed % demoBurstPeriod
+	burst := phase >= demoBurstStart && phase < demoBurstStart+demoBurstLength
+	for i := range metric.CPU {
+		load := 25 + 15*wave(t, 30, float64(i)*0.8) + 5*wave(t, 7, float64(i)*2.1)
+		if burst {
+			load = 93 + 5*wave(t, 3, float64(i))
+		}
+		metric.CPU[i] = load
+		metric.CPUTotal += load / demoCores
+		metric.CPUFreq[i] = CPUFreqStat{CPU: i, Governor: "schedutil", CurrentMHz: math.Round(1200 + 24*load), MaxMHz: 3600}
+	}
+	total := metric.CPUTotal
+	metric.CPUStates = CPUStateStat{
+		User: total * 0.7, System: total * 0.2, IOWait: total * 0.06,
+		SoftIRQ: total * 0.02, Steal: total * 0.02, Idle: 100 - total,
+	}
+	metric.Kernel = &KernelStat{
+		ProcsRunning: 1 + int(total/100*demoCores*1.2), ProcsBlocked: int(2 * sawtooth(t, 11)),
+		EntropyAvail: 256, HasRates: true,
+		ContextSwitchesPerSec: 4000 + 300*total, InterruptsPerSec: 2500 + 100*total, ForksPerSec: 20 + 10*wave(t, 17, 0),
+	}
+
+	metric.GoRuntime = GoRuntimeStat{
+		NumGoroutine: 12 + int(4*sawtooth(t, 13)), MemAlloc: uint64(6e6 + 2e6*wave(t, 10, 0)), MemSys: 24 << 20,
+		NumGC: uint32(t / 4), PauseTotalNs: uint64(t/4) * 150000, HeapObjects: uint64(30000 + 8000*wave(t, 10, 0)),
+		GOMAXPROCS: demoCores,
+	}
+
+	// Memory leaks for five minutes, then is freed
+	usedPercent := 45 + 20*sawtooth(t, 300)
+	used := uint64(demoMemory * usedPercent / 100)
+	metric.Memory = MemoryStat{Total: demoMemory, Used: used, Free: demoMemory - used, UsedPercentage: usedPercent}
+
+	// /data fills from 86% to 94% over each demoDiskPeriod
+	dataPercent := 86 + 8*sawtooth(t, demoDiskPeriod.Seconds())
+	growth := 8 / demoDiskPeriod.Seconds() // percent per second
+	metric.Disk = []DiskStat{
+		demoDisk("/", "/dev/nvme0n1p2", "ext4", 256<<30, 62),
+		demoDisk("/data", "/dev/sda1", "xfs", 4<<40, dataPercent),
+		demoDisk("/boot", "/dev/nvme0n1p1", "vfat", 1<<30, 31),
+	}
+	metric.Disk[1].FullIn = time.Duration((100 - dataPercent) / growth * float64(time.Second))
+
+	// wlan0 counts no traffic while it is down
+	link := elapsed % demoLinkPeriod
+	wlanUp := link < demoLinkDownAt || link >= demoLinkDownAt+demoLinkDown
+	downtime := time.Duration(elapsed/demoLinkPeriod)*demoLinkDown + min(max(link-demoLinkDownAt, 0), demoLinkDown)
+	metric.Network = []NetworkStat{
+		demoInterface("eth0", true, t, 6e6, 4e6, 20, 1.5e6, 1e6, 13),
+		demoInterface("wlan0", wlanUp, (elapsed - downtime).Seconds(), 2e5, 1e5, 9, 5e4, 3e4, 5),
+	}
+	if !wlanUp {
+		wlan := &metric.Network[1]
+		wlan.RxBytesPerSec, wlan.TxBytesPerSec, wlan.RxPacketsPerSec, wlan.TxPacketsPerSec = 0, 0, 0, 0
+	}
+	return metric
+}
+
+// demoDisk returns a disk of size bytes that is usedPercent full
+func demoDisk(path, device, fsType string, size uint64, usedPercent float64) DiskStat {
+	used := uint64(float64(size) * usedPercent / 100)
+	return DiskStat{
+		Path: path, Device: device, Label: path, FsType: fsType,
+		Total: size, Used: used, Free: size - used, UsedPercentage: usedPercent,
+	}
+}
+
+// demoInterface returns an interface whose byte rates are base plus a wave
+// of amplitude and period seconds, at t seconds of traffic. The counters are
+// the integral of the rates, so they only grow.
+func demoInterface(name string, up bool, t, rxBase, rxAmp, rxPeriod, txBase, txAmp, txPeriod float64) NetworkStat {
+	rx := rxBase + rxAmp*wave(t, rxPeriod, 0)
+	tx := txBase + txAmp*wave(t, txPeriod, 1)
+	rxBytes := rxBase*t + rxAmp*rxPeriod/(2*math.Pi)*(1-math.Cos(2*math.Pi*t/rxPeriod))
+	txBytes := txBase*t + txAmp*txPeriod/(2*math.Pi)*(math.Cos(1)-math.Cos(2*math.Pi*t/txPeriod+1))
+	return NetworkStat{
+		Interface: name, Label: name, Up: up, HasRates: true,
+		RxBytes: uint64(rxBytes), TxBytes: uint64(txBytes),
+		RxPackets: uint64(rxBytes / 1200), TxPackets: uint64(txBytes / 800),
+		RxBytesPerSec: rx, TxBytesPerSec: tx, RxPacketsPerSec: rx / 1200, TxPacketsPerSec: tx / 800,
+	}
+}
+
+// wave is a sine of the given period in seconds and phase in radians
+func wave(t, period, phase float64) float64 {
+	return math.Sin(2*math.Pi*t/period + phase)
+}
+
+// sawtooth rises from 0 to 1 over each period seconds
+func sawtooth(t, period float64) float64 {
+	return math.Mod(t, period) / period
+}
@@ -0,0 +1,343 @@
+package metrics
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// dnsRefreshInterval is how often the DNS filter API is polled
+const dnsRefreshInterval = 10 * time.Second
+
+// dnsTimeout bounds a single poll of the DNS filter API
+const dnsTimeout = 5 * time.Second
+
+// dnsHistorySize is the number of blocked-percentage samples kept for the panel
+const dnsHistorySize = 60
+
+// dnsTopClients is the number of top clients reported
+const dnsTopClients = 5
+
+// DNS filter types
+const (
+	DNSPihole  = "pihole"
+	DNSAdGuard = "adguard"
+)
+
+// DNSClient represents a client of the DNS filter and its query count.
+type DNSClient struct {
+	Name    string `json:"name"`
+	Queries uint64 `json:"queries"`
+}
+
+// DNSStat represents the state of a Pi-hole or AdGuard Home DNS filter.
+type DNSStat struct {
+	Source          string      `json:"source"` // pihole or adguard
+	Queries         uint64      `json:"queries"`
+	Blocked         uint64      `json:"blocked"`
+	BlockedPercent  float64     `json:"blocked_percent"`
+	BlockingEnabled bool        `json:"blocking_enabled"`
+	TopClients      []DNSClient `json:"top_clients"`
+	History         []float64   `json:"history"` // blocked percentage per poll, oldest first
+	LastCheck       time.Time   `json:"last_check"`
+	Error           string      `json:"error"`
+}
+
+// ParsePiholeSummary parses the Pi-hole v6 /api/stats/summary response
+func ParsePiholeSummary(data []byte) (DNSStat, error) {
+	var summary struct {
+		Queries struct {
+			Total          uint64  `json:"total"`
+			Blocked        uint64  `json:"blocked"`
+			PercentBlocked float64 `json:"percent_blocked"`
+		} `json:"queries"`
+	}
+	if err := json.Unmarshal(data, &summary); err != nil {
+		return DNSStat{}, fmt.Errorf("failed to parse pi-hole summary: %w", err)
+	}
+	return DNSStat{
+		Source:         DNSPihole,
+		Queries:        summary.Queries.Total,
+		Blocked:        summary.Queries.Blocked,
+		BlockedPercent: summary.Queries.PercentBlocked,
+	}, nil
+}
+
+// ParsePiholeTopClients parses the Pi-hole v6 /api/stats/top_clients response
+func ParsePiholeTopClients(data []byte) ([]DNSClient, error) {
+	var top struct {
+		Clients []struct {
+			IP    string `json:"ip"`
+			Name  string `json:"name"`
+			Count uint64 `json:"count"`
+		} `json:"clients"`
+	}
+	if err := json.Unmarshal(data, &top); err != nil {
+		return nil, fmt.Errorf("failed to parse pi-hole top clients: %w", err)
+	}
+
+	clients := make([]DNSClient, 0, len(top.Clients))
+	for _, c := range top.Clients {
+		name := c.Name
+		if name == "" {
+			name = c.IP
+		}
+		clients = append(clients, DNSClient{Name: name, Queries: c.Count})
+	}
+	return clients, nil
+}
+
+// ParseAdGuardStats parses the AdGuard Home /control/stats response
+func ParseAdGuardStats(data []byte) (DNSStat, error) {
+	var stats struct {
+		Queries    uint64              `json:"num_dns_queries"`
+		Blocked    uint64              `json:"num_blocked_filtering"`
+		TopClients []map[string]uint64 `json:"top_clients"`
+	}
+	if err := json.Unmarshal(data, &stats); err != nil {
+		return DNSStat{}, fmt.Errorf("failed to parse adguard stats: %w", err)
+	}
+
+	stat := DNSStat{
+		Source:  DNSAdGuard,
+		Queries: stats.Queries,
+		Blocked: stats.Blocked,
+	}
+	if stats.Queries > 0 {
+		stat.BlockedPercent = float64(stats.Blocked) / float64(stats.Queries) * 100
+	}
+	// Each entry is a single-key object such as {"192.168.1.10": 1234}
+	for _, entry := range stats.TopClients {
+		for name, count := range entry {
+			stat.TopClients = append(stat.TopClients, DNSClient{Name: name, Queries: count})
+		}
+	}
+	sort.SliceStable(stat.TopClients, func(i, j int) bool {
+		return stat.TopClients[i].Queries > stat.TopClients[j].Queries
+	})
+	return stat, nil
+}
+
+// dnsMonitor polls the DNS filter API in the background so a slow or
+// unreachable filter never stalls collection.
+type dnsMonitor struct {
+	source   string
+	address  string
+	username string
+	password string
+	client   *http.Client
+
+	mu       sync.Mutex
+	stat     DNSStat
+	sid      string // Pi-hole session id
+	checking bool
+}
+
+// SetDNS enables the DNS filter panel. source is DNSPihole or DNSAdGuard and
+// address the base URL of its web interface. Pi-hole only uses the password;
+// AdGuard Home uses basic authentication.
+func (c *SystemCollector) SetDNS(source, address, username, password string) {
+	c.dns = &dnsMonitor{
+		source:   source,
+		address:  strings.TrimSuffix(address, "/"),
+		username: username,
+		password: password,
+		client:   &http.Client{Timeout: dnsTimeout},
+	}
+}
+
+// collectDNSMetrics returns the latest DNS filter state, starting a
+// background poll when one is due. It returns nil when the panel is disabled.
+func (c *SystemCollector) collectDNSMetrics() *DNSStat {
+	if c.dns == nil {
+		return nil
+	}
+	d := c.dns
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if !d.checking && time.Since(d.stat.LastCheck) >= c.pollInterval(dnsRefreshInterval) {
+		d.checking = true
+		historySize := dnsHistorySize
+		if c.lowMemory {
+			historySize = lowMemoryDNSHistorySize
+		}
+		go d.check(historySize)
+	}
+	if d.stat.LastCheck.IsZero() {
+		return nil
+	}
+	stat := d.stat
+	stat.History = append([]float64(nil), d.stat.History...)
+	return &stat
+}
+
+// check polls the DNS filter and appends the result to the history, which
+// keeps historySize points
+func (d *dnsMonitor) check(historySize int) {
+	ctx, cancel := context.WithTimeout(context.Background(), dnsTimeout*3)
+	defer cancel()
+
+	var stat DNSStat
+	var err error
+	if d.source == DNSAdGuard {
+		stat, err = d.pollAdGuard(ctx)
+	} else {
+		stat, err = d.pollPihole(ctx)
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.checking = false
+	if err != nil {
+		// Keep the last good numbers on screen alongside the error
+		d.stat.Error = err.Error()
+		d.stat.LastCheck = time.Now()
+		return
+	}
+	history := append(d.stat.History, stat.BlockedPercent)
+	if len(history) > historySize {
+		history = history[len(history)-historySize:]
+	}
+	if len(stat.TopClients) > dnsTopClients {
+		stat.TopClients = stat.TopClients[:dnsTopClients]
+	}
+	stat.History = history
+	stat.LastCheck = time.Now()
+	d.stat = stat
+}
+
+// get fetches path from the DNS filter API and returns the response body
+func (d *dnsMonitor) get(ctx context.Context, path string) ([]byte, int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, d.address+path, nil)
+	if err != nil {
+		return nil, 0, err
+	}
+	if d.source == DNSAdGuard && d.username != "" {
+		req.SetBasicAuth(d.username, d.password)
+	}
+	if d.sid != "" {
+		req.Header.Set("X-FTL-SID", d.sid)
+	}
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, resp.StatusCode, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return body, resp.StatusCode, fmt.Errorf("GET %s returned %s", path, resp.Status)
+	}
+	return body, resp.StatusCode, nil
+}
+
+// pollAdGuard reads the AdGuard Home statistics and protection status
+func (d *dnsMonitor) pollAdGuard(ctx context.Context) (DNSStat, error) {
+	data, _, err := d.get(ctx, "/control/stats")
+	if err != nil {
+		return DNSStat{}, err
+	}
+	stat, err := ParseAdGuardStats(data)
+	if err != nil {
+		return DNSStat{}, err
+	}
+
+	data, _, err = d.get(ctx, "/control/status")
+	if err != nil {
+		return DNSStat{}, err
+	}
+	var status struct {
+		ProtectionEnabled bool `json:"protection_enabled"`
+	}
+	if err := json.Unmarshal(data, &status); err != nil {
+		return DNSStat{}, fmt.Errorf("failed to parse adguard status: %w", err)
+	}
+	stat.BlockingEnabled = status.ProtectionEnabled
+	return stat, nil
+}
+
+// pollPihole reads the Pi-hole summary, top clients and blocking status,
+// logging in again when the session has expired.
+func (d *dnsMonitor) pollPihole(ctx context.Context) (DNSStat, error) {
+	data, code, err := d.get(ctx, "/api/stats/summary")
+	if code == http.StatusUnauthorized && d.password != "" {
+		if err = d.piholeLogin(ctx); err != nil {
+			return DNSStat{}, err
+		}
+		data, _, err = d.get(ctx, "/api/stats/summary")
+	}
+	if err != nil {
+		return DNSStat{}, err
+	}
+	stat, err := ParsePiholeSummary(data)
+	if err != nil {
+		return DNSStat{}, err
+	}
+
+	data, _, err = d.get(ctx, fmt.Sprintf("/api/stats/top_clients?count=%d", dnsTopClients))
+	if err != nil {
+		return DNSStat{}, err
+	}
+	if stat.TopClients, err = ParsePiholeTopClients(data); err != nil {
+		return DNSStat{}, err
+	}
+
+	data, _, err = d.get(ctx, "/api/dns/blocking")
+	if err != nil {
+		return DNSStat{}, err
+	}
+	var blocking struct {
+		Blocking string `json:"blocking"`
+	}
+	if err := json.Unmarshal(data, &blocking); err != nil {
+		return DNSStat{}, fmt.Errorf("failed to parse pi-hole blocking status: %w", err)
+	}
+	stat.BlockingEnabled = blocking.Blocking == "enabled"
+	return stat, nil
+}
+
+// piholeLogin creates a Pi-hole API session and stores its id
+func (d *dnsMonitor) piholeLogin(ctx context.Context) error {
+	payload, err := json.Marshal(map[string]string{"password": d.password})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, d.address+"/api/auth", bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("pi-hole login failed: %s", resp.Status)
+	}
+
+	var auth struct {
+		Session struct {
+			Valid bool   `json:"valid"`
+			SID   string `json:"sid"`
+		} `json:"session"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&auth); err != nil {
+		return fmt.Errorf("failed to parse pi-hole login response: %w", err)
+	}
+	if !auth.Session.Valid {
+		return fmt.Errorf("pi-hole rejected the password")
+	}
+	d.sid = auth.Session.SID
+	return nil
+}
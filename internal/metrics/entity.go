@@ -0,0 +1,127 @@
+package metrics
+
+import "sort"
+
+// Kinds of Entity
+const (
+	EntityDisk      = "disk"
+	EntityInterface = "net"
+	EntityVM        = "vm"
+	EntityProcess   = "process"
+)
+
+// Entity is one disk, interface, VM or watched process of a sample in a
+// generic form: labels identifying and describing it, and its numeric
+// values by name. Consumers that treat every kind alike (rule values, line
+// protocol) use Entities instead of the per-kind structs, so a new kind
+// only has to be added there.
+type Entity struct {
+	Kind string `json:"kind"` // one of the Entity kinds
+	// Name identifies the entity among its kind: the mountpoint, interface,
+	// VM or process name. It is also in Labels, under a kind-specific key.
+	Name   string             `json:"name"`
+	Labels map[string]string  `json:"labels"` // empty labels are left out
+	Values map[string]float64 `json:"values"`
+}
+
+// LabelNames returns the entity's label names, sorted
+func (e Entity) LabelNames() []string {
+	names := make([]string, 0, len(e.Labels))
+	for name := range e.Labels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ValueNames returns the entity's value names, sorted
+func (e Entity) ValueNames() []string {
+	names := make([]string, 0, len(e.Values))
+	for name := range e.Values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Entities returns the per-entity stats of m: disks, then interfaces, VMs
+// and watched processes
+func Entities(m Metric) []Entity {
+	entities := make([]Entity, 0, len(m.Disk)+len(m.Network)+len(m.VMs)+len(m.Processes))
+	for _, disk := range m.Disk {
+		e := newEntity(EntityDisk, disk.Path, map[string]string{
+			"path": disk.Path, "device": disk.Device, "fs_type": disk.FsType, "label": disk.Label,
+		})
+		e.Values["total"] = float64(disk.Total)
+		e.Values["used"] = float64(disk.Used)
+		e.Values["free"] = float64(disk.Free)
+		e.Values["used_percent"] = disk.UsedPercentage
+		if disk.FullIn > 0 {
+			e.Values["full_in_hours"] = disk.FullIn.Hours()
+		}
+		entities = append(entities, e)
+	}
+	for _, net := range m.Network {
+		e := newEntity(EntityInterface, net.Interface, map[string]string{
+			"interface": net.Interface, "label": net.Label,
+		})
+		e.Values["up"] = boolValue(net.Up)
+		e.Values["rx_bytes"] = float64(net.RxBytes)
+		e.Values["tx_bytes"] = float64(net.TxBytes)
+		e.Values["rx_packets"] = float64(net.RxPackets)
+		e.Values["tx_packets"] = float64(net.TxPackets)
+		if net.HasRates {
+			e.Values["rx_bytes_per_sec"] = net.RxBytesPerSec
+			e.Values["tx_bytes_per_sec"] = net.TxBytesPerSec
+			e.Values["rx_packets_per_sec"] = net.RxPacketsPerSec
+			e.Values["tx_packets_per_sec"] = net.TxPacketsPerSec
+		}
+		entities = append(entities, e)
+	}
+	for _, vm := range m.VMs {
+		e := newEntity(EntityVM, vm.Name, map[string]string{"name": vm.Name, "state": vm.State})
+		e.Values["cpu_percent"] = vm.CPUPercent
+		e.Values["vcpus"] = float64(vm.VCPUs)
+		e.Values["memory"] = float64(vm.Memory)
+		e.Values["net_rx_bytes"] = float64(vm.NetRxBytes)
+		e.Values["net_tx_bytes"] = float64(vm.NetTxBytes)
+		e.Values["block_read_bytes"] = float64(vm.BlockReadBytes)
+		e.Values["block_write_bytes"] = float64(vm.BlockWriteBytes)
+		e.Values["net_rx_bytes_per_sec"] = vm.NetRxBytesPerSec
+		e.Values["net_tx_bytes_per_sec"] = vm.NetTxBytesPerSec
+		e.Values["block_read_bytes_per_sec"] = vm.BlockReadBytesPerSec
+		e.Values["block_write_bytes_per_sec"] = vm.BlockWriteBytesPerSec
+		entities = append(entities, e)
+	}
+	for _, p := range m.Processes {
+		e := newEntity(EntityProcess, p.Name, map[string]string{"name": p.Name, "status": p.Status})
+		e.Values["count"] = float64(p.Count)
+		e.Values["cpu_percent"] = p.CPUPercent
+		e.Values["memory"] = float64(p.Memory)
+		e.Values["restarts"] = float64(p.Restarts)
+		if p.PSS > 0 {
+			e.Values["pss"] = float64(p.PSS)
+			e.Values["uss"] = float64(p.USS)
+		}
+		entities = append(entities, e)
+	}
+	return entities
+}
+
+// newEntity creates an Entity with the non-empty labels
+func newEntity(kind, name string, labels map[string]string) Entity {
+	for k, v := range labels {
+		if v == "" {
+			delete(labels, k)
+		}
+	}
+	return Entity{Kind: kind, Name: name, Labels: labels, Values: make(map[string]float64)}
+}
+
+// boolValue converts a flag to 1 or 0
+func boolValue(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,110 @@
+package metrics
+
+import "time"
+
+// Disk usage is sampled for forecasting every forecastSpacing and kept for
+// forecastWindow. A forecast needs at least forecastMinSpan of history so
+// short bursts such as a large download do not predict a full disk.
+const (
+	forecastSpacing = time.Minute
+	forecastWindow  = 24 * time.Hour
+	forecastMinSpan = 30 * time.Minute
+)
+
+// usagePoint is one sample of a disk's used bytes
+type usagePoint struct {
+	t    time.Time
+	used float64
+}
+
+// diskHistory is one mountpoint's usage history and its latest forecast
+type diskHistory struct {
+	points []usagePoint
+	fullIn time.Duration
+}
+
+// diskForecaster keeps a downsampled usage history per mountpoint
+type diskForecaster struct {
+	history map[string]*diskHistory
+	window  time.Duration // how much history to keep, forecastWindow when zero
+}
+
+// forecast records stat's usage at now and returns how long until the disk
+// is full at the current growth rate, or zero when it is not growing or
+// there is not enough history. The fit only changes when a point is added,
+// once per forecastSpacing, so it is cached in between.
+func (f *diskForecaster) forecast(stat DiskStat, now time.Time) time.Duration {
+	if f.history == nil {
+		f.history = make(map[string]*diskHistory)
+	}
+	h := f.history[stat.Path]
+	if h == nil {
+		h = &diskHistory{}
+		f.history[stat.Path] = h
+	}
+	points := h.points
+	if n := len(points); n > 0 && now.Sub(points[n-1].t) < forecastSpacing {
+		return h.fullIn
+	}
+	points = append(points, usagePoint{now, float64(stat.Used)})
+	window := f.window
+	if window == 0 {
+		window = forecastWindow
+	}
+	for now.Sub(points[0].t) > window {
+		points = points[1:]
+	}
+	h.points = points
+	h.fullIn = 0
+	if len(points) < 2 || points[len(points)-1].t.Sub(points[0].t) < forecastMinSpan {
+		return 0
+	}
+
+	times := make([]float64, len(points))
+	used := make([]float64, len(points))
+	for i, p := range points {
+		times[i] = p.t.Sub(points[0].t).Seconds()
+		used[i] = p.used
+	}
+	h.fullIn = TimeUntilFull(times, used, float64(stat.Free))
+	return h.fullIn
+}
+
+// prune drops the history of mountpoints that are no longer mounted
+func (f *diskForecaster) prune(mounted map[string]bool) {
+	for path := range f.history {
+		if !mounted[path] {
+			delete(f.history, path)
+		}
+	}
+}
+
+// TimeUntilFull fits a least-squares line to used bytes sampled at times
+// (in seconds) and returns how long the remaining free bytes last at that
+// growth rate. It returns zero when usage is flat or shrinking.
+func TimeUntilFull(times, used []float64, free float64) time.Duration {
+	n := float64(len(times))
+	if n < 2 {
+		return 0
+	}
+	var sumT, sumU, sumTT, sumTU float64
+	for i := range times {
+		sumT += times[i]
+		sumU += used[i]
+		sumTT += times[i] * times[i]
+		sumTU += times[i] * used[i]
+	}
+	denominator := n*sumTT - sumT*sumT
+	if denominator == 0 {
+		return 0
+	}
+	slope := (n*sumTU - sumT*sumU) / denominator // bytes per second
+	if slope <= 0 {
+		return 0
+	}
+	seconds := free / slope
+	if seconds > float64(365*24*time.Hour/time.Second) {
+		return 0 // too far out to be meaningful
+	}
+	return time.Duration(seconds * float64(time.Second))
+}
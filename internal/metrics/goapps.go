@@ -0,0 +1,198 @@
+package metrics
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// goAppRefreshInterval is how often external Go applications are scraped
+const goAppRefreshInterval = 5 * time.Second
+
+// goAppTimeout bounds a single scrape of one application
+const goAppTimeout = 3 * time.Second
+
+// Response size limits. Only the first line of the goroutine profile, which
+// holds the count, is read rather than the stacks of every goroutine.
+const (
+	expvarLimit          = 8 << 20
+	goroutineHeaderLimit = 256
+)
+
+// GoApp describes an external Go application exposing expvar, pprof or both.
+type GoApp struct {
+	Name string
+	URL  string // base URL of the debug server, e.g. http://localhost:6060
+}
+
+// GoAppStat represents the runtime state of an external Go application.
+type GoAppStat struct {
+	Name         string `json:"name"`
+	Goroutines   int    `json:"goroutines"` // -1 when /debug/pprof is not exposed
+	HeapAlloc    uint64 `json:"heap_alloc"`
+	HeapObjects  uint64 `json:"heap_objects"`
+	Sys          uint64 `json:"sys"`
+	NumGC        uint32 `json:"num_gc"`
+	PauseTotalNs uint64 `json:"pause_total_ns"`
+	HasMemStats  bool   `json:"has_memstats"` // false when /debug/vars is not exposed
+	Error        string `json:"error"`        // set when neither endpoint could be read
+}
+
+// ParseExpvar parses the memstats of a /debug/vars response
+func ParseExpvar(data []byte) (GoAppStat, error) {
+	var vars struct {
+		MemStats *struct {
+			HeapAlloc    uint64
+			HeapObjects  uint64
+			Sys          uint64
+			NumGC        uint32
+			PauseTotalNs uint64
+		} `json:"memstats"`
+	}
+	if err := json.Unmarshal(data, &vars); err != nil {
+		return GoAppStat{}, fmt.Errorf("failed to parse expvar: %w", err)
+	}
+	if vars.MemStats == nil {
+		return GoAppStat{}, fmt.Errorf("expvar response has no memstats")
+	}
+	return GoAppStat{
+		Goroutines:   -1,
+		HasMemStats:  true,
+		HeapAlloc:    vars.MemStats.HeapAlloc,
+		HeapObjects:  vars.MemStats.HeapObjects,
+		Sys:          vars.MemStats.Sys,
+		NumGC:        vars.MemStats.NumGC,
+		PauseTotalNs: vars.MemStats.PauseTotalNs,
+	}, nil
+}
+
+// ParseGoroutineProfile returns the goroutine count from a
+// /debug/pprof/goroutine?debug=1 response, whose first line is
+// "goroutine profile: total 42"
+func ParseGoroutineProfile(r io.Reader) (int, error) {
+	scanner := bufio.NewScanner(r)
+	if !scanner.Scan() {
+		if err := scanner.Err(); err != nil {
+			return 0, err
+		}
+		return 0, fmt.Errorf("empty goroutine profile")
+	}
+	total, ok := strings.CutPrefix(scanner.Text(), "goroutine profile: total ")
+	if !ok {
+		return 0, fmt.Errorf("unexpected goroutine profile header %q", scanner.Text())
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(total))
+	if err != nil {
+		return 0, fmt.Errorf("invalid goroutine count %q: %w", total, err)
+	}
+	return n, nil
+}
+
+// goAppMonitor scrapes applications in the background so a slow or hung
+// application never stalls collection.
+type goAppMonitor struct {
+	apps   []GoApp
+	client *http.Client
+
+	mu       sync.Mutex
+	stats    []GoAppStat
+	last     time.Time
+	checking bool
+}
+
+// SetGoApps enables scraping of the given applications' expvar and pprof endpoints
+func (c *SystemCollector) SetGoApps(apps []GoApp) {
+	c.goApps = &goAppMonitor{
+		apps:   apps,
+		client: &http.Client{Timeout: goAppTimeout},
+	}
+}
+
+// collectGoAppMetrics returns the latest application stats, starting a
+// background scrape when one is due. It returns nil when none are configured.
+func (c *SystemCollector) collectGoAppMetrics() []GoAppStat {
+	if c.goApps == nil {
+		return nil
+	}
+	g := c.goApps
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if !g.checking && time.Since(g.last) >= c.pollInterval(goAppRefreshInterval) {
+		g.checking = true
+		go g.scrape()
+	}
+	return g.stats
+}
+
+// scrape reads every application's expvar memstats and goroutine count
+func (g *goAppMonitor) scrape() {
+	stats := make([]GoAppStat, 0, len(g.apps))
+	for _, app := range g.apps {
+		stat := g.scrapeApp(app)
+		stat.Name = app.Name
+		stats = append(stats, stat)
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.stats = stats
+	g.last = time.Now()
+	g.checking = false
+}
+
+// scrapeApp reads one application's expvar memstats and pprof goroutine
+// count. Either endpoint may be missing; the stat only reports an error when
+// neither could be read.
+func (g *goAppMonitor) scrapeApp(app GoApp) GoAppStat {
+	ctx, cancel := context.WithTimeout(context.Background(), goAppTimeout)
+	defer cancel()
+	base := strings.TrimSuffix(app.URL, "/")
+
+	stat := GoAppStat{Goroutines: -1}
+	body, expvarErr := g.get(ctx, base+"/debug/vars", expvarLimit)
+	if expvarErr == nil {
+		var vars GoAppStat
+		if vars, expvarErr = ParseExpvar(body); expvarErr == nil {
+			stat = vars
+		}
+	}
+
+	body, pprofErr := g.get(ctx, base+"/debug/pprof/goroutine?debug=1", goroutineHeaderLimit)
+	if pprofErr == nil {
+		var n int
+		if n, pprofErr = ParseGoroutineProfile(bytes.NewReader(body)); pprofErr == nil {
+			stat.Goroutines = n
+		}
+	}
+
+	if expvarErr != nil && pprofErr != nil {
+		stat.Error = fmt.Sprintf("%v; %v", expvarErr, pprofErr)
+	}
+	return stat
+}
+
+// get fetches url and returns up to limit bytes of the response body
+func (g *goAppMonitor) get(ctx context.Context, url string, limit int64) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("GET %s returned %s", url, resp.Status)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, limit))
+}
@@ -0,0 +1,80 @@
+package metrics
+
+import (
+	"fmt"
+	stdnet "net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/shirou/gopsutil/v3/net"
+)
+
+const sysClassNetPath = "/sys/class/net"
+
+// InterfaceDetail describes one network interface in more depth than
+// NetworkStat. It is read on demand, for the TUI's interface detail view,
+// rather than on every collection.
+type InterfaceDetail struct {
+	Name         string   `json:"name"`
+	HardwareAddr string   `json:"hardware_addr"`
+	MTU          int      `json:"mtu"`
+	Up           bool     `json:"up"`
+	SpeedMbps    int      `json:"speed_mbps"` // 0 when unknown, e.g. for virtual interfaces
+	Duplex       string   `json:"duplex"`     // full, half or empty when unknown
+	Addrs        []string `json:"addrs"`      // in CIDR notation
+	RxErrors     uint64   `json:"rx_errors"`
+	TxErrors     uint64   `json:"tx_errors"`
+	RxDrops      uint64   `json:"rx_drops"`
+	TxDrops      uint64   `json:"tx_drops"`
+}
+
+// InterfaceDetails returns the details of the interface called name. The
+// link speed and duplex are only known on Linux.
+func InterfaceDetails(name string) (InterfaceDetail, error) {
+	iface, err := stdnet.InterfaceByName(name)
+	if err != nil {
+		return InterfaceDetail{}, fmt.Errorf("failed to get interface %s: %w", name, err)
+	}
+	detail := InterfaceDetail{
+		Name:         iface.Name,
+		HardwareAddr: iface.HardwareAddr.String(),
+		MTU:          iface.MTU,
+		Up:           iface.Flags&stdnet.FlagUp != 0 && iface.Flags&stdnet.FlagRunning != 0,
+	}
+	if addrs, err := iface.Addrs(); err == nil {
+		for _, addr := range addrs {
+			detail.Addrs = append(detail.Addrs, addr.String())
+		}
+	}
+	if counters, err := net.IOCounters(true); err == nil {
+		for _, counter := range counters {
+			if counter.Name == name {
+				detail.RxErrors, detail.TxErrors = counter.Errin, counter.Errout
+				detail.RxDrops, detail.TxDrops = counter.Dropin, counter.Dropout
+				break
+			}
+		}
+	}
+	detail.SpeedMbps, detail.Duplex = ReadLinkSettings(filepath.Join(sysClassNetPath, name))
+	return detail, nil
+}
+
+// ReadLinkSettings reads the link speed in Mbit/s and the duplex from an
+// interface's sysfs directory, e.g. /sys/class/net/eth0. Either is zero
+// when the driver does not report it or the link is down.
+func ReadLinkSettings(dir string) (speedMbps int, duplex string) {
+	if data, err := os.ReadFile(filepath.Join(dir, "speed")); err == nil {
+		// Drivers report -1 or garbage when the speed is unknown
+		if speed, err := strconv.Atoi(strings.TrimSpace(string(data))); err == nil && speed > 0 {
+			speedMbps = speed
+		}
+	}
+	if data, err := os.ReadFile(filepath.Join(dir, "duplex")); err == nil {
+		if d := strings.TrimSpace(string(data)); d == "full" || d == "half" {
+			duplex = d
+		}
+	}
+	return speedMbps, duplex
+}
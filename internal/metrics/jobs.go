@@ -0,0 +1,50 @@
+package metrics
+
+import (
+	"time"
+
+	"github.com/j-raghavan/godash/internal/jobs"
+)
+
+// jobsRefreshInterval limits how often the job state file is read
+const jobsRefreshInterval = 5 * time.Second
+
+// SetJobs enables monitoring of periodic jobs whose completions are
+// recorded in store.
+func (c *SystemCollector) SetJobs(list []jobs.Job, store *jobs.Store) {
+	c.jobs = list
+	c.jobStore = store
+}
+
+// collectJobMetrics returns the status of the monitored jobs. It returns
+// nil when no jobs are configured.
+func (c *SystemCollector) collectJobMetrics() ([]jobs.Status, error) {
+	if len(c.jobs) == 0 || c.jobStore == nil {
+		return nil, nil
+	}
+	now := time.Now()
+	var watchErr error
+	if c.jobWatched == nil {
+		names := make([]string, len(c.jobs))
+		for i, job := range c.jobs {
+			names[i] = job.Name
+		}
+		c.jobWatched, watchErr = c.jobStore.Watch(names, now)
+		if watchErr != nil {
+			// Watch from now if the state cannot be written
+			c.jobWatched = make(map[string]time.Time, len(names))
+			for _, name := range names {
+				c.jobWatched[name] = now
+			}
+		}
+	}
+	if c.jobRuns == nil || time.Since(c.jobsLastUpdate) >= jobsRefreshInterval {
+		lastRuns, err := c.jobStore.LastRuns()
+		if err != nil {
+			return nil, err
+		}
+		c.jobRuns = lastRuns
+		c.jobsLastUpdate = now
+	}
+	return jobs.Check(c.jobs, c.jobRuns, c.jobWatched, now), watchErr
+}
@@ -0,0 +1,109 @@
+package metrics
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	procStatPath = "/proc/stat"
+	entropyPath  = "/proc/sys/kernel/random/entropy_avail"
+)
+
+// kernelRefreshInterval limits how often /proc/stat is read; the counters
+// are only shown as per-second rates
+const kernelRefreshInterval = time.Second
+
+// KernelStat holds kernel activity counters, useful for diagnosing
+// performance problems that CPU and memory usage do not explain
+type KernelStat struct {
+	ContextSwitches uint64 `json:"context_switches"` // since boot
+	Interrupts      uint64 `json:"interrupts"`       // since boot
+	Forks           uint64 `json:"forks"`            // processes created since boot
+	ProcsRunning    int    `json:"procs_running"`
+	ProcsBlocked    int    `json:"procs_blocked"` // waiting for I/O
+	EntropyAvail    int    `json:"entropy_avail"` // bits, -1 when unavailable
+
+	// HasRates is false until two samples have been seen
+	HasRates              bool    `json:"has_rates"`
+	ContextSwitchesPerSec float64 `json:"context_switches_per_sec"`
+	InterruptsPerSec      float64 `json:"interrupts_per_sec"`
+	ForksPerSec           float64 `json:"forks_per_sec"`
+}
+
+// ParseProcStat parses the kernel counters of /proc/stat
+func ParseProcStat(r io.Reader) (KernelStat, error) {
+	stat := KernelStat{EntropyAvail: -1}
+	scanner := bufio.NewScanner(r)
+	// The intr line lists every interrupt number and can be very long
+	scanner.Buffer(nil, 1024*1024)
+	for scanner.Scan() {
+		key, rest, ok := strings.Cut(scanner.Text(), " ")
+		if !ok {
+			continue
+		}
+		// Only the first field matters; for intr it is the total
+		value, _, _ := strings.Cut(strings.TrimSpace(rest), " ")
+		n, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			continue
+		}
+		switch key {
+		case "ctxt":
+			stat.ContextSwitches = n
+		case "intr":
+			stat.Interrupts = n
+		case "processes":
+			stat.Forks = n
+		case "procs_running":
+			stat.ProcsRunning = int(n)
+		case "procs_blocked":
+			stat.ProcsBlocked = int(n)
+		}
+	}
+	return stat, scanner.Err()
+}
+
+// collectKernelMetrics reads the kernel counters every
+// kernelRefreshInterval and derives their rates from the previous reading.
+// It returns nil where /proc/stat does not exist.
+func (c *SystemCollector) collectKernelMetrics() (*KernelStat, error) {
+	if c.prevKernel != nil && time.Since(c.prevKernelTime) < kernelRefreshInterval {
+		return c.prevKernel, nil
+	}
+	f, err := os.Open(procStatPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+
+	stat, err := ParseProcStat(f)
+	if err != nil {
+		return nil, err
+	}
+	if data, err := os.ReadFile(entropyPath); err == nil {
+		if n, err := strconv.Atoi(strings.TrimSpace(string(data))); err == nil {
+			stat.EntropyAvail = n
+		}
+	}
+
+	now := time.Now()
+	if prev := c.prevKernel; prev != nil {
+		if seconds := now.Sub(c.prevKernelTime).Seconds(); seconds > 0 {
+			stat.HasRates = true
+			stat.ContextSwitchesPerSec = CounterRate(prev.ContextSwitches, stat.ContextSwitches, seconds)
+			stat.InterruptsPerSec = CounterRate(prev.Interrupts, stat.Interrupts, seconds)
+			stat.ForksPerSec = CounterRate(prev.Forks, stat.Forks, seconds)
+		}
+	}
+	c.prevKernel = &stat
+	c.prevKernelTime = now
+	return &stat, nil
+}
@@ -0,0 +1,230 @@
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// lanRefreshInterval limits how often the ARP table and leases are read
+const lanRefreshInterval = 5 * time.Second
+
+// unknownDeviceTTL is how long a device stays listed as unknown while it
+// remains on the network. Its unknown_device event fires once, when listed.
+const unknownDeviceTTL = time.Hour
+
+// deviceForgetAfter is how long a device must be gone before it counts as
+// new again when no known MAC list is configured
+const deviceForgetAfter = 24 * time.Hour
+
+const arpTablePath = "/proc/net/arp"
+
+// Neighbor represents a device in the ARP/neighbor table.
+type Neighbor struct {
+	IP       string `json:"ip"`
+	MAC      string `json:"mac"`
+	Device   string `json:"device"`
+	Hostname string `json:"hostname"` // from the DHCP leases, when known
+}
+
+// Lease represents a DHCP lease.
+type Lease struct {
+	Expiry   time.Time // zero for infinite leases
+	MAC      string
+	IP       string
+	Hostname string
+}
+
+// LANStat represents the local network as seen by a router/gateway.
+type LANStat struct {
+	Neighbors    []Neighbor `json:"neighbors"`
+	ActiveLeases int        `json:"active_leases"`
+	// UnknownDevices are neighbors not in the known MAC list, or, when no
+	// list is configured, devices that appeared after godash started. A
+	// device is listed until it leaves the neighbor table, for at most an
+	// hour.
+	UnknownDevices []Neighbor `json:"unknown_devices"`
+}
+
+// ParseARPTable parses the contents of /proc/net/arp, skipping incomplete entries
+func ParseARPTable(r io.Reader) ([]Neighbor, error) {
+	var neighbors []Neighbor
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 6 || fields[0] == "IP" {
+			continue
+		}
+		// Flags 0x0 marks an incomplete entry
+		if fields[2] == "0x0" || fields[3] == "00:00:00:00:00:00" {
+			continue
+		}
+		neighbors = append(neighbors, Neighbor{
+			IP:     fields[0],
+			MAC:    strings.ToLower(fields[3]),
+			Device: fields[5],
+		})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return neighbors, nil
+}
+
+// ParseDnsmasqLeases parses a dnsmasq leases file, where each line is
+// "<expiry> <mac> <ip> <hostname> <client-id>"
+func ParseDnsmasqLeases(r io.Reader) ([]Lease, error) {
+	var leases []Lease
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 4 {
+			continue
+		}
+		expiry, err := strconv.ParseInt(fields[0], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid lease expiry %q: %w", fields[0], err)
+		}
+		lease := Lease{
+			MAC: strings.ToLower(fields[1]),
+			IP:  fields[2],
+		}
+		if expiry > 0 {
+			lease.Expiry = time.Unix(expiry, 0)
+		}
+		if fields[3] != "*" {
+			lease.Hostname = fields[3]
+		}
+		leases = append(leases, lease)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return leases, nil
+}
+
+// DeviceTracker decides which neighbors are unknown devices
+type DeviceTracker struct {
+	known        map[string]bool      // configured MACs, empty to flag new ones
+	seen         map[string]time.Time // last time each MAC was a neighbor
+	unknownSince map[string]time.Time // when each present MAC was flagged
+}
+
+// NewDeviceTracker creates a tracker flagging devices not in knownMACs, or,
+// when it is empty, devices that appear after the first update
+func NewDeviceTracker(knownMACs []string) *DeviceTracker {
+	t := &DeviceTracker{known: make(map[string]bool, len(knownMACs)), unknownSince: make(map[string]time.Time)}
+	for _, mac := range knownMACs {
+		t.known[strings.ToLower(mac)] = true
+	}
+	return t
+}
+
+// Update returns the unknown devices among the current neighbors. A device
+// is listed from when it is first flagged until it leaves the table or
+// unknownDeviceTTL passes, so short-lived and randomized MACs do not
+// accumulate.
+func (t *DeviceTracker) Update(neighbors []Neighbor, now time.Time) []Neighbor {
+	firstUpdate := t.seen == nil
+	if firstUpdate {
+		t.seen = make(map[string]time.Time)
+	}
+	present := make(map[string]bool, len(neighbors))
+	var unknown []Neighbor
+	for _, neighbor := range neighbors {
+		present[neighbor.MAC] = true
+		_, seen := t.seen[neighbor.MAC]
+		t.seen[neighbor.MAC] = now
+		flag := !firstUpdate && !seen
+		if len(t.known) > 0 {
+			flag = !t.known[neighbor.MAC]
+		}
+		since, flagged := t.unknownSince[neighbor.MAC]
+		if flag && !flagged {
+			since, flagged = now, true
+			t.unknownSince[neighbor.MAC] = now
+		}
+		if flagged && now.Sub(since) < unknownDeviceTTL {
+			unknown = append(unknown, neighbor)
+		}
+	}
+	for mac := range t.unknownSince {
+		if !present[mac] {
+			delete(t.unknownSince, mac)
+		}
+	}
+	for mac, last := range t.seen {
+		if now.Sub(last) > deviceForgetAfter {
+			delete(t.seen, mac)
+		}
+	}
+	return unknown
+}
+
+// SetLAN enables the LAN collector, reading DHCP leases from leasesFile
+// (dnsmasq format) when set and flagging devices not in knownMACs.
+func (c *SystemCollector) SetLAN(leasesFile string, knownMACs []string) {
+	c.lanEnabled = true
+	c.leasesFile = leasesFile
+	c.devices = NewDeviceTracker(knownMACs)
+}
+
+// collectLANMetrics collects neighbor and DHCP lease metrics, caching the
+// result between refreshes. It returns nil when the collector is disabled.
+func (c *SystemCollector) collectLANMetrics() (*LANStat, error) {
+	if !c.lanEnabled {
+		return nil, nil
+	}
+	if c.lanStat != nil && time.Since(c.lanLastUpdate) < lanRefreshInterval {
+		return c.lanStat, nil
+	}
+
+	f, err := os.Open(arpTablePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read neighbor table: %w", err)
+	}
+	defer f.Close()
+	neighbors, err := ParseARPTable(f)
+	if err != nil {
+		return nil, err
+	}
+
+	stat := &LANStat{Neighbors: neighbors}
+	if c.leasesFile != "" {
+		lf, err := os.Open(c.leasesFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read DHCP leases: %w", err)
+		}
+		defer lf.Close()
+		leases, err := ParseDnsmasqLeases(lf)
+		if err != nil {
+			return nil, err
+		}
+
+		hostnames := make(map[string]string, len(leases))
+		now := time.Now()
+		for _, lease := range leases {
+			if lease.Expiry.IsZero() || lease.Expiry.After(now) {
+				stat.ActiveLeases++
+			}
+			hostnames[lease.MAC] = lease.Hostname
+		}
+		for i := range stat.Neighbors {
+			stat.Neighbors[i].Hostname = hostnames[stat.Neighbors[i].MAC]
+		}
+	}
+
+	stat.UnknownDevices = c.devices.Update(stat.Neighbors, time.Now())
+
+	c.lanStat = stat
+	c.lanLastUpdate = time.Now()
+	return stat, nil
+}
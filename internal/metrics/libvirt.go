@@ -0,0 +1,184 @@
+package metrics
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// libvirtRefreshInterval limits how often virsh is invoked
+const libvirtRefreshInterval = 5 * time.Second
+
+// libvirtTimeout bounds a run of virsh
+const libvirtTimeout = 5 * time.Second
+
+// libvirt domain states as reported by state.state
+var vmStates = map[string]string{
+	"0": "nostate",
+	"1": "running",
+	"2": "blocked",
+	"3": "paused",
+	"4": "shutdown",
+	"5": "shutoff",
+	"6": "crashed",
+	"7": "pmsuspended",
+}
+
+// VMStat represents a libvirt/KVM virtual machine.
+type VMStat struct {
+	Name            string  `json:"name"`
+	State           string  `json:"state"`
+	VCPUs           int     `json:"vcpus"`
+	CPUTime         uint64  `json:"cpu_time"`    // cumulative vCPU time in nanoseconds
+	CPUPercent      float64 `json:"cpu_percent"` // utilization of the VM's vCPUs since the last sample
+	Memory          uint64  `json:"memory"`      // current balloon size in bytes
+	NetRxBytes      uint64  `json:"net_rx_bytes"`
+	NetTxBytes      uint64  `json:"net_tx_bytes"`
+	BlockReadBytes  uint64  `json:"block_read_bytes"`
+	BlockWriteBytes uint64  `json:"block_write_bytes"`
+	// Rates since the last sample, in bytes per second
+	NetRxBytesPerSec      float64 `json:"net_rx_bytes_per_sec"`
+	NetTxBytesPerSec      float64 `json:"net_tx_bytes_per_sec"`
+	BlockReadBytesPerSec  float64 `json:"block_read_bytes_per_sec"`
+	BlockWriteBytesPerSec float64 `json:"block_write_bytes_per_sec"`
+}
+
+// ParseDomstats parses the output of `virsh domstats --raw`
+func ParseDomstats(r io.Reader) ([]VMStat, error) {
+	var vms []VMStat
+	var current *VMStat
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		if name, ok := strings.CutPrefix(line, "Domain:"); ok {
+			vms = append(vms, VMStat{Name: strings.Trim(strings.TrimSpace(name), "'")})
+			current = &vms[len(vms)-1]
+			continue
+		}
+		if current == nil {
+			continue
+		}
+
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+
+		switch {
+		case key == "state.state":
+			current.State = vmStates[value]
+		case key == "vcpu.current":
+			n, err := strconv.Atoi(value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s for %s: %w", key, current.Name, err)
+			}
+			current.VCPUs = n
+		case key == "cpu.time", key == "balloon.current",
+			strings.HasPrefix(key, "net.") && (strings.HasSuffix(key, ".rx.bytes") || strings.HasSuffix(key, ".tx.bytes")),
+			strings.HasPrefix(key, "block.") && (strings.HasSuffix(key, ".rd.bytes") || strings.HasSuffix(key, ".wr.bytes")):
+			n, err := strconv.ParseUint(value, 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s for %s: %w", key, current.Name, err)
+			}
+			switch {
+			case key == "cpu.time":
+				current.CPUTime = n
+			case key == "balloon.current":
+				current.Memory = n * 1024 // reported in KiB
+			case strings.HasSuffix(key, ".rx.bytes"):
+				current.NetRxBytes += n
+			case strings.HasSuffix(key, ".tx.bytes"):
+				current.NetTxBytes += n
+			case strings.HasSuffix(key, ".rd.bytes"):
+				current.BlockReadBytes += n
+			case strings.HasSuffix(key, ".wr.bytes"):
+				current.BlockWriteBytes += n
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return vms, nil
+}
+
+// SetLibvirt enables or disables the libvirt virtual machine collector
+func (c *SystemCollector) SetLibvirt(enabled bool) {
+	c.libvirtEnabled = enabled
+}
+
+// collectVMMetrics collects libvirt VM metrics via virsh, caching the result
+// between invocations. A failing virsh is retried at the next refresh. It
+// returns nil when the collector is disabled.
+func (c *SystemCollector) collectVMMetrics() ([]VMStat, error) {
+	if !c.libvirtEnabled {
+		return nil, nil
+	}
+	if time.Since(c.vmLastUpdate) < libvirtRefreshInterval {
+		return c.vmStats, c.vmErr
+	}
+
+	now := time.Now()
+	vms, err := queryVMs()
+	if err != nil {
+		c.vmStats, c.vmErr, c.vmLastUpdate = nil, err, now
+		return nil, err
+	}
+
+	seconds := now.Sub(c.vmLastUpdate).Seconds()
+	prev := make(map[string]VMStat, len(c.vmStats))
+	for _, vm := range c.vmStats {
+		prev[vm.Name] = vm
+	}
+	for i := range vms {
+		vm := &vms[i]
+		p, ok := prev[vm.Name]
+		if !ok {
+			continue
+		}
+		if vm.VCPUs > 0 && vm.CPUTime >= p.CPUTime {
+			used := float64(vm.CPUTime - p.CPUTime)
+			vm.CPUPercent = used / (seconds * 1e9 * float64(vm.VCPUs)) * 100
+		}
+		vm.NetRxBytesPerSec = vmRate(p.NetRxBytes, vm.NetRxBytes, seconds)
+		vm.NetTxBytesPerSec = vmRate(p.NetTxBytes, vm.NetTxBytes, seconds)
+		vm.BlockReadBytesPerSec = vmRate(p.BlockReadBytes, vm.BlockReadBytes, seconds)
+		vm.BlockWriteBytesPerSec = vmRate(p.BlockWriteBytes, vm.BlockWriteBytes, seconds)
+	}
+
+	c.vmStats, c.vmErr, c.vmLastUpdate = vms, nil, now
+	return vms, nil
+}
+
+// queryVMs runs `virsh domstats`
+func queryVMs() ([]VMStat, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), libvirtTimeout)
+	defer cancel()
+	out, err := exec.CommandContext(ctx, "virsh", "domstats", "--raw",
+		"--state", "--cpu-total", "--balloon", "--vcpu", "--interface", "--block").Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to query libvirt: %w", err)
+	}
+	return ParseDomstats(bytes.NewReader(out))
+}
+
+// vmRate returns the per-second rate of a VM's 64-bit counter, or 0 when it
+// went backwards because the VM or one of its devices was restarted
+func vmRate(prev, cur uint64, seconds float64) float64 {
+	if cur < prev || seconds <= 0 {
+		return 0
+	}
+	return float64(cur-prev) / seconds
+}
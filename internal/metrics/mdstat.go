@@ -0,0 +1,117 @@
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const mdstatPath = "/proc/mdstat"
+
+var (
+	mdDisksRe = regexp.MustCompile(`\[(\d+)/(\d+)\]\s+\[([U_]+)\]`)
+	mdSyncRe  = regexp.MustCompile(`(recovery|resync|reshape|check)\s*=\s*([\d.]+)%`)
+)
+
+// RaidStat represents the state of a Linux software RAID (mdadm) array.
+type RaidStat struct {
+	Name         string   `json:"name"`
+	Level        string   `json:"level"`
+	State        string   `json:"state"` // active, inactive
+	Devices      []string `json:"devices"`
+	DisksTotal   int      `json:"disks_total"`
+	DisksActive  int      `json:"disks_active"`
+	Degraded     bool     `json:"degraded"`
+	SyncAction   string   `json:"sync_action"`   // recovery, resync, reshape or check; empty when idle
+	SyncProgress float64  `json:"sync_progress"` // percentage of the current sync action
+}
+
+// ParseMdstat parses the contents of /proc/mdstat
+func ParseMdstat(r io.Reader) ([]RaidStat, error) {
+	var arrays []RaidStat
+	var current *RaidStat
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			current = nil
+			continue
+		}
+
+		// Array header: "md0 : active raid1 sdb1[1] sda1[0]"
+		if strings.HasPrefix(fields[0], "md") && len(fields) >= 3 && fields[1] == ":" {
+			arrays = append(arrays, RaidStat{Name: fields[0], State: fields[2]})
+			current = &arrays[len(arrays)-1]
+			rest := fields[3:]
+			// The level is absent for inactive arrays
+			if len(rest) > 0 && !strings.Contains(rest[0], "[") {
+				if strings.HasPrefix(rest[0], "(") {
+					rest = rest[1:] // e.g. "(auto-read-only)"
+				}
+				if len(rest) > 0 && !strings.Contains(rest[0], "[") {
+					current.Level = rest[0]
+					rest = rest[1:]
+				}
+			}
+			for _, dev := range rest {
+				if i := strings.Index(dev, "["); i > 0 {
+					current.Devices = append(current.Devices, dev[:i])
+				}
+			}
+			continue
+		}
+
+		if current == nil {
+			continue
+		}
+
+		if match := mdDisksRe.FindStringSubmatch(line); match != nil {
+			total, err := strconv.Atoi(match[1])
+			if err != nil {
+				return nil, fmt.Errorf("invalid disk count in %q: %w", line, err)
+			}
+			active, err := strconv.Atoi(match[2])
+			if err != nil {
+				return nil, fmt.Errorf("invalid disk count in %q: %w", line, err)
+			}
+			current.DisksTotal = total
+			current.DisksActive = active
+			current.Degraded = active < total || strings.Contains(match[3], "_")
+		}
+
+		if match := mdSyncRe.FindStringSubmatch(line); match != nil {
+			progress, err := strconv.ParseFloat(match[2], 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid sync progress in %q: %w", line, err)
+			}
+			current.SyncAction = match[1]
+			current.SyncProgress = progress
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return arrays, nil
+}
+
+// collectRaidMetrics collects software RAID status. It returns nil when
+// mdadm is not in use on this system.
+func collectRaidMetrics() ([]RaidStat, error) {
+	f, err := os.Open(mdstatPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+
+	return ParseMdstat(f)
+}
@@ -0,0 +1,82 @@
+package metrics
+
+import (
+	"errors"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/shirou/gopsutil/v3/disk"
+)
+
+// Health states reported for network filesystem mounts.
+const (
+	MountHealthy = "ok"
+	MountStale   = "stale"
+	MountHung    = "hung"
+	MountError   = "error"
+)
+
+// mountTimeout bounds how long a network mount may take to answer stat()
+// before it is reported as hung.
+const mountTimeout = 500 * time.Millisecond
+
+// mountProbe is the result of a stat() call against a mountpoint
+type mountProbe struct {
+	usage   *disk.UsageStat
+	err     error
+	latency time.Duration
+}
+
+// IsNetworkFilesystem reports whether fstype is an NFS, SMB or other
+// network-backed filesystem that may hang when the server goes away.
+func IsNetworkFilesystem(fstype string) bool {
+	switch fstype {
+	case "nfs", "nfs4", "cifs", "smbfs", "smb3", "afs", "9p", "ceph", "glusterfs":
+		return true
+	}
+	return strings.HasPrefix(fstype, "fuse.sshfs")
+}
+
+// probeMount stats a network mountpoint without letting a hung server
+// stall collection. A probe that does not answer within mountTimeout is
+// left running in the background and the mount is reported as hung until
+// it returns, so at most one stat() is outstanding per mount.
+func (c *SystemCollector) probeMount(mountpoint string) (*disk.UsageStat, time.Duration, string) {
+	if probe, pending := c.pendingMounts[mountpoint]; pending {
+		select {
+		case res := <-probe:
+			delete(c.pendingMounts, mountpoint)
+			return mountResult(res)
+		default:
+			return nil, mountTimeout, MountHung
+		}
+	}
+
+	probe := make(chan mountProbe, 1)
+	go func(start time.Time) {
+		usage, err := disk.Usage(mountpoint)
+		probe <- mountProbe{usage: usage, err: err, latency: time.Since(start)}
+	}(time.Now())
+
+	timer := time.NewTimer(mountTimeout)
+	defer timer.Stop()
+	select {
+	case res := <-probe:
+		return mountResult(res)
+	case <-timer.C:
+		c.pendingMounts[mountpoint] = probe
+		return nil, mountTimeout, MountHung
+	}
+}
+
+// mountResult maps a completed probe to its usage, latency and health
+func mountResult(res mountProbe) (*disk.UsageStat, time.Duration, string) {
+	switch {
+	case errors.Is(res.err, syscall.ESTALE):
+		return nil, res.latency, MountStale
+	case res.err != nil:
+		return nil, res.latency, MountError
+	}
+	return res.usage, res.latency, MountHealthy
+}
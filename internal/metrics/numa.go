@@ -0,0 +1,236 @@
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	meminfoPath = "/proc/meminfo"
+	nodeSysPath = "/sys/devices/system/node"
+)
+
+// numaRefreshInterval limits how often hugepage and NUMA node memory is read
+const numaRefreshInterval = 5 * time.Second
+
+// HugePageStat reports the preallocated huge page pool
+type HugePageStat struct {
+	Total    uint64 `json:"total"`     // pages
+	Free     uint64 `json:"free"`      // pages
+	Reserved uint64 `json:"reserved"`  // pages promised to mappings but not yet faulted in
+	Surplus  uint64 `json:"surplus"`   // pages allocated beyond Total by overcommit
+	PageSize uint64 `json:"page_size"` // bytes
+}
+
+// UsedPercent returns the share of the pool in use, counting reserved pages
+// as used since they are no longer available
+func (h HugePageStat) UsedPercent() float64 {
+	if h.Total == 0 {
+		return 0
+	}
+	return float64(h.Total-h.Free+h.Reserved) / float64(h.Total) * 100
+}
+
+// NUMANode reports the memory and CPU usage of one NUMA node
+type NUMANode struct {
+	ID             int     `json:"id"`
+	CPUs           []int   `json:"cpus"`
+	MemTotal       uint64  `json:"mem_total"` // bytes
+	MemFree        uint64  `json:"mem_free"`  // bytes
+	MemUsed        uint64  `json:"mem_used"`  // bytes
+	HugePagesTotal uint64  `json:"huge_pages_total"`
+	HugePagesFree  uint64  `json:"huge_pages_free"`
+	CPUPercent     float64 `json:"cpu_percent"` // average busy percentage of the node's CPUs
+}
+
+// MemUsedPercent returns the share of the node's memory in use
+func (n NUMANode) MemUsedPercent() float64 {
+	if n.MemTotal == 0 {
+		return 0
+	}
+	return float64(n.MemUsed) / float64(n.MemTotal) * 100
+}
+
+// ParseMeminfoHugePages parses the huge page pool from /proc/meminfo
+func ParseMeminfoHugePages(r io.Reader) (HugePageStat, error) {
+	var stat HugePageStat
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 {
+			continue
+		}
+		n, err := strconv.ParseUint(fields[1], 10, 64)
+		if err != nil {
+			continue
+		}
+		switch fields[0] {
+		case "HugePages_Total:":
+			stat.Total = n
+		case "HugePages_Free:":
+			stat.Free = n
+		case "HugePages_Rsvd:":
+			stat.Reserved = n
+		case "HugePages_Surp:":
+			stat.Surplus = n
+		case "Hugepagesize:":
+			stat.PageSize = n * 1024 // kB
+		}
+	}
+	return stat, scanner.Err()
+}
+
+// ParseNodeMeminfo parses a /sys/devices/system/node/node<N>/meminfo file,
+// whose lines read "Node 0 MemTotal:  5603064 kB"
+func ParseNodeMeminfo(r io.Reader) (NUMANode, error) {
+	var node NUMANode
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 4 || fields[0] != "Node" {
+			continue
+		}
+		id, err := strconv.Atoi(fields[1])
+		if err != nil {
+			continue
+		}
+		node.ID = id
+		n, err := strconv.ParseUint(fields[3], 10, 64)
+		if err != nil {
+			continue
+		}
+		switch fields[2] {
+		case "MemTotal:":
+			node.MemTotal = n * 1024
+		case "MemFree:":
+			node.MemFree = n * 1024
+		case "MemUsed:":
+			node.MemUsed = n * 1024
+		case "HugePages_Total:":
+			node.HugePagesTotal = n
+		case "HugePages_Free:":
+			node.HugePagesFree = n
+		}
+	}
+	return node, scanner.Err()
+}
+
+// ParseCPUList parses a kernel CPU list such as "0-3,8-11"
+func ParseCPUList(s string) ([]int, error) {
+	var cpus []int
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return nil, nil
+	}
+	for _, part := range strings.Split(s, ",") {
+		lo, hi, isRange := strings.Cut(part, "-")
+		first, err := strconv.Atoi(lo)
+		if err != nil {
+			return nil, fmt.Errorf("invalid cpu list %q", s)
+		}
+		last := first
+		if isRange {
+			if last, err = strconv.Atoi(hi); err != nil || last < first {
+				return nil, fmt.Errorf("invalid cpu list %q", s)
+			}
+		}
+		for cpu := first; cpu <= last; cpu++ {
+			cpus = append(cpus, cpu)
+		}
+	}
+	return cpus, nil
+}
+
+// readNUMANodes reads every NUMA node's memory and CPU list. It returns nil
+// where the kernel does not expose nodes.
+func readNUMANodes() ([]NUMANode, error) {
+	dirs, err := filepath.Glob(filepath.Join(nodeSysPath, "node[0-9]*"))
+	if err != nil || len(dirs) == 0 {
+		return nil, err
+	}
+	nodes := make([]NUMANode, 0, len(dirs))
+	for _, dir := range dirs {
+		f, err := os.Open(filepath.Join(dir, "meminfo"))
+		if err != nil {
+			return nil, err
+		}
+		node, err := ParseNodeMeminfo(f)
+		f.Close()
+		if err != nil {
+			return nil, err
+		}
+		if data, err := os.ReadFile(filepath.Join(dir, "cpulist")); err == nil {
+			if node.CPUs, err = ParseCPUList(string(data)); err != nil {
+				return nil, err
+			}
+		}
+		nodes = append(nodes, node)
+	}
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].ID < nodes[j].ID })
+	return nodes, nil
+}
+
+// readHugePages reads the huge page pool. It returns nil where
+// /proc/meminfo does not exist.
+func readHugePages() (*HugePageStat, error) {
+	f, err := os.Open(meminfoPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+	stat, err := ParseMeminfoHugePages(f)
+	if err != nil {
+		return nil, err
+	}
+	return &stat, nil
+}
+
+// collectNUMAMetrics returns the huge page pool and the NUMA nodes with the
+// CPU usage of each, given the per-core busy percentages. Memory is reread
+// every numaRefreshInterval.
+func (c *SystemCollector) collectNUMAMetrics(cpuPercents []float64) (*HugePageStat, []NUMANode, error) {
+	if c.numaLastUpdate.IsZero() || time.Since(c.numaLastUpdate) >= numaRefreshInterval {
+		hugePages, err := readHugePages()
+		if err != nil {
+			return nil, nil, err
+		}
+		nodes, err := readNUMANodes()
+		if err != nil {
+			return nil, nil, err
+		}
+		c.hugePages, c.numaNodes = hugePages, nodes
+		c.numaLastUpdate = time.Now()
+	}
+	if len(c.numaNodes) == 0 {
+		return c.hugePages, nil, nil
+	}
+
+	// The cached nodes are shared with earlier samples, so CPU usage is
+	// filled in on a copy
+	nodes := make([]NUMANode, len(c.numaNodes))
+	copy(nodes, c.numaNodes)
+	for i := range nodes {
+		var sum float64
+		var n int
+		for _, cpu := range nodes[i].CPUs {
+			if cpu < len(cpuPercents) {
+				sum += cpuPercents[cpu]
+				n++
+			}
+		}
+		if n > 0 {
+			nodes[i].CPUPercent = sum / float64(n)
+		}
+	}
+	return c.hugePages, nodes, nil
+}
@@ -0,0 +1,67 @@
+package metrics
+
+import "sort"
+
+// pinRank returns the position of the first id found in pinned, or -1
+func pinRank(pinned []string, ids ...string) int {
+	for i, p := range pinned {
+		for _, id := range ids {
+			if id != "" && id == p {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// OrderDisks returns disks with pinned entries first, in the order they
+// were pinned, followed by the remaining disks sorted by mountpoint.
+// Entries may be pinned by mountpoint, device or display name.
+func OrderDisks(disks []DiskStat, pinned []string) []DiskStat {
+	ordered := make([]DiskStat, len(disks))
+	copy(ordered, disks)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		ri := pinRank(pinned, ordered[i].Path, ordered[i].Device, ordered[i].Label)
+		rj := pinRank(pinned, ordered[j].Path, ordered[j].Device, ordered[j].Label)
+		switch {
+		case ri >= 0 && rj >= 0:
+			return ri < rj
+		case ri >= 0 || rj >= 0:
+			return ri >= 0
+		default:
+			return ordered[i].Path < ordered[j].Path
+		}
+	})
+	return ordered
+}
+
+// OrderInterfaces returns interfaces with pinned entries first, in the order
+// they were pinned, followed by the remaining interfaces by current
+// throughput, then by traffic since boot (both descending) and then by name. Entries may be pinned by interface or
+// display name.
+func OrderInterfaces(stats []NetworkStat, pinned []string) []NetworkStat {
+	ordered := make([]NetworkStat, len(stats))
+	copy(ordered, stats)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		ri := pinRank(pinned, ordered[i].Interface, ordered[i].Label)
+		rj := pinRank(pinned, ordered[j].Interface, ordered[j].Label)
+		switch {
+		case ri >= 0 && rj >= 0:
+			return ri < rj
+		case ri >= 0 || rj >= 0:
+			return ri >= 0
+		}
+		rateI := ordered[i].RxBytesPerSec + ordered[i].TxBytesPerSec
+		rateJ := ordered[j].RxBytesPerSec + ordered[j].TxBytesPerSec
+		if rateI != rateJ {
+			return rateI > rateJ
+		}
+		ti := ordered[i].RxBytes + ordered[i].TxBytes
+		tj := ordered[j].RxBytes + ordered[j].TxBytes
+		if ti != tj {
+			return ti > tj
+		}
+		return ordered[i].Interface < ordered[j].Interface
+	})
+	return ordered
+}
@@ -0,0 +1,219 @@
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/shirou/gopsutil/v3/net"
+)
+
+// portRefreshInterval limits how often the socket tables are read
+const portRefreshInterval = 5 * time.Second
+
+// Port watch expectations
+const (
+	PortListening = "listening"
+	PortClosed    = "closed"
+)
+
+// procNetFiles maps each protocol to its socket tables
+var procNetFiles = map[string][]string{
+	"tcp": {"/proc/net/tcp", "/proc/net/tcp6"},
+	"udp": {"/proc/net/udp", "/proc/net/udp6"},
+}
+
+// Socket states in /proc/net tables: TCP_LISTEN, and TCP_CLOSE which is
+// what an unconnected (bound) UDP socket reports
+const (
+	procNetListen  = "0A"
+	procNetUnbound = "07"
+)
+
+// PortWatch describes a local port that is expected to be listening, or
+// expected to stay closed
+type PortWatch struct {
+	Port     int
+	Protocol string // "tcp" or "udp"
+	Expect   string // PortListening or PortClosed
+}
+
+// Listener is a local port accepting connections or datagrams
+type Listener struct {
+	Port     int
+	Protocol string
+}
+
+// PortStatus reports the state of a watched port
+type PortStatus struct {
+	Port      int    `json:"port"`
+	Protocol  string `json:"protocol"`
+	Expect    string `json:"expect"`
+	Listening bool   `json:"listening"`
+	OK        bool   `json:"ok"` // Listening matches Expect
+	// Unexpected marks a TCP port that started listening after godash
+	// started without being watched
+	Unexpected bool `json:"unexpected"`
+}
+
+// Name returns the port in "tcp/22" form
+func (s PortStatus) Name() string {
+	return fmt.Sprintf("%s/%d", s.Protocol, s.Port)
+}
+
+// ParseProcNetListeners parses a /proc/net/{tcp,tcp6,udp,udp6} table and
+// returns its listening sockets, tagged with protocol
+func ParseProcNetListeners(r io.Reader, protocol string) ([]Listener, error) {
+	var listeners []Listener
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		// "0: 00000000:0016 00000000:0000 0A ..."
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 4 || !strings.HasSuffix(fields[0], ":") {
+			continue // header
+		}
+		state := fields[3]
+		if protocol == "tcp" && state != procNetListen ||
+			protocol == "udp" && state != procNetUnbound {
+			continue
+		}
+		_, hexPort, ok := strings.Cut(fields[1], ":")
+		if !ok {
+			continue
+		}
+		port, err := strconv.ParseUint(hexPort, 16, 16)
+		if err != nil || port == 0 {
+			continue
+		}
+		listeners = append(listeners, Listener{Port: int(port), Protocol: protocol})
+	}
+	return listeners, scanner.Err()
+}
+
+// WatchPorts evaluates watches against the listening sockets. When baseline
+// is non-nil, TCP listeners that are neither in it nor watched are reported
+// as unexpected.
+func WatchPorts(watches []PortWatch, listeners []Listener, baseline map[Listener]bool) []PortStatus {
+	listening := make(map[Listener]bool, len(listeners))
+	for _, l := range listeners {
+		listening[l] = true
+	}
+
+	statuses := make([]PortStatus, 0, len(watches))
+	watched := make(map[Listener]bool, len(watches))
+	for _, watch := range watches {
+		key := Listener{Port: watch.Port, Protocol: watch.Protocol}
+		watched[key] = true
+		status := PortStatus{
+			Port:      watch.Port,
+			Protocol:  watch.Protocol,
+			Expect:    watch.Expect,
+			Listening: listening[key],
+		}
+		status.OK = status.Listening == (watch.Expect != PortClosed)
+		statuses = append(statuses, status)
+	}
+
+	if baseline != nil {
+		var unexpected []PortStatus
+		for l := range listening {
+			if l.Protocol != "tcp" || baseline[l] || watched[l] {
+				continue
+			}
+			unexpected = append(unexpected, PortStatus{
+				Port:       l.Port,
+				Protocol:   l.Protocol,
+				Expect:     PortClosed,
+				Listening:  true,
+				Unexpected: true,
+			})
+		}
+		sort.Slice(unexpected, func(i, j int) bool { return unexpected[i].Port < unexpected[j].Port })
+		statuses = append(statuses, unexpected...)
+	}
+	return statuses
+}
+
+// SetPortWatches enables monitoring of the given ports. With newPorts set,
+// TCP ports that start listening after the first scan are reported too.
+func (c *SystemCollector) SetPortWatches(watches []PortWatch, newPorts bool) {
+	c.portWatches = watches
+	c.watchNewPorts = newPorts
+}
+
+// collectPortMetrics returns the status of the watched ports, rereading
+// the socket tables every portRefreshInterval
+func (c *SystemCollector) collectPortMetrics() ([]PortStatus, error) {
+	if len(c.portWatches) == 0 && !c.watchNewPorts {
+		return nil, nil
+	}
+	if c.portStatuses != nil && time.Since(c.portLastUpdate) < portRefreshInterval {
+		return c.portStatuses, nil
+	}
+
+	listeners, err := listListeners()
+	if err != nil {
+		return nil, err
+	}
+	if c.watchNewPorts && c.portBaseline == nil {
+		c.portBaseline = make(map[Listener]bool, len(listeners))
+		for _, l := range listeners {
+			c.portBaseline[l] = true
+		}
+	}
+	c.portStatuses = WatchPorts(c.portWatches, listeners, c.portBaseline)
+	c.portLastUpdate = time.Now()
+	return c.portStatuses, nil
+}
+
+// listListeners returns the listening sockets, read from /proc/net where
+// available and from gopsutil elsewhere
+func listListeners() ([]Listener, error) {
+	if _, err := os.Stat(procNetFiles["tcp"][0]); err != nil {
+		return listConnectionListeners()
+	}
+	var listeners []Listener
+	for protocol, paths := range procNetFiles {
+		for _, path := range paths {
+			f, err := os.Open(path)
+			if err != nil {
+				if os.IsNotExist(err) {
+					continue // e.g. IPv6 disabled
+				}
+				return nil, err
+			}
+			found, err := ParseProcNetListeners(f, protocol)
+			f.Close()
+			if err != nil {
+				return nil, err
+			}
+			listeners = append(listeners, found...)
+		}
+	}
+	return listeners, nil
+}
+
+// listConnectionListeners returns the listening sockets reported by the
+// platform's connection table
+func listConnectionListeners() ([]Listener, error) {
+	conns, err := net.Connections("inet")
+	if err != nil {
+		return nil, err
+	}
+	var listeners []Listener
+	for _, conn := range conns {
+		switch {
+		case conn.Type == syscall.SOCK_STREAM && conn.Status == "LISTEN":
+			listeners = append(listeners, Listener{Port: int(conn.Laddr.Port), Protocol: "tcp"})
+		case conn.Type == syscall.SOCK_DGRAM && conn.Raddr.Port == 0:
+			listeners = append(listeners, Listener{Port: int(conn.Laddr.Port), Protocol: "udp"})
+		}
+	}
+	return listeners, nil
+}
@@ -0,0 +1,303 @@
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/shirou/gopsutil/v3/process"
+)
+
+// processRefreshInterval limits how often the process table is scanned
+const processRefreshInterval = 5 * time.Second
+
+// restartBadgeDuration is how long a restarted process keeps its badge
+const restartBadgeDuration = 10 * time.Minute
+
+// Process watch states
+const (
+	ProcessOK        = "ok"
+	ProcessDown      = "down"
+	ProcessRestarted = "restarted"
+	ProcessOverLimit = "over_limit"
+)
+
+// ProcessWatch describes a process that is expected to be running
+type ProcessWatch struct {
+	Name      string  // process name, e.g. "postgres"
+	MinCount  int     // fewer matching processes is reported as down
+	MaxCPU    float64 // total CPU percent of one core, 0 for no limit
+	MaxMemory uint64  // total memory in bytes (PSS when measured), 0 for no limit
+}
+
+// ProcessInfo is the per-process data process watches are evaluated on
+type ProcessInfo struct {
+	PID        int32
+	Name       string
+	CPUPercent float64
+	Memory     uint64 // RSS
+	PSS        uint64 // proportional set size, 0 unless measured
+	USS        uint64 // unique (private) set size, 0 unless measured
+}
+
+// ProcessStatus reports the state of a watched process
+type ProcessStatus struct {
+	Name        string    `json:"name"`
+	Status      string    `json:"status"` // ProcessOK, ProcessDown, ProcessRestarted or ProcessOverLimit
+	Count       int       `json:"count"`
+	PIDs        []int32   `json:"pids"`
+	CPUPercent  float64   `json:"cpu_percent"`
+	Memory      uint64    `json:"memory"`       // total RSS
+	PSS         uint64    `json:"pss"`          // total PSS, 0 unless smaps memory is enabled
+	USS         uint64    `json:"uss"`          // total USS, 0 unless smaps memory is enabled
+	Restarts    int       `json:"restarts"`     // PID changes seen since godash started
+	LastRestart time.Time `json:"last_restart"` // zero if never restarted
+}
+
+// WatchProcesses evaluates watches against the running processes. A watch
+// counts as restarted when one of its previous PIDs is gone and a new one
+// has appeared; prev holds the previous statuses by name.
+func WatchProcesses(watches []ProcessWatch, procs []ProcessInfo, prev map[string]ProcessStatus, now time.Time) []ProcessStatus {
+	statuses := make([]ProcessStatus, 0, len(watches))
+	for _, watch := range watches {
+		status := ProcessStatus{Name: watch.Name}
+		for _, p := range procs {
+			if p.Name == watch.Name {
+				status.Count++
+				status.PIDs = append(status.PIDs, p.PID)
+				status.CPUPercent += p.CPUPercent
+				status.Memory += p.Memory
+				status.PSS += p.PSS
+				status.USS += p.USS
+			}
+		}
+		sort.Slice(status.PIDs, func(i, j int) bool { return status.PIDs[i] < status.PIDs[j] })
+
+		if last, ok := prev[watch.Name]; ok {
+			status.Restarts = last.Restarts
+			status.LastRestart = last.LastRestart
+			if pidsReplaced(last.PIDs, status.PIDs) {
+				status.Restarts++
+				status.LastRestart = now
+			}
+		}
+
+		// RSS counts shared pages once per process, overstating workloads
+		// that share memory, so PSS is compared when it was measured
+		memory := status.Memory
+		if status.PSS > 0 {
+			memory = status.PSS
+		}
+
+		minCount := watch.MinCount
+		if minCount < 1 {
+			minCount = 1
+		}
+		switch {
+		case status.Count < minCount:
+			status.Status = ProcessDown
+		case watch.MaxCPU > 0 && status.CPUPercent > watch.MaxCPU,
+			watch.MaxMemory > 0 && memory > watch.MaxMemory:
+			status.Status = ProcessOverLimit
+		case !status.LastRestart.IsZero() && now.Sub(status.LastRestart) < restartBadgeDuration:
+			status.Status = ProcessRestarted
+		default:
+			status.Status = ProcessOK
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses
+}
+
+// pidsReplaced reports whether a PID in before is gone and a PID in after is
+// new, both lists being sorted
+func pidsReplaced(before, after []int32) bool {
+	gone, added := false, false
+	i, j := 0, 0
+	for i < len(before) || j < len(after) {
+		switch {
+		case j == len(after) || (i < len(before) && before[i] < after[j]):
+			gone = true
+			i++
+		case i == len(before) || after[j] < before[i]:
+			added = true
+			j++
+		default:
+			i++
+			j++
+		}
+	}
+	return gone && added
+}
+
+// ParseSmapsRollup parses a /proc/<pid>/smaps_rollup file and returns the
+// process's proportional set size (shared pages divided among the processes
+// mapping them) and unique set size (private pages), in bytes
+func ParseSmapsRollup(r io.Reader) (pss, uss uint64, err error) {
+	found := false
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 {
+			continue
+		}
+		n, err := strconv.ParseUint(fields[1], 10, 64)
+		if err != nil {
+			continue
+		}
+		switch fields[0] {
+		case "Pss:":
+			pss = n * 1024 // kB
+			found = true
+		case "Private_Clean:", "Private_Dirty:":
+			uss += n * 1024
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return 0, 0, err
+	}
+	if !found {
+		return 0, 0, fmt.Errorf("smaps_rollup has no Pss field")
+	}
+	return pss, uss, nil
+}
+
+// readSmapsRollup returns the PSS and USS of pid
+func readSmapsRollup(pid int32) (pss, uss uint64, err error) {
+	f, err := os.Open(fmt.Sprintf("/proc/%d/smaps_rollup", pid))
+	if err != nil {
+		return 0, 0, err
+	}
+	defer f.Close()
+	return ParseSmapsRollup(f)
+}
+
+// commLen is the length Linux truncates process names (comm) to
+const commLen = 15
+
+// processMonitor scans the process table in the background, as listing
+// every process and reading its name can take long on busy hosts
+type processMonitor struct {
+	watches     []ProcessWatch
+	smapsMemory bool
+	// names maps process names to the watch they belong to: the watch
+	// names, plus the commLen prefix of longer ones
+	names map[string]string
+
+	// processes is only used by the scan in progress
+	processes map[int32]*process.Process
+
+	mu       sync.Mutex
+	statuses []ProcessStatus
+	err      error
+	last     time.Time
+	checking bool
+}
+
+// SetProcessWatches enables monitoring of the given processes. With
+// smapsMemory set, their PSS and USS are read from smaps_rollup on Linux,
+// which costs a page table walk per process on every scan.
+func (c *SystemCollector) SetProcessWatches(watches []ProcessWatch, smapsMemory bool) {
+	if len(watches) == 0 {
+		c.procs = nil
+		return
+	}
+	names := make(map[string]string, len(watches))
+	for _, watch := range watches {
+		if len(watch.Name) > commLen {
+			names[watch.Name[:commLen]] = watch.Name
+		}
+	}
+	for _, watch := range watches {
+		names[watch.Name] = watch.Name
+	}
+	c.procs = &processMonitor{
+		watches:     watches,
+		smapsMemory: smapsMemory,
+		names:       names,
+		processes:   make(map[int32]*process.Process),
+	}
+}
+
+// collectProcessMetrics returns the status of the watched processes,
+// starting a background scan of the process table every
+// processRefreshInterval. It returns nil until the first scan completes.
+func (c *SystemCollector) collectProcessMetrics() ([]ProcessStatus, error) {
+	if c.procs == nil {
+		return nil, nil
+	}
+	m := c.procs
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if !m.checking && time.Since(m.last) >= c.pollInterval(processRefreshInterval) {
+		m.checking = true
+		go m.scan(m.smapsMemory && !c.lowMemory)
+	}
+	return m.statuses, m.err
+}
+
+// scan evaluates the watches against the running processes. Process names
+// are truncated to commLen characters on Linux; gopsutil restores the full
+// name from the command line when it can, and otherwise a watch longer
+// than commLen matches on its prefix.
+func (m *processMonitor) scan(smapsMemory bool) {
+	procs, err := process.Processes()
+	if err != nil {
+		m.mu.Lock()
+		m.err, m.last, m.checking = err, time.Now(), false
+		m.mu.Unlock()
+		return
+	}
+	seen := make(map[int32]bool)
+	var infos []ProcessInfo
+	for _, p := range procs {
+		name, err := p.Name()
+		if err != nil {
+			continue
+		}
+		watch, ok := m.names[name]
+		if !ok {
+			continue
+		}
+		// Keep Process objects between scans so Percent measures the CPU
+		// used since the previous scan
+		if cached, ok := m.processes[p.Pid]; ok {
+			p = cached
+		} else {
+			m.processes[p.Pid] = p
+		}
+		seen[p.Pid] = true
+		info := ProcessInfo{PID: p.Pid, Name: watch}
+		info.CPUPercent, _ = p.Percent(0)
+		if mem, err := p.MemoryInfo(); err == nil {
+			info.Memory = mem.RSS
+		}
+		if smapsMemory {
+			// Unavailable before Linux 4.14 and for other users' processes
+			// without privileges; RSS is used for those
+			info.PSS, info.USS, _ = readSmapsRollup(p.Pid)
+		}
+		infos = append(infos, info)
+	}
+	for pid := range m.processes {
+		if !seen[pid] {
+			delete(m.processes, pid)
+		}
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	prev := make(map[string]ProcessStatus, len(m.statuses))
+	for _, status := range m.statuses {
+		prev[status.Name] = status
+	}
+	m.statuses = WatchProcesses(m.watches, infos, prev, time.Now())
+	m.err, m.last, m.checking = nil, time.Now(), false
+}
@@ -0,0 +1,160 @@
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Raspberry Pi firmware throttling flags as reported by get_throttled.
+const (
+	PiUnderVoltage         uint64 = 1 << 0
+	PiFreqCapped           uint64 = 1 << 1
+	PiThrottled            uint64 = 1 << 2
+	PiSoftTempLimit        uint64 = 1 << 3
+	PiUnderVoltageOccurred uint64 = 1 << 16
+	PiFreqCapOccurred      uint64 = 1 << 17
+	PiThrottledOccurred    uint64 = 1 << 18
+	PiSoftTempOccurred     uint64 = 1 << 19
+)
+
+// piRefreshInterval limits how often the firmware is queried, since
+// vcgencmd is far too expensive to run on every collection tick.
+const piRefreshInterval = 5 * time.Second
+
+// piTimeout bounds a run of vcgencmd, which can hang when the firmware
+// mailbox is busy
+const piTimeout = 2 * time.Second
+
+const (
+	piModelPath     = "/proc/device-tree/model"
+	piThermalPath   = "/sys/class/thermal/thermal_zone0/temp"
+	piThrottledPath = "/sys/devices/platform/soc/soc:firmware/get_throttled"
+)
+
+// PiStat represents Raspberry Pi SoC health.
+type PiStat struct {
+	Model          string  `json:"model"`
+	Temperature    float64 `json:"temperature"` // SoC temperature in °C
+	ThrottledFlags uint64  `json:"throttled_flags"`
+	GPUMemory      uint64  `json:"gpu_memory"` // GPU memory split in bytes
+}
+
+// Warnings returns human readable warnings for the active and past
+// throttling conditions, most severe first.
+func (p PiStat) Warnings() []string {
+	var warnings []string
+	checks := []struct {
+		flag uint64
+		text string
+	}{
+		{PiUnderVoltage, "Under-voltage detected"},
+		{PiThrottled, "CPU throttled"},
+		{PiFreqCapped, "ARM frequency capped"},
+		{PiSoftTempLimit, "Soft temperature limit active"},
+		{PiUnderVoltageOccurred, "Under-voltage has occurred"},
+		{PiThrottledOccurred, "Throttling has occurred"},
+		{PiFreqCapOccurred, "Frequency capping has occurred"},
+		{PiSoftTempOccurred, "Soft temperature limit has occurred"},
+	}
+	for _, check := range checks {
+		if p.ThrottledFlags&check.flag != 0 {
+			warnings = append(warnings, check.text)
+		}
+	}
+	return warnings
+}
+
+// ParseThrottled parses the output of `vcgencmd get_throttled` or the
+// firmware sysfs node, e.g. "throttled=0x50005" or "50005".
+func ParseThrottled(s string) (uint64, error) {
+	s = strings.TrimSpace(s)
+	s = strings.TrimPrefix(s, "throttled=")
+	s = strings.TrimPrefix(s, "0x")
+	flags, err := strconv.ParseUint(s, 16, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid throttled value %q: %w", s, err)
+	}
+	return flags, nil
+}
+
+// ParseGPUMemory parses the output of `vcgencmd get_mem gpu`, e.g. "gpu=76M".
+func ParseGPUMemory(s string) (uint64, error) {
+	s = strings.TrimSpace(s)
+	s = strings.TrimPrefix(s, "gpu=")
+	if !strings.HasSuffix(s, "M") {
+		return 0, fmt.Errorf("invalid gpu memory value %q", s)
+	}
+	mb, err := strconv.ParseUint(strings.TrimSuffix(s, "M"), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid gpu memory value %q: %w", s, err)
+	}
+	return mb * 1024 * 1024, nil
+}
+
+// detectPi returns the board model if running on a Raspberry Pi
+func detectPi() (string, bool) {
+	data, err := os.ReadFile(piModelPath)
+	if err != nil {
+		return "", false
+	}
+	model := strings.TrimRight(string(data), "\x00\n")
+	return model, strings.HasPrefix(model, "Raspberry Pi")
+}
+
+// vcgencmd runs a firmware query and returns its output
+func vcgencmd(args ...string) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), piTimeout)
+	defer cancel()
+	return exec.CommandContext(ctx, "vcgencmd", args...).Output()
+}
+
+// collectPiMetrics collects Raspberry Pi SoC metrics, caching the result
+// between firmware queries. It returns nil when not running on a Pi.
+func (c *SystemCollector) collectPiMetrics() *PiStat {
+	if !c.piChecked {
+		c.piChecked = true
+		model, ok := detectPi()
+		if !ok {
+			return nil
+		}
+		c.piStat = &PiStat{Model: model}
+		if out, err := vcgencmd("get_mem", "gpu"); err == nil {
+			if gpuMem, err := ParseGPUMemory(string(out)); err == nil {
+				c.piStat.GPUMemory = gpuMem
+			}
+		}
+	}
+	if c.piStat == nil {
+		return nil
+	}
+	if time.Since(c.piLastUpdate) < piRefreshInterval {
+		stat := *c.piStat
+		return &stat
+	}
+
+	if data, err := os.ReadFile(piThermalPath); err == nil {
+		if milli, err := strconv.ParseFloat(strings.TrimSpace(string(data)), 64); err == nil {
+			c.piStat.Temperature = milli / 1000
+		}
+	}
+
+	// Prefer the firmware sysfs node and fall back to vcgencmd
+	raw, err := os.ReadFile(piThrottledPath)
+	if err != nil {
+		raw, err = vcgencmd("get_throttled")
+	}
+	if err == nil {
+		if flags, err := ParseThrottled(string(raw)); err == nil {
+			c.piStat.ThrottledFlags = flags
+		}
+	}
+
+	c.piLastUpdate = time.Now()
+	stat := *c.piStat
+	return &stat
+}
@@ -0,0 +1,222 @@
+package metrics
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// tunnelRefreshInterval limits how often wg and the OpenVPN status file are read
+const tunnelRefreshInterval = 5 * time.Second
+
+// tunnelTimeout bounds a run of wg
+const tunnelTimeout = 2 * time.Second
+
+// TunnelPeer represents a VPN peer or client connection.
+type TunnelPeer struct {
+	Source          string    `json:"source"` // wireguard or openvpn
+	Interface       string    `json:"interface"`
+	Peer            string    `json:"peer"` // WireGuard public key or OpenVPN common name
+	Endpoint        string    `json:"endpoint"`
+	LatestHandshake time.Time `json:"latest_handshake"` // WireGuard only; zero if never completed
+	ConnectedSince  time.Time `json:"connected_since"`  // OpenVPN only
+	RxBytes         uint64    `json:"rx_bytes"`
+	TxBytes         uint64    `json:"tx_bytes"`
+}
+
+// ParseWireGuardDump parses the output of `wg show all dump`
+func ParseWireGuardDump(r io.Reader) ([]TunnelPeer, error) {
+	var peers []TunnelPeer
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		fields := strings.Split(scanner.Text(), "\t")
+		// Interface lines have 5 fields, peer lines have 9
+		if len(fields) != 9 {
+			continue
+		}
+
+		handshake, err := strconv.ParseInt(fields[5], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid handshake for peer %s: %w", fields[1], err)
+		}
+		rx, err := strconv.ParseUint(fields[6], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid rx bytes for peer %s: %w", fields[1], err)
+		}
+		tx, err := strconv.ParseUint(fields[7], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid tx bytes for peer %s: %w", fields[1], err)
+		}
+
+		peer := TunnelPeer{
+			Source:    "wireguard",
+			Interface: fields[0],
+			Peer:      fields[1],
+			RxBytes:   rx,
+			TxBytes:   tx,
+		}
+		if fields[3] != "(none)" {
+			peer.Endpoint = fields[3]
+		}
+		if handshake > 0 {
+			peer.LatestHandshake = time.Unix(handshake, 0)
+		}
+		peers = append(peers, peer)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return peers, nil
+}
+
+// ParseOpenVPNStatus parses an OpenVPN server status file in any of the
+// status-version 1, 2 or 3 formats.
+func ParseOpenVPNStatus(r io.Reader) ([]TunnelPeer, error) {
+	var peers []TunnelPeer
+	// Column positions, taken from the header line
+	columns := map[string]int{}
+	inClientList := false
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		sep := ","
+		if strings.Contains(line, "\t") {
+			sep = "\t" // status-version 3
+		}
+		fields := strings.Split(line, sep)
+
+		switch {
+		case fields[0] == "HEADER" && len(fields) > 1 && fields[1] == "CLIENT_LIST":
+			// status-version 2/3: "HEADER,CLIENT_LIST,Common Name,..."
+			for i, name := range fields[2:] {
+				columns[name] = i
+			}
+			continue
+		case fields[0] == "Common Name":
+			// status-version 1 client list header
+			inClientList = true
+			for i, name := range fields {
+				columns[name] = i
+			}
+			continue
+		case fields[0] == "ROUTING TABLE" || fields[0] == "GLOBAL STATS":
+			inClientList = false
+			continue
+		}
+
+		if fields[0] == "CLIENT_LIST" {
+			fields = fields[1:]
+		} else if !inClientList {
+			continue
+		}
+
+		get := func(name string) string {
+			if i, ok := columns[name]; ok && i < len(fields) {
+				return fields[i]
+			}
+			return ""
+		}
+
+		rx, err := strconv.ParseUint(get("Bytes Received"), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid bytes received for client %s: %w", get("Common Name"), err)
+		}
+		tx, err := strconv.ParseUint(get("Bytes Sent"), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid bytes sent for client %s: %w", get("Common Name"), err)
+		}
+
+		peer := TunnelPeer{
+			Source:   "openvpn",
+			Peer:     get("Common Name"),
+			Endpoint: get("Real Address"),
+			RxBytes:  rx,
+			TxBytes:  tx,
+		}
+		if since, err := strconv.ParseInt(get("Connected Since (time_t)"), 10, 64); err == nil {
+			peer.ConnectedSince = time.Unix(since, 0)
+		} else if since, err := time.ParseInLocation(time.ANSIC, get("Connected Since"), time.Local); err == nil {
+			peer.ConnectedSince = since
+		}
+		peers = append(peers, peer)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return peers, nil
+}
+
+// SetTunnels configures the VPN sources: WireGuard via `wg show all dump`
+// and an optional OpenVPN status file.
+func (c *SystemCollector) SetTunnels(wireguard bool, openvpnStatus string) {
+	c.wireguardEnabled = wireguard
+	c.openvpnStatus = openvpnStatus
+}
+
+// collectTunnelMetrics collects VPN peer metrics, caching the result
+// between refreshes. A failing source is retried at the next refresh and
+// does not hide the peers of the other. It returns nil when no VPN source is
+// configured.
+func (c *SystemCollector) collectTunnelMetrics() ([]TunnelPeer, error) {
+	if !c.wireguardEnabled && c.openvpnStatus == "" {
+		return nil, nil
+	}
+	if time.Since(c.tunnelLastUpdate) < tunnelRefreshInterval {
+		return c.tunnelStats, c.tunnelErr
+	}
+
+	var peers []TunnelPeer
+	var errs []error
+	if c.wireguardEnabled {
+		wgPeers, err := collectWireGuardPeers()
+		if err != nil {
+			errs = append(errs, err)
+		}
+		peers = append(peers, wgPeers...)
+	}
+	if c.openvpnStatus != "" {
+		ovpnPeers, err := collectOpenVPNPeers(c.openvpnStatus)
+		if err != nil {
+			errs = append(errs, err)
+		}
+		peers = append(peers, ovpnPeers...)
+	}
+
+	c.tunnelStats = peers
+	c.tunnelErr = errors.Join(errs...)
+	c.tunnelLastUpdate = time.Now()
+	return c.tunnelStats, c.tunnelErr
+}
+
+// collectWireGuardPeers runs `wg show all dump`
+func collectWireGuardPeers() ([]TunnelPeer, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), tunnelTimeout)
+	defer cancel()
+	out, err := exec.CommandContext(ctx, "wg", "show", "all", "dump").Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to query wireguard: %w", err)
+	}
+	return ParseWireGuardDump(bytes.NewReader(out))
+}
+
+// collectOpenVPNPeers reads an OpenVPN status file
+func collectOpenVPNPeers(path string) ([]TunnelPeer, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read openvpn status: %w", err)
+	}
+	defer f.Close()
+	return ParseOpenVPNStatus(f)
+}
@@ -0,0 +1,157 @@
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// wanTimeout bounds each public IP lookup and gateway dial
+const wanTimeout = 5 * time.Second
+
+// DefaultIPProviders are queried in order until one returns a valid address
+var DefaultIPProviders = []string{
+	"https://api.ipify.org",
+	"https://ifconfig.me/ip",
+	"https://icanhazip.com",
+}
+
+// WANStat represents the public IP and WAN gateway status.
+type WANStat struct {
+	PublicIP       string        `json:"public_ip"`
+	PreviousIP     string        `json:"previous_ip"` // set once the public IP has changed
+	ChangedAt      time.Time     `json:"changed_at"`  // when the public IP last changed
+	Provider       string        `json:"provider"`    // provider that answered the last lookup
+	GatewayLatency time.Duration `json:"gateway_latency_ns"`
+	LastCheck      time.Time     `json:"last_check"`
+	Error          string        `json:"error"`
+}
+
+// wanMonitor refreshes the WAN status in the background so slow providers
+// never stall collection.
+type wanMonitor struct {
+	providers []string
+	interval  time.Duration
+	gateway   string
+	client    *http.Client
+
+	mu       sync.Mutex
+	stat     WANStat
+	checking bool
+}
+
+// ResolvePublicIP queries providers in order and returns the first valid
+// IP address along with the provider that returned it.
+func ResolvePublicIP(ctx context.Context, client *http.Client, providers []string) (string, string, error) {
+	var lastErr error
+	for _, provider := range providers {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, provider, nil)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		body, err := io.ReadAll(io.LimitReader(resp.Body, 256))
+		resp.Body.Close()
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		if resp.StatusCode != http.StatusOK {
+			lastErr = fmt.Errorf("%s returned %s", provider, resp.Status)
+			continue
+		}
+		ip := strings.TrimSpace(string(body))
+		if net.ParseIP(ip) == nil {
+			lastErr = fmt.Errorf("%s returned an invalid address %q", provider, ip)
+			continue
+		}
+		return ip, provider, nil
+	}
+	if lastErr == nil {
+		lastErr = fmt.Errorf("no public IP providers configured")
+	}
+	return "", "", lastErr
+}
+
+// SetWAN enables the public IP and WAN widget. Providers are queried every
+// interval; gateway is an optional host:port whose TCP connect time is
+// reported as the WAN gateway latency.
+func (c *SystemCollector) SetWAN(providers []string, interval time.Duration, gateway string) {
+	if len(providers) == 0 {
+		providers = DefaultIPProviders
+	}
+	c.wan = &wanMonitor{
+		providers: providers,
+		interval:  interval,
+		gateway:   gateway,
+		client:    &http.Client{Timeout: wanTimeout},
+	}
+}
+
+// collectWANMetrics returns the latest WAN status, starting a background
+// refresh when one is due. It returns nil when the widget is disabled.
+func (c *SystemCollector) collectWANMetrics() *WANStat {
+	if c.wan == nil {
+		return nil
+	}
+	w := c.wan
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if !w.checking && time.Since(w.stat.LastCheck) >= c.pollInterval(w.interval) {
+		w.checking = true
+		go w.check()
+	}
+	if w.stat.LastCheck.IsZero() {
+		return nil
+	}
+	stat := w.stat
+	return &stat
+}
+
+// check resolves the public IP and measures the gateway latency
+func (w *wanMonitor) check() {
+	ctx, cancel := context.WithTimeout(context.Background(), wanTimeout*time.Duration(len(w.providers)))
+	defer cancel()
+	ip, provider, err := ResolvePublicIP(ctx, w.client, w.providers)
+
+	var latency time.Duration
+	if w.gateway != "" {
+		start := time.Now()
+		if conn, dialErr := net.DialTimeout("tcp", w.gateway, wanTimeout); dialErr == nil {
+			latency = time.Since(start)
+			conn.Close()
+		} else if err == nil {
+			err = dialErr
+		}
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.checking = false
+	w.stat.LastCheck = time.Now()
+	w.stat.GatewayLatency = latency
+	w.stat.Error = ""
+	if err != nil {
+		w.stat.Error = err.Error()
+	}
+	if ip == "" {
+		return
+	}
+	if w.stat.PublicIP != "" && w.stat.PublicIP != ip {
+		w.stat.PreviousIP = w.stat.PublicIP
+		w.stat.ChangedAt = w.stat.LastCheck
+	}
+	w.stat.PublicIP = ip
+	w.stat.Provider = provider
+}
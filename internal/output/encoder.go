@@ -0,0 +1,197 @@
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/j-raghavan/godash/internal/metrics"
+)
+
+// Encoder turns a sample into one record of an output format. A record may
+// span several lines but has no trailing newline.
+type Encoder interface {
+	Encode(metric metrics.Metric) ([]byte, error)
+}
+
+// Formats are the names of the encodings selectable with --format
+var Formats = []string{"json", "line-protocol", "msgpack", "protobuf"}
+
+// NewEncoder returns the encoder for format, "json" when empty, or for the
+// template spec (as for Parse) when spec is set
+func NewEncoder(format, spec string) (Encoder, error) {
+	if spec != "" {
+		tmpl, err := Parse(spec)
+		if err != nil {
+			return nil, err
+		}
+		return NewTemplateEncoder(tmpl), nil
+	}
+	switch format {
+	case "", "json":
+		return JSONEncoder{}, nil
+	case "line-protocol":
+		return NewLineProtocolEncoder(), nil
+	case "msgpack":
+		return MsgpackEncoder{}, nil
+	case "protobuf":
+		return NewProtobufEncoder(), nil
+	}
+	return nil, fmt.Errorf("unknown output format %q (available: %s)", format, strings.Join(Formats, ", "))
+}
+
+// framer is implemented by binary encoders whose records are not
+// separated by newlines in a stream
+type framer interface {
+	frame(record []byte) []byte
+}
+
+// Frame returns record as written to a stream of encoder's records: text
+// records end with a newline, binary ones are framed as their format
+// requires
+func Frame(encoder Encoder, record []byte) []byte {
+	if f, ok := encoder.(framer); ok {
+		return f.frame(record)
+	}
+	return append(record, '\n')
+}
+
+// JSONEncoder encodes a sample as one line of JSON, see docs/schema.md
+type JSONEncoder struct{}
+
+// Encode implements Encoder
+func (JSONEncoder) Encode(metric metrics.Metric) ([]byte, error) {
+	data, err := json.Marshal(metric)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode metrics: %w", err)
+	}
+	return data, nil
+}
+
+// TemplateEncoder renders a sample through a template
+type TemplateEncoder struct {
+	tmpl *template.Template
+}
+
+// NewTemplateEncoder creates a TemplateEncoder for tmpl, as returned by
+// Parse
+func NewTemplateEncoder(tmpl *template.Template) *TemplateEncoder {
+	return &TemplateEncoder{tmpl: tmpl}
+}
+
+// Encode implements Encoder
+func (e *TemplateEncoder) Encode(metric metrics.Metric) ([]byte, error) {
+	text, err := Render(e.tmpl, metric)
+	if err != nil {
+		return nil, err
+	}
+	return []byte(strings.TrimSuffix(text, "\n")), nil
+}
+
+// Header renders the template's header, if it defines one
+func (e *TemplateEncoder) Header() (string, bool, error) {
+	return Header(e.tmpl)
+}
+
+// LineProtocolEncoder encodes a sample as InfluxDB line protocol: one line
+// each for CPU and memory, one per core, and one per metrics.Entity (disk,
+// interface, VM, watched process) tagged with its labels. The host name and
+// host tags are tags of every line.
+type LineProtocolEncoder struct {
+	tags string // ",host=...,env=..." less the sample's own tags
+}
+
+// NewLineProtocolEncoder creates a LineProtocolEncoder tagging lines with
+// this host's name
+func NewLineProtocolEncoder() *LineProtocolEncoder {
+	host, _ := os.Hostname()
+	e := &LineProtocolEncoder{}
+	if host != "" {
+		e.tags = ",host=" + escapeTag(host)
+	}
+	return e
+}
+
+// Encode implements Encoder
+func (e *LineProtocolEncoder) Encode(metric metrics.Metric) ([]byte, error) {
+	tags := e.tags + sortedTags(metric.Tags)
+	ts := " " + strconv.FormatInt(metric.Timestamp.UnixNano(), 10)
+	var b strings.Builder
+	line := func(measurement, extraTags string, fields ...string) {
+		if b.Len() > 0 {
+			b.WriteByte('\n')
+		}
+		b.WriteString(measurement)
+		b.WriteString(tags)
+		b.WriteString(extraTags)
+		b.WriteByte(' ')
+		b.WriteString(strings.Join(fields, ","))
+		b.WriteString(ts)
+	}
+
+	s := metric.CPUStates
+	line("godash_cpu", "", floatField("usage_percent", metric.CPUTotal),
+		floatField("user", s.User), floatField("nice", s.Nice), floatField("system", s.System),
+		floatField("iowait", s.IOWait), floatField("steal", s.Steal), floatField("irq", s.IRQ),
+		floatField("softirq", s.SoftIRQ), floatField("idle", s.Idle))
+	for i, pct := range metric.CPU {
+		line("godash_cpu_core", ",cpu="+strconv.Itoa(i), floatField("usage_percent", pct))
+	}
+	mem := metric.Memory
+	line("godash_memory", "", intField("total", mem.Total), intField("used", mem.Used),
+		intField("free", mem.Free), floatField("used_percent", mem.UsedPercentage))
+	for _, e := range metrics.Entities(metric) {
+		var extra strings.Builder
+		for _, name := range e.LabelNames() {
+			extra.WriteString("," + escapeTag(name) + "=" + escapeTag(e.Labels[name]))
+		}
+		names := e.ValueNames()
+		fields := make([]string, len(names))
+		for i, name := range names {
+			fields[i] = floatField(name, e.Values[name])
+		}
+		line("godash_"+e.Kind, extra.String(), fields...)
+	}
+	return []byte(b.String()), nil
+}
+
+// sortedTags formats tags as line protocol tags, sorted by key as InfluxDB
+// prefers
+func sortedTags(tags map[string]string) string {
+	keys := make([]string, 0, len(tags))
+	for k := range tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var b strings.Builder
+	for _, k := range keys {
+		if tags[k] == "" {
+			continue // empty tag values are invalid
+		}
+		b.WriteString("," + escapeTag(k) + "=" + escapeTag(tags[k]))
+	}
+	return b.String()
+}
+
+// tagEscaper escapes the characters special in line protocol tag keys and
+// values
+var tagEscaper = strings.NewReplacer(",", `\,`, "=", `\=`, " ", `\ `)
+
+// escapeTag escapes a tag key or value
+func escapeTag(s string) string {
+	return tagEscaper.Replace(s)
+}
+
+// floatField formats a float field
+func floatField(name string, v float64) string {
+	return name + "=" + strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+// intField formats an integer field
+func intField(name string, v uint64) string {
+	return name + "=" + strconv.FormatUint(v, 10) + "i"
+}
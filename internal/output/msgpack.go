@@ -0,0 +1,141 @@
+package output
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+
+	"github.com/j-raghavan/godash/internal/metrics"
+)
+
+// MsgpackEncoder encodes a sample as a MessagePack map with the same fields
+// and nesting as the JSON schema, see docs/schema.md. Records need no
+// separator in a stream.
+type MsgpackEncoder struct{}
+
+// Encode implements Encoder
+func (MsgpackEncoder) Encode(metric metrics.Metric) ([]byte, error) {
+	data, err := json.Marshal(metric)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode metrics: %w", err)
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return nil, fmt.Errorf("failed to encode metrics: %w", err)
+	}
+	return appendMsgpack(nil, v)
+}
+
+// frame implements framer: MessagePack values are self-delimiting
+func (MsgpackEncoder) frame(record []byte) []byte {
+	return record
+}
+
+// appendMsgpack appends the MessagePack encoding of v, a value decoded from
+// JSON with numbers kept as json.Number, to b. Map keys are sorted.
+func appendMsgpack(b []byte, v any) ([]byte, error) {
+	switch v := v.(type) {
+	case nil:
+		return append(b, 0xc0), nil
+	case bool:
+		if v {
+			return append(b, 0xc3), nil
+		}
+		return append(b, 0xc2), nil
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return appendMsgpackInt(b, i), nil
+		}
+		if u, err := strconv.ParseUint(v.String(), 10, 64); err == nil {
+			return appendMsgpackUint(b, u), nil
+		}
+		f, err := v.Float64()
+		if err != nil {
+			return nil, err
+		}
+		return binary.BigEndian.AppendUint64(append(b, 0xcb), math.Float64bits(f)), nil
+	case string:
+		b = appendMsgpackHeader(b, len(v), 0xa0, 32, 0xd9, 0xda, 0xdb)
+		return append(b, v...), nil
+	case []any:
+		b = appendMsgpackHeader(b, len(v), 0x90, 16, 0, 0xdc, 0xdd)
+		for _, item := range v {
+			var err error
+			if b, err = appendMsgpack(b, item); err != nil {
+				return nil, err
+			}
+		}
+		return b, nil
+	case map[string]any:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		b = appendMsgpackHeader(b, len(v), 0x80, 16, 0, 0xde, 0xdf)
+		for _, k := range keys {
+			var err error
+			if b, err = appendMsgpack(b, k); err != nil {
+				return nil, err
+			}
+			if b, err = appendMsgpack(b, v[k]); err != nil {
+				return nil, err
+			}
+		}
+		return b, nil
+	}
+	return nil, fmt.Errorf("cannot encode %T as msgpack", v)
+}
+
+// appendMsgpackHeader appends the header of a string, array or map of n
+// items: the fix form below fixLimit, else the 8-bit (when the type has
+// one), 16-bit or 32-bit length form
+func appendMsgpackHeader(b []byte, n int, fix byte, fixLimit int, len8, len16, len32 byte) []byte {
+	switch {
+	case n < fixLimit:
+		return append(b, fix|byte(n))
+	case len8 != 0 && n <= math.MaxUint8:
+		return append(b, len8, byte(n))
+	case n <= math.MaxUint16:
+		return binary.BigEndian.AppendUint16(append(b, len16), uint16(n))
+	}
+	return binary.BigEndian.AppendUint32(append(b, len32), uint32(n))
+}
+
+// appendMsgpackInt appends i in its shortest MessagePack form
+func appendMsgpackInt(b []byte, i int64) []byte {
+	switch {
+	case i >= 0:
+		return appendMsgpackUint(b, uint64(i))
+	case i >= -32:
+		return append(b, byte(i))
+	case i >= math.MinInt8:
+		return append(b, 0xd0, byte(i))
+	case i >= math.MinInt16:
+		return binary.BigEndian.AppendUint16(append(b, 0xd1), uint16(i))
+	case i >= math.MinInt32:
+		return binary.BigEndian.AppendUint32(append(b, 0xd2), uint32(i))
+	}
+	return binary.BigEndian.AppendUint64(append(b, 0xd3), uint64(i))
+}
+
+// appendMsgpackUint appends u in its shortest MessagePack form
+func appendMsgpackUint(b []byte, u uint64) []byte {
+	switch {
+	case u <= 0x7f:
+		return append(b, byte(u))
+	case u <= math.MaxUint8:
+		return append(b, 0xcc, byte(u))
+	case u <= math.MaxUint16:
+		return binary.BigEndian.AppendUint16(append(b, 0xcd), uint16(u))
+	case u <= math.MaxUint32:
+		return binary.BigEndian.AppendUint32(append(b, 0xce), uint32(u))
+	}
+	return binary.BigEndian.AppendUint64(append(b, 0xcf), u)
+}
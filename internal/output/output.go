@@ -0,0 +1,183 @@
+// Package output renders metric samples through Go text/templates and
+// encodes them as JSON or line protocol, for the oneline and agent commands.
+package output
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/j-raghavan/godash/internal/metrics"
+	"github.com/j-raghavan/godash/internal/rules"
+	"github.com/j-raghavan/godash/internal/units"
+)
+
+// HeaderTemplate is the name of an optional template defined alongside the
+// main one, e.g. {{define "header"}}time,cpu{{end}}, that is rendered once
+// before the first sample
+const HeaderTemplate = "header"
+
+// Builtin holds the named templates selectable with --template
+var Builtin = map[string]string{
+	// oneline is the default of "godash oneline"
+	"oneline": `cpu {{pct .CPU}} | mem {{pct .Memory}} | / {{pct (disk "/")}} | ↓{{rate .Rx}} ↑{{rate .Tx}}`,
+	// csv emits one row per sample after a header row
+	"csv": `{{define "header"}}time,cpu_percent,memory_percent,rx_bytes_per_sec,tx_bytes_per_sec{{end}}` +
+		`{{.Metric.Timestamp.Unix}},{{printf "%.1f" .CPU}},{{printf "%.1f" .Memory}},{{printf "%.0f" .Rx}},{{printf "%.0f" .Tx}}`,
+	// nagios follows the plugin output format, with perfdata after the "|"
+	"nagios": `OK - cpu {{pct .CPU}}, memory {{pct .Memory}} | cpu={{printf "%.1f" .CPU}}%;;;0;100 memory={{printf "%.1f" .Memory}}%;;;0;100`,
+	// influx is InfluxDB line protocol, one line per sample, with the host
+	// tags as InfluxDB tags
+	"influx": `godash{{with .Host}},host={{.}}{{end}}{{range $k, $v := .Tags}},{{$k}}={{$v}}{{end}} cpu={{.CPU}},memory={{.Memory}},rx={{.Rx}},tx={{.Tx}} {{.Metric.Timestamp.UnixNano}}`,
+	// values lists every named value as name=value pairs
+	"values": `{{range $name, $v := .Values}}{{$name}}={{$v}} {{end}}`,
+}
+
+// format is used by the bytes and rate helpers, set with SetUnits
+var format units.Format
+
+// location is the time zone of Data.Time and the rfc3339 helper, set with
+// SetLocation
+var location = time.Local
+
+// BuiltinNames returns the names of the built-in templates, sorted
+func BuiltinNames() []string {
+	names := make([]string, 0, len(Builtin))
+	for name := range Builtin {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Data is what templates are executed on
+type Data struct {
+	Time   string // the sample's RFC 3339 timestamp in the display time zone
+	Host   string
+	Tags   map[string]string // host tags from the configuration
+	CPU    float64           // total busy percentage
+	Memory float64           // used percentage
+	Rx     float64           // received bytes per second, all interfaces
+	Tx     float64           // transmitted bytes per second, all interfaces
+	Metric metrics.Metric
+	Values map[string]float64 // named values, as used by rules and query
+}
+
+// NewData prepares metric for rendering
+func NewData(metric metrics.Metric) *Data {
+	host, _ := os.Hostname()
+	data := &Data{
+		Time:   metric.Timestamp.In(location).Format(time.RFC3339),
+		Host:   host,
+		Tags:   metric.Tags,
+		CPU:    metric.CPUTotal,
+		Memory: metric.Memory.UsedPercentage,
+		Metric: metric,
+		Values: rules.Values(metric),
+	}
+	for _, net := range metric.Network {
+		data.Rx += net.RxBytesPerSec
+		data.Tx += net.TxBytesPerSec
+	}
+	return data
+}
+
+// funcs are the helpers available to templates, bound to data
+func funcs(data *Data) template.FuncMap {
+	return template.FuncMap{
+		"pct": func(v float64) string { return fmt.Sprintf("%.0f%%", v) },
+		// rfc3339 formats a time, e.g. a job's last run, in the display
+		// time zone
+		"rfc3339": func(t time.Time) string { return t.In(location).Format(time.RFC3339) },
+		"rate":    func(v float64) string { return format.CompactRate(v) },
+		"bytes": func(v any) string {
+			switch n := v.(type) {
+			case uint64:
+				return format.Compact(float64(n))
+			case float64:
+				return format.Compact(n)
+			}
+			return fmt.Sprint(v)
+		},
+		// disk returns the used percentage of the disk mounted at path
+		"disk": func(path string) float64 {
+			for _, d := range data.Metric.Disk {
+				if d.Path == path {
+					return d.UsedPercentage
+				}
+			}
+			return 0
+		},
+		// value returns a named value such as "disk./home.used_percent"
+		"value": func(name string) float64 { return data.Values[name] },
+	}
+}
+
+// Parse compiles a template given by spec: the name of a built-in template,
+// "@path" to read one from a file, or the template text itself
+func Parse(spec string) (*template.Template, error) {
+	text := spec
+	if builtin, ok := Builtin[spec]; ok {
+		text = builtin
+	} else if path, ok := strings.CutPrefix(spec, "@"); ok {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read template: %w", err)
+		}
+		text = strings.TrimSuffix(string(data), "\n")
+	}
+	tmpl, err := template.New("output").Funcs(funcs(&Data{})).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid template: %w", err)
+	}
+	return tmpl, nil
+}
+
+// Header renders the template's header, if it defines one
+func Header(tmpl *template.Template) (string, bool, error) {
+	header := tmpl.Lookup(HeaderTemplate)
+	if header == nil {
+		return "", false, nil
+	}
+	var buf bytes.Buffer
+	if err := header.Execute(&buf, nil); err != nil {
+		return "", false, fmt.Errorf("failed to render header: %w", err)
+	}
+	return buf.String(), true, nil
+}
+
+// Render executes tmpl on metric. Newlines are kept, so a template may span
+// several lines per sample.
+func Render(tmpl *template.Template, metric metrics.Metric) (string, error) {
+	data := NewData(metric)
+	// Rebind the helpers to this sample's data
+	tmpl, err := tmpl.Clone()
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Funcs(funcs(data)).Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("failed to render template: %w", err)
+	}
+	return buf.String(), nil
+}
+
+// CompactBytes formats a byte count tersely in IEC units, e.g. "1.2MB" or
+// "300KB"
+func CompactBytes(v float64) string {
+	return units.Format{}.Compact(v)
+}
+
+// SetLocation sets the time zone of Data.Time and the rfc3339 helper
+func SetLocation(loc *time.Location) {
+	location = loc
+}
+
+// SetUnits sets the units of the bytes and rate template helpers
+func SetUnits(f units.Format) {
+	format = f
+}
@@ -0,0 +1,123 @@
+package output
+
+import (
+	"encoding/binary"
+	"math"
+	"os"
+	"sort"
+
+	"github.com/j-raghavan/godash/internal/metrics"
+)
+
+// Protobuf wire types
+const (
+	wireVarint  = 0
+	wireFixed64 = 1
+	wireBytes   = 2
+)
+
+// ProtobufEncoder encodes a sample as a godash.v1.Sample protobuf message,
+// defined in docs/godash.proto: CPU, memory and every metrics.Entity, as in
+// line protocol. In a stream each message is prefixed with its varint
+// length, as protobuf's writeDelimitedTo does.
+type ProtobufEncoder struct {
+	host string
+}
+
+// NewProtobufEncoder creates a ProtobufEncoder naming this host in samples
+func NewProtobufEncoder() *ProtobufEncoder {
+	host, _ := os.Hostname()
+	return &ProtobufEncoder{host: host}
+}
+
+// Encode implements Encoder
+func (e *ProtobufEncoder) Encode(metric metrics.Metric) ([]byte, error) {
+	var b []byte
+	if !metric.Timestamp.IsZero() {
+		b = appendProtoVarint(b, 1, uint64(metric.Timestamp.UnixNano()))
+	}
+	b = appendProtoString(b, 2, e.host)
+	for _, k := range sortedKeys(metric.Tags) {
+		b = appendProtoBytes(b, 3, appendProtoString(appendProtoString(nil, 1, k), 2, metric.Tags[k]))
+	}
+	b = appendProtoDouble(b, 4, metric.CPUTotal)
+	if len(metric.CPU) > 0 {
+		cores := make([]byte, 0, 8*len(metric.CPU))
+		for _, pct := range metric.CPU {
+			cores = binary.LittleEndian.AppendUint64(cores, math.Float64bits(pct))
+		}
+		b = appendProtoBytes(b, 5, cores)
+	}
+	mem := metric.Memory
+	var memory []byte
+	memory = appendProtoVarint(memory, 1, mem.Total)
+	memory = appendProtoVarint(memory, 2, mem.Used)
+	memory = appendProtoVarint(memory, 3, mem.Free)
+	memory = appendProtoDouble(memory, 4, mem.UsedPercentage)
+	b = appendProtoBytes(b, 6, memory)
+	for _, entity := range metrics.Entities(metric) {
+		var msg []byte
+		msg = appendProtoString(msg, 1, entity.Kind)
+		msg = appendProtoString(msg, 2, entity.Name)
+		for _, name := range entity.LabelNames() {
+			msg = appendProtoBytes(msg, 3, appendProtoString(appendProtoString(nil, 1, name), 2, entity.Labels[name]))
+		}
+		for _, name := range entity.ValueNames() {
+			msg = appendProtoBytes(msg, 4, appendProtoDouble(appendProtoString(nil, 1, name), 2, entity.Values[name]))
+		}
+		b = appendProtoBytes(b, 7, msg)
+	}
+	return b, nil
+}
+
+// frame implements framer
+func (e *ProtobufEncoder) frame(record []byte) []byte {
+	return append(binary.AppendUvarint(nil, uint64(len(record))), record...)
+}
+
+// sortedKeys returns the keys of m in order
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// appendProtoTag appends a field's key
+func appendProtoTag(b []byte, field int, wireType byte) []byte {
+	return binary.AppendUvarint(b, uint64(field)<<3|uint64(wireType))
+}
+
+// appendProtoVarint appends an integer field, leaving out zero as proto3
+// does
+func appendProtoVarint(b []byte, field int, v uint64) []byte {
+	if v == 0 {
+		return b
+	}
+	return binary.AppendUvarint(appendProtoTag(b, field, wireVarint), v)
+}
+
+// appendProtoDouble appends a double field, leaving out zero
+func appendProtoDouble(b []byte, field int, v float64) []byte {
+	if v == 0 {
+		return b
+	}
+	return binary.LittleEndian.AppendUint64(appendProtoTag(b, field, wireFixed64), math.Float64bits(v))
+}
+
+// appendProtoString appends a string field, leaving out the empty string
+func appendProtoString(b []byte, field int, s string) []byte {
+	if s == "" {
+		return b
+	}
+	return appendProtoBytes(b, field, []byte(s))
+}
+
+// appendProtoBytes appends a length-delimited field: bytes, an embedded
+// message or a packed repeated field
+func appendProtoBytes(b []byte, field int, data []byte) []byte {
+	b = binary.AppendUvarint(appendProtoTag(b, field, wireBytes), uint64(len(data)))
+	return append(b, data...)
+}
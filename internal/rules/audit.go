@@ -0,0 +1,182 @@
+package rules
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/j-raghavan/godash/internal/config"
+)
+
+// auditOutputLimit is how much of a command's output the audit trail keeps,
+// from the end
+const auditOutputLimit = 1024
+
+// CommandRun is the audit record of one firing of a command action: what
+// ran for which alert, how it ended and the tail of its output. Firings
+// skipped because max_concurrent runs were in progress are recorded too.
+type CommandRun struct {
+	Rule     string        `json:"rule"`
+	Subject  string        `json:"subject,omitempty"`
+	Value    float64       `json:"value"`
+	Command  string        `json:"command"`
+	Args     []string      `json:"args,omitempty"`
+	Started  time.Time     `json:"started"`
+	Duration time.Duration `json:"duration_ns"`
+	ExitCode int           `json:"exit_code"` // -1 when it did not exit by itself
+	TimedOut bool          `json:"timed_out,omitempty"`
+	Skipped  bool          `json:"skipped,omitempty"`
+	Error    string        `json:"error,omitempty"`
+	Output   string        `json:"output,omitempty"`
+}
+
+// Audit appends command runs to a file as JSON lines
+type Audit struct {
+	path string
+	mu   sync.Mutex
+}
+
+// NewAudit creates an Audit backed by the file at path
+func NewAudit(path string) *Audit {
+	return &Audit{path: path}
+}
+
+// DefaultAudit returns the Audit in the godash data directory
+func DefaultAudit() (*Audit, error) {
+	dir, err := config.DataDir()
+	if err != nil {
+		return nil, err
+	}
+	return NewAudit(filepath.Join(dir, "commands.jsonl")), nil
+}
+
+// Record appends run to the audit trail
+func (a *Audit) Record(run CommandRun) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	data, err := json.Marshal(run)
+	if err != nil {
+		return fmt.Errorf("failed to marshal command run: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(a.path), 0o755); err != nil {
+		return fmt.Errorf("failed to create data directory: %w", err)
+	}
+	f, err := os.OpenFile(a.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to open command audit: %w", err)
+	}
+	defer f.Close()
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to write command audit: %w", err)
+	}
+	return nil
+}
+
+// Prune rewrites the audit trail without the runs that started before the
+// cutoff. Like History.Compact, it only runs when a monitor or agent starts.
+func (a *Audit) Prune(before time.Time) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	f, err := os.Open(a.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to read command audit: %w", err)
+	}
+	var kept []any
+	records := 0
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		records++
+		var run CommandRun
+		if err := json.Unmarshal(scanner.Bytes(), &run); err != nil || run.Started.Before(before) {
+			continue // expired, or a line torn by a crash
+		}
+		kept = append(kept, run)
+	}
+	err = scanner.Err()
+	f.Close()
+	if err != nil {
+		return fmt.Errorf("failed to read command audit: %w", err)
+	}
+	if len(kept) == records {
+		return nil
+	}
+	if err := writeJSONLines(a.path, kept, 0o600); err != nil {
+		return fmt.Errorf("failed to prune command audit: %w", err)
+	}
+	return nil
+}
+
+// commandRunner returns the run function of a command action: it skips
+// firings while the action's max_concurrent runs are in progress and
+// records every firing in the audit trail
+func (e *Engine) commandRunner(action Action) func(context.Context, Event) error {
+	return func(ctx context.Context, event Event) error {
+		run := CommandRun{Rule: event.Rule, Subject: event.Subject, Value: event.Value,
+			Command: action.Command, Args: action.Args, Started: time.Now(), ExitCode: -1}
+		if action.slots != nil {
+			select {
+			case action.slots <- struct{}{}:
+				defer func() { <-action.slots }()
+			default:
+				run.Skipped = true
+				e.recordRun(run)
+				return fmt.Errorf("skipped, %d run(s) still in progress", cap(action.slots))
+			}
+		}
+
+		out, err := action.runCommand(ctx, event)
+		run.Duration = time.Since(run.Started)
+		run.Output = tail(strings.TrimSpace(string(out)), auditOutputLimit)
+		var exitErr *exec.ExitError
+		switch {
+		case errors.Is(ctx.Err(), context.DeadlineExceeded):
+			run.TimedOut = true
+		case err == nil:
+			run.ExitCode = 0
+		case errors.As(err, &exitErr):
+			run.ExitCode = exitErr.ExitCode()
+		}
+		if err != nil {
+			if run.TimedOut {
+				err = fmt.Errorf("timed out after %s", action.timeout())
+			}
+			run.Error = err.Error()
+		}
+		e.recordRun(run)
+		if err != nil {
+			return fmt.Errorf("command failed: %w: %s", err, run.Output)
+		}
+		return nil
+	}
+}
+
+// recordRun saves run to the audit trail, if any
+func (e *Engine) recordRun(run CommandRun) {
+	if e.audit == nil {
+		return
+	}
+	if err := e.audit.Record(run); err != nil && e.log != nil {
+		fmt.Fprintf(e.log, "rule %s: %v\n", run.Rule, err)
+	}
+}
+
+// tail returns the last n bytes of s
+func tail(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[len(s)-n:]
+}
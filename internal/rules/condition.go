@@ -0,0 +1,163 @@
+package rules
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/j-raghavan/godash/internal/units"
+)
+
+// comparison operators, longest first so ">=" is not read as ">"
+var operators = []string{">=", "<=", "==", "!=", ">", "<"}
+
+// defaultRateWindow is the period a rate() is measured over by default
+const defaultRateWindow = 5 * time.Minute
+
+// Condition compares a named value against a threshold, e.g. "cpu > 90".
+// The name may contain '*' wildcards, e.g. "disk.*.used_percent > 90".
+// A rate condition compares the value's change per second over Window
+// instead, e.g. "rate(disk./.free, 1h) < -1G/h".
+type Condition struct {
+	Metric    string
+	Op        string
+	Threshold float64 // per second for rate conditions
+	Rate      bool
+	Window    time.Duration // rate conditions only
+}
+
+// ParseCondition parses an expression of the form "<metric> <op> <number>"
+// or "rate(<metric>[, <window>]) <op> <number>[/<unit>]". Numbers may carry
+// a K, M, G or T suffix (powers of 1024) and rates a per-time unit of s, m,
+// h or d, e.g. "-1G/h".
+func ParseCondition(expr string) (Condition, error) {
+	for _, op := range operators {
+		name, threshold, ok := strings.Cut(expr, op)
+		if !ok {
+			continue
+		}
+		cond := Condition{Op: op}
+		name = strings.TrimSpace(name)
+		if inner, ok := strings.CutPrefix(name, "rate("); ok {
+			inner, ok = strings.CutSuffix(inner, ")")
+			if !ok {
+				return Condition{}, fmt.Errorf("unterminated rate() in %q", expr)
+			}
+			cond.Rate = true
+			cond.Window = defaultRateWindow
+			if metric, window, ok := strings.Cut(inner, ","); ok {
+				d, err := time.ParseDuration(strings.TrimSpace(window))
+				if err != nil || d <= 0 {
+					return Condition{}, fmt.Errorf("invalid rate window in %q", expr)
+				}
+				cond.Window = d
+				inner = metric
+			}
+			name = strings.TrimSpace(inner)
+		}
+		if name == "" || strings.ContainsAny(name, " \t<>=!(),") {
+			return Condition{}, fmt.Errorf("invalid metric name in %q", expr)
+		}
+		cond.Metric = name
+		value, err := parseThreshold(strings.TrimSpace(threshold), cond.Rate)
+		if err != nil {
+			return Condition{}, fmt.Errorf("invalid threshold in %q", expr)
+		}
+		cond.Threshold = value
+		return cond, nil
+	}
+	return Condition{}, fmt.Errorf("missing comparison operator in %q", expr)
+}
+
+// rateUnits converts a per-time unit to seconds
+var rateUnits = map[string]float64{"s": 1, "m": 60, "h": 3600, "d": 86400}
+
+// parseThreshold parses a number with an optional size suffix and, for
+// rates, an optional per-time unit, returning rates per second
+func parseThreshold(s string, rate bool) (float64, error) {
+	perSecond := 1.0
+	if number, unit, ok := strings.Cut(s, "/"); ok {
+		seconds, known := rateUnits[unit]
+		if !rate || !known {
+			return 0, fmt.Errorf("invalid unit %q", unit)
+		}
+		s, perSecond = number, seconds
+	}
+	value, err := units.ParseSize(s)
+	if err != nil {
+		return 0, err
+	}
+	return value / perSecond, nil
+}
+
+// Matches returns the values whose names match the condition's metric and
+// that satisfy the comparison, keyed by name.
+func (c Condition) Matches(values map[string]float64) map[string]float64 {
+	matched := make(map[string]float64)
+	for name, value := range values {
+		if matchName(c.Metric, name) && c.compare(value) {
+			matched[name] = value
+		}
+	}
+	return matched
+}
+
+// compare applies the operator to value and the threshold
+func (c Condition) compare(value float64) bool {
+	switch c.Op {
+	case ">":
+		return value > c.Threshold
+	case ">=":
+		return value >= c.Threshold
+	case "<":
+		return value < c.Threshold
+	case "<=":
+		return value <= c.Threshold
+	case "==":
+		return value == c.Threshold
+	case "!=":
+		return value != c.Threshold
+	}
+	return false
+}
+
+// String returns the condition in expression form
+func (c Condition) String() string {
+	if c.Rate {
+		return fmt.Sprintf("rate(%s, %s) %s %g/s", c.Metric, c.Window, c.Op, c.Threshold)
+	}
+	return fmt.Sprintf("%s %s %g", c.Metric, c.Op, c.Threshold)
+}
+
+// matchName reports whether name matches pattern, where '*' matches any
+// run of characters (including '.' and '/').
+func matchName(pattern, name string) bool {
+	parts := strings.Split(pattern, "*")
+	if len(parts) == 1 {
+		return pattern == name
+	}
+	if !strings.HasPrefix(name, parts[0]) {
+		return false
+	}
+	name = name[len(parts[0]):]
+	for _, part := range parts[1 : len(parts)-1] {
+		i := strings.Index(name, part)
+		if i < 0 {
+			return false
+		}
+		name = name[i+len(part):]
+	}
+	return strings.HasSuffix(name, parts[len(parts)-1])
+}
+
+// worse reports whether value is further past the threshold than peak,
+// e.g. higher for a ">" condition. Equality conditions never get worse.
+func (c Condition) worse(value, peak float64) bool {
+	switch c.Op {
+	case ">", ">=":
+		return value > peak
+	case "<", "<=":
+		return value < peak
+	}
+	return false
+}
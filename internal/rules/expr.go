@@ -0,0 +1,181 @@
+package rules
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Expr is a rule expression: conditions combined with "&&" and "||", where
+// "&&" binds tighter, e.g. "memory.used_percent > 90 && cpu > 80 || raid.degraded > 0".
+type Expr struct {
+	Any [][]Condition // holds when every condition of any group holds
+	raw string
+}
+
+// ParseExpr parses a rule expression
+func ParseExpr(s string) (Expr, error) {
+	expr := Expr{raw: strings.TrimSpace(s)}
+	for _, group := range strings.Split(s, "||") {
+		var all []Condition
+		for _, term := range strings.Split(group, "&&") {
+			cond, err := ParseCondition(strings.TrimSpace(term))
+			if err != nil {
+				return Expr{}, err
+			}
+			all = append(all, cond)
+		}
+		expr.Any = append(expr.Any, all)
+	}
+	return expr, nil
+}
+
+// Single returns the expression's condition if it has just one
+func (x Expr) Single() (Condition, bool) {
+	if len(x.Any) == 1 && len(x.Any[0]) == 1 {
+		return x.Any[0][0], true
+	}
+	return Condition{}, false
+}
+
+// Conditions returns every condition in the expression
+func (x Expr) Conditions() []Condition {
+	var conds []Condition
+	for _, group := range x.Any {
+		conds = append(conds, group...)
+	}
+	return conds
+}
+
+// Matches evaluates the expression, with lookup returning the values a
+// condition compares (plain values or rates). A single condition matches
+// per name, like Condition.Matches. A compound expression that holds
+// matches once, keyed by the expression itself, with the value of its first
+// matching condition.
+func (x Expr) Matches(lookup func(Condition) map[string]float64) map[string]float64 {
+	if cond, ok := x.Single(); ok {
+		return cond.Matches(lookup(cond))
+	}
+	for _, group := range x.Any {
+		value, holds := 0.0, true
+		for i, cond := range group {
+			matched := cond.Matches(lookup(cond))
+			if len(matched) == 0 {
+				holds = false
+				break
+			}
+			if i == 0 {
+				value = matched[Names(matched)[0]]
+			}
+		}
+		if holds {
+			return map[string]float64{x.String(): value}
+		}
+	}
+	return map[string]float64{}
+}
+
+// String returns the expression as written
+func (x Expr) String() string {
+	return x.raw
+}
+
+// worse reports whether value is further past the threshold than peak,
+// judged by the expression's first condition
+func (x Expr) worse(value, peak float64) bool {
+	return x.Any[0][0].worse(value, peak)
+}
+
+// ratePoint is one sample of a value kept for rate calculations
+type ratePoint struct {
+	t time.Time
+	v float64
+}
+
+// rateSamples is the number of points kept per value and rate window,
+// lowMemoryRateSamples in low-memory mode
+const (
+	rateSamples          = 64
+	lowMemoryRateSamples = 16
+)
+
+// rateTracker keeps a downsampled history of the values referenced by rate
+// conditions and derives their change per second
+type rateTracker struct {
+	series  map[string][]ratePoint // window|name -> points, oldest first
+	samples int                    // points kept per series, rateSamples when zero
+}
+
+// observe records the values matching cond at now, keeping at most
+// r.samples points spread over the condition's window
+func (r *rateTracker) observe(cond Condition, values map[string]float64, now time.Time) {
+	if r.series == nil {
+		r.series = make(map[string][]ratePoint)
+	}
+	samples := r.samples
+	if samples == 0 {
+		samples = rateSamples
+	}
+	spacing := cond.Window / time.Duration(samples)
+	for name, v := range values {
+		if !matchName(cond.Metric, name) {
+			continue
+		}
+		key := cond.Window.String() + "|" + name
+		points := r.series[key]
+		if n := len(points); n > 0 && now.Sub(points[n-1].t) < spacing {
+			continue
+		}
+		points = append(points, ratePoint{now, v})
+		for len(points) > 0 && now.Sub(points[0].t) > cond.Window {
+			points = points[1:]
+		}
+		r.series[key] = points
+	}
+}
+
+// rates returns the change per second of each value matching cond across
+// its window. A value needs history covering at least half the window;
+// values that stopped being reported for a whole window are forgotten.
+func (r *rateTracker) rates(cond Condition, now time.Time) map[string]float64 {
+	rates := make(map[string]float64)
+	prefix := cond.Window.String() + "|"
+	for key, points := range r.series {
+		name, ok := strings.CutPrefix(key, prefix)
+		if !ok || !matchName(cond.Metric, name) {
+			continue
+		}
+		last := points[len(points)-1]
+		if now.Sub(last.t) > cond.Window {
+			delete(r.series, key)
+			continue
+		}
+		if len(points) < 2 {
+			continue
+		}
+		first := points[0]
+		span := last.t.Sub(first.t)
+		if span < cond.Window/2 {
+			continue
+		}
+		rates[name] = (last.v - first.v) / span.Seconds()
+	}
+	return rates
+}
+
+// describe explains why a compound expression holds, listing the values of
+// its matching conditions
+func describe(x Expr, lookup func(Condition) map[string]float64, names map[string]string) string {
+	var parts []string
+	for _, cond := range x.Conditions() {
+		matched := cond.Matches(lookup(cond))
+		for _, name := range Names(matched) {
+			if cond.Rate {
+				parts = append(parts, fmt.Sprintf("rate(%s) = %g/s", labelled(name, names), matched[name]))
+			} else {
+				parts = append(parts, fmt.Sprintf("%s = %g", labelled(name, names), matched[name]))
+			}
+		}
+	}
+	return fmt.Sprintf("%s holds (%s)", x, strings.Join(parts, ", "))
+}
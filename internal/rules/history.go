@@ -0,0 +1,293 @@
+package rules
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/shirou/gopsutil/v3/process"
+
+	"github.com/j-raghavan/godash/internal/config"
+)
+
+// recentAlerts is how many alerts History keeps in memory for display
+const recentAlerts = 50
+
+// Alert is one firing of a rule. Threshold alerts stay active until their
+// condition stops holding, or the godash process evaluating it stops; event
+// alerts resolve the moment they fire.
+type Alert struct {
+	Rule       string            `json:"rule"`
+	Kind       string            `json:"kind"`
+	Subject    string            `json:"subject,omitempty"`
+	Message    string            `json:"message"`
+	Host       string            `json:"host"`
+	Tags       map[string]string `json:"tags,omitempty"`
+	FiredAt    time.Time         `json:"fired_at"`
+	ResolvedAt time.Time         `json:"resolved_at,omitempty"` // zero while active
+	// Peak is the most extreme value seen while active: the highest for
+	// ">" and ">=" conditions, the lowest for "<" and "<=", otherwise the
+	// value at firing
+	Peak float64 `json:"peak"`
+	// PID is the godash process that recorded the alert
+	PID int `json:"pid,omitempty"`
+	// Stopped is set when the alert was resolved because its godash process
+	// stopped, not because its condition cleared
+	Stopped bool `json:"stopped,omitempty"`
+}
+
+// Active reports whether the alert has not resolved yet
+func (a Alert) Active() bool {
+	return a.ResolvedAt.IsZero()
+}
+
+// key identifies an alert across its firing and resolution records
+func (a Alert) key() string {
+	return fmt.Sprintf("%s|%s|%d", a.Rule, a.Subject, a.FiredAt.UnixNano())
+}
+
+// History persists alert firings and resolutions as JSON lines, one record
+// per state change, and keeps the most recent alerts in memory.
+type History struct {
+	path string
+	pid  int
+
+	mu     sync.Mutex
+	recent []Alert
+	active map[string]Alert // recorded by this process and still active
+}
+
+// NewHistory creates a History backed by the file at path
+func NewHistory(path string) *History {
+	return &History{path: path, pid: os.Getpid(), active: make(map[string]Alert)}
+}
+
+// DefaultHistory returns the History in the godash data directory
+func DefaultHistory() (*History, error) {
+	dir, err := config.DataDir()
+	if err != nil {
+		return nil, err
+	}
+	return NewHistory(filepath.Join(dir, "alerts.jsonl")), nil
+}
+
+// Record appends the current state of alert to the history
+func (h *History) Record(alert Alert) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.record(alert)
+}
+
+// record is Record with h.mu held
+func (h *History) record(alert Alert) error {
+	if alert.PID == 0 {
+		alert.PID = h.pid
+	}
+	if alert.Active() {
+		h.active[alert.key()] = alert
+	} else {
+		delete(h.active, alert.key())
+	}
+
+	replaced := false
+	for i := range h.recent {
+		if h.recent[i].key() == alert.key() {
+			h.recent[i] = alert
+			replaced = true
+		}
+	}
+	if !replaced {
+		h.recent = append(h.recent, alert)
+		if len(h.recent) > recentAlerts {
+			h.recent = h.recent[len(h.recent)-recentAlerts:]
+		}
+	}
+	return h.write(alert)
+}
+
+// write appends alert to the file
+func (h *History) write(alert Alert) error {
+	data, err := json.Marshal(alert)
+	if err != nil {
+		return fmt.Errorf("failed to marshal alert: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(h.path), 0o755); err != nil {
+		return fmt.Errorf("failed to create data directory: %w", err)
+	}
+	f, err := os.OpenFile(h.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to open alert history: %w", err)
+	}
+	defer f.Close()
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to write alert history: %w", err)
+	}
+	return nil
+}
+
+// Close resolves the alerts this process recorded that are still active,
+// as godash is exiting and no longer evaluates them
+func (h *History) Close() error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	now := time.Now()
+	for _, alert := range h.active {
+		alert.ResolvedAt, alert.Stopped = now, true
+		if err := h.record(alert); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Reconcile resolves the active alerts in the file whose godash process is
+// no longer running, e.g. because it was killed before it could close them.
+// Their resolution time is now, as when the process stopped is not known.
+func (h *History) Reconcile() error {
+	alerts, err := h.Load(time.Time{}, time.Time{})
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	for _, alert := range alerts {
+		if !alert.Active() || alert.PID == h.pid {
+			continue
+		}
+		// Records from before PIDs were recorded are resolved regardless
+		if alert.PID != 0 {
+			if running, err := process.PidExists(int32(alert.PID)); err != nil || running {
+				continue
+			}
+		}
+		alert.ResolvedAt, alert.Stopped = now, true
+		if err := h.write(alert); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Recent returns up to n alerts recorded by this process, newest first
+func (h *History) Recent(n int) []Alert {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if n > len(h.recent) {
+		n = len(h.recent)
+	}
+	alerts := make([]Alert, 0, n)
+	for i := len(h.recent) - 1; len(alerts) < n; i-- {
+		alerts = append(alerts, h.recent[i])
+	}
+	return alerts
+}
+
+// Load returns the alerts that were active at any point between from and
+// to, oldest first. A zero from or to leaves that end of the range open.
+func (h *History) Load(from, to time.Time) ([]Alert, error) {
+	all, _, err := h.read()
+	if err != nil {
+		return nil, err
+	}
+	var alerts []Alert
+	for _, alert := range all {
+		if !to.IsZero() && alert.FiredAt.After(to) {
+			continue
+		}
+		if !from.IsZero() && !alert.Active() && alert.ResolvedAt.Before(from) {
+			continue
+		}
+		alerts = append(alerts, alert)
+	}
+	return alerts, nil
+}
+
+// Compact rewrites the file with only the latest record of each alert,
+// dropping the alerts that resolved before the cutoff. A record appended by
+// another godash process while the file is rewritten is lost, so it only
+// runs when a monitor or agent starts.
+func (h *History) Compact(before time.Time) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	alerts, records, err := h.read()
+	if err != nil {
+		return err
+	}
+	kept := make([]any, 0, len(alerts))
+	for _, alert := range alerts {
+		if !alert.Active() && alert.ResolvedAt.Before(before) {
+			continue
+		}
+		kept = append(kept, alert)
+	}
+	if len(kept) == records {
+		return nil
+	}
+	if err := writeJSONLines(h.path, kept, 0o644); err != nil {
+		return fmt.Errorf("failed to compact alert history: %w", err)
+	}
+	return nil
+}
+
+// read returns the latest record of each alert in the file, oldest first,
+// and how many records the file holds
+func (h *History) read() ([]Alert, int, error) {
+	f, err := os.Open(h.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, 0, nil
+		}
+		return nil, 0, fmt.Errorf("failed to read alert history: %w", err)
+	}
+	defer f.Close()
+
+	// Later records of the same alert supersede earlier ones
+	byKey := make(map[string]Alert)
+	records := 0
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		records++
+		var alert Alert
+		if err := json.Unmarshal(scanner.Bytes(), &alert); err != nil {
+			continue // skip a line torn by a crash
+		}
+		byKey[alert.key()] = alert
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, 0, fmt.Errorf("failed to read alert history: %w", err)
+	}
+
+	alerts := make([]Alert, 0, len(byKey))
+	for _, alert := range byKey {
+		alerts = append(alerts, alert)
+	}
+	sort.Slice(alerts, func(i, j int) bool {
+		if !alerts[i].FiredAt.Equal(alerts[j].FiredAt) {
+			return alerts[i].FiredAt.Before(alerts[j].FiredAt)
+		}
+		return alerts[i].key() < alerts[j].key()
+	})
+	return alerts, records, nil
+}
+
+// writeJSONLines replaces the file at path with records as JSON lines,
+// through a temporary file so a crash never leaves half a file
+func writeJSONLines(path string, records []any, perm os.FileMode) error {
+	var data []byte
+	for _, record := range records {
+		line, err := json.Marshal(record)
+		if err != nil {
+			return err
+		}
+		data = append(append(data, line...), '\n')
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, perm); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
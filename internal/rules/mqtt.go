@@ -0,0 +1,106 @@
+package rules
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strconv"
+)
+
+// MQTT 3.1.1 control packet types
+const (
+	mqttConnect    = 0x10
+	mqttConnAck    = 0x20
+	mqttPublish    = 0x30
+	mqttDisconnect = 0xE0
+)
+
+// mqttPublishOnce connects to broker, publishes payload to topic with QoS 0
+// and disconnects. It implements just enough of MQTT 3.1.1 to send one
+// message without pulling in a client library.
+func mqttPublishOnce(ctx context.Context, broker, username, password, topic string, payload []byte) error {
+	if _, _, err := net.SplitHostPort(broker); err != nil {
+		broker = net.JoinHostPort(broker, "1883")
+	}
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", broker)
+	if err != nil {
+		return fmt.Errorf("failed to connect to mqtt broker: %w", err)
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+
+	// CONNECT with a clean session and a 60s keep-alive
+	var connect bytes.Buffer
+	writeMQTTString(&connect, "MQTT")
+	connect.WriteByte(4) // protocol level 3.1.1
+	flags := byte(0x02)
+	if username != "" {
+		flags |= 0x80
+		if password != "" {
+			flags |= 0x40
+		}
+	}
+	connect.WriteByte(flags)
+	connect.Write([]byte{0, 60})
+	writeMQTTString(&connect, "godash-"+strconv.Itoa(os.Getpid()))
+	if username != "" {
+		writeMQTTString(&connect, username)
+		if password != "" {
+			writeMQTTString(&connect, password)
+		}
+	}
+	if err := writeMQTTPacket(conn, mqttConnect, connect.Bytes()); err != nil {
+		return err
+	}
+
+	ack := make([]byte, 4)
+	if _, err := io.ReadFull(conn, ack); err != nil {
+		return fmt.Errorf("failed to read mqtt connack: %w", err)
+	}
+	if ack[0] != mqttConnAck || ack[3] != 0 {
+		return fmt.Errorf("mqtt broker refused connection (code %d)", ack[3])
+	}
+
+	var publish bytes.Buffer
+	writeMQTTString(&publish, topic)
+	publish.Write(payload)
+	if err := writeMQTTPacket(conn, mqttPublish, publish.Bytes()); err != nil {
+		return err
+	}
+	return writeMQTTPacket(conn, mqttDisconnect, nil)
+}
+
+// writeMQTTPacket writes a control packet with its variable-length size
+func writeMQTTPacket(w io.Writer, packetType byte, body []byte) error {
+	packet := []byte{packetType}
+	n := len(body)
+	for {
+		b := byte(n % 128)
+		n /= 128
+		if n > 0 {
+			b |= 0x80
+		}
+		packet = append(packet, b)
+		if n == 0 {
+			break
+		}
+	}
+	packet = append(packet, body...)
+	if _, err := w.Write(packet); err != nil {
+		return fmt.Errorf("failed to write mqtt packet: %w", err)
+	}
+	return nil
+}
+
+// writeMQTTString writes a length-prefixed UTF-8 string
+func writeMQTTString(buf *bytes.Buffer, s string) {
+	buf.WriteByte(byte(len(s) >> 8))
+	buf.WriteByte(byte(len(s)))
+	buf.WriteString(s)
+}
@@ -0,0 +1,178 @@
+package rules
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/j-raghavan/godash/internal/config"
+)
+
+// Notifier types
+const (
+	NotifierNtfy     = "ntfy"
+	NotifierTelegram = "telegram"
+	NotifierSlack    = "slack"
+	NotifierDiscord  = "discord"
+)
+
+// Default service endpoints, overridable with a notifier's URL
+const (
+	defaultNtfyServer  = "https://ntfy.sh"
+	defaultTelegramAPI = "https://api.telegram.org"
+)
+
+// Notifier sends rule events to a chat or push notification service. Every
+// fired event whose rule matches one of Rules is sent; an empty Rules list
+// receives all of them. Tags further limits the notifier to hosts carrying
+// matching tags.
+type Notifier struct {
+	Name   string
+	Type   string
+	URL    string // webhook URL, or the ntfy server / Telegram API base
+	Topic  string // ntfy topic
+	Token  string // ntfy access token or Telegram bot token
+	ChatID string // Telegram chat
+	Rules  []string
+	Tags   map[string]string // tag name -> value pattern, '*' wildcards
+}
+
+// ParseNotifiers converts and validates the configured notifiers
+func ParseNotifiers(cfgs []config.NotifierConfig) ([]Notifier, error) {
+	notifiers := make([]Notifier, 0, len(cfgs))
+	for _, c := range cfgs {
+		n := Notifier{
+			Name:   c.Name,
+			Type:   c.Type,
+			URL:    strings.TrimSuffix(c.URL, "/"),
+			Topic:  c.Topic,
+			Token:  c.Token,
+			ChatID: c.ChatID,
+			Rules:  c.Rules,
+			Tags:   c.Tags,
+		}
+		if n.Name == "" {
+			n.Name = n.Type
+		}
+		switch c.Type {
+		case NotifierNtfy:
+			if c.Topic == "" {
+				return nil, fmt.Errorf("notifier %s: ntfy needs a topic", n.Name)
+			}
+			if n.URL == "" {
+				n.URL = defaultNtfyServer
+			}
+		case NotifierTelegram:
+			if c.Token == "" || c.ChatID == "" {
+				return nil, fmt.Errorf("notifier %s: telegram needs a token and chat_id", n.Name)
+			}
+			if n.URL == "" {
+				n.URL = defaultTelegramAPI
+			}
+		case NotifierSlack, NotifierDiscord:
+			if c.URL == "" {
+				return nil, fmt.Errorf("notifier %s: %s needs a webhook url", n.Name, c.Type)
+			}
+		default:
+			return nil, fmt.Errorf("notifier %s: unknown type %q", n.Name, c.Type)
+		}
+		notifiers = append(notifiers, n)
+	}
+	return notifiers, nil
+}
+
+// Routes reports whether events of the named rule go to this notifier
+func (n Notifier) Routes(rule string) bool {
+	if len(n.Rules) == 0 {
+		return true
+	}
+	for _, pattern := range n.Rules {
+		if matchName(pattern, rule) {
+			return true
+		}
+	}
+	return false
+}
+
+// RoutesTags reports whether a host tagged with tags matches every tag
+// filter of this notifier
+func (n Notifier) RoutesTags(tags map[string]string) bool {
+	for name, pattern := range n.Tags {
+		value, ok := tags[name]
+		if !ok || !matchName(pattern, value) {
+			return false
+		}
+	}
+	return true
+}
+
+// Send delivers event to the service
+func (n Notifier) Send(ctx context.Context, event Event) error {
+	text := fmt.Sprintf("%s: %s", event.Host, event.Message)
+	switch n.Type {
+	case NotifierNtfy:
+		headers := map[string]string{
+			"Title": "godash: " + event.Rule,
+			"Tags":  "warning",
+		}
+		if n.Token != "" {
+			headers["Authorization"] = "Bearer " + n.Token
+		}
+		return post(ctx, n.URL+"/"+n.Topic, "text/plain", []byte(text), headers)
+	case NotifierTelegram:
+		return postJSON(ctx, n.URL+"/bot"+n.Token+"/sendMessage", map[string]string{
+			"chat_id": n.ChatID,
+			"text":    fmt.Sprintf("%s\n%s", event.Rule, text),
+		})
+	case NotifierSlack:
+		return postJSON(ctx, n.URL, map[string]string{"text": fmt.Sprintf("*%s* %s", event.Rule, text)})
+	case NotifierDiscord:
+		return postJSON(ctx, n.URL, map[string]string{"content": fmt.Sprintf("**%s** %s", event.Rule, text)})
+	}
+	return fmt.Errorf("unknown notifier type %q", n.Type)
+}
+
+// postJSON posts v encoded as JSON to endpoint
+func postJSON(ctx context.Context, endpoint string, v any) error {
+	body, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return post(ctx, endpoint, "application/json", body, nil)
+}
+
+// post sends body to endpoint, failing on a non-2xx response. The URL is
+// left out of errors since webhook and bot URLs embed secrets.
+func post(ctx context.Context, endpoint, contentType string, body []byte, headers map[string]string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("invalid notifier url")
+	}
+	req.Header.Set("Content-Type", contentType)
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", stripURL(err))
+	}
+	resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("service returned %s", resp.Status)
+	}
+	return nil
+}
+
+// stripURL removes the request URL from a client error
+func stripURL(err error) error {
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		return urlErr.Err
+	}
+	return err
+}
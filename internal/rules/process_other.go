@@ -0,0 +1,9 @@
+//go:build !unix
+
+package rules
+
+import "os/exec"
+
+// setProcessGroup leaves cmd as is: without Unix process groups only the
+// action's own process is killed when its context is done
+func setProcessGroup(cmd *exec.Cmd) {}
@@ -0,0 +1,716 @@
+package rules
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/j-raghavan/godash/internal/config"
+	"github.com/j-raghavan/godash/internal/metrics"
+	"github.com/j-raghavan/godash/internal/units"
+)
+
+// actionTimeout bounds a single action run unless the action sets its own
+const actionTimeout = 10 * time.Second
+
+// processWaitDelay bounds how long the output of a script or command action
+// is read after it exited or was killed, when children it left running keep
+// the output open
+const processWaitDelay = time.Second
+
+// Event kinds. Threshold events come from "when" expressions, the others
+// from changes between consecutive samples.
+const (
+	EventThreshold        = "threshold"
+	EventInterfaceDown    = "interface_down"
+	EventInterfaceUp      = "interface_up"
+	EventPublicIPChanged  = "public_ip_changed"
+	EventUnknownDevice    = "unknown_device"
+	EventJobOverdue       = "job_overdue"
+	EventRAIDDegraded     = "raid_degraded"
+	EventProcessDown      = "process_down"
+	EventProcessRestart   = "process_restarted"
+	EventProcessOverLimit = "process_over_limit"
+	EventPortDown         = "port_down"
+	EventPortOpened       = "port_opened"
+	EventCertExpiring     = "cert_expiring"
+	EventBandwidthQuota   = "bandwidth_quota"
+)
+
+// knownEvents lists the kinds a rule can subscribe to with "event"
+var knownEvents = map[string]bool{
+	EventInterfaceDown:    true,
+	EventInterfaceUp:      true,
+	EventPublicIPChanged:  true,
+	EventUnknownDevice:    true,
+	EventJobOverdue:       true,
+	EventRAIDDegraded:     true,
+	EventProcessDown:      true,
+	EventProcessRestart:   true,
+	EventProcessOverLimit: true,
+	EventPortDown:         true,
+	EventPortOpened:       true,
+	EventCertExpiring:     true,
+	EventBandwidthQuota:   true,
+}
+
+// Rule triggers its actions when its expression holds for For, or when a
+// matching event occurs.
+type Rule struct {
+	Name    string
+	Expr    *Expr // nil for event rules
+	Event   string
+	Match   string
+	For     time.Duration
+	Actions []Action
+}
+
+// Action is something a rule does when it fires.
+type Action struct {
+	Type     string
+	URL      string
+	Method   string
+	Headers  map[string]string
+	Command  string
+	Args     []string
+	Broker   string
+	Topic    string
+	Username string
+	Password string
+	Payload  *template.Template // nil sends the event as JSON
+	Timeout  time.Duration      // 0 uses actionTimeout
+
+	// slots holds one token per running command; nil runs commands without
+	// a limit
+	slots chan struct{}
+}
+
+// Event describes why a rule fired. It is the data passed to payload templates.
+type Event struct {
+	Rule    string            `json:"rule"`
+	Kind    string            `json:"kind"`
+	Subject string            `json:"subject,omitempty"` // metric name, interface, device, job or array
+	Value   float64           `json:"value"`
+	Message string            `json:"message"`
+	Host    string            `json:"host"`
+	Tags    map[string]string `json:"tags,omitempty"` // host tags from the configuration
+	Time    time.Time         `json:"time"`
+}
+
+// ParseRules converts the configured rules, validating expressions,
+// durations, actions and payload templates
+func ParseRules(cfgs []config.RuleConfig) ([]Rule, error) {
+	rules := make([]Rule, 0, len(cfgs))
+	for _, c := range cfgs {
+		if c.Name == "" {
+			return nil, fmt.Errorf("rule is missing a name")
+		}
+		rule := Rule{Name: c.Name, Event: c.Event, Match: c.Match}
+		switch {
+		case c.When != "" && c.Event != "":
+			return nil, fmt.Errorf("rule %s sets both when and event", c.Name)
+		case c.When != "":
+			expr, err := ParseExpr(c.When)
+			if err != nil {
+				return nil, fmt.Errorf("rule %s: %w", c.Name, err)
+			}
+			rule.Expr = &expr
+		case knownEvents[c.Event]:
+		default:
+			return nil, fmt.Errorf("rule %s needs a when expression or a known event", c.Name)
+		}
+		if c.For != "" {
+			d, err := time.ParseDuration(c.For)
+			if err != nil || d < 0 {
+				return nil, fmt.Errorf("invalid for %q in rule %s", c.For, c.Name)
+			}
+			rule.For = d
+		}
+		for _, a := range c.Actions {
+			action, err := parseAction(a)
+			if err != nil {
+				return nil, fmt.Errorf("rule %s: %w", c.Name, err)
+			}
+			rule.Actions = append(rule.Actions, action)
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+// parseAction validates an action and compiles its payload template
+func parseAction(c config.ActionConfig) (Action, error) {
+	action := Action{
+		Type:     c.Type,
+		URL:      c.URL,
+		Method:   c.Method,
+		Headers:  c.Headers,
+		Command:  c.Command,
+		Args:     c.Args,
+		Broker:   c.Broker,
+		Topic:    c.Topic,
+		Username: c.Username,
+		Password: c.Password,
+	}
+	switch c.Type {
+	case "http":
+		if c.URL == "" {
+			return Action{}, fmt.Errorf("http action is missing a url")
+		}
+		if action.Method == "" {
+			action.Method = http.MethodPost
+		}
+	case "script":
+		if c.Command == "" {
+			return Action{}, fmt.Errorf("script action is missing a command")
+		}
+	case "command":
+		if c.Command == "" {
+			return Action{}, fmt.Errorf("command action is missing a command")
+		}
+		if c.MaxConcurrent < 0 {
+			return Action{}, fmt.Errorf("invalid max_concurrent %d", c.MaxConcurrent)
+		}
+		action.slots = make(chan struct{}, max(c.MaxConcurrent, 1))
+	case "mqtt":
+		if c.Broker == "" || c.Topic == "" {
+			return Action{}, fmt.Errorf("mqtt action needs a broker and topic")
+		}
+	default:
+		return Action{}, fmt.Errorf("unknown action type %q", c.Type)
+	}
+	if c.MaxConcurrent != 0 && c.Type != "command" {
+		return Action{}, fmt.Errorf("max_concurrent only applies to command actions")
+	}
+	if c.Timeout != "" {
+		d, err := time.ParseDuration(c.Timeout)
+		if err != nil || d <= 0 {
+			return Action{}, fmt.Errorf("invalid timeout %q", c.Timeout)
+		}
+		action.Timeout = d
+	}
+	if c.Payload != "" {
+		tmpl, err := template.New("payload").Parse(c.Payload)
+		if err != nil {
+			return Action{}, fmt.Errorf("invalid payload template: %w", err)
+		}
+		action.Payload = tmpl
+	}
+	return action, nil
+}
+
+// Render returns the action's payload for event
+func (a Action) Render(event Event) ([]byte, error) {
+	if a.Payload == nil {
+		return json.Marshal(event)
+	}
+	var buf bytes.Buffer
+	if err := a.Payload.Execute(&buf, event); err != nil {
+		return nil, fmt.Errorf("failed to render payload: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// Run performs the action for event
+func (a Action) Run(ctx context.Context, event Event) error {
+	payload, err := a.Render(event)
+	if err != nil {
+		return err
+	}
+
+	switch a.Type {
+	case "http":
+		req, err := http.NewRequestWithContext(ctx, a.Method, a.URL, bytes.NewReader(payload))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		for k, v := range a.Headers {
+			req.Header.Set(k, v)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+		if resp.StatusCode >= 300 {
+			return fmt.Errorf("%s %s returned %s", a.Method, a.URL, resp.Status)
+		}
+		return nil
+	case "script":
+		cmd := exec.CommandContext(ctx, "sh", "-c", a.Command)
+		cmd.Stdin = bytes.NewReader(payload)
+		cmd.Env = eventEnv(event)
+		if out, err := combinedOutput(cmd); err != nil {
+			return fmt.Errorf("script failed: %w: %s", err, strings.TrimSpace(string(out)))
+		}
+		return nil
+	case "command":
+		if out, err := a.runCommand(ctx, event); err != nil {
+			return fmt.Errorf("command failed: %w: %s", err, strings.TrimSpace(string(out)))
+		}
+		return nil
+	case "mqtt":
+		return mqttPublishOnce(ctx, a.Broker, a.Username, a.Password, a.Topic, payload)
+	}
+	return fmt.Errorf("unknown action type %q", a.Type)
+}
+
+// runCommand runs a command action's program with its arguments, without
+// a shell, and returns the combined output. The event is passed in
+// environment variables only.
+func (a Action) runCommand(ctx context.Context, event Event) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, a.Command, a.Args...)
+	cmd.Env = eventEnv(event)
+	return combinedOutput(cmd)
+}
+
+// eventEnv returns the environment of script and command actions: godash's
+// own, plus the event in GODASH_* variables
+func eventEnv(event Event) []string {
+	return append(os.Environ(),
+		"GODASH_ALERT_NAME="+event.Rule,
+		"GODASH_EVENT="+event.Kind,
+		"GODASH_SUBJECT="+event.Subject,
+		"GODASH_VALUE="+strconv.FormatFloat(event.Value, 'f', -1, 64),
+		"GODASH_MESSAGE="+event.Message,
+		"GODASH_HOST="+event.Host,
+	)
+}
+
+// combinedOutput runs cmd and returns its combined output like
+// CombinedOutput, but kills its whole process group when its context is
+// done and stops reading the output processWaitDelay after it exited or
+// was killed
+func combinedOutput(cmd *exec.Cmd) ([]byte, error) {
+	setProcessGroup(cmd)
+	cmd.WaitDelay = processWaitDelay
+	out, err := cmd.CombinedOutput()
+	if errors.Is(err, exec.ErrWaitDelay) {
+		// It succeeded but left children running with the output open
+		err = nil
+	}
+	return out, err
+}
+
+// timeout returns how long a run of the action may take
+func (a Action) timeout() time.Duration {
+	if a.Timeout > 0 {
+		return a.Timeout
+	}
+	return actionTimeout
+}
+
+// Engine evaluates rules against successive metric samples and runs the
+// actions of rules that fire.
+type Engine struct {
+	rules []Rule
+	host  string
+	tags  map[string]string
+	log   io.Writer
+
+	mu        sync.Mutex
+	pending   map[string]time.Time // rule/subject -> when the condition started holding
+	firing    map[string]*Alert    // rule/subject pairs that already fired
+	prev      *metrics.Metric
+	running   sync.WaitGroup
+	history   *History // nil when alerts are not recorded
+	audit     *Audit   // nil when command runs are not recorded
+	notifiers []Notifier
+	rates     rateTracker
+}
+
+// NewEngine creates an Engine for rules. Action failures are written to log.
+func NewEngine(rules []Rule, log io.Writer) *Engine {
+	host, _ := os.Hostname()
+	return &Engine{
+		rules:   rules,
+		host:    host,
+		log:     log,
+		pending: make(map[string]time.Time),
+		firing:  make(map[string]*Alert),
+	}
+}
+
+// SetNotifiers sends fired events to the notifiers whose routes match
+func (e *Engine) SetNotifiers(notifiers []Notifier) {
+	e.notifiers = notifiers
+}
+
+// SetTags attaches the host's tags to events and alerts, and routes events
+// only to notifiers whose tag filters match them
+func (e *Engine) SetTags(tags map[string]string) {
+	e.tags = tags
+}
+
+// SetLowMemory keeps fewer points per value for rate conditions, for the
+// constrained-memory mode
+func (e *Engine) SetLowMemory(enabled bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.rates.samples = 0
+	if enabled {
+		e.rates.samples = lowMemoryRateSamples
+	}
+}
+
+// SetHistory records alert firings and resolutions in h
+func (e *Engine) SetHistory(h *History) {
+	e.history = h
+}
+
+// SetAudit records every run of a command action in a
+func (e *Engine) SetAudit(a *Audit) {
+	e.audit = a
+}
+
+// record saves the current state of alert to the history, if any
+func (e *Engine) record(alert Alert) {
+	if e.history == nil {
+		return
+	}
+	if err := e.history.Record(alert); err != nil && e.log != nil {
+		fmt.Fprintf(e.log, "rule %s: %v\n", alert.Rule, err)
+	}
+}
+
+// Evaluate checks every rule against metric, starts the actions of the
+// rules that fired and returns their events.
+func (e *Engine) Evaluate(metric metrics.Metric) []Event {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	var fired []Event
+	values := Values(metric)
+	changes := e.changes(metric)
+	for _, rule := range e.rules {
+		if rule.Expr == nil {
+			continue
+		}
+		for _, cond := range rule.Expr.Conditions() {
+			if cond.Rate {
+				e.rates.observe(cond, values, metric.Timestamp)
+			}
+		}
+	}
+	names := displayNames(metric)
+	lookup := func(cond Condition) map[string]float64 {
+		if cond.Rate {
+			return e.rates.rates(cond, metric.Timestamp)
+		}
+		return values
+	}
+
+	for _, rule := range e.rules {
+		var events []Event
+		if rule.Expr != nil {
+			events = e.evaluateCondition(rule, lookup, names, metric.Timestamp)
+		} else {
+			for _, change := range changes {
+				if change.Kind == rule.Event && (rule.Match == "" || matchName(rule.Match, change.Subject)) {
+					change.Rule = rule.Name
+					events = append(events, change)
+					e.record(Alert{Rule: rule.Name, Kind: change.Kind, Subject: change.Subject,
+						Message: change.Message, Host: e.host, Tags: e.tags, FiredAt: change.Time,
+						ResolvedAt: change.Time, Peak: change.Value})
+				}
+			}
+		}
+		for _, event := range events {
+			event.Host = e.host
+			event.Tags = e.tags
+			fired = append(fired, event)
+			for _, action := range rule.Actions {
+				run := action.Run
+				if action.Type == "command" {
+					run = e.commandRunner(action)
+				}
+				e.dispatch(event, action.Type+" action", action.timeout(), run)
+			}
+			for _, notifier := range e.notifiers {
+				if notifier.Routes(rule.Name) && notifier.RoutesTags(e.tags) {
+					e.dispatch(event, "notifier "+notifier.Name, actionTimeout, notifier.Send)
+				}
+			}
+		}
+	}
+	e.prev = &metric
+	return fired
+}
+
+// Wait blocks until all started actions have finished
+func (e *Engine) Wait() {
+	e.running.Wait()
+}
+
+// dispatch runs an action or notification for event in the background,
+// logging failures
+func (e *Engine) dispatch(event Event, what string, timeout time.Duration, run func(context.Context, Event) error) {
+	e.running.Add(1)
+	go func() {
+		defer e.running.Done()
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+		if err := run(ctx, event); err != nil && e.log != nil {
+			fmt.Fprintf(e.log, "rule %s: %s failed: %v\n", event.Rule, what, err)
+		}
+	}()
+}
+
+// evaluateCondition fires once per matching value when the rule's
+// expression has held for the rule's duration, and re-arms when it stops
+// holding
+func (e *Engine) evaluateCondition(rule Rule, lookup func(Condition) map[string]float64, labels map[string]string, now time.Time) []Event {
+	matched := rule.Expr.Matches(lookup)
+
+	names := make([]string, 0, len(matched))
+	for name := range matched {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var events []Event
+	for _, name := range names {
+		value := matched[name]
+		key := rule.Name + "|" + name
+		since, ok := e.pending[key]
+		if !ok {
+			since = now
+			e.pending[key] = now
+		}
+		if alert := e.firing[key]; alert != nil {
+			if rule.Expr.worse(value, alert.Peak) {
+				alert.Peak = value
+			}
+			continue
+		}
+		if now.Sub(since) < rule.For {
+			continue
+		}
+		event := Event{
+			Rule:    rule.Name,
+			Kind:    EventThreshold,
+			Subject: name,
+			Value:   value,
+			Message: message(*rule.Expr, name, value, lookup, labels),
+			Time:    now,
+		}
+		events = append(events, event)
+		alert := &Alert{Rule: rule.Name, Kind: EventThreshold, Subject: name,
+			Message: event.Message, Host: e.host, Tags: e.tags, FiredAt: now, Peak: value}
+		e.firing[key] = alert
+		e.record(*alert)
+	}
+
+	// Forget values that no longer match so the rule fires again next time,
+	// resolving their alerts
+	prefix := rule.Name + "|"
+	for key := range e.pending {
+		if name, ok := strings.CutPrefix(key, prefix); ok {
+			if _, still := matched[name]; !still {
+				if alert := e.firing[key]; alert != nil {
+					alert.ResolvedAt = now
+					e.record(*alert)
+				}
+				delete(e.pending, key)
+				delete(e.firing, key)
+			}
+		}
+	}
+	return events
+}
+
+// message describes why rule expression x fired for name, naming disks and
+// interfaces by their display names too
+func message(x Expr, name string, value float64, lookup func(Condition) map[string]float64, names map[string]string) string {
+	cond, ok := x.Single()
+	switch {
+	case !ok:
+		return describe(x, lookup, names)
+	case cond.Rate:
+		return fmt.Sprintf("%s is changing by %g/s over %s (%s %g/s)", labelled(name, names), value, cond.Window, cond.Op, cond.Threshold)
+	}
+	return fmt.Sprintf("%s is %g (%s %g)", labelled(name, names), value, cond.Op, cond.Threshold)
+}
+
+// interfaceName returns an interface's name followed by its display name,
+// if one is configured
+func interfaceName(net metrics.NetworkStat) string {
+	if net.Label == "" {
+		return net.Interface
+	}
+	return fmt.Sprintf("%s (%s)", net.Interface, net.Label)
+}
+
+// changes returns the events implied by the differences between the
+// previous sample and metric. The first sample only sets the baseline,
+// except that watched ports are compared against their expected state,
+// and watched processes against a healthy one when first reported, so
+// ones already down when godash starts are reported.
+func (e *Engine) changes(metric metrics.Metric) []Event {
+	prev := e.prev
+	if prev == nil {
+		baseline := metric
+		baseline.Processes = nil
+		baseline.Ports = make([]metrics.PortStatus, 0, len(metric.Ports))
+		for _, p := range metric.Ports {
+			baseline.Ports = append(baseline.Ports, metrics.PortStatus{Port: p.Port, Protocol: p.Protocol,
+				Expect: p.Expect, Listening: p.Expect == metrics.PortListening})
+		}
+		prev = &baseline
+	}
+	now := metric.Timestamp
+	var events []Event
+
+	wasUp := make(map[string]bool, len(prev.Network))
+	labels := make(map[string]string, len(prev.Network))
+	for _, net := range prev.Network {
+		wasUp[net.Interface] = net.Up
+		labels[net.Interface] = interfaceName(net)
+	}
+	seen := make(map[string]bool, len(metric.Network))
+	for _, net := range metric.Network {
+		seen[net.Interface] = true
+		up, known := wasUp[net.Interface]
+		switch {
+		case known && up && !net.Up:
+			events = append(events, Event{Kind: EventInterfaceDown, Subject: net.Interface,
+				Message: fmt.Sprintf("interface %s is down", interfaceName(net)), Time: now})
+		case known && !up && net.Up:
+			events = append(events, Event{Kind: EventInterfaceUp, Subject: net.Interface, Value: 1,
+				Message: fmt.Sprintf("interface %s is up", interfaceName(net)), Time: now})
+		}
+	}
+	for name, up := range wasUp {
+		if up && !seen[name] {
+			events = append(events, Event{Kind: EventInterfaceDown, Subject: name,
+				Message: fmt.Sprintf("interface %s was removed", labels[name]), Time: now})
+		}
+	}
+
+	if prev.WAN != nil && metric.WAN != nil && prev.WAN.PublicIP != "" &&
+		metric.WAN.PublicIP != "" && prev.WAN.PublicIP != metric.WAN.PublicIP {
+		events = append(events, Event{Kind: EventPublicIPChanged, Subject: metric.WAN.PublicIP,
+			Message: fmt.Sprintf("public IP changed from %s to %s", prev.WAN.PublicIP, metric.WAN.PublicIP), Time: now})
+	}
+
+	if metric.LAN != nil {
+		known := make(map[string]bool)
+		if prev.LAN != nil {
+			for _, device := range prev.LAN.UnknownDevices {
+				known[device.MAC] = true
+			}
+		}
+		for _, device := range metric.LAN.UnknownDevices {
+			if !known[device.MAC] {
+				events = append(events, Event{Kind: EventUnknownDevice, Subject: device.MAC,
+					Message: fmt.Sprintf("unknown device %s (%s) joined the network", device.MAC, device.IP), Time: now})
+			}
+		}
+	}
+
+	wasOverdue := make(map[string]bool, len(prev.Jobs))
+	for _, job := range prev.Jobs {
+		wasOverdue[job.Name] = job.Overdue
+	}
+	for _, job := range metric.Jobs {
+		if job.Overdue && !wasOverdue[job.Name] {
+			events = append(events, Event{Kind: EventJobOverdue, Subject: job.Name, Value: job.Late.Seconds(),
+				Message: fmt.Sprintf("job %s is overdue", job.Name), Time: now})
+		}
+	}
+
+	wasDegraded := make(map[string]bool, len(prev.RAID))
+	for _, array := range prev.RAID {
+		wasDegraded[array.Name] = array.Degraded
+	}
+	for _, array := range metric.RAID {
+		if array.Degraded && !wasDegraded[array.Name] {
+			events = append(events, Event{Kind: EventRAIDDegraded, Subject: array.Name,
+				Message: fmt.Sprintf("RAID %s is degraded [%d/%d]", array.Name, array.DisksTotal, array.DisksActive), Time: now})
+		}
+	}
+
+	prevProcesses := make(map[string]metrics.ProcessStatus, len(prev.Processes))
+	for _, p := range prev.Processes {
+		prevProcesses[p.Name] = p
+	}
+	for _, p := range metric.Processes {
+		// Processes are first reported once the background scan completes,
+		// after which a down or over-limit process is reported as a change
+		last, known := prevProcesses[p.Name]
+		if !known {
+			last = metrics.ProcessStatus{Name: p.Name, Status: metrics.ProcessOK, Restarts: p.Restarts}
+		}
+		if p.Restarts > last.Restarts {
+			events = append(events, Event{Kind: EventProcessRestart, Subject: p.Name, Value: float64(p.Restarts),
+				Message: fmt.Sprintf("process %s restarted", p.Name), Time: now})
+		}
+		if p.Status == last.Status {
+			continue
+		}
+		switch p.Status {
+		case metrics.ProcessDown:
+			events = append(events, Event{Kind: EventProcessDown, Subject: p.Name, Value: float64(p.Count),
+				Message: fmt.Sprintf("process %s is down (%d running)", p.Name, p.Count), Time: now})
+		case metrics.ProcessOverLimit:
+			events = append(events, Event{Kind: EventProcessOverLimit, Subject: p.Name, Value: p.CPUPercent,
+				Message: fmt.Sprintf("process %s is over its limits (cpu %.1f%%, memory %.0f MiB)", p.Name, p.CPUPercent, float64(p.Memory)/(1<<20)), Time: now})
+		}
+	}
+
+	wasListening := make(map[string]bool, len(prev.Ports))
+	for _, p := range prev.Ports {
+		wasListening[p.Name()] = p.Listening
+	}
+	for _, p := range metric.Ports {
+		listening, known := wasListening[p.Name()]
+		switch {
+		case p.Unexpected && !listening:
+			events = append(events, Event{Kind: EventPortOpened, Subject: p.Name(), Value: float64(p.Port),
+				Message: fmt.Sprintf("unexpected port %s started listening", p.Name()), Time: now})
+		case !known || listening == p.Listening:
+		case p.Expect == metrics.PortListening && !p.Listening:
+			events = append(events, Event{Kind: EventPortDown, Subject: p.Name(), Value: float64(p.Port),
+				Message: fmt.Sprintf("port %s stopped listening", p.Name()), Time: now})
+		case p.Expect == metrics.PortClosed && p.Listening:
+			events = append(events, Event{Kind: EventPortOpened, Subject: p.Name(), Value: float64(p.Port),
+				Message: fmt.Sprintf("port %s started listening", p.Name()), Time: now})
+		}
+	}
+
+	wasExpiring := make(map[string]bool, len(prev.Certs))
+	for _, cert := range prev.Certs {
+		wasExpiring[cert.Name] = cert.Expiring
+	}
+	for _, cert := range metric.Certs {
+		if cert.Expiring && !wasExpiring[cert.Name] {
+			events = append(events, Event{Kind: EventCertExpiring, Subject: cert.Name, Value: cert.DaysLeft,
+				Message: fmt.Sprintf("certificate %s expires in %.0f days (%s)", cert.Name, cert.DaysLeft, cert.NotAfter.Format("2006-01-02")), Time: now})
+		}
+	}
+
+	// A quota level is reported once per period, when it is first reached
+	if bw := metric.Bandwidth; bw != nil && bw.QuotaLevel > 0 {
+		if prev.Bandwidth == nil || bw.QuotaLevel > prev.Bandwidth.QuotaLevel {
+			events = append(events, Event{Kind: EventBandwidthQuota, Subject: fmt.Sprintf("%g%%", bw.QuotaLevel), Value: bw.QuotaPercent,
+				Message: fmt.Sprintf("%.0f%% of the monthly bandwidth quota used (%s of %s since %s)",
+					bw.QuotaPercent, units.Format{}.Bytes(float64(bw.QuotaUsed)), units.Format{}.Bytes(float64(bw.Quota)),
+					bw.PeriodStart.Format("2006-01-02")), Time: now})
+		}
+	}
+
+	return events
+}
@@ -0,0 +1,199 @@
+package rules
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/j-raghavan/godash/internal/metrics"
+)
+
+// displayNames maps the "<kind>.<name>" prefix of the values of each disk
+// and interface with a configured display name to that name
+func displayNames(m metrics.Metric) map[string]string {
+	names := make(map[string]string)
+	for _, e := range metrics.Entities(m) {
+		if label := e.Labels["label"]; label != "" {
+			names[e.Kind+"."+e.Name] = label
+		}
+	}
+	return names
+}
+
+// labelled appends the display name of the disk or interface that value
+// name belongs to, e.g. "disk./srv.used_percent (media)"
+func labelled(name string, names map[string]string) string {
+	var prefix string
+	for p := range names {
+		if strings.HasPrefix(name, p+".") && len(p) > len(prefix) {
+			prefix = p
+		}
+	}
+	if prefix == "" {
+		return name
+	}
+	return fmt.Sprintf("%s (%s)", name, names[prefix])
+}
+
+// Values flattens a metric sample into named values that rule expressions
+// refer to, e.g. "cpu", "memory.used_percent" or "disk./home.used_percent".
+func Values(m metrics.Metric) map[string]float64 {
+	values := make(map[string]float64)
+
+	if len(m.CPU) > 0 {
+		for i, pct := range m.CPU {
+			values[fmt.Sprintf("cpu.%d", i)] = pct
+		}
+		values["cpu"] = m.CPUTotal
+		// Not "cpu.<state>", which "cpu.*" patterns for the cores would match
+		states := m.CPUStates
+		values["cpu_state.user"] = states.User
+		values["cpu_state.nice"] = states.Nice
+		values["cpu_state.system"] = states.System
+		values["cpu_state.iowait"] = states.IOWait
+		values["cpu_state.steal"] = states.Steal
+		values["cpu_state.irq"] = states.IRQ
+		values["cpu_state.softirq"] = states.SoftIRQ
+		values["cpu_state.idle"] = states.Idle
+	}
+
+	for _, core := range m.CPUFreq {
+		values[fmt.Sprintf("cpufreq.%d.current_mhz", core.CPU)] = core.CurrentMHz
+	}
+
+	values["memory.used_percent"] = m.Memory.UsedPercentage
+	values["memory.used"] = float64(m.Memory.Used)
+	values["memory.free"] = float64(m.Memory.Free)
+
+	// Disks, interfaces, VMs and watched processes, e.g. "disk./home.free"
+	for _, e := range metrics.Entities(m) {
+		prefix := e.Kind + "." + e.Name + "."
+		for name, v := range e.Values {
+			values[prefix+name] = v
+		}
+	}
+
+	values["goruntime.goroutines"] = float64(m.GoRuntime.NumGoroutine)
+	values["goruntime.alloc"] = float64(m.GoRuntime.MemAlloc)
+
+	if m.Pi != nil {
+		values["pi.temperature"] = m.Pi.Temperature
+	}
+	if m.WAN != nil {
+		values["wan.gateway_latency_ms"] = float64(m.WAN.GatewayLatency.Milliseconds())
+	}
+	if m.LAN != nil {
+		values["lan.unknown_devices"] = float64(len(m.LAN.UnknownDevices))
+	}
+	if m.DNS != nil {
+		values["dns.blocked_percent"] = m.DNS.BlockedPercent
+	}
+	for _, sensor := range m.Hardware {
+		values["hardware."+sensor.Host+"."+sensor.Name] = sensor.Value
+	}
+
+	if k := m.Kernel; k != nil {
+		values["kernel.procs_running"] = float64(k.ProcsRunning)
+		values["kernel.procs_blocked"] = float64(k.ProcsBlocked)
+		if k.EntropyAvail >= 0 {
+			values["kernel.entropy_avail"] = float64(k.EntropyAvail)
+		}
+		if k.HasRates {
+			values["kernel.context_switches_per_sec"] = k.ContextSwitchesPerSec
+			values["kernel.interrupts_per_sec"] = k.InterruptsPerSec
+			values["kernel.forks_per_sec"] = k.ForksPerSec
+		}
+	}
+	if hp := m.HugePages; hp != nil && hp.Total > 0 {
+		values["hugepages.used_percent"] = hp.UsedPercent()
+		values["hugepages.free"] = float64(hp.Free)
+	}
+	for _, node := range m.NUMA {
+		prefix := fmt.Sprintf("numa.%d.", node.ID)
+		values[prefix+"cpu"] = node.CPUPercent
+		values[prefix+"memory.used_percent"] = node.MemUsedPercent()
+	}
+	for _, cert := range m.Certs {
+		if cert.Error == "" {
+			values["cert."+cert.Name+".days_left"] = cert.DaysLeft
+		}
+	}
+	for _, p := range m.Ports {
+		if !p.Unexpected {
+			values[fmt.Sprintf("port.%s.%d.listening", p.Protocol, p.Port)] = boolValue(p.Listening)
+		}
+	}
+
+	if bw := m.Bandwidth; bw != nil {
+		for _, usage := range bw.Interfaces {
+			prefix := "bandwidth." + usage.Interface + "."
+			values[prefix+"today_bytes"] = float64(usage.TodayRx + usage.TodayTx)
+			values[prefix+"month_bytes"] = float64(usage.MonthRx + usage.MonthTx)
+		}
+		if bw.Quota > 0 {
+			values["bandwidth.quota_percent"] = bw.QuotaPercent
+		}
+	}
+
+	var overdue, degraded float64
+	for _, job := range m.Jobs {
+		if job.Overdue {
+			overdue++
+		}
+	}
+	for _, array := range m.RAID {
+		if array.Degraded {
+			degraded++
+		}
+	}
+	values["jobs.overdue"] = overdue
+	values["raid.degraded"] = degraded
+
+	return values
+}
+
+// Names returns the sorted names of values
+func Names(values map[string]float64) []string {
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Select evaluates a query against values. A condition such as "cpu > 50"
+// returns the values satisfying it; a name or '*' pattern returns the
+// matching values.
+func Select(values map[string]float64, query string) (map[string]float64, error) {
+	query = strings.TrimSpace(query)
+	if strings.ContainsAny(query, "<>=!") {
+		cond, err := ParseCondition(query)
+		if err != nil {
+			return nil, err
+		}
+		if cond.Rate {
+			return nil, fmt.Errorf("rate() needs history and only works in rules")
+		}
+		return cond.Matches(values), nil
+	}
+	if query == "" || strings.ContainsAny(query, " \t") {
+		return nil, fmt.Errorf("invalid query %q", query)
+	}
+
+	selected := make(map[string]float64)
+	for name, value := range values {
+		if matchName(query, name) {
+			selected[name] = value
+		}
+	}
+	return selected, nil
+}
+
+// boolValue converts a flag to 1 or 0
+func boolValue(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,220 @@
+package tui
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/j-raghavan/godash/internal/metrics"
+)
+
+// clipboardTimeout bounds a run of the system clipboard tool
+const clipboardTimeout = 2 * time.Second
+
+// copySelection copies the selected item, or the whole sample when nothing
+// is selected, to the clipboard as text or JSON and reports the outcome in
+// the status bar. The selection is the current search match, else the
+// interface chosen with the arrow keys. Clipboard tools run off the event
+// loop, as each may take up to clipboardTimeout.
+func (ui *UI) copySelection(asJSON bool) {
+	what, data, err := ui.selectionData(asJSON)
+	if err != nil {
+		ui.copied(what, err)
+		return
+	}
+	go func() {
+		copied := runClipboardTool(data)
+		ui.app.QueueUpdateDraw(func() {
+			var err error
+			if !copied {
+				err = ui.setTerminalClipboard(data)
+			}
+			ui.copied(what, err)
+			ui.renderStatusBar(ui.lastMetric)
+		})
+	}()
+}
+
+// copied reports the outcome of copying what in the status bar
+func (ui *UI) copied(what string, err error) {
+	if err != nil {
+		ui.notice = ui.tr.Sprintf("[red]copy failed: %v[white]", err)
+	} else {
+		ui.notice = ui.tr.Sprintf("[green]copied %s[white]", what)
+	}
+	ui.noticeUntil = time.Now().Add(5 * time.Second)
+}
+
+// selectionData returns a description and the text or JSON of the
+// selection
+func (ui *UI) selectionData(asJSON bool) (string, []byte, error) {
+	metric := ui.lastMetric
+	selection := ui.currentMatch
+	if ui.filter == nil || selection == (searchMatch{}) {
+		selection = searchMatch{}
+		if ui.selectedInterface != "" {
+			selection = searchMatch{metrics.EntityInterface, ui.selectedInterface}
+		}
+	}
+	if selection == (searchMatch{}) {
+		if asJSON {
+			data, err := json.Marshal(metric)
+			return "sample", data, err
+		}
+		return "sample", []byte(ui.sampleText(metric)), nil
+	}
+
+	what := selection.kind + " " + selection.name
+	var item any
+	for _, e := range metrics.Entities(metric) {
+		if e.Kind == selection.kind && e.Name == selection.name {
+			item = e
+		}
+	}
+	switch selection.kind {
+	case "raid":
+		for _, array := range metric.RAID {
+			if array.Name == selection.name {
+				item = array
+			}
+		}
+	case "port":
+		for _, p := range metric.Ports {
+			if p.Name() == selection.name {
+				item = p
+			}
+		}
+	case "cert":
+		for _, cert := range metric.Certs {
+			if cert.Name == selection.name {
+				item = cert
+			}
+		}
+	}
+	if item == nil {
+		return what, nil, fmt.Errorf("%s is gone", what)
+	}
+	if e, ok := item.(metrics.Entity); ok && !asJSON {
+		return what, []byte(ui.entityText(e)), nil
+	}
+	data, err := json.Marshal(item)
+	if err != nil || asJSON {
+		return what, data, err
+	}
+	// Items other than entities are copied as indented JSON in text form
+	var buf bytes.Buffer
+	_ = json.Indent(&buf, data, "", "  ")
+	return what, []byte(what + "\n" + buf.String() + "\n"), nil
+}
+
+// entityText formats an entity as its kind and name followed by its labels
+// and values, one per line
+func (ui *UI) entityText(e metrics.Entity) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s %s\n", e.Kind, e.Name)
+	for _, name := range e.LabelNames() {
+		fmt.Fprintf(&b, "  %s: %s\n", name, e.Labels[name])
+	}
+	for _, name := range e.ValueNames() {
+		fmt.Fprintf(&b, "  %s: %s\n", name, ui.formatValue(name, e.Values[name]))
+	}
+	return b.String()
+}
+
+// formatValue formats an entity value in the units shown, judging its
+// kind by its name
+func (ui *UI) formatValue(name string, v float64) string {
+	switch {
+	case strings.HasSuffix(name, "_percent"):
+		return fmt.Sprintf("%.1f%%", v)
+	case strings.HasSuffix(name, "bytes_per_sec"):
+		return ui.units.Rate(v)
+	case strings.HasSuffix(name, "_per_sec"):
+		return fmt.Sprintf("%.1f/s", v)
+	case strings.HasSuffix(name, "_bytes"), name == "total", name == "used", name == "free",
+		name == "memory", name == "pss", name == "uss":
+		return ui.units.Bytes(v)
+	}
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+// sampleText summarizes a sample: CPU and memory use, and the disks and
+// interfaces
+func (ui *UI) sampleText(metric metrics.Metric) string {
+	var b strings.Builder
+	host, _ := os.Hostname()
+	fmt.Fprintf(&b, "%s at %s\n", host, metric.Timestamp.In(ui.location).Format(time.RFC3339))
+	fmt.Fprintf(&b, "cpu: %.1f%%\n", metric.CPUTotal)
+	fmt.Fprintf(&b, "memory: %.1f%% (%s of %s)\n", metric.Memory.UsedPercentage,
+		ui.units.Bytes(float64(metric.Memory.Used)), ui.units.Bytes(float64(metric.Memory.Total)))
+	for _, disk := range metrics.OrderDisks(metric.Disk, ui.pinnedDisks) {
+		fmt.Fprintf(&b, "disk %s: %.1f%% (%s of %s)\n", diskLabel(disk), disk.UsedPercentage,
+			ui.units.Bytes(float64(disk.Used)), ui.units.Bytes(float64(disk.Total)))
+	}
+	for _, net := range metrics.OrderInterfaces(metric.Network, ui.pinnedInterfaces) {
+		fmt.Fprintf(&b, "net %s: ↓ %s ↑ %s\n", interfaceLabel(net),
+			ui.units.Rate(net.RxBytesPerSec), ui.units.Rate(net.TxBytesPerSec))
+	}
+	return b.String()
+}
+
+// runClipboardTool puts data on the system clipboard with the platform's
+// clipboard tool and reports whether one worked. SSH sessions are left to
+// setTerminalClipboard, as the tools would reach the remote host's
+// clipboard.
+func runClipboardTool(data []byte) bool {
+	if os.Getenv("SSH_TTY") != "" || os.Getenv("SSH_CONNECTION") != "" {
+		return false
+	}
+	for _, args := range clipboardCommands() {
+		if _, err := exec.LookPath(args[0]); err != nil {
+			continue
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), clipboardTimeout)
+		cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+		cmd.Stdin = bytes.NewReader(data)
+		err := cmd.Run()
+		cancel()
+		if err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// setTerminalClipboard asks the terminal to put data on the clipboard with
+// an OSC 52 sequence, which most terminals support
+func (ui *UI) setTerminalClipboard(data []byte) error {
+	if ui.screen == nil {
+		return fmt.Errorf("no clipboard available")
+	}
+	ui.screen.SetClipboard(data)
+	return nil
+}
+
+// clipboardCommands returns the clipboard tools to try on this platform, in
+// order of preference
+func clipboardCommands() [][]string {
+	switch runtime.GOOS {
+	case "darwin":
+		return [][]string{{"pbcopy"}}
+	case "windows":
+		return [][]string{{"clip.exe"}}
+	}
+	var commands [][]string
+	if os.Getenv("WAYLAND_DISPLAY") != "" {
+		commands = append(commands, []string{"wl-copy"})
+	}
+	if os.Getenv("DISPLAY") != "" {
+		commands = append(commands, []string{"xclip", "-selection", "clipboard"},
+			[]string{"xsel", "--clipboard", "--input"})
+	}
+	return commands
+}
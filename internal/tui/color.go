@@ -0,0 +1,65 @@
+package tui
+
+import (
+	"fmt"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// Color modes
+const (
+	ColorNormal       = "normal"
+	ColorNone         = "none"          // no colors, for NO_COLOR and monochrome terminals
+	ColorHighContrast = "high-contrast" // white on black, critical values in reverse video
+)
+
+// ParseColorMode validates a color mode, with "" meaning normal
+func ParseColorMode(mode string) (string, error) {
+	switch mode {
+	case "", ColorNormal:
+		return ColorNormal, nil
+	case ColorNone, ColorHighContrast:
+		return mode, nil
+	}
+	return "", fmt.Errorf("invalid color mode %q: must be %q, %q or %q", mode, ColorNormal, ColorNone, ColorHighContrast)
+}
+
+// restyledScreen rewrites the style of everything drawn, so color modes
+// apply to every pane, border and color tag alike
+type restyledScreen struct {
+	tcell.Screen
+	mode string
+}
+
+// restyle maps style to the color mode. Status markers such as "!" carry
+// the meaning of colors, so no information is lost.
+func (s restyledScreen) restyle(style tcell.Style) tcell.Style {
+	fg, _, attrs := style.Decompose()
+	if s.mode == ColorNone {
+		return tcell.StyleDefault.Attributes(attrs)
+	}
+	style = tcell.StyleDefault.Foreground(tcell.ColorWhite).Background(tcell.ColorBlack).Attributes(attrs)
+	switch fg {
+	case tcell.ColorRed, tcell.ColorMaroon:
+		return style.Reverse(true).Bold(true)
+	case tcell.ColorYellow, tcell.ColorOlive, tcell.ColorOrange:
+		return style.Bold(true)
+	}
+	return style
+}
+
+func (s restyledScreen) SetContent(x, y int, primary rune, combining []rune, style tcell.Style) {
+	s.Screen.SetContent(x, y, primary, combining, s.restyle(style))
+}
+
+func (s restyledScreen) SetCell(x, y int, style tcell.Style, ch ...rune) {
+	s.Screen.SetCell(x, y, s.restyle(style), ch...)
+}
+
+func (s restyledScreen) Fill(ch rune, style tcell.Style) {
+	s.Screen.Fill(ch, s.restyle(style))
+}
+
+func (s restyledScreen) SetStyle(style tcell.Style) {
+	s.Screen.SetStyle(s.restyle(style))
+}
@@ -0,0 +1,113 @@
+package tui
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"time"
+
+	"github.com/j-raghavan/godash/internal/metrics"
+)
+
+// crashMetrics is how many recent samples a crash dump includes
+const crashMetrics = 10
+
+// CrashDump is the JSON written when the TUI panics. It stays on disk and
+// is never sent anywhere; attach it to a bug report if you like.
+type CrashDump struct {
+	Time    time.Time        `json:"time"`
+	Panic   string           `json:"panic"`
+	Stack   string           `json:"stack"`
+	Metrics []metrics.Metric `json:"metrics"` // oldest first
+}
+
+// CrashError is returned by Start when the TUI panicked. The terminal has
+// been restored by the time it is returned.
+type CrashError struct {
+	Panic any
+	Path  string // crash dump, empty if it could not be written
+	Err   error  // why the dump could not be written
+}
+
+func (e *CrashError) Error() string {
+	if e.Path == "" {
+		return fmt.Sprintf("godash crashed: %v (failed to write crash dump: %v)", e.Panic, e.Err)
+	}
+	return fmt.Sprintf("godash crashed: %v\ncrash dump with a stack trace and the last metrics written to %s", e.Panic, e.Path)
+}
+
+// recordRecent keeps metric among the samples included in a crash dump
+func (ui *UI) recordRecent(metric metrics.Metric) {
+	ui.recent = append(ui.recent, metric)
+	if len(ui.recent) > crashMetrics {
+		ui.recent = ui.recent[len(ui.recent)-crashMetrics:]
+	}
+}
+
+// panicReporter is implemented by collectors that can hand panics in their
+// collection goroutine to a handler, like metrics.SystemCollector
+type panicReporter interface {
+	SetPanicHandler(metrics.PanicHandler)
+}
+
+// guard runs fn, stopping the application if it panics so that Start can
+// restore the terminal and report the crash
+func (ui *UI) guard(fn func()) {
+	defer func() {
+		if p := recover(); p != nil {
+			ui.fail(p, debug.Stack())
+		}
+	}()
+	fn()
+}
+
+// fail keeps the first panic p of a goroutine other than the event loop,
+// with its stack, and stops the application so that Start can restore the
+// terminal and report the crash
+func (ui *UI) fail(p any, stack []byte) {
+	ui.crashMu.Lock()
+	if ui.crash == nil {
+		ui.crash, ui.crashStack = p, stack
+	}
+	ui.crashMu.Unlock()
+	ui.cancel()
+	ui.app.Stop()
+}
+
+// crashed returns the error for a panic caught by guard or recovered from
+// the event loop, writing the crash dump. It returns nil if nothing panicked.
+func (ui *UI) crashed(p any, stack []byte) error {
+	if p == nil {
+		ui.crashMu.Lock()
+		p, stack = ui.crash, ui.crashStack
+		ui.crashMu.Unlock()
+		if p == nil {
+			return nil
+		}
+	}
+	dump := CrashDump{Time: time.Now(), Panic: fmt.Sprint(p), Stack: string(stack), Metrics: ui.recent}
+	path, err := WriteCrashDump(ui.crashDir, dump)
+	return &CrashError{Panic: p, Path: path, Err: err}
+}
+
+// WriteCrashDump writes dump into dir as godash-crash-<time>.json and
+// returns its path
+func WriteCrashDump(dir string, dump CrashDump) (string, error) {
+	if dir == "" {
+		dir = os.TempDir()
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", fmt.Errorf("failed to create crash directory: %w", err)
+	}
+	data, err := json.MarshalIndent(dump, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal crash dump: %w", err)
+	}
+	path := filepath.Join(dir, "godash-crash-"+dump.Time.Format("20060102-150405")+".json")
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		return "", fmt.Errorf("failed to write crash dump: %w", err)
+	}
+	return path, nil
+}
@@ -0,0 +1,151 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/j-raghavan/godash/internal/metrics"
+)
+
+// The interface detail view keeps ifaceHistoryLength throughput points per
+// interface, one per historySpacing, and re-reads the details every
+// ifaceDetailInterval
+const (
+	ifaceHistoryLength  = 60
+	ifaceDetailInterval = time.Second
+)
+
+// recordInterfaceRates appends each interface's throughput to its sparkline
+// history
+func (ui *UI) recordInterfaceRates(metric metrics.Metric) {
+	if ui.ifaceRates == nil {
+		ui.ifaceRates = make(map[string][]float64)
+	}
+	for _, net := range metric.Network {
+		rates := append(ui.ifaceRates[net.Interface], net.RxBytesPerSec+net.TxBytesPerSec)
+		if len(rates) > ifaceHistoryLength {
+			rates = rates[len(rates)-ifaceHistoryLength:]
+		}
+		ui.ifaceRates[net.Interface] = rates
+	}
+}
+
+// selectInterface moves the interface selection by delta, wrapping around,
+// in the order interfaces are listed
+func (ui *UI) selectInterface(delta int) {
+	ifaces := metrics.OrderInterfaces(ui.lastMetric.Network, ui.pinnedInterfaces)
+	if len(ifaces) == 0 {
+		return
+	}
+	index := -1
+	for i, net := range ifaces {
+		if net.Interface == ui.selectedInterface {
+			index = i
+		}
+	}
+	if index < 0 && delta < 0 {
+		index = 0 // the first step up selects the last interface
+	}
+	index = ((index+delta)%len(ifaces) + len(ifaces)) % len(ifaces)
+	ui.selectedInterface = ifaces[index].Interface
+	ui.lastNetworkUpdate = time.Time{}
+	if ui.interfaceOpen {
+		ui.ifaceDetailAt = time.Time{}
+		ui.renderInterfaceDetail(ui.lastMetric)
+		return
+	}
+	ui.notice = ui.tr.Sprintf("[green]interface %s, Enter for details[white]", interfaceLabel(ifaces[index]))
+	ui.noticeUntil = time.Now().Add(5 * time.Second)
+}
+
+// toggleInterfaceDetail shows the selected interface's details in place of
+// the network pane, or the network pane again
+func (ui *UI) toggleInterfaceDetail() {
+	if ui.interfaceOpen {
+		ui.interfaceOpen = false
+		ui.grid.RemoveItem(ui.ifaceView).
+			AddItem(ui.networkView, 2, 0, 1, 1, 0, 0, false)
+		ui.lastNetworkUpdate = time.Time{}
+		return
+	}
+	if ui.selectedInterface == "" {
+		ui.selectInterface(1)
+		if ui.selectedInterface == "" {
+			return
+		}
+	}
+	ui.interfaceOpen = true
+	ui.ifaceDetailAt = time.Time{}
+	ui.grid.RemoveItem(ui.networkView).
+		AddItem(ui.ifaceView, 2, 0, 1, 1, 0, 0, false)
+	ui.renderInterfaceDetail(ui.lastMetric)
+}
+
+// renderInterfaceDetail shows the selected interface's counters, link
+// settings, addresses and recent throughput
+func (ui *UI) renderInterfaceDetail(metric metrics.Metric) {
+	if time.Since(ui.ifaceDetailAt) >= ifaceDetailInterval {
+		ui.ifaceDetail, ui.ifaceDetailErr = ui.interfaceDetails(ui.selectedInterface)
+		ui.ifaceDetailAt = time.Now()
+	}
+	net := metrics.NetworkStat{Interface: ui.selectedInterface, Label: ui.selectedInterface}
+	for _, stat := range metric.Network {
+		if stat.Interface == ui.selectedInterface {
+			net = stat
+		}
+	}
+
+	ui.ifaceView.Clear()
+	ui.ifaceView.SetTitle(ui.tr.Sprintf(ui.titles[ui.ifaceView], interfaceLabel(net)))
+	if ui.ifaceDetailErr != nil {
+		_, _ = fmt.Fprintf(ui.ifaceView, "[red]%v[white]\n", ui.ifaceDetailErr)
+		return
+	}
+	detail := ui.ifaceDetail
+	state := "[green]up[white]"
+	if !detail.Up {
+		state = "[red]! down[white]"
+	}
+	link := "speed unknown"
+	if detail.SpeedMbps > 0 {
+		link = fmt.Sprintf("%d Mbit/s", detail.SpeedMbps)
+	}
+	if detail.Duplex != "" {
+		link += ", " + detail.Duplex + " duplex"
+	}
+	_, _ = fmt.Fprintf(ui.ifaceView, "%s  MTU %d  %s", state, detail.MTU, link)
+	if detail.HardwareAddr != "" {
+		_, _ = fmt.Fprintf(ui.ifaceView, "  MAC %s", detail.HardwareAddr)
+	}
+	addrs := "none"
+	if len(detail.Addrs) > 0 {
+		addrs = strings.Join(detail.Addrs, ", ")
+	}
+	_, _ = fmt.Fprintf(ui.ifaceView, "\nAddresses: %s\n\n", addrs)
+	_, _ = fmt.Fprintf(ui.ifaceView, "↓ RX: %-28s errors %s  drops %s\n",
+		ui.formatRate(net, net.RxBytesPerSec, net.RxPacketsPerSec), problemCount(detail.RxErrors), problemCount(detail.RxDrops))
+	_, _ = fmt.Fprintf(ui.ifaceView, "↑ TX: %-28s errors %s  drops %s\n",
+		ui.formatRate(net, net.TxBytesPerSec, net.TxPacketsPerSec), problemCount(detail.TxErrors), problemCount(detail.TxDrops))
+	_, _ = fmt.Fprintf(ui.ifaceView, "Total: %s", ui.units.Bytes(float64(net.RxBytes+net.TxBytes)))
+	if metric.Bandwidth != nil {
+		for _, usage := range metric.Bandwidth.Interfaces {
+			if usage.Interface == net.Interface {
+				_, _ = fmt.Fprintf(ui.ifaceView, "   today ↓ %s ↑ %s, this month ↓ %s ↑ %s",
+					ui.units.Bytes(float64(usage.TodayRx)), ui.units.Bytes(float64(usage.TodayTx)),
+					ui.units.Bytes(float64(usage.MonthRx)), ui.units.Bytes(float64(usage.MonthTx)))
+			}
+		}
+	}
+	_, _ = fmt.Fprintf(ui.ifaceView, "\n")
+	_, _ = fmt.Fprintf(ui.ifaceView, "Last %ds: %s\n", ifaceHistoryLength, Sparkline(ui.ifaceRates[net.Interface], 0))
+	_, _ = fmt.Fprintf(ui.ifaceView, "[yellow]↑/↓ other interfaces, Enter or Esc to go back[white]")
+}
+
+// problemCount formats an error or drop counter, flagged when non-zero
+func problemCount(n uint64) string {
+	if n == 0 {
+		return "0"
+	}
+	return fmt.Sprintf("[yellow]! %d[white]", n)
+}
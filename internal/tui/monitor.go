@@ -3,16 +3,52 @@ package tui
 import (
 	"context"
 	"fmt"
+	"math"
+	"regexp"
+	"runtime/debug"
 	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/gdamore/tcell/v2"
 	"github.com/rivo/tview"
 
+	"github.com/j-raghavan/godash/internal/bandwidth"
+	"github.com/j-raghavan/godash/internal/i18n"
 	"github.com/j-raghavan/godash/internal/metrics"
+	"github.com/j-raghavan/godash/internal/rules"
+	"github.com/j-raghavan/godash/internal/units"
 )
 
+// Default render rate and pane refresh intervals
+const (
+	defaultMaxFPS            = 10
+	defaultMemoryInterval    = 5 * time.Second
+	defaultNetworkInterval   = 5 * time.Second
+	defaultInterfaceInterval = 30 * time.Second
+)
+
+// Cadence sets how often the UI redraws. Samples arriving faster than
+// MaxFPS are coalesced and only the latest is drawn. The memory and network
+// panes are redrawn at most every Memory and Network, and the busiest
+// interfaces shown are re-chosen every Interfaces. Zero fields keep their
+// defaults. CPU and network values are shown averaged over Smoothing, if
+// set; snapshots keep the raw samples.
+type Cadence struct {
+	MaxFPS     int
+	Memory     time.Duration
+	Network    time.Duration
+	Interfaces time.Duration
+	Smoothing  time.Duration
+}
+
+// alertsShown is how many recent alerts the alerts pane lists
+const alertsShown = 5
+
+// statusHelp is the key binding help shown in the status bar
+const statusHelp = "[yellow]Press 'q' to quit, 'g' to toggle Go runtime stats, 'k' kernel counters, 'n' NUMA layout, 'c' CPU detail, 's' save snapshot, 'u'/'b' units, 'd'/'D' more disks, ↑/↓ Enter interface details, '/' search, 'y'/'Y' copy as text/JSON[white]"
+
 // UI represents the terminal user interface
 type UI struct {
 	app                 *tview.Application
@@ -21,16 +57,75 @@ type UI struct {
 	memoryView          *tview.TextView
 	diskView            *tview.TextView
 	networkView         *tview.TextView
+	vmView              *tview.TextView
+	hardwareView        *tview.TextView
+	dnsView             *tview.TextView
+	runtimeView         *tview.TextView
+	goAppsView          *tview.TextView
+	alertsView          *tview.TextView
+	processView         *tview.TextView
+	kernelView          *tview.TextView
+	numaView            *tview.TextView
+	middleRow           *tview.Flex
 	statusBar           *tview.TextView
 	collector           metrics.Collector
 	metricsChan         chan metrics.Metric
 	showGoRuntime       bool
+	showKernel          bool
+	showNUMA            bool
+	showCPUDetail       bool // run queue and core frequencies in place of the core bars
 	ctx                 context.Context
 	cancel              context.CancelFunc
+	cadence             Cadence
+	smoother            smoother // only used by update
 	lastNetworkUpdate   time.Time
 	lastMemoryUpdate    time.Time
 	topInterfaces       []string // Store top 3 interfaces
 	lastInterfaceUpdate time.Time
+	pinnedDisks         []string
+	diskPage            int  // page of unpinned disks shown, advanced with 'd'
+	disksExpanded       bool // disk row grown to fill the screen with 'D'
+	pinnedInterfaces    []string
+	// Interface chosen with the arrow keys, whose details Enter shows in
+	// ifaceView in place of the network pane
+	ifaceView         *tview.TextView
+	selectedInterface string
+	interfaceOpen     bool
+	ifaceRates        map[string][]float64 // recent throughput per interface
+	ifaceDetail       metrics.InterfaceDetail
+	ifaceDetailErr    error
+	ifaceDetailAt     time.Time
+	interfaceDetails  func(name string) (metrics.InterfaceDetail, error)
+	lowMemory         bool
+	collectInterval   time.Duration // base sampling interval
+	alertHistory      *rules.History
+	// Recent samples for snapshots, saved into snapshotDir with 's'
+	lastMetric  metrics.Metric
+	history     []HistoryPoint
+	snapshotDir string
+	notice      string // shown in the status bar until noticeUntil
+	noticeUntil time.Time
+	units       units.Format // toggled with 'u' (SI/IEC) and 'b' (bits)
+	tr          *i18n.Printer
+	colorMode   string
+	location    *time.Location             // time zone clock times are shown in
+	titles      map[*tview.TextView]string // pane titles in English
+	// Recent samples and the first panic, written to crashDir on a crash
+	recent     []metrics.Metric
+	crashDir   string
+	crashMu    sync.Mutex
+	crash      any
+	crashStack []byte
+	recorder   *Recorder // records the session when set
+	// '/' search: the query typed into the status bar filters the disk,
+	// network, VM and watched service panes; 'n'/'N' step through matches
+	searching    bool
+	searchInput  string
+	filter       *regexp.Regexp // nil shows everything
+	matchIndex   int
+	currentMatch searchMatch
+	revealMatch  bool         // turn the disk pane to currentMatch on the next render
+	screen       tcell.Screen // last drawn to, for OSC 52 clipboard requests
 }
 
 // NewUI initializes a new UI instance
@@ -46,7 +141,7 @@ func NewUI(collector metrics.Collector, showGoRuntime bool) *UI {
 	memoryView := tview.NewTextView()
 	memoryView.SetDynamicColors(true).
 		SetBorder(true).
-		SetTitle("Memory Usage (Updates every 5s)")
+		SetTitle("Memory Usage (Updates every %s)")
 
 	diskView := tview.NewTextView()
 	diskView.SetDynamicColors(true).
@@ -56,11 +151,66 @@ func NewUI(collector metrics.Collector, showGoRuntime bool) *UI {
 	networkView := tview.NewTextView()
 	networkView.SetDynamicColors(true).
 		SetBorder(true).
-		SetTitle("Network I/O (Updates every 5s)")
+		SetTitle("Network I/O (Updates every %s)")
+
+	vmView := tview.NewTextView()
+	vmView.SetDynamicColors(true).
+		SetBorder(true).
+		SetTitle("Virtual Machines")
+
+	hardwareView := tview.NewTextView()
+	hardwareView.SetDynamicColors(true).
+		SetBorder(true).
+		SetTitle("Hardware")
+
+	dnsView := tview.NewTextView()
+	dnsView.SetDynamicColors(true).
+		SetBorder(true).
+		SetTitle("DNS Filter")
+
+	runtimeView := tview.NewTextView()
+	runtimeView.SetDynamicColors(true).
+		SetBorder(true).
+		SetTitle("Go Runtime")
+
+	goAppsView := tview.NewTextView()
+	goAppsView.SetDynamicColors(true).
+		SetBorder(true).
+		SetTitle("Go Applications")
+
+	alertsView := tview.NewTextView()
+	alertsView.SetDynamicColors(true).
+		SetBorder(true).
+		SetTitle("Recent Alerts")
+
+	kernelView := tview.NewTextView()
+	kernelView.SetDynamicColors(true).
+		SetBorder(true).
+		SetTitle("Kernel")
+
+	numaView := tview.NewTextView()
+	numaView.SetDynamicColors(true).
+		SetBorder(true).
+		SetTitle("NUMA Nodes")
+
+	processView := tview.NewTextView()
+	processView.SetDynamicColors(true).
+		SetBorder(true).
+		SetTitle("Watched Services")
+
+	ifaceView := tview.NewTextView()
+	ifaceView.SetDynamicColors(true).
+		SetBorder(true).
+		SetTitle("Interface %s")
 
 	statusBar := tview.NewTextView()
 	statusBar.SetDynamicColors(true)
 
+	// The VM, hardware and DNS panes are added to the middle row once reported
+	middleRow := tview.NewFlex().
+		AddItem(diskView, 0, 1, false).
+		AddItem(memoryView, 0, 1, false)
+
 	// Create grid layout
 	grid := tview.NewGrid().
 		SetRows(10, 10, 10, 1). // Three main rows of height 10, and 1 row for status
@@ -69,40 +219,86 @@ func NewUI(collector metrics.Collector, showGoRuntime bool) *UI {
 
 	// Add items to grid
 	grid.AddItem(cpuView, 0, 0, 1, 1, 0, 0, false).
-		AddItem(tview.NewFlex().
-			AddItem(diskView, 0, 1, false).
-			AddItem(memoryView, 0, 1, false),
-			1, 0, 1, 1, 0, 0, false).
+		AddItem(middleRow, 1, 0, 1, 1, 0, 0, false).
 		AddItem(networkView, 2, 0, 1, 1, 0, 0, false).
 		AddItem(statusBar, 3, 0, 1, 1, 0, 0, false)
 
-	return &UI{
-		app:                 tview.NewApplication(),
-		grid:                grid,
-		cpuView:             cpuView,
-		memoryView:          memoryView,
-		diskView:            diskView,
-		networkView:         networkView,
-		statusBar:           statusBar,
-		collector:           collector,
-		metricsChan:         make(chan metrics.Metric, 10),
-		showGoRuntime:       showGoRuntime,
-		ctx:                 ctx,
-		cancel:              cancel,
-		lastNetworkUpdate:   time.Now().Add(-5 * time.Second),  // Force first update
-		lastMemoryUpdate:    time.Now().Add(-5 * time.Second),  // Force first update
-		lastInterfaceUpdate: time.Now().Add(-30 * time.Second), // Force first update
-		topInterfaces:       make([]string, 0),
-	}
-}
-
-// Start initializes and starts the UI
-func (ui *UI) Start(refreshInterval time.Duration) error {
+	ui := &UI{
+		app:           tview.NewApplication(),
+		grid:          grid,
+		cpuView:       cpuView,
+		memoryView:    memoryView,
+		diskView:      diskView,
+		networkView:   networkView,
+		vmView:        vmView,
+		hardwareView:  hardwareView,
+		dnsView:       dnsView,
+		runtimeView:   runtimeView,
+		goAppsView:    goAppsView,
+		alertsView:    alertsView,
+		processView:   processView,
+		ifaceView:     ifaceView,
+		kernelView:    kernelView,
+		numaView:      numaView,
+		middleRow:     middleRow,
+		statusBar:     statusBar,
+		collector:     collector,
+		metricsChan:   make(chan metrics.Metric, 10),
+		showGoRuntime: showGoRuntime,
+		ctx:           ctx,
+		cancel:        cancel,
+		topInterfaces: make([]string, 0),
+		tr:            i18n.New(i18n.English.Code),
+		location:      time.Local,
+		titles:        make(map[*tview.TextView]string),
+
+		interfaceDetails: metrics.InterfaceDetails,
+	}
+	for _, view := range []*tview.TextView{cpuView, memoryView, diskView, networkView, vmView, hardwareView,
+		dnsView, runtimeView, goAppsView, alertsView, kernelView, numaView, processView, ifaceView} {
+		ui.titles[view] = view.GetTitle()
+	}
+	ui.SetCadence(Cadence{})
+	return ui
+}
+
+// Start initializes and starts the UI. If the UI panics, the terminal is
+// restored, a crash dump is written and a *CrashError is returned.
+func (ui *UI) Start(refreshInterval time.Duration) (err error) {
+	defer func() {
+		// tview restores the terminal before re-raising panics from the
+		// event loop
+		if p := recover(); p != nil {
+			err = ui.crashed(p, debug.Stack())
+		}
+	}()
+
 	// Set up status bar
-	ui.statusBar.SetText("[yellow]Press 'q' to quit, 'g' to toggle Go runtime stats[white]")
+	ui.statusBar.SetText(ui.tr.T(statusHelp))
 
 	// Set up key handlers
 	ui.app.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		if ui.searching {
+			return ui.searchKey(event)
+		}
+		switch event.Key() {
+		case tcell.KeyUp:
+			ui.selectInterface(-1)
+			return nil
+		case tcell.KeyDown:
+			ui.selectInterface(1)
+			return nil
+		case tcell.KeyEnter:
+			ui.toggleInterfaceDetail()
+			return nil
+		case tcell.KeyEscape:
+			if ui.interfaceOpen {
+				ui.toggleInterfaceDetail()
+			} else if ui.filter != nil {
+				ui.setFilter("")
+			}
+			return nil
+		}
 		switch event.Rune() {
 		case 'q':
 			ui.cancel()
@@ -111,18 +307,101 @@ func (ui *UI) Start(refreshInterval time.Duration) error {
 		case 'g':
 			ui.showGoRuntime = !ui.showGoRuntime
 			return nil
+		case 'k':
+			ui.showKernel = !ui.showKernel
+			return nil
+		case 'n':
+			if ui.filter != nil {
+				ui.nextMatch(1)
+				return nil
+			}
+			ui.showNUMA = !ui.showNUMA
+			return nil
+		case 'N':
+			ui.nextMatch(-1)
+			return nil
+		case '/':
+			ui.startSearch()
+			return nil
+		case 'y':
+			ui.copySelection(false)
+			return nil
+		case 'Y':
+			ui.copySelection(true)
+			return nil
+		case 'c':
+			ui.showCPUDetail = !ui.showCPUDetail
+			return nil
+		case 's':
+			ui.saveSnapshot()
+			return nil
+		case 'd':
+			ui.diskPage++
+			return nil
+		case 'D':
+			ui.disksExpanded = !ui.disksExpanded
+			if ui.disksExpanded {
+				ui.grid.SetRows(10, -1, 10, 1)
+			} else {
+				ui.grid.SetRows(10, 10, 10, 1)
+			}
+			return nil
+		case 'u':
+			ui.units.SI = !ui.units.SI
+			ui.notice = ui.tr.Sprintf("[green]units: %s[white]", ui.units.String())
+			ui.noticeUntil = time.Now().Add(5 * time.Second)
+			return nil
+		case 'b':
+			ui.units.Bits = !ui.units.Bits
+			ui.notice = ui.tr.Sprintf("[green]units: %s[white]", ui.units.String())
+			ui.noticeUntil = time.Now().Add(5 * time.Second)
+			return nil
 		}
 		return event
 	})
 
-	// Start metrics collection with a fixed 100ms interval for smoother updates
-	ui.collector.Start(100*time.Millisecond, ui.metricsChan)
+	if ui.recorder != nil {
+		defer func() {
+			if cerr := ui.recorder.Close(); err == nil {
+				err = cerr
+			}
+		}()
+	}
+	ui.app.SetAfterDrawFunc(func(screen tcell.Screen) {
+		ui.screen = screen
+		if ui.recorder != nil {
+			ui.recorder.Frame(screen, time.Now())
+		}
+	})
+
+	// Start metrics collection with a fixed 100ms interval for smoother
+	// updates, unless memory is constrained
+	collectInterval := 100 * time.Millisecond
+	if ui.lowMemory {
+		collectInterval = refreshInterval
+	}
+	ui.collectInterval = collectInterval
+	if c, ok := ui.collector.(panicReporter); ok {
+		c.SetPanicHandler(ui.fail)
+	}
+	ui.collector.Start(collectInterval, ui.metricsChan)
+
+	if ui.colorMode != "" && ui.colorMode != ColorNormal {
+		screen, err := tcell.NewScreen()
+		if err != nil {
+			return err
+		}
+		ui.app.SetScreen(restyledScreen{Screen: screen, mode: ui.colorMode})
+	}
 
 	// Start the UI update routine
-	go ui.update()
+	go ui.guard(ui.update)
 
 	// Run the application
-	return ui.app.SetRoot(ui.grid, true).Run()
+	if err := ui.app.SetRoot(ui.grid, true).Run(); err != nil {
+		return err
+	}
+	return ui.crashed(nil, nil)
 }
 
 // Stop shuts down the UI and metrics collection
@@ -132,32 +411,56 @@ func (ui *UI) Stop() {
 	close(ui.metricsChan)
 }
 
-// update refreshes the UI with the latest metrics
+// update refreshes the UI with the latest metrics, at most MaxFPS times a
+// second. Samples received between frames are coalesced into the latest.
 func (ui *UI) update() {
+	frame := time.NewTicker(time.Second / time.Duration(ui.cadence.MaxFPS))
+	defer frame.Stop()
+	var latest metrics.Metric
+	pending := false
 	for {
 		select {
 		case metric, ok := <-ui.metricsChan:
 			if !ok {
 				return
 			}
-			ui.renderMetrics(metric)
+			ui.smoother.observe(metric)
+			latest, pending = metric, true
+		case <-frame.C:
+			if pending {
+				ui.renderMetrics(latest, ui.smoother.apply(latest))
+				pending = false
+			}
 		case <-ui.ctx.Done():
 			return
 		}
 	}
 }
 
-// renderMetrics updates the UI with the provided metrics
-func (ui *UI) renderMetrics(metric metrics.Metric) {
+// renderMetrics updates the UI with the provided metrics: the raw sample,
+// which is kept for snapshots, and the one to display, which may be smoothed
+func (ui *UI) renderMetrics(raw, metric metrics.Metric) {
 	ui.app.QueueUpdateDraw(func() {
+		ui.recordHistory(raw)
+
 		// Update CPU View
 		ui.cpuView.Clear()
 		if len(metric.CPU) > 0 {
-			_, _ = fmt.Fprintf(ui.cpuView, "Overall: %.1f%%\n\n", metric.CPU[0])
+			_, _ = ui.tr.Fprintf(ui.cpuView, "Overall: %.1f%%", metric.CPUTotal)
+			states := metric.CPUStates
+			_, _ = ui.tr.Fprintf(ui.cpuView, "  [%s] us %.1f sy %.1f wa %.1f st %.1f hi %.1f",
+				cpuStateBar(states, 20), states.User+states.Nice, states.System, states.IOWait, states.Steal,
+				states.IRQ+states.SoftIRQ)
+			if metric.Pi != nil {
+				_, _ = ui.tr.Fprintf(ui.cpuView, "   SoC: %.1f°C", metric.Pi.Temperature)
+			}
+			_, _ = fmt.Fprintf(ui.cpuView, "\n\n")
 
 			// Display CPU cores in 4 columns
-			if len(metric.CPU) > 1 {
-				numCores := len(metric.CPU[1:])
+			if ui.showCPUDetail {
+				ui.renderCPUDetail(metric)
+			} else if len(metric.CPU) > 1 {
+				numCores := len(metric.CPU)
 				cols := 4
 				rows := (numCores + cols - 1) / cols
 
@@ -165,9 +468,9 @@ func (ui *UI) renderMetrics(metric metrics.Metric) {
 					for col := 0; col < cols; col++ {
 						coreIndex := row*cols + col
 						if coreIndex < numCores {
-							cpu := metric.CPU[coreIndex+1]
+							cpu := metric.CPU[coreIndex]
 							bar := createProgressBar(cpu, 12)
-							_, _ = fmt.Fprintf(ui.cpuView, "Core %2d: [%s] %5.1f%%   ",
+							_, _ = ui.tr.Fprintf(ui.cpuView, "Core %2d: [%s] %5.1f%%   ",
 								coreIndex, bar, cpu)
 						}
 					}
@@ -177,63 +480,217 @@ func (ui *UI) renderMetrics(metric metrics.Metric) {
 		}
 
 		// Update Memory View every 5 seconds
-		if time.Since(ui.lastMemoryUpdate) >= 5*time.Second {
+		if time.Since(ui.lastMemoryUpdate) >= ui.cadence.Memory {
 			ui.memoryView.Clear()
 			memBar := createProgressBar(metric.Memory.UsedPercentage, 20)
-			_, _ = fmt.Fprintf(ui.memoryView, "[%s] %.1f%%\n", memBar, metric.Memory.UsedPercentage)
-			_, _ = fmt.Fprintf(ui.memoryView, "Used: %s\nTotal: %s\n",
-				formatBytes(metric.Memory.Used),
-				formatBytes(metric.Memory.Total))
-			if ui.showGoRuntime {
-				_, _ = fmt.Fprintf(ui.memoryView, "\nGo Runtime:\n")
-				_, _ = fmt.Fprintf(ui.memoryView, "Goroutines: %d\n", metric.GoRuntime.NumGoroutine)
-				_, _ = fmt.Fprintf(ui.memoryView, "Alloc: %s\n", formatBytes(metric.GoRuntime.MemAlloc))
+			_, _ = ui.tr.Fprintf(ui.memoryView, "[%s] %.1f%%\n", memBar, metric.Memory.UsedPercentage)
+			_, _ = ui.tr.Fprintf(ui.memoryView, "Used: %s\nTotal: %s\n",
+				ui.units.Bytes(float64(metric.Memory.Used)),
+				ui.units.Bytes(float64(metric.Memory.Total)))
+			if hp := metric.HugePages; hp != nil && hp.Total > 0 {
+				_, _ = ui.tr.Fprintf(ui.memoryView, "Huge pages: %d/%d used (%s each)\n",
+					hp.Total-hp.Free, hp.Total, ui.units.Bytes(float64(hp.PageSize)))
 			}
 			ui.lastMemoryUpdate = time.Now()
 		}
 
 		// Update Disk View
 		ui.diskView.Clear()
-		for _, disk := range metric.Disk {
+		for _, disk := range ui.pageDisks(metric.Disk) {
+			label := ui.searchMarker(metrics.EntityDisk, disk.Path) + ui.highlight(diskLabel(disk))
+			if disk.Health != "" && disk.Health != metrics.MountHealthy {
+				_, _ = fmt.Fprintf(ui.diskView, "%s\n[red]%s %s mount[white]\n\n",
+					label, strings.ToUpper(disk.Health), disk.FsType)
+				continue
+			}
 			bar := createProgressBar(disk.UsedPercentage, 20)
-			_, _ = fmt.Fprintf(ui.diskView, "%s\n[%s] %.1f%%\n",
-				disk.Path, bar, disk.UsedPercentage)
-			_, _ = fmt.Fprintf(ui.diskView, "Used: %s / %s\n\n",
-				formatBytes(disk.Used),
-				formatBytes(disk.Total))
+			_, _ = fmt.Fprintf(ui.diskView, "%s", label)
+			if disk.Health != "" {
+				_, _ = fmt.Fprintf(ui.diskView, " (%s, %s)", disk.FsType, disk.Latency.Round(time.Millisecond))
+			}
+			_, _ = ui.tr.Fprintf(ui.diskView, "\n[%s] %.1f%%\n", bar, disk.UsedPercentage)
+			_, _ = ui.tr.Fprintf(ui.diskView, "Used: %s / %s\n",
+				ui.units.Bytes(float64(disk.Used)),
+				ui.units.Bytes(float64(disk.Total)))
+			if disk.FullIn > 0 {
+				_, _ = ui.tr.Fprintf(ui.diskView, "[yellow]Full in %s[white]\n", formatETA(disk.FullIn))
+			}
+			_, _ = fmt.Fprintf(ui.diskView, "\n")
+		}
+		for _, array := range metric.RAID {
+			if !ui.filterMatches(array.Name) {
+				continue
+			}
+			color, marker := "green", ui.searchMarker("raid", array.Name)
+			if array.Degraded {
+				color, marker = "red", marker+"! "
+			}
+			_, _ = fmt.Fprintf(ui.diskView, "[%s]%s%s %s %s [%d/%d][white]",
+				color, marker, ui.highlight(array.Name), array.Level, array.State, array.DisksTotal, array.DisksActive)
+			if array.SyncAction != "" {
+				_, _ = fmt.Fprintf(ui.diskView, " %s %.1f%%", array.SyncAction, array.SyncProgress)
+			}
+			_, _ = fmt.Fprintf(ui.diskView, "\n")
 		}
 
-		// Update top interfaces list every 30 seconds
-		if time.Since(ui.lastInterfaceUpdate) >= 30*time.Second {
-			// Create a slice of interfaces with their total traffic
-			type interfaceStats struct {
-				name       string
-				totalBytes uint64
+		// Update VM View
+		if len(metric.VMs) > 0 {
+			ui.showPane(ui.vmView)
+			ui.vmView.Clear()
+			for _, vm := range metric.VMs {
+				if !ui.filterMatches(vm.Name) {
+					continue
+				}
+				color := "white"
+				if vm.State != "running" {
+					color = "gray"
+				}
+				_, _ = fmt.Fprintf(ui.vmView, "[%s]%s%s %-8s[white] %5.1f%% %s net ↓ %s ↑ %s disk r %s w %s\n",
+					color, ui.searchMarker(metrics.EntityVM, vm.Name), ui.highlight(fmt.Sprintf("%-16.16s", vm.Name)),
+					vm.State, vm.CPUPercent, ui.units.Bytes(float64(vm.Memory)),
+					ui.units.Rate(vm.NetRxBytesPerSec), ui.units.Rate(vm.NetTxBytesPerSec),
+					ui.units.Rate(vm.BlockReadBytesPerSec), ui.units.Rate(vm.BlockWriteBytesPerSec))
 			}
-			var netStats []interfaceStats
-			for _, net := range metric.Network {
-				totalBytes := net.RxBytes + net.TxBytes
-				netStats = append(netStats, interfaceStats{
-					name:       net.Interface,
-					totalBytes: totalBytes,
-				})
+		}
+
+		// Update Hardware View
+		if len(metric.Hardware) > 0 {
+			ui.showPane(ui.hardwareView)
+			ui.hardwareView.Clear()
+			for _, sensor := range metric.Hardware {
+				if !sensor.Healthy() {
+					_, _ = fmt.Fprintf(ui.hardwareView, "[red]! %-10.10s %-16.16s %7.0f %s %s[white]\n",
+						sensor.Host, sensor.Name, sensor.Value, sensor.Unit, sensor.Status)
+					continue
+				}
+				_, _ = fmt.Fprintf(ui.hardwareView, "  %-10.10s %-16.16s %7.0f %s\n",
+					sensor.Host, sensor.Name, sensor.Value, sensor.Unit)
+			}
+		}
+
+		// Update Go Runtime View, toggled with 'g'
+		if ui.showGoRuntime {
+			ui.showPane(ui.runtimeView)
+			ui.renderGoRuntime(metric.GoRuntime)
+		} else {
+			ui.middleRow.RemoveItem(ui.runtimeView)
+		}
+
+		// Update Kernel View, toggled with 'k'
+		if ui.showKernel && metric.Kernel != nil {
+			ui.showPane(ui.kernelView)
+			ui.renderKernel(*metric.Kernel)
+		} else {
+			ui.middleRow.RemoveItem(ui.kernelView)
+		}
+
+		// Update NUMA View, toggled with 'n'
+		if ui.showNUMA && len(metric.NUMA) > 0 {
+			ui.showPane(ui.numaView)
+			ui.renderNUMA(metric.NUMA)
+		} else {
+			ui.middleRow.RemoveItem(ui.numaView)
+		}
+
+		// Update Go Applications View
+		if len(metric.GoApps) > 0 {
+			ui.showPane(ui.goAppsView)
+			ui.goAppsView.Clear()
+			for _, app := range metric.GoApps {
+				if app.Error != "" {
+					_, _ = fmt.Fprintf(ui.goAppsView, "%-14.14s [red]%s[white]\n", app.Name, app.Error)
+					continue
+				}
+				goroutines := "-"
+				if app.Goroutines >= 0 {
+					goroutines = fmt.Sprintf("%d", app.Goroutines)
+				}
+				heap, gc := "-", "-"
+				if app.HasMemStats {
+					heap, gc = ui.units.Bytes(float64(app.HeapAlloc)), fmt.Sprintf("%d", app.NumGC)
+				}
+				_, _ = fmt.Fprintf(ui.goAppsView, "%-14.14s g:%-6s heap:%s gc:%s\n",
+					app.Name, goroutines, heap, gc)
+			}
+		}
+
+		// Update Watched Services View
+		if len(metric.Processes) > 0 || len(metric.Ports) > 0 || len(metric.Certs) > 0 {
+			ui.showPane(ui.processView)
+			ui.processView.Clear()
+			for _, p := range metric.Processes {
+				if !ui.filterMatches(p.Name) {
+					continue
+				}
+				_, _ = fmt.Fprintf(ui.processView, "%s%s %s %d procs cpu %.1f%% mem %s",
+					ui.searchMarker(metrics.EntityProcess, p.Name), ui.highlight(fmt.Sprintf("%-14.14s", p.Name)),
+					processBadge(p.Status), p.Count, p.CPUPercent, ui.units.Bytes(float64(p.Memory)))
+				if p.PSS > 0 {
+					_, _ = fmt.Fprintf(ui.processView, " pss %s uss %s", ui.units.Bytes(float64(p.PSS)), ui.units.Bytes(float64(p.USS)))
+				}
+				if p.Restarts > 0 {
+					_, _ = fmt.Fprintf(ui.processView, " restarts:%d", p.Restarts)
+				}
+				_, _ = fmt.Fprintf(ui.processView, "\n")
+			}
+			for _, p := range metric.Ports {
+				if !ui.filterMatches(p.Name()) {
+					continue
+				}
+				_, _ = fmt.Fprintf(ui.processView, "%s%s %s\n",
+					ui.searchMarker("port", p.Name()), ui.highlight(fmt.Sprintf("%-14.14s", p.Name())), portBadge(p))
 			}
+			for _, cert := range metric.Certs {
+				if !ui.filterMatches(cert.Name) {
+					continue
+				}
+				name := ui.searchMarker("cert", cert.Name) + ui.highlight(fmt.Sprintf("%-14.14s", cert.Name))
+				switch {
+				case cert.Error != "":
+					_, _ = fmt.Fprintf(ui.processView, "%s [red]ERROR[white] %s\n", name, cert.Error)
+				case cert.Expiring:
+					_, _ = fmt.Fprintf(ui.processView, "%s [red]EXPIRING[white] in %.0f days\n", name, cert.DaysLeft)
+				default:
+					_, _ = fmt.Fprintf(ui.processView, "%s [green]VALID[white] %.0f days left\n", name, cert.DaysLeft)
+				}
+			}
+		}
 
-			// Sort interfaces by total traffic (descending)
-			sort.Slice(netStats, func(i, j int) bool {
-				return netStats[i].totalBytes > netStats[j].totalBytes
-			})
+		// Update Recent Alerts View
+		if ui.alertHistory != nil {
+			if alerts := ui.alertHistory.Recent(alertsShown); len(alerts) > 0 {
+				ui.showPane(ui.alertsView)
+				ui.renderAlerts(alerts)
+			}
+		}
 
-			// Update top 3 interfaces
+		// Update DNS View
+		if metric.DNS != nil {
+			ui.showPane(ui.dnsView)
+			ui.renderDNS(*metric.DNS)
+		}
+
+		// Update top interfaces list
+		if time.Since(ui.lastInterfaceUpdate) >= ui.cadence.Interfaces {
+			// Pinned interfaces come first, then the busiest ones, of
+			// those matching the search
 			ui.topInterfaces = make([]string, 0)
-			for i := 0; i < len(netStats) && i < 3; i++ {
-				ui.topInterfaces = append(ui.topInterfaces, netStats[i].name)
+			for _, net := range metrics.OrderInterfaces(metric.Network, ui.pinnedInterfaces) {
+				if len(ui.topInterfaces) == 3 {
+					break
+				}
+				if ui.filterMatches(net.Interface, net.Label) {
+					ui.topInterfaces = append(ui.topInterfaces, net.Interface)
+				}
 			}
+			ui.revealInterface()
 			ui.lastInterfaceUpdate = time.Now()
 		}
 
-		// Update Network View every 5 seconds
-		if time.Since(ui.lastNetworkUpdate) >= 5*time.Second {
+		// Update Network View, or the selected interface's details
+		if ui.interfaceOpen {
+			ui.renderInterfaceDetail(metric)
+		} else if time.Since(ui.lastNetworkUpdate) >= ui.cadence.Network {
 			ui.networkView.Clear()
 
 			// Create a map for quick lookup
@@ -242,27 +699,44 @@ func (ui *UI) renderMetrics(metric metrics.Metric) {
 				netMap[net.Interface] = net
 			}
 
+			if metric.WAN != nil {
+				ui.renderWAN(*metric.WAN)
+			}
+			if metric.LAN != nil {
+				_, _ = fmt.Fprintf(ui.networkView, "LAN: %d neighbors, %d DHCP leases\n",
+					len(metric.LAN.Neighbors), metric.LAN.ActiveLeases)
+			}
+
 			if len(ui.topInterfaces) > 0 {
 				colWidth := 30 // Fixed width for each column
 
 				// Print headers
-				_, _ = fmt.Fprintf(ui.networkView, "Top 3 Interfaces by Traffic:\n\n")
+				_, _ = fmt.Fprintf(ui.networkView, "Top 3 Interfaces by Traffic:\n")
+				if metric.Bandwidth != nil {
+					ui.renderBandwidth(*metric.Bandwidth)
+				}
+				_, _ = fmt.Fprintf(ui.networkView, "\n")
 				for _, iface := range ui.topInterfaces {
-					paddingLen := colWidth - len(iface)
+					name := iface
+					if net, ok := netMap[iface]; ok {
+						name = interfaceLabel(net)
+					}
+					if iface == ui.selectedInterface {
+						name = "▸ " + name
+					}
+					paddingLen := colWidth - len(name)
 					if paddingLen < 0 {
 						paddingLen = 0
 					}
 					padding := strings.Repeat(" ", paddingLen)
-					_, _ = fmt.Fprintf(ui.networkView, "%.*s%s", colWidth, iface, padding)
+					_, _ = fmt.Fprintf(ui.networkView, "%s%s", ui.highlight(fmt.Sprintf("%.*s", colWidth, name)), padding)
 				}
 				_, _ = fmt.Fprintf(ui.networkView, "\n")
 
 				// Print RX stats
 				for _, iface := range ui.topInterfaces {
 					if net, ok := netMap[iface]; ok {
-						stats := fmt.Sprintf("↓ RX: %s/s (%d pkts/s)",
-							formatBytes(net.RxBytes),
-							net.RxPackets)
+						stats := "↓ RX: " + ui.formatRate(net, net.RxBytesPerSec, net.RxPacketsPerSec)
 						paddingLen := colWidth - len(stats)
 						if paddingLen < 0 {
 							paddingLen = 0
@@ -276,9 +750,7 @@ func (ui *UI) renderMetrics(metric metrics.Metric) {
 				// Print TX stats
 				for _, iface := range ui.topInterfaces {
 					if net, ok := netMap[iface]; ok {
-						stats := fmt.Sprintf("↑ TX: %s/s (%d pkts/s)",
-							formatBytes(net.TxBytes),
-							net.TxPackets)
+						stats := "↑ TX: " + ui.formatRate(net, net.TxBytesPerSec, net.TxPacketsPerSec)
 						paddingLen := colWidth - len(stats)
 						if paddingLen < 0 {
 							paddingLen = 0
@@ -292,9 +764,8 @@ func (ui *UI) renderMetrics(metric metrics.Metric) {
 				_, _ = fmt.Fprintf(ui.networkView, "\n")
 				for _, iface := range ui.topInterfaces {
 					if net, ok := netMap[iface]; ok {
-						totalBytes := net.RxBytes + net.TxBytes
-						stats := fmt.Sprintf("Total: %s/s",
-							formatBytes(totalBytes))
+						stats := fmt.Sprintf("Total: %s",
+							ui.units.Bytes(float64(net.RxBytes+net.TxBytes)))
 						paddingLen := colWidth - len(stats)
 						if paddingLen < 0 {
 							paddingLen = 0
@@ -304,12 +775,474 @@ func (ui *UI) renderMetrics(metric metrics.Metric) {
 					}
 				}
 			}
+
+			// Print VPN peers below the interfaces
+			if len(metric.Tunnels) > 0 {
+				_, _ = fmt.Fprintf(ui.networkView, "\n\nVPN Peers:\n")
+			}
+			for _, peer := range metric.Tunnels {
+				endpoint := peer.Endpoint
+				if endpoint == "" {
+					endpoint = "-"
+				}
+				_, _ = fmt.Fprintf(ui.networkView, "%s %.12s %s %s ↓ %s ↑ %s\n",
+					peer.Source, peer.Peer, endpoint, tunnelStatus(peer),
+					ui.units.Bytes(float64(peer.RxBytes)), ui.units.Bytes(float64(peer.TxBytes)))
+			}
 			ui.lastNetworkUpdate = time.Now()
 		}
+
+		ui.renderStatusBar(metric)
 	})
 }
 
-// createProgressBar creates a colored progress bar
+// renderBandwidth shows the transfer accounted today and this month across
+// interfaces, and the use of the monthly quota
+func (ui *UI) renderBandwidth(bw bandwidth.Report) {
+	var today, month [2]uint64
+	for _, usage := range bw.Interfaces {
+		today[0] += usage.TodayRx
+		today[1] += usage.TodayTx
+		month[0] += usage.MonthRx
+		month[1] += usage.MonthTx
+	}
+	_, _ = fmt.Fprintf(ui.networkView, "Today ↓ %s ↑ %s, this month ↓ %s ↑ %s",
+		ui.units.Bytes(float64(today[0])), ui.units.Bytes(float64(today[1])),
+		ui.units.Bytes(float64(month[0])), ui.units.Bytes(float64(month[1])))
+	if bw.Quota > 0 {
+		color, marker := "green", ""
+		if bw.QuotaLevel > 0 {
+			color, marker = "red", "! "
+		}
+		_, _ = fmt.Fprintf(ui.networkView, " [%s]%s%.0f%% of %s quota[white]",
+			color, marker, bw.QuotaPercent, ui.units.Bytes(float64(bw.Quota)))
+	}
+	_, _ = fmt.Fprintf(ui.networkView, "\n")
+}
+
+// renderCPUDetail shows the scheduler run queue and each core's clock and
+// frequency governor. Cores clocked below half their maximum are marked.
+func (ui *UI) renderCPUDetail(metric metrics.Metric) {
+	if k := metric.Kernel; k != nil {
+		color := "green"
+		if k.ProcsRunning > len(metric.CPU) {
+			color = "yellow" // more runnable tasks than cores
+		}
+		_, _ = ui.tr.Fprintf(ui.cpuView, "Run queue: [%s]%d runnable[white], %d blocked on I/O, %d cores\n",
+			color, k.ProcsRunning, k.ProcsBlocked, len(metric.CPU))
+	}
+	if len(metric.CPUFreq) == 0 {
+		_, _ = ui.tr.Fprintf(ui.cpuView, "Core frequencies are not reported on this system\n")
+		return
+	}
+
+	governors := make(map[string]int)
+	var names []string
+	for _, core := range metric.CPUFreq {
+		if governors[core.Governor] == 0 {
+			names = append(names, core.Governor)
+		}
+		governors[core.Governor]++
+	}
+	for i, name := range names {
+		if len(names) > 1 {
+			names[i] = fmt.Sprintf("%s (%d cores)", name, governors[name])
+		}
+	}
+	_, _ = ui.tr.Fprintf(ui.cpuView, "Governor: %s\n", strings.Join(names, ", "))
+
+	const cols = 4
+	for i, core := range metric.CPUFreq {
+		marker := " "
+		if core.MaxMHz > 0 && core.CurrentMHz < core.MaxMHz/2 {
+			marker = "[yellow]▼[white]"
+		}
+		_, _ = ui.tr.Fprintf(ui.cpuView, "Core %2d: %4.2f GHz%s   ", core.CPU, core.CurrentMHz/1000, marker)
+		if (i+1)%cols == 0 || i == len(metric.CPUFreq)-1 {
+			_, _ = fmt.Fprintf(ui.cpuView, "\n")
+		}
+	}
+}
+
+// renderKernel shows the kernel activity counters
+func (ui *UI) renderKernel(k metrics.KernelStat) {
+	ui.kernelView.Clear()
+	_, _ = fmt.Fprintf(ui.kernelView, "Ctx switches: %s\n", formatCount(k.HasRates, k.ContextSwitchesPerSec))
+	_, _ = fmt.Fprintf(ui.kernelView, "Interrupts:   %s\n", formatCount(k.HasRates, k.InterruptsPerSec))
+	_, _ = fmt.Fprintf(ui.kernelView, "Forks:        %s\n", formatCount(k.HasRates, k.ForksPerSec))
+	_, _ = fmt.Fprintf(ui.kernelView, "Procs:        %d running, %d blocked\n", k.ProcsRunning, k.ProcsBlocked)
+	if k.EntropyAvail >= 0 {
+		_, _ = fmt.Fprintf(ui.kernelView, "Entropy:      %d bits\n", k.EntropyAvail)
+	}
+}
+
+// renderNUMA shows each NUMA node's CPUs, CPU usage and memory usage
+func (ui *UI) renderNUMA(nodes []metrics.NUMANode) {
+	ui.numaView.Clear()
+	for _, node := range nodes {
+		_, _ = fmt.Fprintf(ui.numaView, "node%d (%d cpus)\n", node.ID, len(node.CPUs))
+		_, _ = fmt.Fprintf(ui.numaView, " CPU [%s] %.1f%%\n", createProgressBar(node.CPUPercent, 10), node.CPUPercent)
+		_, _ = fmt.Fprintf(ui.numaView, " Mem [%s] %s/%s\n", createProgressBar(node.MemUsedPercent(), 10),
+			ui.units.Bytes(float64(node.MemUsed)), ui.units.Bytes(float64(node.MemTotal)))
+		if node.HugePagesTotal > 0 {
+			_, _ = fmt.Fprintf(ui.numaView, " Huge pages: %d/%d free\n", node.HugePagesFree, node.HugePagesTotal)
+		}
+	}
+}
+
+// formatCount formats a per-second event rate, or "–" until it is known
+func formatCount(known bool, perSec float64) string {
+	if !known {
+		return "–"
+	}
+	return fmt.Sprintf("%.0f/s", perSec)
+}
+
+// processBadge returns a colored status badge for a watched process
+func processBadge(status string) string {
+	switch status {
+	case metrics.ProcessDown:
+		return "[red]DOWN[white]"
+	case metrics.ProcessOverLimit:
+		return "[red]LIMIT[white]"
+	case metrics.ProcessRestarted:
+		return "[yellow]RESTARTED[white]"
+	}
+	return "[green]OK[white]"
+}
+
+// portBadge returns a colored status badge for a watched port
+func portBadge(p metrics.PortStatus) string {
+	switch {
+	case p.Unexpected:
+		return "[red]NEW LISTENER[white]"
+	case !p.OK && p.Listening:
+		return "[red]OPEN[white]"
+	case !p.OK:
+		return "[red]DOWN[white]"
+	case p.Listening:
+		return "[green]LISTENING[white]"
+	}
+	return "[green]CLOSED[white]"
+}
+
+// renderAlerts lists alerts newest first, active ones in red
+func (ui *UI) renderAlerts(alerts []rules.Alert) {
+	ui.alertsView.Clear()
+	for _, alert := range alerts {
+		if alert.Active() {
+			_, _ = fmt.Fprintf(ui.alertsView, "[red]! %s %s[white]\n",
+				ui.clock(alert.FiredAt), alert.Message)
+			continue
+		}
+		_, _ = fmt.Fprintf(ui.alertsView, "%s %s", ui.clock(alert.FiredAt), alert.Message)
+		if alert.Kind == rules.EventThreshold {
+			_, _ = fmt.Fprintf(ui.alertsView, " (peak %g, resolved %s)", alert.Peak, ui.clock(alert.ResolvedAt))
+		}
+		_, _ = fmt.Fprintf(ui.alertsView, "\n")
+	}
+}
+
+// clock formats t as hours and minutes in the display time zone
+func (ui *UI) clock(t time.Time) string {
+	return t.In(ui.location).Format("15:04")
+}
+
+// renderWAN prints the public IP and gateway latency line
+func (ui *UI) renderWAN(wan metrics.WANStat) {
+	_, _ = fmt.Fprintf(ui.networkView, "WAN: %s", wan.PublicIP)
+	if wan.GatewayLatency > 0 {
+		_, _ = fmt.Fprintf(ui.networkView, " (gateway %s)", wan.GatewayLatency.Round(time.Millisecond))
+	}
+	if wan.PreviousIP != "" {
+		_, _ = fmt.Fprintf(ui.networkView, " [yellow]changed from %s at %s[white]",
+			wan.PreviousIP, ui.clock(wan.ChangedAt))
+	}
+	if wan.Error != "" {
+		_, _ = fmt.Fprintf(ui.networkView, " [red]%s[white]", wan.Error)
+	}
+	_, _ = fmt.Fprintf(ui.networkView, "\n")
+}
+
+// renderGoRuntime prints the Go runtime stats and a GC pause sparkline
+func (ui *UI) renderGoRuntime(rt metrics.GoRuntimeStat) {
+	ui.runtimeView.Clear()
+	_, _ = fmt.Fprintf(ui.runtimeView, "Goroutines: %d  GOMAXPROCS: %d  CGO calls: %d\n",
+		rt.NumGoroutine, rt.GOMAXPROCS, rt.NumCgoCall)
+	_, _ = fmt.Fprintf(ui.runtimeView, "Alloc: %s  Sys: %s  Objects: %d\n",
+		ui.units.Bytes(float64(rt.MemAlloc)), ui.units.Bytes(float64(rt.MemSys)), rt.HeapObjects)
+	_, _ = fmt.Fprintf(ui.runtimeView, "GC: %d runs, %s total pause\n",
+		rt.NumGC, time.Duration(rt.PauseTotalNs).Round(time.Microsecond))
+	if len(rt.RecentPauses) == 0 {
+		return
+	}
+
+	pauses := make([]float64, len(rt.RecentPauses))
+	var max time.Duration
+	for i, pause := range rt.RecentPauses {
+		pauses[i] = float64(pause)
+		if pause > max {
+			max = pause
+		}
+	}
+	_, _ = fmt.Fprintf(ui.runtimeView, "Pauses: %s max %s\n",
+		Sparkline(pauses, 0), max.Round(time.Microsecond))
+}
+
+// renderDNS prints the Pi-hole / AdGuard Home statistics and top clients
+func (ui *UI) renderDNS(dns metrics.DNSStat) {
+	ui.dnsView.Clear()
+	status := "[green]blocking[white]"
+	if !dns.BlockingEnabled {
+		status = "[red]blocking off[white]"
+	}
+	_, _ = fmt.Fprintf(ui.dnsView, "%s %s\n", dns.Source, status)
+	_, _ = fmt.Fprintf(ui.dnsView, "Queries: %d  Blocked: %d (%.1f%%)\n",
+		dns.Queries, dns.Blocked, dns.BlockedPercent)
+	_, _ = fmt.Fprintf(ui.dnsView, "%s\n", Sparkline(dns.History, 100))
+	for _, client := range dns.TopClients {
+		_, _ = fmt.Fprintf(ui.dnsView, "%-20.20s %8d\n", client.Name, client.Queries)
+	}
+	if dns.Error != "" {
+		_, _ = fmt.Fprintf(ui.dnsView, "[red]%s[white]\n", dns.Error)
+	}
+}
+
+// showPane adds view to the middle row unless it is already shown
+func (ui *UI) showPane(view *tview.TextView) {
+	for i := 0; i < ui.middleRow.GetItemCount(); i++ {
+		if ui.middleRow.GetItem(i) == view {
+			return
+		}
+	}
+	ui.middleRow.AddItem(view, 0, 1, false)
+}
+
+// renderStatusBar shows the key bindings, prefixed by any active warnings
+func (ui *UI) renderStatusBar(metric metrics.Metric) {
+	var warnings []string
+	collectors := make([]string, 0, len(metric.Errors))
+	for name := range metric.Errors {
+		collectors = append(collectors, name)
+	}
+	sort.Strings(collectors)
+	for _, name := range collectors {
+		warnings = append(warnings, fmt.Sprintf("%s collector: %s", name, metric.Errors[name]))
+	}
+	if ui.collectInterval > 0 && metric.Interval > ui.collectInterval {
+		warnings = append(warnings, fmt.Sprintf("busy: sampling every %s", metric.Interval))
+	}
+	for _, p := range metric.Processes {
+		if p.Status == metrics.ProcessDown {
+			warnings = append(warnings, fmt.Sprintf("process %s down", p.Name))
+		}
+	}
+	for _, p := range metric.Ports {
+		switch {
+		case p.Unexpected:
+			warnings = append(warnings, fmt.Sprintf("new listener on %s", p.Name()))
+		case !p.OK && p.Listening:
+			warnings = append(warnings, fmt.Sprintf("port %s open", p.Name()))
+		case !p.OK:
+			warnings = append(warnings, fmt.Sprintf("port %s down", p.Name()))
+		}
+	}
+	for _, cert := range metric.Certs {
+		if cert.Expiring {
+			warnings = append(warnings, fmt.Sprintf("cert %s expires in %.0f days", cert.Name, cert.DaysLeft))
+		}
+	}
+	for _, disk := range metric.Disk {
+		if disk.Health == metrics.MountStale || disk.Health == metrics.MountHung {
+			warnings = append(warnings, fmt.Sprintf("%s mount %s", disk.Health, diskLabel(disk)))
+		}
+	}
+	if metric.LAN != nil {
+		for _, device := range metric.LAN.UnknownDevices {
+			name := device.MAC
+			if device.Hostname != "" {
+				name += " " + device.Hostname
+			}
+			warnings = append(warnings, fmt.Sprintf("unknown device %s (%s)", name, device.IP))
+		}
+	}
+	for _, job := range metric.Jobs {
+		if job.Overdue {
+			warnings = append(warnings, fmt.Sprintf("job %s overdue by %s",
+				job.Name, job.Late.Round(time.Minute)))
+		}
+	}
+	for _, array := range metric.RAID {
+		if array.Degraded {
+			warnings = append(warnings, fmt.Sprintf("CRITICAL: RAID %s degraded [%d/%d]",
+				array.Name, array.DisksTotal, array.DisksActive))
+		}
+	}
+	for _, sensor := range metric.Hardware {
+		if !sensor.Healthy() {
+			warnings = append(warnings, fmt.Sprintf("%s %s %s %s",
+				sensor.Host, sensor.Kind, sensor.Name, sensor.Status))
+		}
+	}
+	if metric.DNS != nil {
+		if !metric.DNS.BlockingEnabled && metric.DNS.Error == "" {
+			warnings = append(warnings, metric.DNS.Source+" blocking disabled")
+		}
+		if metric.DNS.Error != "" {
+			warnings = append(warnings, metric.DNS.Source+" unreachable")
+		}
+	}
+	if metric.Pi != nil {
+		warnings = append(warnings, metric.Pi.Warnings()...)
+	}
+
+	help := ui.tr.T(statusHelp)
+	if search := ui.searchStatus(metric); search != "" {
+		help = search + "  " + help
+	}
+	if ui.notice != "" && time.Now().Before(ui.noticeUntil) {
+		help = ui.notice + "  " + help
+	}
+	if len(warnings) == 0 {
+		ui.statusBar.SetText(help)
+		return
+	}
+	ui.statusBar.SetText("[red::b]⚠ " + strings.Join(warnings, " | ") + "[white::-]  " + help)
+}
+
+// diskLabel returns the configured display name for a disk, or its mountpoint
+func diskLabel(disk metrics.DiskStat) string {
+	if disk.Label != "" {
+		return disk.Label
+	}
+	return disk.Path
+}
+
+// interfaceLabel returns the configured display name for a network interface
+func interfaceLabel(net metrics.NetworkStat) string {
+	if net.Label != "" {
+		return net.Label
+	}
+	return net.Interface
+}
+
+// tunnelStatus describes a VPN peer's connection age. WireGuard peers
+// without a handshake in the last 3 minutes are flagged as stale.
+func tunnelStatus(peer metrics.TunnelPeer) string {
+	switch {
+	case !peer.ConnectedSince.IsZero():
+		return "up " + time.Since(peer.ConnectedSince).Round(time.Second).String()
+	case peer.LatestHandshake.IsZero():
+		return "[red]no handshake[white]"
+	}
+	age := time.Since(peer.LatestHandshake).Round(time.Second)
+	if age > 3*time.Minute {
+		return "[red]handshake " + age.String() + " ago[white]"
+	}
+	return "handshake " + age.String() + " ago"
+}
+
+// diskLines is how many lines a disk usually takes in the disk pane
+const diskLines = 4
+
+// pageDisks returns the disks to show on the current page of the disk pane:
+// the pinned disks, which stay at the top of every page, followed by a page
+// of the others. The pane title shows the page.
+func (ui *UI) pageDisks(disks []metrics.DiskStat) []metrics.DiskStat {
+	ordered := metrics.OrderDisks(disks, ui.pinnedDisks)
+	if ui.filter != nil {
+		matching := ordered[:0:0]
+		for _, disk := range ordered {
+			if ui.filterMatches(disk.Path, disk.Label, disk.Device) {
+				matching = append(matching, disk)
+			}
+		}
+		ordered = matching
+	}
+	pinned := 0
+	for pinned < len(ordered) && ui.isPinnedDisk(ordered[pinned]) {
+		pinned++
+	}
+	_, _, _, height := ui.diskView.GetInnerRect()
+	perPage := height/diskLines - pinned
+	if perPage < 1 {
+		perPage = 1
+	}
+	if ui.revealMatch && ui.currentMatch.kind == metrics.EntityDisk {
+		for i, disk := range ordered[pinned:] {
+			if disk.Path == ui.currentMatch.name {
+				ui.diskPage = i / perPage
+			}
+		}
+		ui.revealMatch = false
+	}
+	start, end, pages := PageRange(len(ordered)-pinned, perPage, ui.diskPage)
+	title := ui.tr.T(ui.titles[ui.diskView])
+	if pages > 1 {
+		ui.diskPage %= pages
+		title += fmt.Sprintf(" [%d/%d]", start/perPage+1, pages)
+	}
+	ui.diskView.SetTitle(title)
+	return append(ordered[:pinned:pinned], ordered[pinned+start:pinned+end]...)
+}
+
+// isPinnedDisk reports whether disk is listed in pinned_disks
+func (ui *UI) isPinnedDisk(disk metrics.DiskStat) bool {
+	for _, name := range ui.pinnedDisks {
+		if name == disk.Path || name == disk.Device || (disk.Label != "" && name == disk.Label) {
+			return true
+		}
+	}
+	return false
+}
+
+// PageRange returns the range [start, end) of the items on page of a list
+// of total items split into pages of perPage, and the number of pages.
+// Pages past the last wrap around to the first.
+func PageRange(total, perPage, page int) (start, end, pages int) {
+	if total <= 0 || perPage <= 0 {
+		return 0, 0, 0
+	}
+	pages = (total + perPage - 1) / perPage
+	start = (page % pages) * perPage
+	end = min(start+perPage, total)
+	return start, end, pages
+}
+
+// cpuStates are the segments of the CPU state bar, each with its own glyph
+// so that they stay apart without color
+var cpuStates = []struct {
+	color string
+	glyph string
+	share func(metrics.CPUStateStat) float64
+}{
+	{"green", "█", func(s metrics.CPUStateStat) float64 { return s.User + s.Nice }},
+	{"red", "▓", func(s metrics.CPUStateStat) float64 { return s.System }},
+	{"yellow", "▒", func(s metrics.CPUStateStat) float64 { return s.IOWait }},
+	{"fuchsia", "#", func(s metrics.CPUStateStat) float64 { return s.Steal }},
+	{"blue", "+", func(s metrics.CPUStateStat) float64 { return s.IRQ + s.SoftIRQ }},
+}
+
+// cpuStateBar renders the CPU states as a stacked bar of width cells: user,
+// system, iowait, steal and interrupts, then idle
+func cpuStateBar(states metrics.CPUStateStat, width int) string {
+	var bar strings.Builder
+	cum, drawn := 0.0, 0
+	for _, state := range cpuStates {
+		// Rounding the running total keeps the segments adding up to it
+		cum += state.share(states)
+		end := min(width, int(math.Round(cum*float64(width)/100)))
+		if end > drawn {
+			bar.WriteString("[" + state.color + "]" + strings.Repeat(state.glyph, end-drawn))
+			drawn = end
+		}
+	}
+	bar.WriteString("[white]" + strings.Repeat("░", width-drawn))
+	return bar.String()
+}
+
+// createProgressBar creates a colored progress bar, followed by a marker
+// that does not rely on color: "!" from 80% and "~" from 50%
 func createProgressBar(percentage float64, width int) string {
 	filled := int(percentage * float64(width) / 100)
 	if filled > width {
@@ -318,14 +1251,12 @@ func createProgressBar(percentage float64, width int) string {
 	empty := width - filled
 
 	// Choose color based on percentage
-	var color string
+	color, marker := "red", "!"
 	switch {
 	case percentage < 50:
-		color = "green"
+		color, marker = "green", " "
 	case percentage < 80:
-		color = "yellow"
-	default:
-		color = "red"
+		color, marker = "yellow", "~"
 	}
 
 	bar := ""
@@ -335,21 +1266,58 @@ func createProgressBar(percentage float64, width int) string {
 	for i := 0; i < empty; i++ {
 		bar += "░"
 	}
-	return "[" + color + "]" + bar + "[white]"
+	return "[" + color + "]" + bar + marker + "[white]"
 }
 
-// formatBytes formats bytes to human readable format
-func formatBytes(b uint64) string {
-	const unit = 1024
-	if b < unit {
-		return fmt.Sprintf("%d B", b)
+// sparkBlocks are the bar characters used by Sparkline, lowest first
+var sparkBlocks = []rune("▁▂▃▄▅▆▇█")
+
+// Sparkline renders values as a row of bar characters scaled to max. When
+// max is zero the largest value is used.
+func Sparkline(values []float64, max float64) string {
+	if max <= 0 {
+		for _, v := range values {
+			if v > max {
+				max = v
+			}
+		}
+	}
+	if max <= 0 {
+		max = 1
+	}
+
+	var b strings.Builder
+	for _, v := range values {
+		i := int(v / max * float64(len(sparkBlocks)-1))
+		if i < 0 {
+			i = 0
+		}
+		if i >= len(sparkBlocks) {
+			i = len(sparkBlocks) - 1
+		}
+		b.WriteRune(sparkBlocks[i])
+	}
+	return b.String()
+}
+
+// formatETA formats a forecast duration roughly, e.g. "~3 days"
+func formatETA(d time.Duration) string {
+	switch {
+	case d < time.Hour:
+		return fmt.Sprintf("~%d min", int(d.Minutes())+1)
+	case d < 48*time.Hour:
+		return fmt.Sprintf("~%d hours", int(d.Hours()+0.5))
 	}
-	div, exp := uint64(unit), 0
-	for n := b / unit; n >= unit; n /= unit {
-		div *= unit
-		exp++
+	return fmt.Sprintf("~%d days", int(d.Hours()/24+0.5))
+}
+
+// formatRate formats a byte and packet rate, or a placeholder until the
+// collector has two samples to compute rates from
+func (ui *UI) formatRate(net metrics.NetworkStat, bytesPerSec, packetsPerSec float64) string {
+	if !net.HasRates {
+		return "–"
 	}
-	return fmt.Sprintf("%.1f %ciB", float64(b)/float64(div), "KMGTPE"[exp])
+	return fmt.Sprintf("%s (%.0f pkts/s)", ui.units.Rate(bytesPerSec), packetsPerSec)
 }
 
 // CPUView returns the CPU metrics view
@@ -372,6 +1340,11 @@ func (ui *UI) NetworkView() *tview.TextView {
 	return ui.networkView
 }
 
+// InterfaceView returns the interface detail view
+func (ui *UI) InterfaceView() *tview.TextView {
+	return ui.ifaceView
+}
+
 // App returns the tview application
 func (ui *UI) App() *tview.Application {
 	return ui.app
@@ -384,21 +1357,12 @@ func (ui *UI) ShowGoRuntime() bool {
 
 // RenderMetrics renders the metrics in the UI
 func (ui *UI) RenderMetrics(metric metrics.Metric) {
-	ui.renderMetrics(metric)
+	ui.renderMetrics(metric, metric)
 }
 
-// FormatBytes formats bytes into a human-readable string
+// FormatBytes formats bytes into a human-readable string, in IEC units
 func FormatBytes(bytes uint64) string {
-	const unit = 1024
-	if bytes < unit {
-		return fmt.Sprintf("%d B", bytes)
-	}
-	div, exp := int64(unit), 0
-	for n := bytes / unit; n >= unit; n /= unit {
-		div *= unit
-		exp++
-	}
-	return fmt.Sprintf("%.1f %ciB", float64(bytes)/float64(div), "KMGTPE"[exp])
+	return units.Format{}.Bytes(float64(bytes))
 }
 
 // CreateProgressBar creates a progress bar string
@@ -412,6 +1376,105 @@ func CreateProgressBar(percent float64, width int) string {
 	return fmt.Sprintf("[green]%s[white]%s", bar, empty)
 }
 
+// SetPinned sets the disks and interfaces that are always shown first, in
+// the given order. Entries may be raw identifiers or display names.
+func (ui *UI) SetPinned(disks, interfaces []string) {
+	ui.pinnedDisks = disks
+	ui.pinnedInterfaces = interfaces
+}
+
+// SetLowMemory switches the UI to the constrained-memory mode: metrics are
+// collected at the refresh interval instead of every 100ms, at most one
+// sample is buffered and snapshots keep two minutes of history. It must be
+// called before Start.
+func (ui *UI) SetLowMemory(enabled bool) {
+	ui.lowMemory = enabled
+	if enabled {
+		ui.metricsChan = make(chan metrics.Metric, 1)
+	}
+}
+
+// SetAlertHistory lists the most recent alerts from h in an alerts pane
+func (ui *UI) SetAlertHistory(h *rules.History) {
+	ui.alertHistory = h
+}
+
+// SetUnits sets how byte counts and network rates are displayed
+func (ui *UI) SetUnits(format units.Format) {
+	if format.Decimal == "" {
+		format.Decimal = ui.units.Decimal
+	}
+	ui.units = format
+}
+
+// SetLocation sets the time zone clock times are shown in
+func (ui *UI) SetLocation(loc *time.Location) {
+	ui.location = loc
+}
+
+// SetColorMode sets the color mode, one of ColorNormal, ColorNone and
+// ColorHighContrast
+func (ui *UI) SetColorMode(mode string) {
+	ui.colorMode = mode
+}
+
+// SetLocale translates the UI's labels and formats its numbers with p
+func (ui *UI) SetLocale(p *i18n.Printer) {
+	ui.tr = p
+	ui.units.Decimal = p.Decimal()
+	ui.setTitles()
+}
+
+// SetCadence sets the render rate and pane refresh intervals
+func (ui *UI) SetCadence(c Cadence) {
+	if c.MaxFPS <= 0 {
+		c.MaxFPS = defaultMaxFPS
+	}
+	if c.Memory <= 0 {
+		c.Memory = defaultMemoryInterval
+	}
+	if c.Network <= 0 {
+		c.Network = defaultNetworkInterval
+	}
+	if c.Interfaces <= 0 {
+		c.Interfaces = defaultInterfaceInterval
+	}
+	ui.cadence = c
+	ui.smoother = smoother{window: c.Smoothing}
+	ui.setTitles()
+}
+
+// setTitles sets the translated pane titles, with the memory and network
+// panes' refresh intervals
+func (ui *UI) setTitles() {
+	for view, title := range ui.titles {
+		switch view {
+		case ui.memoryView:
+			view.SetTitle(ui.tr.Sprintf(title, ui.cadence.Memory))
+		case ui.networkView:
+			view.SetTitle(ui.tr.Sprintf(title, ui.cadence.Network))
+		default:
+			view.SetTitle(ui.tr.T(title))
+		}
+	}
+}
+
+// SetCrashDir sets the directory crash dumps are written to
+func (ui *UI) SetCrashDir(dir string) {
+	ui.crashDir = dir
+}
+
+// SetRecorder records the session as drawn, and the samples drawn, with r.
+// Start closes r when the UI quits.
+func (ui *UI) SetRecorder(r *Recorder) {
+	ui.recorder = r
+}
+
+// SetSnapshotDir sets where the 's' key saves snapshots
+func (ui *UI) SetSnapshotDir(dir string) {
+	ui.snapshotDir = dir
+}
+
 // SetApp sets the tview application
 func (ui *UI) SetApp(app *tview.Application) {
 	ui.app = app
@@ -0,0 +1,215 @@
+package tui
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+
+	"github.com/j-raghavan/godash/internal/metrics"
+)
+
+// CastHeader is the first line of an asciinema v2 cast file
+type CastHeader struct {
+	Version   int               `json:"version"`
+	Width     int               `json:"width"`
+	Height    int               `json:"height"`
+	Timestamp int64             `json:"timestamp"`
+	Title     string            `json:"title,omitempty"`
+	Env       map[string]string `json:"env,omitempty"`
+}
+
+// Recorder writes the session as drawn to an asciinema v2 cast file, which
+// "asciinema play" replays, and the samples drawn to a JSON lines file next
+// to it, in the schema of "godash agent". Each frame only rewrites the rows
+// that changed since the previous one.
+type Recorder struct {
+	castPath    string
+	cast        *os.File
+	castW       *bufio.Writer
+	metrics     *os.File
+	metricsW    *bufio.Writer
+	start       time.Time
+	title       string
+	width, rows int
+	prev        []string // rows of the previous frame, as escape sequences
+	err         error    // first write error, reported by Close
+}
+
+// NewRecorder creates a Recorder writing the cast to path and the samples
+// to path with its extension replaced by .jsonl
+func NewRecorder(path, title string) (*Recorder, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create recording directory: %w", err)
+	}
+	cast, err := os.OpenFile(path, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create recording: %w", err)
+	}
+	metricsPath := MetricsRecordingPath(path)
+	samples, err := os.OpenFile(metricsPath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o600)
+	if err != nil {
+		cast.Close()
+		return nil, fmt.Errorf("failed to create metric recording: %w", err)
+	}
+	return &Recorder{
+		castPath: path,
+		cast:     cast,
+		castW:    bufio.NewWriter(cast),
+		metrics:  samples,
+		metricsW: bufio.NewWriter(samples),
+		title:    title,
+	}, nil
+}
+
+// MetricsRecordingPath returns where a Recorder writing the cast to path
+// writes the samples
+func MetricsRecordingPath(path string) string {
+	return strings.TrimSuffix(path, filepath.Ext(path)) + ".jsonl"
+}
+
+// Path returns the path of the cast file
+func (r *Recorder) Path() string {
+	return r.castPath
+}
+
+// Frame records what screen shows at t. The header is written with the
+// first frame, when the terminal size is known; later size changes are
+// recorded as resize events.
+func (r *Recorder) Frame(screen tcell.Screen, t time.Time) {
+	width, rows := screen.Size()
+	if r.start.IsZero() {
+		r.start = t
+		r.width, r.rows = width, rows
+		r.writeJSON(r.castW, CastHeader{
+			Version: 2, Width: width, Height: rows, Timestamp: t.Unix(), Title: r.title,
+			Env: map[string]string{"TERM": "xterm-256color", "SHELL": os.Getenv("SHELL")},
+		})
+	}
+	elapsed := t.Sub(r.start).Seconds()
+	if width != r.width || rows != r.rows {
+		r.width, r.rows = width, rows
+		r.prev = nil
+		r.writeJSON(r.castW, []any{elapsed, "r", fmt.Sprintf("%dx%d", width, rows)})
+	}
+
+	var out strings.Builder
+	if r.prev == nil {
+		out.WriteString("\x1b[0m\x1b[2J")
+	}
+	frame := make([]string, rows)
+	for y := 0; y < rows; y++ {
+		frame[y] = encodeRow(screen, y, width)
+		if y < len(r.prev) && r.prev[y] == frame[y] {
+			continue
+		}
+		fmt.Fprintf(&out, "\x1b[%d;1H%s\x1b[0m", y+1, frame[y])
+	}
+	r.prev = frame
+	if out.Len() > 0 {
+		r.writeJSON(r.castW, []any{elapsed, "o", out.String()})
+	}
+}
+
+// Sample records a sample drawn
+func (r *Recorder) Sample(metric metrics.Metric) {
+	r.writeJSON(r.metricsW, metric)
+}
+
+// writeJSON writes v to w as one line, keeping the first error
+func (r *Recorder) writeJSON(w *bufio.Writer, v any) {
+	if r.err != nil {
+		return
+	}
+	data, err := json.Marshal(v)
+	if err == nil {
+		data = append(data, '\n')
+		_, err = w.Write(data)
+	}
+	if err != nil {
+		r.err = fmt.Errorf("failed to write recording: %w", err)
+	}
+}
+
+// Close flushes and closes both files, returning the first error
+func (r *Recorder) Close() error {
+	err := r.err
+	for _, f := range []struct {
+		w    *bufio.Writer
+		file *os.File
+	}{{r.castW, r.cast}, {r.metricsW, r.metrics}} {
+		if ferr := f.w.Flush(); err == nil && ferr != nil {
+			err = fmt.Errorf("failed to write recording: %w", ferr)
+		}
+		if cerr := f.file.Close(); err == nil && cerr != nil {
+			err = fmt.Errorf("failed to close recording: %w", cerr)
+		}
+	}
+	return err
+}
+
+// encodeRow returns row y of screen as text with SGR escape sequences for
+// its styles
+func encodeRow(screen tcell.Screen, y, width int) string {
+	var b strings.Builder
+	style := tcell.StyleDefault
+	for x := 0; x < width; {
+		primary, combining, cellStyle, w := screen.GetContent(x, y)
+		if cellStyle != style {
+			b.WriteString(sgr(cellStyle))
+			style = cellStyle
+		}
+		if primary == 0 {
+			primary = ' '
+		}
+		b.WriteRune(primary)
+		for _, r := range combining {
+			b.WriteRune(r)
+		}
+		x += max(w, 1)
+	}
+	return b.String()
+}
+
+// sgr returns the escape sequence selecting style from scratch
+func sgr(style tcell.Style) string {
+	fg, bg, attrs := style.Decompose()
+	params := []string{"0"}
+	for _, a := range []struct {
+		mask tcell.AttrMask
+		code string
+	}{
+		{tcell.AttrBold, "1"}, {tcell.AttrDim, "2"}, {tcell.AttrItalic, "3"}, {tcell.AttrUnderline, "4"},
+		{tcell.AttrBlink, "5"}, {tcell.AttrReverse, "7"}, {tcell.AttrStrikeThrough, "9"},
+	} {
+		if attrs&a.mask != 0 {
+			params = append(params, a.code)
+		}
+	}
+	if code := colorParam(fg, "38"); code != "" {
+		params = append(params, code)
+	}
+	if code := colorParam(bg, "48"); code != "" {
+		params = append(params, code)
+	}
+	return "\x1b[" + strings.Join(params, ";") + "m"
+}
+
+// colorParam returns the SGR parameter setting c as the foreground (base
+// "38") or background ("48") color, or "" for the terminal's default
+func colorParam(c tcell.Color, base string) string {
+	switch {
+	case !c.Valid():
+		return ""
+	case c.IsRGB():
+		r, g, b := c.RGB()
+		return fmt.Sprintf("%s;2;%d;%d;%d", base, r, g, b)
+	}
+	return base + ";5;" + strconv.Itoa(int(c-tcell.ColorBlack))
+}
@@ -0,0 +1,214 @@
+package tui
+
+import (
+	"regexp"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+
+	"github.com/j-raghavan/godash/internal/metrics"
+)
+
+// searchMatch is an item matching the search filter: a disk, RAID array,
+// interface, VM, watched process, port or certificate
+type searchMatch struct {
+	kind string // a metrics.Entity kind, "raid", "port" or "cert"
+	name string
+}
+
+// compileFilter compiles a search query as a case-insensitive regular
+// expression, or as a literal substring if it is not a valid one
+func compileFilter(query string) *regexp.Regexp {
+	if re, err := regexp.Compile("(?i)" + query); err == nil {
+		return re
+	}
+	return regexp.MustCompile("(?i)" + regexp.QuoteMeta(query))
+}
+
+// startSearch opens the search prompt in the status bar with the current
+// query
+func (ui *UI) startSearch() {
+	ui.searching = true
+	if ui.filter == nil {
+		ui.searchInput = ""
+	}
+	ui.renderStatusBar(ui.lastMetric)
+}
+
+// searchKey edits the query while the search prompt is open. The panes are
+// filtered as the query is typed; Enter keeps the filter and Esc clears it.
+func (ui *UI) searchKey(event *tcell.EventKey) *tcell.EventKey {
+	switch event.Key() {
+	case tcell.KeyEnter:
+		ui.searching = false
+		ui.nextMatch(0)
+	case tcell.KeyEscape:
+		ui.searching = false
+		ui.searchInput = ""
+		ui.setFilter("")
+	case tcell.KeyBackspace, tcell.KeyBackspace2:
+		if runes := []rune(ui.searchInput); len(runes) > 0 {
+			ui.setFilter(string(runes[:len(runes)-1]))
+		}
+	case tcell.KeyRune:
+		ui.setFilter(ui.searchInput + string(event.Rune()))
+	}
+	ui.renderStatusBar(ui.lastMetric)
+	return nil
+}
+
+// setFilter filters the panes by query, or shows everything again if it is
+// empty
+func (ui *UI) setFilter(query string) {
+	ui.searchInput = query
+	ui.filter = nil
+	if query != "" {
+		ui.filter = compileFilter(query)
+	}
+	ui.matchIndex = 0
+	ui.currentMatch = searchMatch{}
+	ui.diskPage = 0
+	ui.lastInterfaceUpdate = time.Time{}
+	ui.lastNetworkUpdate = time.Time{}
+}
+
+// filterMatches reports whether any of names matches the search filter, or
+// true when there is none
+func (ui *UI) filterMatches(names ...string) bool {
+	if ui.filter == nil {
+		return true
+	}
+	for _, name := range names {
+		if name != "" && ui.filter.MatchString(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// highlight marks the parts of s matching the search filter in reverse
+// video. s must not contain color tags.
+func (ui *UI) highlight(s string) string {
+	if ui.filter == nil {
+		return s
+	}
+	return ui.filter.ReplaceAllStringFunc(s, func(m string) string {
+		return "[::r]" + m + "[::-]"
+	})
+}
+
+// searchMarker returns the marker of the current match, if the item of kind
+// and name is it
+func (ui *UI) searchMarker(kind, name string) string {
+	if ui.filter != nil && ui.currentMatch == (searchMatch{kind, name}) {
+		return "▸ "
+	}
+	return ""
+}
+
+// searchMatches returns the items of metric matching the search filter, in
+// the order the panes list them
+func (ui *UI) searchMatches(metric metrics.Metric) []searchMatch {
+	if ui.filter == nil {
+		return nil
+	}
+	var matches []searchMatch
+	for _, disk := range metrics.OrderDisks(metric.Disk, ui.pinnedDisks) {
+		if ui.filterMatches(disk.Path, disk.Label, disk.Device) {
+			matches = append(matches, searchMatch{metrics.EntityDisk, disk.Path})
+		}
+	}
+	for _, array := range metric.RAID {
+		if ui.filterMatches(array.Name) {
+			matches = append(matches, searchMatch{"raid", array.Name})
+		}
+	}
+	for _, net := range metrics.OrderInterfaces(metric.Network, ui.pinnedInterfaces) {
+		if ui.filterMatches(net.Interface, net.Label) {
+			matches = append(matches, searchMatch{metrics.EntityInterface, net.Interface})
+		}
+	}
+	for _, vm := range metric.VMs {
+		if ui.filterMatches(vm.Name) {
+			matches = append(matches, searchMatch{metrics.EntityVM, vm.Name})
+		}
+	}
+	for _, p := range metric.Processes {
+		if ui.filterMatches(p.Name) {
+			matches = append(matches, searchMatch{metrics.EntityProcess, p.Name})
+		}
+	}
+	for _, p := range metric.Ports {
+		if ui.filterMatches(p.Name()) {
+			matches = append(matches, searchMatch{"port", p.Name()})
+		}
+	}
+	for _, cert := range metric.Certs {
+		if ui.filterMatches(cert.Name) {
+			matches = append(matches, searchMatch{"cert", cert.Name})
+		}
+	}
+	return matches
+}
+
+// nextMatch moves the current match by delta, wrapping around, brings it
+// into view and names it in the status bar. A delta of 0 goes to the first
+// match.
+func (ui *UI) nextMatch(delta int) {
+	matches := ui.searchMatches(ui.lastMetric)
+	if len(matches) == 0 {
+		ui.currentMatch = searchMatch{}
+		if ui.filter != nil {
+			ui.notice = ui.tr.Sprintf("[red]no match for %s[white]", tview.Escape(ui.searchInput))
+			ui.noticeUntil = time.Now().Add(5 * time.Second)
+		}
+		return
+	}
+	if delta == 0 || ui.currentMatch == (searchMatch{}) {
+		ui.matchIndex = 0
+	} else {
+		ui.matchIndex = ((ui.matchIndex+delta)%len(matches) + len(matches)) % len(matches)
+	}
+	ui.currentMatch = matches[ui.matchIndex]
+	ui.revealMatch = true
+	if ui.currentMatch.kind == metrics.EntityInterface {
+		ui.selectedInterface = ui.currentMatch.name
+	}
+	ui.lastInterfaceUpdate = time.Time{}
+	ui.lastNetworkUpdate = time.Time{}
+	ui.notice = ui.tr.Sprintf("[green]match %d/%d: %s %s[white]",
+		ui.matchIndex+1, len(matches), ui.currentMatch.kind, tview.Escape(ui.currentMatch.name))
+	ui.noticeUntil = time.Now().Add(5 * time.Second)
+}
+
+// revealInterface makes the current match one of the interfaces shown, if
+// it is an interface
+func (ui *UI) revealInterface() {
+	if ui.filter == nil || ui.currentMatch.kind != metrics.EntityInterface {
+		return
+	}
+	for _, name := range ui.topInterfaces {
+		if name == ui.currentMatch.name {
+			return
+		}
+	}
+	if len(ui.topInterfaces) == 3 {
+		ui.topInterfaces[2] = ui.currentMatch.name
+		return
+	}
+	ui.topInterfaces = append(ui.topInterfaces, ui.currentMatch.name)
+}
+
+// searchStatus returns the search prompt or the active filter for the
+// status bar, or "" when there is no search
+func (ui *UI) searchStatus(metric metrics.Metric) string {
+	switch {
+	case ui.searching:
+		return "[yellow]/" + tview.Escape(ui.searchInput) + "_[white]"
+	case ui.filter != nil:
+		return ui.tr.Sprintf("[yellow]/%s: %d matches, 'n'/'N' next/previous, Esc clears[white]",
+			tview.Escape(ui.searchInput), len(ui.searchMatches(metric)))
+	}
+	return ""
+}
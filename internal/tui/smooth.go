@@ -0,0 +1,121 @@
+package tui
+
+import (
+	"math"
+	"time"
+
+	"github.com/j-raghavan/godash/internal/metrics"
+)
+
+// EWMA is an exponentially weighted moving average over time: a sample
+// older than Window weighs 1/e of a new one, however often samples come
+type EWMA struct {
+	Window time.Duration
+	value  float64
+	at     time.Time
+	set    bool
+}
+
+// Update adds the sample v taken at t and returns the average
+func (e *EWMA) Update(v float64, t time.Time) float64 {
+	dt := t.Sub(e.at)
+	switch {
+	case !e.set || e.Window <= 0:
+		e.value, e.set = v, true
+	case dt > 0:
+		alpha := 1 - math.Exp(-float64(dt)/float64(e.Window))
+		e.value += alpha * (v - e.value)
+	}
+	e.at = t
+	return e.value
+}
+
+// ifaceAverages smooths the rates of one interface
+type ifaceAverages struct {
+	rx, tx, rxPackets, txPackets EWMA
+}
+
+// smoother averages the CPU and network values shown, so that they do not
+// flicker at fast sampling. Snapshots and exports keep the raw samples.
+type smoother struct {
+	window time.Duration
+	cores  []EWMA
+	total  EWMA
+	states [8]EWMA // in CPUStateStat field order
+	ifaces map[string]*ifaceAverages
+}
+
+// observe adds metric to the averages. It runs for every sample, also those
+// coalesced between frames.
+func (s *smoother) observe(metric metrics.Metric) {
+	if s.window <= 0 {
+		return
+	}
+	t := metric.Timestamp
+	for len(s.cores) < len(metric.CPU) {
+		s.cores = append(s.cores, EWMA{Window: s.window})
+	}
+	for i, pct := range metric.CPU {
+		s.cores[i].Update(pct, t)
+	}
+	s.total.Window = s.window
+	s.total.Update(metric.CPUTotal, t)
+	for i, v := range cpuStateFields(&metric.CPUStates) {
+		s.states[i].Window = s.window
+		s.states[i].Update(*v, t)
+	}
+	if s.ifaces == nil {
+		s.ifaces = make(map[string]*ifaceAverages)
+	}
+	for _, net := range metric.Network {
+		if !net.HasRates {
+			continue
+		}
+		avg, ok := s.ifaces[net.Interface]
+		if !ok {
+			avg = &ifaceAverages{
+				rx: EWMA{Window: s.window}, tx: EWMA{Window: s.window},
+				rxPackets: EWMA{Window: s.window}, txPackets: EWMA{Window: s.window},
+			}
+			s.ifaces[net.Interface] = avg
+		}
+		avg.rx.Update(net.RxBytesPerSec, t)
+		avg.tx.Update(net.TxBytesPerSec, t)
+		avg.rxPackets.Update(net.RxPacketsPerSec, t)
+		avg.txPackets.Update(net.TxPacketsPerSec, t)
+	}
+}
+
+// apply returns a copy of metric showing the averages instead of its CPU
+// and network values. metric itself is left untouched.
+func (s *smoother) apply(metric metrics.Metric) metrics.Metric {
+	if s.window <= 0 {
+		return metric
+	}
+	cpu := make([]float64, len(metric.CPU))
+	for i := range cpu {
+		cpu[i] = s.cores[i].value
+	}
+	metric.CPU = cpu
+	metric.CPUTotal = s.total.value
+	for i, v := range cpuStateFields(&metric.CPUStates) {
+		*v = s.states[i].value
+	}
+	network := make([]metrics.NetworkStat, len(metric.Network))
+	copy(network, metric.Network)
+	for i := range network {
+		if avg, ok := s.ifaces[network[i].Interface]; ok && network[i].HasRates {
+			network[i].RxBytesPerSec = avg.rx.value
+			network[i].TxBytesPerSec = avg.tx.value
+			network[i].RxPacketsPerSec = avg.rxPackets.value
+			network[i].TxPacketsPerSec = avg.txPackets.value
+		}
+	}
+	metric.Network = network
+	return metric
+}
+
+// cpuStateFields returns pointers to the fields of s
+func cpuStateFields(s *metrics.CPUStateStat) [8]*float64 {
+	return [8]*float64{&s.User, &s.Nice, &s.System, &s.IOWait, &s.Steal, &s.IRQ, &s.SoftIRQ, &s.Idle}
+}
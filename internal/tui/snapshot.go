@@ -0,0 +1,217 @@
+package tui
+
+import (
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/j-raghavan/godash/internal/metrics"
+)
+
+// The TUI keeps historyLength points, one per historySpacing, for
+// snapshots, or lowMemoryHistoryLength in low-memory mode
+const (
+	historyLength          = 600
+	lowMemoryHistoryLength = 120
+	historySpacing         = time.Second
+)
+
+// Chart layout and series colors
+const (
+	chartWidth   = 1200
+	chartHeight  = 600
+	chartPadding = 20
+)
+
+var (
+	chartBackground = color.RGBA{0x1e, 0x1e, 0x2e, 0xff}
+	chartGrid       = color.RGBA{0x45, 0x47, 0x5a, 0xff}
+	chartCPU        = color.RGBA{0xa6, 0xe3, 0xa1, 0xff} // green
+	chartMemory     = color.RGBA{0x89, 0xb4, 0xfa, 0xff} // blue
+	chartRx         = color.RGBA{0x94, 0xe2, 0xd5, 0xff} // cyan
+	chartTx         = color.RGBA{0xf5, 0xc2, 0xe7, 0xff} // pink
+)
+
+// HistoryPoint is one downsampled sample kept for snapshots
+type HistoryPoint struct {
+	Time          time.Time `json:"time"`
+	CPUPercent    float64   `json:"cpu_percent"`
+	MemoryPercent float64   `json:"memory_percent"`
+	RxBytesPerSec float64   `json:"rx_bytes_per_sec"`
+	TxBytesPerSec float64   `json:"tx_bytes_per_sec"`
+}
+
+// Snapshot is the JSON saved with the 's' key: the current sample and the
+// recent history
+type Snapshot struct {
+	Metric  metrics.Metric `json:"metric"`
+	History []HistoryPoint `json:"history"`
+}
+
+// recordHistory appends metric to the snapshot history, at most one point
+// per historySpacing
+func (ui *UI) recordHistory(metric metrics.Metric) {
+	ui.lastMetric = metric
+	ui.recordRecent(metric)
+	if ui.recorder != nil {
+		ui.recorder.Sample(metric)
+	}
+	if n := len(ui.history); n > 0 && metric.Timestamp.Sub(ui.history[n-1].Time) < historySpacing {
+		return
+	}
+	ui.recordInterfaceRates(metric)
+	point := HistoryPoint{
+		Time:          metric.Timestamp,
+		CPUPercent:    metric.CPUTotal,
+		MemoryPercent: metric.Memory.UsedPercentage,
+	}
+	for _, net := range metric.Network {
+		point.RxBytesPerSec += net.RxBytesPerSec
+		point.TxBytesPerSec += net.TxBytesPerSec
+	}
+	ui.history = append(ui.history, point)
+	limit := historyLength
+	if ui.lowMemory {
+		limit = lowMemoryHistoryLength
+	}
+	if len(ui.history) > limit {
+		ui.history = ui.history[len(ui.history)-limit:]
+	}
+}
+
+// saveSnapshot writes the current snapshot and reports the outcome in the
+// status bar
+func (ui *UI) saveSnapshot() {
+	snapshot := Snapshot{Metric: ui.lastMetric, History: ui.history}
+	pngPath, _, err := SaveSnapshot(ui.snapshotDir, snapshot, time.Now())
+	if err != nil {
+		ui.notice = ui.tr.Sprintf("[red]snapshot failed: %v[white]", err)
+	} else {
+		ui.notice = ui.tr.Sprintf("[green]saved %s[white]", pngPath)
+	}
+	ui.noticeUntil = time.Now().Add(5 * time.Second)
+}
+
+// SaveSnapshot writes snapshot into dir as godash-<time>.json and a chart of
+// its history as godash-<time>.png, returning both paths
+func SaveSnapshot(dir string, snapshot Snapshot, now time.Time) (pngPath, jsonPath string, err error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", "", fmt.Errorf("failed to create snapshot directory: %w", err)
+	}
+	base := filepath.Join(dir, "godash-"+now.Format("20060102-150405"))
+	pngPath, jsonPath = base+".png", base+".json"
+
+	data, err := json.MarshalIndent(snapshot, "", "  ")
+	if err != nil {
+		return "", "", fmt.Errorf("failed to marshal snapshot: %w", err)
+	}
+	if err := os.WriteFile(jsonPath, data, 0o644); err != nil {
+		return "", "", fmt.Errorf("failed to write snapshot: %w", err)
+	}
+
+	f, err := os.Create(pngPath)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to write chart: %w", err)
+	}
+	defer f.Close()
+	if err := png.Encode(f, RenderChart(snapshot.History)); err != nil {
+		return "", "", fmt.Errorf("failed to encode chart: %w", err)
+	}
+	return pngPath, jsonPath, nil
+}
+
+// RenderChart draws history as two stacked line charts: CPU (green) and
+// memory (blue) percentages on top, received (cyan) and transmitted (pink)
+// bytes per second below, scaled to their peak
+func RenderChart(history []HistoryPoint) image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, chartWidth, chartHeight))
+	draw.Draw(img, img.Bounds(), &image.Uniform{chartBackground}, image.Point{}, draw.Src)
+
+	panelHeight := (chartHeight - 3*chartPadding) / 2
+	top := image.Rect(chartPadding, chartPadding, chartWidth-chartPadding, chartPadding+panelHeight)
+	bottom := top.Add(image.Pt(0, panelHeight+chartPadding))
+	for _, panel := range []image.Rectangle{top, bottom} {
+		for i := 0; i <= 4; i++ {
+			y := panel.Max.Y - i*panel.Dy()/4
+			drawLine(img, panel.Min.X, y, panel.Max.X, y, chartGrid)
+		}
+	}
+
+	var peak float64
+	for _, p := range history {
+		peak = max(peak, p.RxBytesPerSec, p.TxBytesPerSec)
+	}
+	series := []struct {
+		panel image.Rectangle
+		max   float64
+		color color.RGBA
+		value func(HistoryPoint) float64
+	}{
+		{top, 100, chartCPU, func(p HistoryPoint) float64 { return p.CPUPercent }},
+		{top, 100, chartMemory, func(p HistoryPoint) float64 { return p.MemoryPercent }},
+		{bottom, peak, chartRx, func(p HistoryPoint) float64 { return p.RxBytesPerSec }},
+		{bottom, peak, chartTx, func(p HistoryPoint) float64 { return p.TxBytesPerSec }},
+	}
+	if len(history) < 2 {
+		return img
+	}
+	for _, s := range series {
+		if s.max <= 0 {
+			continue
+		}
+		point := func(i int) (int, int) {
+			x := s.panel.Min.X + i*(s.panel.Dx()-1)/(len(history)-1)
+			v := min(max(s.value(history[i])/s.max, 0), 1)
+			return x, s.panel.Max.Y - int(v*float64(s.panel.Dy()-1))
+		}
+		x0, y0 := point(0)
+		for i := 1; i < len(history); i++ {
+			x1, y1 := point(i)
+			drawLine(img, x0, y0, x1, y1, s.color)
+			x0, y0 = x1, y1
+		}
+	}
+	return img
+}
+
+// drawLine draws a line from (x0, y0) to (x1, y1) with Bresenham's algorithm
+func drawLine(img *image.RGBA, x0, y0, x1, y1 int, c color.RGBA) {
+	dx, dy := abs(x1-x0), -abs(y1-y0)
+	sx, sy := 1, 1
+	if x0 > x1 {
+		sx = -1
+	}
+	if y0 > y1 {
+		sy = -1
+	}
+	e := dx + dy
+	for {
+		img.SetRGBA(x0, y0, c)
+		if x0 == x1 && y0 == y1 {
+			return
+		}
+		e2 := 2 * e
+		if e2 >= dy {
+			e += dy
+			x0 += sx
+		}
+		if e2 <= dx {
+			e += dx
+			y0 += sy
+		}
+	}
+}
+
+// abs returns the absolute value of n
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
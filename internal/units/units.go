@@ -0,0 +1,169 @@
+// Package units formats byte counts and rates for display, in IEC (KiB,
+// MiB, powers of 1024) or SI (kB, MB, powers of 1000) units, with network
+// rates optionally in bits per second, and parses sizes in config values
+// and rule thresholds.
+package units
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Unit systems
+const (
+	IEC = "iec"
+	SI  = "si"
+)
+
+// Format selects how values are displayed. The zero value uses IEC units
+// and bytes per second.
+type Format struct {
+	SI   bool // powers of 1000 (kB, MB) instead of 1024 (KiB, MiB)
+	Bits bool // network rates in bits per second (kbit/s, Mbit/s)
+	// Decimal is the decimal separator, "." when empty
+	Decimal string
+}
+
+// Parse returns the format for a unit system name, "iec" (also used when
+// empty) or "si", and whether rates are shown in bits
+func Parse(system string, bits bool) (Format, error) {
+	switch strings.ToLower(system) {
+	case "", IEC:
+		return Format{Bits: bits}, nil
+	case SI:
+		return Format{SI: true, Bits: bits}, nil
+	}
+	return Format{}, fmt.Errorf("invalid units %q: must be %q or %q", system, IEC, SI)
+}
+
+// String returns the unit system's name, with "+bits" when rates are in bits
+func (f Format) String() string {
+	name := IEC
+	if f.SI {
+		name = SI
+	}
+	if f.Bits {
+		name += "+bits"
+	}
+	return name
+}
+
+// scale divides v by the system's base until it is below it, returning the
+// scaled value and its prefix index (0 for none, 1 for kilo, ...)
+func (f Format) scale(v float64) (float64, int) {
+	base := 1024.0
+	if f.SI {
+		base = 1000
+	}
+	exp := 0
+	for v >= base && exp < 6 {
+		v /= base
+		exp++
+	}
+	return v, exp
+}
+
+// number formats v with the given number of decimals and the separator
+func (f Format) number(v float64, decimals int) string {
+	s := strconv.FormatFloat(v, 'f', decimals, 64)
+	if f.Decimal != "" && f.Decimal != "." {
+		s = strings.Replace(s, ".", f.Decimal, 1)
+	}
+	return s
+}
+
+// prefix returns the unit prefix for exp, e.g. "Ki" or "k" for 1
+func (f Format) prefix(exp int) string {
+	if exp == 0 {
+		return ""
+	}
+	if f.SI {
+		return string("kMGTPE"[exp-1])
+	}
+	return string("KMGTPE"[exp-1]) + "i"
+}
+
+// Bytes formats a byte count, e.g. "1.5 KiB" or "1.5 kB"
+func (f Format) Bytes(b float64) string {
+	v, exp := f.scale(b)
+	if exp == 0 {
+		return f.number(b, 0) + " B"
+	}
+	return f.number(v, 1) + " " + f.prefix(exp) + "B"
+}
+
+// Compact formats a byte count tersely, without a space and with one
+// decimal below 10, e.g. "1.5KB" or "300KB". IEC values use the short
+// K/M/G prefixes, like ls -h and top.
+func (f Format) Compact(b float64) string {
+	v, exp := f.scale(b)
+	if exp == 0 {
+		return f.number(b, 0) + "B"
+	}
+	prefix := string("KMGTPE"[exp-1])
+	if f.SI {
+		prefix = f.prefix(exp)
+	}
+	if v < 10 {
+		return f.number(v, 1) + prefix + "B"
+	}
+	return f.number(v, 0) + prefix + "B"
+}
+
+// Rate formats a network rate given in bytes per second, e.g. "1.5 MiB/s",
+// or "12.6 Mbit/s" in bits. Bit rates always use SI prefixes, as link
+// speeds do.
+func (f Format) Rate(bytesPerSec float64) string {
+	if !f.Bits {
+		return f.Bytes(bytesPerSec) + "/s"
+	}
+	v, exp := Format{SI: true}.scale(bytesPerSec * 8)
+	if exp == 0 {
+		return f.number(v, 0) + " bit/s"
+	}
+	return f.number(v, 1) + " " + Format{SI: true}.prefix(exp) + "bit/s"
+}
+
+// CompactRate formats a network rate tersely, e.g. "1.5MB/s" or "12Mb/s"
+func (f Format) CompactRate(bytesPerSec float64) string {
+	if !f.Bits {
+		return f.Compact(bytesPerSec) + "/s"
+	}
+	v, exp := Format{SI: true}.scale(bytesPerSec * 8)
+	prefix := Format{SI: true}.prefix(exp)
+	if v < 10 && exp > 0 {
+		return f.number(v, 1) + prefix + "b/s"
+	}
+	return f.number(v, 0) + prefix + "b/s"
+}
+
+// sizeSuffixes are the multipliers of size suffixes
+var sizeSuffixes = map[byte]float64{'K': 1 << 10, 'M': 1 << 20, 'G': 1 << 30, 'T': 1 << 40}
+
+// ParseSize parses a size such as "512M", "1.5G", "1GiB" or "-2k".
+// Suffixes are binary multiples in either case; a bare number is in bytes.
+func ParseSize(s string) (float64, error) {
+	value := strings.TrimSuffix(strings.TrimSuffix(strings.ToUpper(strings.TrimSpace(s)), "B"), "I")
+	multiplier := 1.0
+	if value != "" {
+		if m, ok := sizeSuffixes[value[len(value)-1]]; ok {
+			value, multiplier = value[:len(value)-1], m
+		}
+	}
+	n, err := strconv.ParseFloat(value, 64)
+	if err != nil || math.IsNaN(n) || math.IsInf(n, 0) {
+		return 0, fmt.Errorf("invalid size %q", s)
+	}
+	return n * multiplier, nil
+}
+
+// ParseBytes parses a byte count with ParseSize, rejecting negative ones
+func ParseBytes(s string) (uint64, error) {
+	n, err := ParseSize(s)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid size %q", s)
+	}
+	return uint64(n), nil
+}
@@ -2,17 +2,27 @@ package cmd_test
 
 import (
 	"bytes"
+	"encoding/json"
+	"errors"
 	"io"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 
 	"github.com/j-raghavan/godash/cmd/godash/core"
 	"github.com/j-raghavan/godash/internal/config"
+	"github.com/j-raghavan/godash/internal/metrics"
 )
 
-func TestRunMonitor(t *testing.T) {
+func TestRunServer(t *testing.T) {
 	// Setup
 	old := os.Stdout
 	r, w, _ := os.Pipe()
@@ -20,10 +30,11 @@ func TestRunMonitor(t *testing.T) {
 
 	// Call the function directly for testing
 	testConfig := config.Config{
-		RefreshInterval: 10,
+		RefreshInterval: 5,
+		WebPort:         9090,
 		EnableGoRuntime: true,
 	}
-	core.RunMonitor(testConfig)
+	core.RunServer(testConfig)
 
 	// Reset stdout
 	if err := w.Close(); err != nil {
@@ -39,44 +50,241 @@ func TestRunMonitor(t *testing.T) {
 	output := buf.String()
 
 	// Assertions
-	assert.Contains(t, output, "refresh interval: 10s")
+	assert.Contains(t, output, "port 9090")
+	assert.Contains(t, output, "Refresh interval: 5s")
 	assert.Contains(t, output, "Go runtime metrics enabled")
 }
 
-func TestRunServer(t *testing.T) {
-	// Setup
-	old := os.Stdout
-	r, w, _ := os.Pipe()
-	os.Stdout = w
+func TestRunAgent(t *testing.T) {
+	var buf bytes.Buffer
+	testConfig := config.Config{RefreshInterval: 1}
 
-	// Call the function directly for testing
-	testConfig := config.Config{
-		RefreshInterval: 5,
-		WebPort:         9090,
-		EnableGoRuntime: true,
-	}
-	core.RunServer(testConfig)
+	err := core.RunAgent(testConfig, 1, "", "", &buf)
+	assert.NoError(t, err)
 
-	// Reset stdout
-	if err := w.Close(); err != nil {
-		t.Fatalf("Failed to close writer: %v", err)
-	}
-	os.Stdout = old
+	var metric metrics.Metric
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &metric))
+	assert.False(t, metric.Timestamp.IsZero())
+	assert.Equal(t, 1, bytes.Count(buf.Bytes(), []byte("\n")))
 
-	// Read the output
+	buf.Reset()
+	assert.NoError(t, core.RunAgent(testConfig, 1, "", "csv", &buf))
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	assert.Len(t, lines, 2)
+	assert.Equal(t, "time,cpu_percent,memory_percent,rx_bytes_per_sec,tx_bytes_per_sec", lines[0])
+
+	buf.Reset()
+	assert.NoError(t, core.RunAgent(testConfig, 1, "line-protocol", "", &buf))
+	assert.True(t, strings.HasPrefix(buf.String(), "godash_cpu,"))
+
+	assert.Error(t, core.RunAgent(testConfig, 1, "", "{{.CPU", &buf))
+	assert.Error(t, core.RunAgent(testConfig, 1, "xml", "", &buf))
+}
+
+func TestRunStress(t *testing.T) {
 	var buf bytes.Buffer
-	if _, err := io.Copy(&buf, r); err != nil {
-		t.Fatalf("Failed to copy: %v", err)
-	}
-	output := buf.String()
+	err := core.RunStress(core.StressOptions{
+		CPU:       1,
+		Duration:  200 * time.Millisecond,
+		Memory:    1 << 20,
+		DiskWrite: t.TempDir(),
+	}, &buf)
+	assert.NoError(t, err)
+	assert.Contains(t, buf.String(), "1 CPU workers")
+	assert.Contains(t, buf.String(), "Wrote ")
+	assert.Contains(t, buf.String(), "Stress finished.")
 
-	// Assertions
-	assert.Contains(t, output, "port 9090")
-	assert.Contains(t, output, "refresh interval: 5s")
-	assert.Contains(t, output, "Go runtime metrics enabled")
+	err = core.RunStress(core.StressOptions{Duration: time.Second}, &buf)
+	assert.Error(t, err, "no load requested")
+
+	err = core.RunStress(core.StressOptions{CPU: -1, Duration: time.Second}, &buf)
+	assert.ErrorContains(t, err, "--cpu must not be negative")
+}
+
+func TestRunQuery(t *testing.T) {
+	var buf bytes.Buffer
+	input := strings.NewReader("goruntime.goroutines\nmemory.used_percent >= 0\nnames goruntime.\nnope\ncpu >\nquit\ncpu\n")
+
+	err := core.RunQuery(config.Config{}, input, &buf)
+	assert.NoError(t, err)
+
+	out := buf.String()
+	assert.Contains(t, out, "goruntime.goroutines = ")
+	assert.Contains(t, out, "memory.used_percent = ")
+	assert.Contains(t, out, "goruntime.alloc\n")
+	assert.Contains(t, out, "(no match)")
+	assert.Contains(t, out, "Error: invalid threshold")
+	assert.NotContains(t, out, "cpu = ", "input after quit is ignored")
+}
+
+func TestCompleteName(t *testing.T) {
+	names := []string{"cpu", "cpu.0", "disk./.used_percent", "disk./home.used_percent", "memory.used_percent"}
+
+	line, pos, ok := core.CompleteName(names, "mem", 3)
+	assert.True(t, ok)
+	assert.Equal(t, "memory.used_percent", line)
+	assert.Equal(t, len(line), pos)
+
+	line, pos, ok = core.CompleteName(names, "di > 90", 2)
+	assert.True(t, ok)
+	assert.Equal(t, "disk./ > 90", line, "completes to the longest common prefix")
+	assert.Equal(t, 6, pos)
+
+	line, _, ok = core.CompleteName(names, "x > memory.u", 12)
+	assert.True(t, ok)
+	assert.Equal(t, "x > memory.used_percent", line)
+
+	_, _, ok = core.CompleteName(names, "net", 3)
+	assert.False(t, ok)
+	_, _, ok = core.CompleteName(names, "cpu", 3)
+	assert.False(t, ok, "nothing to add")
 }
 
 func TestShowVersion(t *testing.T) {
 	version := core.ShowVersion()
 	assert.Equal(t, "GoDash v0.1.0", version)
 }
+
+func TestWriteVersion(t *testing.T) {
+	defer func(commit, date string) { core.Commit, core.BuildDate = commit, date }(core.Commit, core.BuildDate)
+	core.Commit, core.BuildDate = "abc123", "2026-01-02T03:04:05Z"
+
+	var text bytes.Buffer
+	assert.NoError(t, core.WriteVersion(&text, false))
+	assert.Contains(t, text.String(), "GoDash v0.1.0\n")
+	assert.Contains(t, text.String(), "commit:     abc123")
+	assert.Contains(t, text.String(), "built:      2026-01-02T03:04:05Z")
+	assert.Contains(t, text.String(), runtime.GOOS+"/"+runtime.GOARCH)
+
+	var out bytes.Buffer
+	assert.NoError(t, core.WriteVersion(&out, true))
+	var info core.BuildInfo
+	assert.NoError(t, json.Unmarshal(out.Bytes(), &info))
+	assert.Equal(t, "v0.1.0", info.Version)
+	assert.Equal(t, "abc123", info.Commit)
+	assert.Equal(t, runtime.Version(), info.GoVersion)
+	assert.NotNil(t, info.Tags)
+}
+
+func TestCheckValues(t *testing.T) {
+	values := map[string]float64{
+		"cpu":                     42.5,
+		"disk./.used_percent":     91,
+		"disk./home.used_percent": 40,
+		"disk./.free":             5e9,
+		"memory.used_percent":     97,
+	}
+	opts := core.CheckOptions{Warn: 80, Crit: 95}
+
+	status, text := core.CheckValues(values, "cpu", opts)
+	assert.Equal(t, core.CheckOK, status)
+	assert.Equal(t, "OK - cpu 42.50 | 'cpu'=42.50%;80;95;0;100", text)
+
+	status, text = core.CheckValues(values, "disk", opts)
+	assert.Equal(t, core.CheckWarning, status)
+	assert.True(t, strings.HasPrefix(text, "WARNING - disk./.used_percent 91, disk./home.used_percent 40 | "))
+
+	status, _ = core.CheckValues(values, "memory", opts)
+	assert.Equal(t, core.CheckCritical, status)
+
+	status, text = core.CheckValues(values, "disk./.free", core.CheckOptions{Warn: 10e9, Crit: 2e9, Below: true})
+	assert.Equal(t, core.CheckWarning, status)
+	assert.Contains(t, text, "'disk./.free'=5000000000;10000000000:;2000000000:;;")
+
+	status, text = core.CheckValues(values, "load", opts)
+	assert.Equal(t, core.CheckUnknown, status)
+	assert.Contains(t, text, "UNKNOWN")
+}
+
+func TestRunDoctor(t *testing.T) {
+	config.SetDataDir(t.TempDir())
+	defer config.SetDataDir("")
+
+	var out bytes.Buffer
+	cfg := config.DefaultConfig()
+	cfg.WebPort = 0
+	code := core.RunDoctor(cfg, nil, &out)
+	assert.Contains(t, out.String(), "[PASS] config     settings are valid")
+	assert.Contains(t, out.String(), "[PASS] data")
+	if code != 0 {
+		assert.Contains(t, out.String(), "[FAIL]", "a failed check sets the exit code")
+	}
+
+	out.Reset()
+	code = core.RunDoctor(cfg, errors.New("failed to parse config file"), &out)
+	assert.Equal(t, 1, code)
+	assert.Contains(t, out.String(), "[FAIL] config     failed to parse config file\n")
+	assert.Contains(t, out.String(), "→ fix the file")
+	assert.NotContains(t, out.String(), "collect", "collectors are not checked without a config")
+
+	cfg.Watch.Port = []config.PortWatchConfig{{Port: 70000}}
+	checks := core.DoctorChecks(cfg, nil)
+	assert.Equal(t, core.DoctorFail, checks[0].Status)
+	assert.Contains(t, checks[0].Detail, "invalid port")
+
+	cfg.Watch.Port = nil
+	cfg.Bandwidth = config.BandwidthConfig{Enabled: true, ResetDay: 31}
+	checks = core.DoctorChecks(cfg, nil)
+	assert.Contains(t, checks[0].Detail, "invalid bandwidth reset_day 31")
+	cfg.Bandwidth = config.BandwidthConfig{Enabled: true, MonthlyQuota: "lots"}
+	checks = core.DoctorChecks(cfg, nil)
+	assert.Contains(t, checks[0].Detail, `invalid bandwidth monthly_quota "lots"`)
+
+	cfg.Bandwidth = config.BandwidthConfig{}
+	cfg.Privileges = config.PrivilegesConfig{User: "godash-no-such-user"}
+	checks = core.DoctorChecks(cfg, nil)
+	assert.Contains(t, checks[0].Detail, `invalid privileges user "godash-no-such-user"`)
+
+	cfg.Privileges = config.PrivilegesConfig{}
+	cfg.MemoryMode = "lo"
+	checks = core.DoctorChecks(cfg, nil)
+	assert.Contains(t, checks[0].Detail, `invalid memory_mode "lo"`)
+}
+
+func TestPrivilegeWarnings(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.EnableWireGuard = true
+	assert.Empty(t, core.PrivilegeWarnings(cfg), "nothing is dropped without a user")
+
+	cfg.Privileges.User = "nobody"
+	cfg.OpenVPNStatus = filepath.Join(t.TempDir(), "missing.log")
+	warnings := core.PrivilegeWarnings(cfg)
+	assert.Len(t, warnings, 2)
+	assert.Contains(t, warnings[0], "CAP_NET_ADMIN")
+	assert.Contains(t, warnings[1], "missing.log cannot be read")
+}
+
+func TestRulesOnlyInAgent(t *testing.T) {
+	config.SetDataDir(t.TempDir())
+	defer config.SetDataDir("")
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+	}))
+	defer server.Close()
+
+	cfg := config.DefaultConfig()
+	cfg.Rules = []config.RuleConfig{{Name: "always", When: "memory.used_percent >= 0",
+		Actions: []config.ActionConfig{{Type: "http", URL: server.URL}}}}
+
+	assert.NoError(t, core.RunOneline(cfg, "", false, io.Discard))
+	assert.Zero(t, calls.Load(), "one-shot commands do not evaluate rules")
+
+	assert.NoError(t, core.RunAgent(cfg, 1, "", "", io.Discard))
+	assert.Equal(t, int32(1), calls.Load(), "the agent waits for actions before exiting")
+}
+
+func TestShowJobsReadOnly(t *testing.T) {
+	dir := t.TempDir()
+	config.SetDataDir(dir)
+	defer config.SetDataDir("")
+
+	cfg := config.DefaultConfig()
+	cfg.Jobs = []config.JobConfig{{Name: "backup", Interval: "24h"}}
+	var out bytes.Buffer
+	overdue, err := core.ShowJobs(cfg, &out)
+	assert.NoError(t, err)
+	assert.False(t, overdue)
+	assert.Contains(t, out.String(), "backup               never reported, not watched by a monitor yet")
+	assert.NoFileExists(t, filepath.Join(dir, "jobs.json"), "a status query writes no state")
+}
@@ -0,0 +1,107 @@
+package bandwidth
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/j-raghavan/godash/internal/bandwidth"
+)
+
+func TestAccountantAdd(t *testing.T) {
+	store := bandwidth.NewStore(filepath.Join(t.TempDir(), "bandwidth.json"))
+	acct := bandwidth.NewAccountant(store, bandwidth.Quota{Limit: 1000}, time.UTC)
+	day := time.Date(2026, 3, 31, 23, 0, 0, 0, time.UTC)
+
+	// The first sample of an interface is the baseline
+	report, err := acct.Add([]bandwidth.Sample{{Interface: "eth0", RxBytes: 5000, TxBytes: 100}}, day)
+	require.NoError(t, err)
+	assert.Equal(t, []bandwidth.Usage{{Interface: "eth0"}}, report.Interfaces)
+
+	report, err = acct.Add([]bandwidth.Sample{{Interface: "eth0", RxBytes: 5600, TxBytes: 300}}, day.Add(time.Minute))
+	require.NoError(t, err)
+	assert.Equal(t, []bandwidth.Usage{{Interface: "eth0", TodayRx: 600, TodayTx: 200, MonthRx: 600, MonthTx: 200}},
+		report.Interfaces)
+	assert.Equal(t, uint64(800), report.QuotaUsed)
+	assert.InDelta(t, 80, report.QuotaPercent, 0.001)
+	assert.Equal(t, 80.0, report.QuotaLevel)
+
+	// A new day and month start from zero; a counter reset counts from zero
+	report, err = acct.Add([]bandwidth.Sample{{Interface: "eth0", RxBytes: 50, TxBytes: 400}}, day.Add(2*time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, []bandwidth.Usage{{Interface: "eth0", TodayRx: 50, TodayTx: 100, MonthRx: 50, MonthTx: 100}},
+		report.Interfaces)
+	assert.Equal(t, time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC), report.PeriodStart)
+	assert.Equal(t, 0.0, report.QuotaLevel)
+}
+
+func TestAccountantPersists(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bandwidth.json")
+	now := time.Date(2026, 5, 10, 12, 0, 0, 0, time.UTC)
+	acct := bandwidth.NewAccountant(bandwidth.NewStore(path), bandwidth.Quota{}, time.UTC)
+	_, err := acct.Add([]bandwidth.Sample{{Interface: "eth0", RxBytes: 100}}, now)
+	require.NoError(t, err)
+	_, err = acct.Add([]bandwidth.Sample{{Interface: "eth0", RxBytes: 300}}, now.Add(time.Second))
+	require.NoError(t, err)
+	require.NoError(t, acct.Flush())
+
+	// Transfer while no accountant ran is counted from the saved counters
+	acct = bandwidth.NewAccountant(bandwidth.NewStore(path), bandwidth.Quota{}, time.UTC)
+	report, err := acct.Add([]bandwidth.Sample{{Interface: "eth0", RxBytes: 1000}}, now.Add(time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, uint64(900), report.Interfaces[0].TodayRx)
+	assert.Equal(t, 0.0, report.QuotaPercent, "no quota")
+}
+
+// TestAccountantFlushWhileAdding tests that the counters can be flushed on
+// exit while the collector is still adding samples
+func TestAccountantFlushWhileAdding(t *testing.T) {
+	acct := bandwidth.NewAccountant(bandwidth.NewStore(filepath.Join(t.TempDir(), "bandwidth.json")),
+		bandwidth.Quota{}, time.UTC)
+	now := time.Date(2026, 5, 10, 12, 0, 0, 0, time.UTC)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 100; i++ {
+			iface := fmt.Sprintf("eth%d", i)
+			_, err := acct.Add([]bandwidth.Sample{{Interface: iface, RxBytes: uint64(i)}}, now)
+			assert.NoError(t, err)
+		}
+	}()
+	for i := 0; i < 10; i++ {
+		require.NoError(t, acct.Flush())
+	}
+	<-done
+}
+
+func TestAccountantCorruptState(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bandwidth.json")
+	require.NoError(t, os.WriteFile(path, []byte("{"), 0o644))
+	acct := bandwidth.NewAccountant(bandwidth.NewStore(path), bandwidth.Quota{}, time.UTC)
+	_, err := acct.Add([]bandwidth.Sample{{Interface: "eth0"}}, time.Now())
+	assert.ErrorContains(t, err, "failed to parse bandwidth state")
+}
+
+func TestPeriodStart(t *testing.T) {
+	tests := []struct {
+		name     string
+		now      time.Time
+		resetDay int
+		want     time.Time
+	}{
+		{"first of the month", time.Date(2026, 3, 15, 8, 0, 0, 0, time.UTC), 1, time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)},
+		{"after the reset day", time.Date(2026, 3, 20, 8, 0, 0, 0, time.UTC), 15, time.Date(2026, 3, 15, 0, 0, 0, 0, time.UTC)},
+		{"before the reset day", time.Date(2026, 3, 10, 8, 0, 0, 0, time.UTC), 15, time.Date(2026, 2, 15, 0, 0, 0, 0, time.UTC)},
+		{"across the year", time.Date(2026, 1, 3, 8, 0, 0, 0, time.UTC), 5, time.Date(2025, 12, 5, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, bandwidth.PeriodStart(tt.now, tt.resetDay, time.UTC))
+		})
+	}
+}
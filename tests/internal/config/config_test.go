@@ -3,7 +3,9 @@ package config
 import (
 	"os"
 	"path/filepath"
+	"runtime"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -154,6 +156,52 @@ func TestLoadConfig_DefaultLocations(t *testing.T) {
 	assert.Empty(t, cfg.ConfigFile)
 }
 
+func TestLoadProfile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "godash.toml")
+	require.NoError(t, os.WriteFile(path, []byte(`refresh_interval = 5
+pinned_disks = ["/"]
+
+[display_names]
+eth0 = "LAN"
+
+[wan]
+enabled = true
+interval = 60
+
+[profile.server]
+refresh_interval = 10
+pinned_disks = ["/srv"]
+
+[profile.server.display_names]
+eth1 = "WAN"
+
+[profile.server.wan]
+interval = 600
+`), 0o644))
+
+	cfg, err := config.LoadProfile(path, "")
+	require.NoError(t, err)
+	assert.Equal(t, 5, cfg.RefreshInterval)
+	assert.Equal(t, 60, cfg.WAN.Interval)
+
+	cfg, err = config.LoadProfile(path, "server")
+	require.NoError(t, err)
+	assert.Equal(t, "server", cfg.Profile)
+	assert.Equal(t, 10, cfg.RefreshInterval)
+	assert.Equal(t, []string{"/srv"}, cfg.PinnedDisks, "arrays are replaced")
+	assert.Equal(t, map[string]string{"eth0": "LAN", "eth1": "WAN"}, cfg.DisplayNames, "tables are merged")
+	assert.True(t, cfg.WAN.Enabled)
+	assert.Equal(t, 600, cfg.WAN.Interval)
+
+	t.Setenv(config.ProfileEnv, "server")
+	cfg, err = config.LoadConfig(path)
+	require.NoError(t, err)
+	assert.Equal(t, 10, cfg.RefreshInterval)
+
+	_, err = config.LoadProfile(path, "laptop")
+	assert.ErrorContains(t, err, `profile "laptop" not found`)
+}
+
 func TestSaveConfig(t *testing.T) {
 	tests := []struct {
 		name        string
@@ -255,3 +303,248 @@ func TestConfig_Validation(t *testing.T) {
 		})
 	}
 }
+
+func TestLoadConfig_DisplayNames(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), "names.toml")
+	err := os.WriteFile(configFile, []byte(`[display_names]
+"/dev/sdb1" = "Backup drive"
+enp3s0 = "LAN"`), 0o644)
+	require.NoError(t, err)
+
+	cfg, err := config.LoadConfig(configFile)
+	require.NoError(t, err)
+	assert.Equal(t, "Backup drive", cfg.DisplayNames["/dev/sdb1"])
+	assert.Equal(t, "LAN", cfg.DisplayNames["enp3s0"])
+}
+
+func TestConfig_LowMemory(t *testing.T) {
+	tests := []struct {
+		name            string
+		memoryMode      string
+		lowMemorySystem bool
+		want            bool
+	}{
+		{name: "auto on small host", memoryMode: "", lowMemorySystem: true, want: true},
+		{name: "auto on large host", memoryMode: "auto", lowMemorySystem: false, want: false},
+		{name: "forced low", memoryMode: "low", lowMemorySystem: false, want: true},
+		{name: "forced normal", memoryMode: "normal", lowMemorySystem: true, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.Config{MemoryMode: tt.memoryMode}
+			low, err := cfg.LowMemory(tt.lowMemorySystem)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, low)
+		})
+	}
+
+	_, err := config.Config{MemoryMode: "lo"}.LowMemory(true)
+	assert.EqualError(t, err, `invalid memory_mode "lo": must be auto, low or normal`)
+}
+
+func TestConfig_Location(t *testing.T) {
+	loc, err := config.Config{}.Location()
+	require.NoError(t, err)
+	assert.Equal(t, time.Local, loc)
+
+	loc, err = config.Config{Timezone: "utc"}.Location()
+	require.NoError(t, err)
+	assert.Equal(t, time.UTC, loc)
+
+	if loc, err = (config.Config{Timezone: "Europe/Berlin"}).Location(); err == nil {
+		assert.Equal(t, "Europe/Berlin", loc.String())
+	} else {
+		t.Logf("no time zone database: %v", err)
+	}
+
+	_, err = config.Config{Timezone: "Mars/Olympus_Mons"}.Location()
+	assert.Error(t, err)
+}
+
+func TestLoadConfig_Include(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "conf.d"), 0o755))
+	path := filepath.Join(dir, "godash.toml")
+	require.NoError(t, os.WriteFile(path, []byte(`include = ["conf.d/*.toml"]
+refresh_interval = 5
+pinned_disks = ["/"]
+
+[display_names]
+eth0 = "LAN"
+
+[[rules]]
+name = "hot"
+when = "cpu > 90"
+`), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "conf.d", "10-disk.toml"), []byte(`refresh_interval = 2
+
+[[rules]]
+name = "disk-full"
+when = "disk./.used_percent > 90"
+`), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "conf.d", "20-net.toml"), []byte(`pinned_disks = ["/home"]
+
+[display_names]
+eth1 = "WAN"
+
+[[rules]]
+name = "uplink-down"
+event = "interface_down"
+`), 0o644))
+
+	cfg, err := config.LoadConfig(path)
+	require.NoError(t, err)
+	assert.Equal(t, 2, cfg.RefreshInterval, "later files replace scalars")
+	assert.Equal(t, []string{"/", "/home"}, cfg.PinnedDisks, "arrays are appended")
+	assert.Equal(t, map[string]string{"eth0": "LAN", "eth1": "WAN"}, cfg.DisplayNames, "tables are merged")
+	require.Len(t, cfg.Rules, 3)
+	assert.Equal(t, "hot", cfg.Rules[0].Name)
+	assert.Equal(t, "disk-full", cfg.Rules[1].Name)
+	assert.Equal(t, "uplink-down", cfg.Rules[2].Name)
+
+	missing := filepath.Join(dir, "missing.toml")
+	require.NoError(t, os.WriteFile(missing, []byte(`include = ["nope.toml"]`), 0o644))
+	_, err = config.LoadConfig(missing)
+	assert.ErrorContains(t, err, "does not exist")
+
+	nested := filepath.Join(dir, "nested.toml")
+	require.NoError(t, os.WriteFile(nested, []byte(`include = ["godash.toml"]`), 0o644))
+	_, err = config.LoadConfig(nested)
+	assert.ErrorContains(t, err, "cannot include others")
+}
+
+func TestDataDir(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("XDG directories apply on Linux")
+	}
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_DATA_HOME", filepath.Join(home, "data"))
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(home, "conf"))
+
+	dir, err := config.DataDir()
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(home, "data", "godash"), dir)
+	path, err := config.DefaultConfigFile()
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(home, "conf", "godash", "godash.toml"), path)
+
+	// An unmigrated ~/.godash is used until it is moved
+	legacy := filepath.Join(home, ".godash")
+	require.NoError(t, os.MkdirAll(legacy, 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(legacy, "alerts.jsonl"), []byte("{}\n"), 0o644))
+	dir, err = config.DataDir()
+	require.NoError(t, err)
+	assert.Equal(t, legacy, dir)
+
+	from, to, err := config.MigrateDataDir()
+	require.NoError(t, err)
+	assert.Equal(t, legacy, from)
+	assert.Equal(t, filepath.Join(home, "data", "godash"), to)
+	assert.FileExists(t, filepath.Join(to, "alerts.jsonl"))
+	assert.NoDirExists(t, legacy)
+	from, _, err = config.MigrateDataDir()
+	require.NoError(t, err)
+	assert.Empty(t, from, "nothing left to migrate")
+
+	config.SetDataDir("/srv/godash")
+	defer config.SetDataDir("")
+	dir, err = config.DataDir()
+	require.NoError(t, err)
+	assert.Equal(t, "/srv/godash", dir)
+}
+
+func TestLoadConfig_XDGLocation(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(home, "conf"))
+	path, err := config.DefaultConfigFile()
+	require.NoError(t, err)
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+	require.NoError(t, os.WriteFile(path, []byte(`refresh_interval = 7`), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(home, ".godash.toml"), []byte(`refresh_interval = 3`), 0o644))
+
+	cfg, err := config.LoadConfig("")
+	require.NoError(t, err)
+	assert.Equal(t, 7, cfg.RefreshInterval, "the XDG location comes first")
+}
+
+func TestLoadConfig_YAMLAndJSON(t *testing.T) {
+	dir := t.TempDir()
+	yamlPath := filepath.Join(dir, "godash.yaml")
+	require.NoError(t, os.WriteFile(yamlPath, []byte(`refresh_interval: 5
+pinned_disks: ["/"]
+display_names:
+  eth0: LAN
+wan:
+  enabled: true
+  interval: 60
+rules:
+  - name: hot
+    when: cpu > 90
+    for: 1m
+    actions:
+      - type: script
+        command: /bin/true
+profile:
+  server:
+    refresh_interval: 10
+`), 0o644))
+	cfg, err := config.LoadConfig(yamlPath)
+	require.NoError(t, err)
+	assert.Equal(t, 5, cfg.RefreshInterval)
+	assert.Equal(t, []string{"/"}, cfg.PinnedDisks)
+	assert.Equal(t, "LAN", cfg.DisplayNames["eth0"])
+	assert.Equal(t, 60, cfg.WAN.Interval)
+	require.Len(t, cfg.Rules, 1)
+	assert.Equal(t, "cpu > 90", cfg.Rules[0].When)
+	assert.Equal(t, "/bin/true", cfg.Rules[0].Actions[0].Command)
+
+	cfg, err = config.LoadProfile(yamlPath, "server")
+	require.NoError(t, err)
+	assert.Equal(t, 10, cfg.RefreshInterval)
+
+	jsonPath := filepath.Join(dir, "godash.json")
+	require.NoError(t, os.WriteFile(jsonPath, []byte(`{
+  "refresh_interval": 3,
+  "memory_mode": null,
+  "adaptive": {"enabled": true, "cpu_threshold": 72.5}
+}`), 0o644))
+	cfg, err = config.LoadConfig(jsonPath)
+	require.NoError(t, err)
+	assert.Equal(t, 3, cfg.RefreshInterval)
+	assert.True(t, cfg.Adaptive.Enabled)
+	assert.Equal(t, 72.5, cfg.Adaptive.CPUThreshold)
+
+	require.NoError(t, os.WriteFile(jsonPath, []byte(`{"refresh_interval": `), 0o644))
+	_, err = config.LoadConfig(jsonPath)
+	assert.Error(t, err)
+}
+
+func TestSaveConfig_Formats(t *testing.T) {
+	for _, name := range []string{"godash.yaml", "godash.json", "godash.toml"} {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), name)
+			saved := config.Config{
+				RefreshInterval: 4,
+				WebPort:         9090,
+				ConfigFile:      path,
+				DisplayNames:    map[string]string{"/dev/sdb1": "Backup drive"},
+				Rules:           []config.RuleConfig{{Name: "hot", When: "cpu > 90"}},
+			}
+			require.NoError(t, config.SaveConfig(saved))
+			data, err := os.ReadFile(path)
+			require.NoError(t, err)
+			assert.Contains(t, string(data), "refresh_interval")
+
+			loaded, err := config.LoadConfig(path)
+			require.NoError(t, err)
+			assert.Equal(t, 4, loaded.RefreshInterval)
+			assert.Equal(t, 9090, loaded.WebPort)
+			assert.Equal(t, saved.DisplayNames, loaded.DisplayNames)
+			require.Len(t, loaded.Rules, 1)
+			assert.Equal(t, "cpu > 90", loaded.Rules[0].When)
+		})
+	}
+}
@@ -0,0 +1,109 @@
+package export_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/j-raghavan/godash/internal/export"
+)
+
+func TestZabbixValues(t *testing.T) {
+	values := map[string]float64{
+		"cpu":                      12.5,
+		"memory.used_percent":      40,
+		"disk./.used_percent":      71.2,
+		"disk./home.used_percent":  30,
+		"disk./home.full_in_hours": 5,
+	}
+	items := []export.ZabbixItem{
+		{Metric: "cpu", Key: "godash.cpu"},
+		{Metric: "disk.*.used_percent", Key: "godash.disk[{name}]"},
+		{Metric: "nope", Key: "godash.nope"},
+	}
+	got := export.ZabbixValues(values, items, "web-01", 1700000000)
+	assert.Equal(t, []export.ZabbixValue{
+		{Host: "web-01", Key: "godash.cpu", Value: "12.5", Clock: 1700000000},
+		{Host: "web-01", Key: "godash.disk[disk./.used_percent]", Value: "71.2", Clock: 1700000000},
+		{Host: "web-01", Key: "godash.disk[disk./home.used_percent]", Value: "30", Clock: 1700000000},
+	}, got)
+}
+
+func TestZabbixPacket(t *testing.T) {
+	packet := export.EncodeZabbixPacket([]byte(`{"a":1}`))
+	assert.Equal(t, []byte("ZBXD\x01\x07\x00\x00\x00\x00\x00\x00\x00{\"a\":1}"), packet)
+
+	data, err := export.ReadZabbixPacket(bytes.NewReader(packet))
+	require.NoError(t, err)
+	assert.Equal(t, `{"a":1}`, string(data))
+
+	_, err = export.ReadZabbixPacket(bytes.NewReader([]byte("HTTP/1.1 400 Bad Request\r\n")))
+	assert.Error(t, err)
+}
+
+// fakeZabbix accepts one sender request, passes it to requests and answers
+// with info
+func fakeZabbix(t *testing.T, info string, requests chan<- []byte) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		data, err := export.ReadZabbixPacket(conn)
+		if err != nil {
+			return
+		}
+		requests <- data
+		response, _ := json.Marshal(map[string]string{"response": "success", "info": info})
+		conn.Write(export.EncodeZabbixPacket(response))
+	}()
+	return ln.Addr().String()
+}
+
+func TestZabbixSend(t *testing.T) {
+	requests := make(chan []byte, 1)
+	addr := fakeZabbix(t, "processed: 1; failed: 0; total: 1; seconds spent: 0.000055", requests)
+	sender := export.NewZabbixSender(addr, "web-01", nil, time.Minute, nil)
+	values := []export.ZabbixValue{{Host: "web-01", Key: "godash.cpu", Value: "12.5", Clock: 1700000000}}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	require.NoError(t, sender.Send(ctx, values))
+
+	var request struct {
+		Request string               `json:"request"`
+		Data    []export.ZabbixValue `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(<-requests, &request))
+	assert.Equal(t, "sender data", request.Request)
+	assert.Equal(t, values, request.Data)
+}
+
+func TestZabbixSendRejected(t *testing.T) {
+	requests := make(chan []byte, 1)
+	addr := fakeZabbix(t, "processed: 0; failed: 1; total: 1; seconds spent: 0.000030", requests)
+	sender := export.NewZabbixSender(addr, "web-01", nil, time.Minute, nil)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	err := sender.Send(ctx, []export.ZabbixValue{{Host: "web-01", Key: "godash.cpu", Value: "1"}})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "1 of 1 values rejected")
+}
+
+func TestNewZabbixSenderDefaultPort(t *testing.T) {
+	sender := export.NewZabbixSender("zabbix.example.com", "", nil, time.Minute, nil)
+	assert.Equal(t, "zabbix.example.com:10051", sender.Server)
+	assert.NotEmpty(t, sender.Host)
+}
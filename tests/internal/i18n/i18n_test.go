@@ -0,0 +1,56 @@
+package i18n_test
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/j-raghavan/godash/internal/i18n"
+)
+
+func TestNewFallsBackToEnglish(t *testing.T) {
+	for _, locale := range []string{"", "C", "POSIX", "xx_YY.UTF-8"} {
+		assert.Equal(t, "en", i18n.New(locale).Language(), locale)
+	}
+	assert.Equal(t, "de", i18n.New("de_AT.UTF-8").Language())
+	assert.True(t, i18n.Has("de-DE"))
+	assert.False(t, i18n.Has("xx"))
+}
+
+func TestTranslate(t *testing.T) {
+	de := i18n.New("de")
+	assert.Equal(t, "CPU-Auslastung", de.T("CPU Usage"))
+	assert.Equal(t, "not translated", de.T("not translated"))
+	assert.Equal(t, "Gesamt: 42,5 %", de.Sprintf("Overall: %.1f%%", 42.5))
+	assert.Equal(t, "Kern  3: [##]  12,5%   ", de.Sprintf("Core %2d: [%s] %5.1f%%   ", 3, "##", 12.5))
+
+	en := i18n.New("en_US.UTF-8")
+	assert.Equal(t, "Overall: 42.5%", en.Sprintf("Overall: %.1f%%", 42.5))
+}
+
+func TestNumber(t *testing.T) {
+	assert.Equal(t, "1,234,567.89", i18n.New("en").Number(1234567.891, 2))
+	assert.Equal(t, "1.234.567,89", i18n.New("de").Number(1234567.891, 2))
+	assert.Equal(t, "-999", i18n.New("en").Number(-999, 0))
+	assert.Equal(t, "-1,000", i18n.New("en").Number(-1000, 0))
+}
+
+// TestCatalogVerbs checks that every translation keeps its message's
+// formatting verbs in order
+func TestCatalogVerbs(t *testing.T) {
+	verbs := regexp.MustCompile(`%[-+# 0]*[0-9]*(\.[0-9]+)?[a-zA-Z%]`)
+	strip := func(s string) []string {
+		var out []string
+		for _, verb := range verbs.FindAllString(s, -1) {
+			out = append(out, verb[len(verb)-1:])
+		}
+		return out
+	}
+	for _, code := range i18n.Languages() {
+		p := i18n.New(code)
+		for _, msg := range i18n.Messages(code) {
+			assert.Equal(t, strip(msg), strip(p.T(msg)), "%s: %q", code, msg)
+		}
+	}
+}
@@ -0,0 +1,174 @@
+package jobs
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/j-raghavan/godash/internal/config"
+	"github.com/j-raghavan/godash/internal/jobs"
+)
+
+func TestParseJobs(t *testing.T) {
+	tests := []struct {
+		name        string
+		configs     []config.JobConfig
+		want        []jobs.Job
+		errContains string
+	}{
+		{
+			name:    "valid jobs",
+			configs: []config.JobConfig{{Name: "backup", Interval: "24h", Grace: "1h"}, {Name: "sync", Interval: "15m"}},
+			want: []jobs.Job{
+				{Name: "backup", Interval: 24 * time.Hour, Grace: time.Hour},
+				{Name: "sync", Interval: 15 * time.Minute},
+			},
+		},
+		{
+			name:        "missing name",
+			configs:     []config.JobConfig{{Interval: "1h"}},
+			errContains: "missing a name",
+		},
+		{
+			name:        "invalid interval",
+			configs:     []config.JobConfig{{Name: "backup", Interval: "daily"}},
+			errContains: "invalid interval",
+		},
+		{
+			name:        "invalid grace",
+			configs:     []config.JobConfig{{Name: "backup", Interval: "1h", Grace: "soon"}},
+			errContains: "invalid grace",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := jobs.ParseJobs(tt.configs)
+			if tt.errContains != "" {
+				assert.ErrorContains(t, err, tt.errContains)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestCheck(t *testing.T) {
+	now := time.Date(2025, 4, 15, 12, 0, 0, 0, time.UTC)
+	jobList := []jobs.Job{
+		{Name: "backup", Interval: 24 * time.Hour, Grace: time.Hour},
+		{Name: "sync", Interval: 15 * time.Minute},
+		{Name: "new", Interval: time.Hour},
+		{Name: "broken", Interval: time.Hour},
+	}
+	lastRuns := map[string]time.Time{
+		"backup": now.Add(-24 * time.Hour),
+		"sync":   now.Add(-time.Hour),
+	}
+
+	watched := map[string]time.Time{
+		"backup": now.Add(-48 * time.Hour),
+		"new":    now.Add(-30 * time.Minute),
+		"broken": now.Add(-2 * time.Hour),
+	}
+
+	statuses := jobs.Check(jobList, lastRuns, watched, now)
+	require.Len(t, statuses, 4)
+
+	assert.False(t, statuses[0].Overdue, "backup is within its grace period")
+	assert.Equal(t, now.Add(time.Hour), statuses[0].NextDue)
+
+	assert.True(t, statuses[1].Overdue)
+	assert.Equal(t, 45*time.Minute, statuses[1].Late)
+
+	assert.False(t, statuses[2].Overdue, "new is due an interval after it was first watched")
+	assert.True(t, statuses[2].LastRun.IsZero())
+	assert.Equal(t, now.Add(30*time.Minute), statuses[2].NextDue)
+
+	assert.True(t, statuses[3].Overdue, "broken never ran since it was first watched")
+	assert.Equal(t, time.Hour, statuses[3].Late)
+
+	assert.False(t, jobs.Check(jobList[3:], nil, nil, now)[0].Overdue, "unwatched jobs are not overdue")
+}
+
+func TestStore(t *testing.T) {
+	store := jobs.NewStore(filepath.Join(t.TempDir(), "state", "jobs.json"))
+
+	lastRuns, err := store.LastRuns()
+	require.NoError(t, err)
+	assert.Empty(t, lastRuns)
+
+	at := time.Date(2025, 4, 15, 12, 0, 0, 0, time.UTC)
+	require.NoError(t, store.RecordRun("backup", at))
+	require.NoError(t, store.RecordRun("sync", at.Add(time.Minute)))
+
+	lastRuns, err = store.LastRuns()
+	require.NoError(t, err)
+	assert.True(t, at.Equal(lastRuns["backup"]))
+	assert.True(t, at.Add(time.Minute).Equal(lastRuns["sync"]))
+}
+
+func TestStoreWatch(t *testing.T) {
+	store := jobs.NewStore(filepath.Join(t.TempDir(), "jobs.json"))
+	at := time.Date(2025, 4, 15, 12, 0, 0, 0, time.UTC)
+
+	watched, err := store.Watch([]string{"backup"}, at)
+	require.NoError(t, err)
+	assert.True(t, at.Equal(watched["backup"]))
+
+	watched, err = store.Watch([]string{"backup", "sync"}, at.Add(time.Hour))
+	require.NoError(t, err)
+	assert.True(t, at.Equal(watched["backup"]), "the first watch is kept")
+	assert.True(t, at.Add(time.Hour).Equal(watched["sync"]))
+
+	watched, err = store.Watched()
+	require.NoError(t, err)
+	assert.Len(t, watched, 2)
+	assert.True(t, at.Equal(watched["backup"]))
+}
+
+func TestStoreLegacyState(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "jobs.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"backup": "2025-04-15T12:00:00Z"}`), 0o644))
+	store := jobs.NewStore(path)
+
+	lastRuns, err := store.LastRuns()
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2025, 4, 15, 12, 0, 0, 0, time.UTC), lastRuns["backup"].UTC())
+
+	require.NoError(t, store.RecordRun("sync", time.Now()))
+	lastRuns, err = store.LastRuns()
+	require.NoError(t, err)
+	assert.Len(t, lastRuns, 2, "the last runs are kept when the format is upgraded")
+}
+
+func TestStoreConcurrentRuns(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "jobs.json")
+	at := time.Date(2025, 4, 15, 12, 0, 0, 0, time.UTC)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			// Separate stores, as in separate "godash job done" processes
+			assert.NoError(t, jobs.NewStore(path).RecordRun(fmt.Sprintf("job%d", i), at))
+		}(i)
+	}
+	wg.Wait()
+
+	lastRuns, err := jobs.NewStore(path).LastRuns()
+	require.NoError(t, err)
+	assert.Len(t, lastRuns, 20, "no completion is lost")
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Len(t, entries, 2, "only the state and its lock file are left")
+}
@@ -0,0 +1,92 @@
+package metrics
+
+import (
+	m "github.com/j-raghavan/godash/internal/metrics"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// TestParseIPMISensors tests parsing of `ipmitool sdr` output
+func TestParseIPMISensors(t *testing.T) {
+	sdr := `CPU Temp         | 45 degrees C      | ok
+System Temp      | 31 degrees C      | ok
+FAN1             | 3000 RPM          | ok
+FAN2             | 0 RPM             | cr
+PS1 Status       | 0x01              | ok
+PW Consumption   | 150 Watts         | ok
+FAN3             | no reading        | ns
+`
+	sensors, err := m.ParseIPMISensors(strings.NewReader(sdr))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	want := []m.HardwareSensor{
+		{Name: "CPU Temp", Kind: m.SensorTemperature, Value: 45, Unit: "°C", Status: "ok"},
+		{Name: "System Temp", Kind: m.SensorTemperature, Value: 31, Unit: "°C", Status: "ok"},
+		{Name: "FAN1", Kind: m.SensorFan, Value: 3000, Unit: "RPM", Status: "ok"},
+		{Name: "FAN2", Kind: m.SensorFan, Value: 0, Unit: "RPM", Status: "cr"},
+		{Name: "PW Consumption", Kind: m.SensorPower, Value: 150, Unit: "W", Status: "ok"},
+	}
+	if !reflect.DeepEqual(sensors, want) {
+		t.Errorf("Expected %+v, got %+v", want, sensors)
+	}
+	if sensors[3].Healthy() {
+		t.Errorf("Expected FAN2 to be unhealthy")
+	}
+}
+
+// TestParseRedfishThermal tests parsing of a Redfish Thermal resource
+func TestParseRedfishThermal(t *testing.T) {
+	thermal := `{
+  "Temperatures": [
+    {"Name": "Inlet Temp", "ReadingCelsius": 24, "Status": {"State": "Enabled", "Health": "OK"}},
+    {"Name": "CPU2 Temp", "ReadingCelsius": null, "Status": {"State": "Absent"}}
+  ],
+  "Fans": [
+    {"FanName": "Fan 1", "Reading": 4200, "ReadingUnits": "RPM", "Status": {"State": "Enabled", "Health": "OK"}},
+    {"Name": "Fan 2", "Reading": 35, "ReadingUnits": "Percent", "Status": {"State": "Enabled", "Health": "Critical"}}
+  ]
+}`
+	sensors, err := m.ParseRedfishThermal([]byte(thermal))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	want := []m.HardwareSensor{
+		{Name: "Inlet Temp", Kind: m.SensorTemperature, Value: 24, Unit: "°C", Status: "OK"},
+		{Name: "Fan 1", Kind: m.SensorFan, Value: 4200, Unit: "RPM", Status: "OK"},
+		{Name: "Fan 2", Kind: m.SensorFan, Value: 35, Unit: "Percent", Status: "Critical"},
+	}
+	if !reflect.DeepEqual(sensors, want) {
+		t.Errorf("Expected %+v, got %+v", want, sensors)
+	}
+}
+
+// TestParseRedfishPower tests parsing of a Redfish Power resource
+func TestParseRedfishPower(t *testing.T) {
+	power := `{
+  "PowerSupplies": [
+    {"Name": "PSU 1", "LastPowerOutputWatts": 180, "Status": {"State": "Enabled", "Health": "OK"}},
+    {"Name": "PSU 2", "Status": {"State": "UnavailableOffline", "Health": "Critical"}},
+    {"Name": "PSU 3", "Status": {"State": "Absent"}}
+  ]
+}`
+	sensors, err := m.ParseRedfishPower([]byte(power))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	want := []m.HardwareSensor{
+		{Name: "PSU 1", Kind: m.SensorPower, Value: 180, Unit: "W", Status: "OK"},
+		{Name: "PSU 2", Kind: m.SensorPower, Unit: "W", Status: "Critical"},
+	}
+	if !reflect.DeepEqual(sensors, want) {
+		t.Errorf("Expected %+v, got %+v", want, sensors)
+	}
+
+	if _, err := m.ParseRedfishPower([]byte("not json")); err == nil {
+		t.Errorf("Expected an error for invalid JSON")
+	}
+}
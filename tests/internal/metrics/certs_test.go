@@ -0,0 +1,68 @@
+package metrics
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"testing"
+	"time"
+
+	m "github.com/j-raghavan/godash/internal/metrics"
+)
+
+// selfSigned returns a PEM encoded certificate for name expiring at notAfter
+func selfSigned(t *testing.T, name string, notAfter time.Time) []byte {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    notAfter.Add(-365 * 24 * time.Hour),
+		NotAfter:     notAfter,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("failed to create certificate: %v", err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+}
+
+// TestCertStatFromChain tests that the first certificate to expire is reported
+func TestCertStatFromChain(t *testing.T) {
+	now := time.Now()
+	data := append(selfSigned(t, "proxy.lan", now.Add(60*24*time.Hour)),
+		selfSigned(t, "Homelab CA", now.Add(10*24*time.Hour))...)
+
+	chain, err := m.ParseCertFile(data)
+	if err != nil {
+		t.Fatalf("ParseCertFile returned error: %v", err)
+	}
+	if len(chain) != 2 {
+		t.Fatalf("Expected 2 certificates, got %d", len(chain))
+	}
+
+	stat := m.CertStatFromChain(chain, now, 14)
+	if stat.Subject != "Homelab CA" {
+		t.Errorf("Expected the CA to expire first, got %s", stat.Subject)
+	}
+	if stat.DaysLeft < 9.9 || stat.DaysLeft > 10.1 {
+		t.Errorf("Expected 10 days left, got %.2f", stat.DaysLeft)
+	}
+	if !stat.Expiring {
+		t.Error("Expected the certificate to be flagged as expiring")
+	}
+	if m.CertStatFromChain(chain[:1], now, 14).Expiring {
+		t.Error("Expected a certificate with 60 days left not to be expiring")
+	}
+
+	if _, err := m.ParseCertFile([]byte("not a certificate")); err == nil {
+		t.Error("Expected an error for data without certificates")
+	}
+}
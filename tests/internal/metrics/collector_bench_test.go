@@ -0,0 +1,59 @@
+package metrics
+
+import (
+	"testing"
+
+	m "github.com/j-raghavan/godash/internal/metrics"
+	"github.com/j-raghavan/godash/internal/output"
+)
+
+// encodeAllocBudget is the allocation target for encoding a sample, see
+// CONTRIBUTING.md
+const encodeAllocBudget = 8
+
+// BenchmarkCollect measures one full collection tick, the cost paid every
+// refresh interval (100ms by default). Its allocations grow with the host's
+// cores, interfaces and mounts, so the target in CONTRIBUTING.md is checked
+// here rather than by a test.
+func BenchmarkCollect(b *testing.B) {
+	collector := m.NewSystemCollector()
+	if _, err := collector.Collect(); err != nil {
+		b.Fatal(err)
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := collector.Collect(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkEncodeMetric measures encoding one sample as JSON, as the agent
+// streams it every tick
+func BenchmarkEncodeMetric(b *testing.B) {
+	metric := schemaFixture()
+	encoder := output.JSONEncoder{}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := encoder.Encode(metric); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// TestEncodeAllocBudget tests that encoding a sample stays within its
+// allocation budget
+func TestEncodeAllocBudget(t *testing.T) {
+	metric := schemaFixture()
+	encoder := output.JSONEncoder{}
+	allocs := testing.AllocsPerRun(20, func() {
+		if _, err := encoder.Encode(metric); err != nil {
+			t.Fatal(err)
+		}
+	})
+	if allocs > encodeAllocBudget {
+		t.Errorf("Expected at most %d allocs per sample, got %.0f", encodeAllocBudget, allocs)
+	}
+}
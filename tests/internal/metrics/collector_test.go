@@ -2,8 +2,13 @@ package metrics
 
 import (
 	m "github.com/j-raghavan/godash/internal/metrics"
+	"github.com/shirou/gopsutil/v3/cpu"
+	"math"
+	"os"
+	"path/filepath"
 	"reflect"
 	"runtime"
+	"strings"
 	"testing"
 	"time"
 )
@@ -127,3 +132,212 @@ func TestCollectorInterface(t *testing.T) {
 	var _ m.Collector = m.NewSystemCollector()
 
 }
+
+// TestDisplayNames tests that configured display names are attached as labels
+func TestDisplayNames(t *testing.T) {
+	collector := m.NewSystemCollector()
+	metric, err := collector.Collect()
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(metric.Network) == 0 {
+		t.Skip("No network interfaces available")
+	}
+
+	iface := metric.Network[0].Interface
+	if metric.Network[0].Label != iface {
+		t.Errorf("Expected default label %q, got %q", iface, metric.Network[0].Label)
+	}
+
+	collector.SetDisplayNames(map[string]string{iface: "LAN"})
+	metric, err = collector.Collect()
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	for _, net := range metric.Network {
+		if net.Interface == iface && net.Label != "LAN" {
+			t.Errorf("Expected label %q for %s, got %q", "LAN", iface, net.Label)
+		}
+	}
+}
+
+// TestRecentGCPauses tests reading recent pauses from the circular buffer
+func TestRecentGCPauses(t *testing.T) {
+	var memStats runtime.MemStats
+	if pauses := m.RecentGCPauses(&memStats, 4); len(pauses) != 0 {
+		t.Errorf("Expected no pauses before the first GC, got %v", pauses)
+	}
+
+	// 258 GCs have wrapped the 256-entry buffer; the latest is at index 1
+	memStats.NumGC = 258
+	memStats.PauseNs[255] = 100
+	memStats.PauseNs[0] = 200
+	memStats.PauseNs[1] = 300
+	pauses := m.RecentGCPauses(&memStats, 3)
+	want := []time.Duration{100, 200, 300}
+	if !reflect.DeepEqual(pauses, want) {
+		t.Errorf("Expected %v, got %v", want, pauses)
+	}
+}
+
+// TestCollectPartialResults tests that a failing collector does not fail the sample
+func TestCollectPartialResults(t *testing.T) {
+	collector := m.NewSystemCollector()
+	collector.SetTunnels(false, "/nonexistent/openvpn-status.log")
+
+	metric, err := collector.Collect()
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if metric == nil {
+		t.Fatal("Expected a metric despite the failing collector")
+	}
+	if msg := metric.Errors["tunnels"]; !strings.Contains(msg, "openvpn") {
+		t.Errorf("Expected a tunnels error, got %q", msg)
+	}
+	if len(metric.CPU) == 0 {
+		t.Errorf("Expected CPU data alongside the error")
+	}
+}
+
+// TestCollectTunnelsWireGuardFailure tests that a failing wg keeps the
+// OpenVPN peers and is not retried before the next refresh
+func TestCollectTunnelsWireGuardFailure(t *testing.T) {
+	t.Setenv("PATH", "")
+	status := filepath.Join(t.TempDir(), "openvpn-status.log")
+	if err := os.WriteFile(status, []byte(`TITLE,OpenVPN 2.4.7
+HEADER,CLIENT_LIST,Common Name,Real Address,Virtual Address,Virtual IPv6 Address,Bytes Received,Bytes Sent,Connected Since,Connected Since (time_t),Username,Client ID,Peer ID
+CLIENT_LIST,laptop,198.51.100.7:1194,10.8.0.6,,12345,67890,Thu Jun 18 08:00:00 2015,1434614400,UNDEF,0,0
+END
+`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	collector := m.NewSystemCollector()
+	collector.SetTunnels(true, status)
+
+	for i := 0; i < 2; i++ {
+		metric, err := collector.Collect()
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if msg := metric.Errors["tunnels"]; !strings.Contains(msg, "wireguard") {
+			t.Errorf("Sample %d: expected a wireguard error, got %q", i, msg)
+		}
+		if len(metric.Tunnels) != 1 || metric.Tunnels[0].Peer != "laptop" {
+			t.Errorf("Sample %d: expected the OpenVPN peer, got %+v", i, metric.Tunnels)
+		}
+	}
+}
+
+// TestCounterRate tests rate calculation across counter wraparound and reset
+func TestCounterRate(t *testing.T) {
+	tests := []struct {
+		name      string
+		prev, cur uint64
+		seconds   float64
+		want      float64
+		rate      func(prev, cur uint64, seconds float64) float64
+	}{
+		{name: "increase", prev: 1000, cur: 3000, seconds: 2, want: 1000, rate: m.CounterRate},
+		{name: "no time elapsed", prev: 1000, cur: 3000, seconds: 0, want: 0, rate: m.CounterRate},
+		{name: "reset", prev: 1 << 40, cur: 500, seconds: 1, want: 500, rate: m.CounterRate},
+		{name: "reset below 4 GiB", prev: math.MaxUint32 - 99, cur: 100, seconds: 1, want: 100, rate: m.CounterRate},
+		{name: "32-bit wrap", prev: math.MaxUint32 - 99, cur: 100, seconds: 1, want: 200, rate: m.Counter32Rate},
+		{name: "32-bit increase", prev: 1000, cur: 3000, seconds: 2, want: 1000, rate: m.Counter32Rate},
+		{name: "reset above 4 GiB", prev: 1 << 40, cur: 500, seconds: 1, want: 500, rate: m.Counter32Rate},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.rate(tt.prev, tt.cur, tt.seconds); got != tt.want {
+				t.Errorf("Expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+// TestNetworkRatesNeedTwoSamples tests that rates are suppressed on the first sample
+func TestNetworkRatesNeedTwoSamples(t *testing.T) {
+	collector := m.NewSystemCollector()
+	first, err := collector.Collect()
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	for _, net := range first.Network {
+		if net.HasRates || net.RxBytesPerSec != 0 || net.TxBytesPerSec != 0 {
+			t.Errorf("Expected no rates for %s on the first sample, got %+v", net.Interface, net)
+		}
+	}
+
+	time.Sleep(10 * time.Millisecond)
+	second, err := collector.Collect()
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	for _, net := range second.Network {
+		if !net.HasRates {
+			t.Errorf("Expected rates for %s on the second sample", net.Interface)
+		}
+	}
+}
+
+// TestCPUPercent tests busy percentage calculation between two CPU time readings
+func TestCPUPercent(t *testing.T) {
+	prev := cpu.TimesStat{User: 100, System: 50, Idle: 800, Iowait: 50}
+	tests := []struct {
+		name string
+		cur  cpu.TimesStat
+		want float64
+	}{
+		{name: "quarter busy", cur: cpu.TimesStat{User: 120, System: 55, Idle: 870, Iowait: 55}, want: 25},
+		{name: "iowait is idle", cur: cpu.TimesStat{User: 100, System: 50, Idle: 800, Iowait: 150}, want: 0},
+		{name: "fully busy", cur: cpu.TimesStat{User: 150, System: 100, Idle: 800, Iowait: 50}, want: 100},
+		{name: "no time elapsed", cur: prev, want: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := m.CPUPercent(prev, tt.cur); math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("Expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+// TestCPUStates tests the CPU time breakdown by state between two readings
+func TestCPUStates(t *testing.T) {
+	prev := cpu.TimesStat{User: 100, System: 50, Idle: 800, Iowait: 50}
+	cur := cpu.TimesStat{User: 140, Nice: 5, System: 60, Idle: 810, Iowait: 70, Steal: 10, Irq: 2, Softirq: 3}
+	expected := m.CPUStateStat{User: 40, Nice: 5, System: 10, IOWait: 20, Steal: 10, IRQ: 2, SoftIRQ: 3, Idle: 10}
+	if got := m.CPUStates(prev, cur); got != expected {
+		t.Errorf("Expected %+v, got %+v", expected, got)
+	}
+	if got := m.CPUStates(prev, prev); got != (m.CPUStateStat{}) {
+		t.Errorf("Expected no breakdown when no time elapsed, got %+v", got)
+	}
+}
+
+// TestNextInterval tests adaptive sampling backing off under load and recovering
+func TestNextInterval(t *testing.T) {
+	base := 100 * time.Millisecond
+	a := m.Adaptive{CPUThreshold: 80, SelfBudget: 1, MaxInterval: time.Second}
+	tests := []struct {
+		name      string
+		current   time.Duration
+		systemCPU float64
+		selfCPU   float64
+		want      time.Duration
+	}{
+		{name: "system busy", current: base, systemCPU: 95, want: 200 * time.Millisecond},
+		{name: "over self budget", current: base, systemCPU: 10, selfCPU: 2, want: 200 * time.Millisecond},
+		{name: "capped", current: 800 * time.Millisecond, systemCPU: 95, want: time.Second},
+		{name: "hysteresis", current: 400 * time.Millisecond, systemCPU: 60, selfCPU: 0.2, want: 400 * time.Millisecond},
+		{name: "idle recovers", current: 400 * time.Millisecond, systemCPU: 10, selfCPU: 0.2, want: 200 * time.Millisecond},
+		{name: "never below base", current: base, systemCPU: 10, want: base},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := m.NextInterval(tt.current, base, tt.systemCPU, tt.selfCPU, a); got != tt.want {
+				t.Errorf("Expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
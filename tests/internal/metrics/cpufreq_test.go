@@ -0,0 +1,49 @@
+package metrics
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	m "github.com/j-raghavan/godash/internal/metrics"
+)
+
+// TestReadCPUFreq tests reading per-core frequency scaling from sysfs
+func TestReadCPUFreq(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"cpu0/cpufreq/scaling_cur_freq":  "1200000\n",
+		"cpu0/cpufreq/cpuinfo_max_freq":  "3600000\n",
+		"cpu0/cpufreq/scaling_governor":  "powersave\n",
+		"cpu10/cpufreq/scaling_cur_freq": "3400000\n",
+		"cpu10/cpufreq/scaling_governor": "performance\n",
+		"cpu2/cpufreq/scaling_governor":  "powersave\n", // no current frequency
+		"cpuidle/state0/name":            "POLL\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	stats, err := m.ReadCPUFreq(dir)
+	if err != nil {
+		t.Fatalf("ReadCPUFreq returned error: %v", err)
+	}
+	expected := []m.CPUFreqStat{
+		{CPU: 0, Governor: "powersave", CurrentMHz: 1200, MaxMHz: 3600},
+		{CPU: 10, Governor: "performance", CurrentMHz: 3400},
+	}
+	if !reflect.DeepEqual(stats, expected) {
+		t.Errorf("Expected %+v, got %+v", expected, stats)
+	}
+
+	if stats, err := m.ReadCPUFreq(filepath.Join(dir, "missing")); err != nil || len(stats) != 0 {
+		t.Errorf("Expected no cores and no error without cpufreq, got %+v, %v", stats, err)
+	}
+}
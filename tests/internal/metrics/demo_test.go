@@ -0,0 +1,50 @@
+package metrics
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	m "github.com/j-raghavan/godash/internal/metrics"
+)
+
+// TestDemoSample tests that the synthetic host is deterministic and shows
+// its bursts and outages
+func TestDemoSample(t *testing.T) {
+	start := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	demo := m.NewDemoCollector(start)
+	if !reflect.DeepEqual(demo.Sample(17*time.Second), m.NewDemoCollector(start).Sample(17*time.Second)) {
+		t.Error("Expected the same sample for the same elapsed time")
+	}
+
+	calm, burst := demo.Sample(10*time.Second), demo.Sample(42*time.Second)
+	if calm.CPUTotal > 60 || burst.CPUTotal < 90 {
+		t.Errorf("Expected a CPU burst at 42s, got %.1f%% then %.1f%%", calm.CPUTotal, burst.CPUTotal)
+	}
+	if !burst.Timestamp.Equal(start.Add(42 * time.Second)) {
+		t.Errorf("Unexpected timestamp %v", burst.Timestamp)
+	}
+
+	// wlan0 is down from 90s to 105s and counts no traffic meanwhile
+	down, up := demo.Sample(95*time.Second), demo.Sample(110*time.Second)
+	if down.Network[1].Up || !up.Network[1].Up {
+		t.Error("Expected wlan0 down at 95s only")
+	}
+	if down.Network[1].RxBytesPerSec != 0 {
+		t.Errorf("Expected no wlan0 traffic while down, got %.0f B/s", down.Network[1].RxBytesPerSec)
+	}
+	if a, b := demo.Sample(91*time.Second), demo.Sample(104*time.Second); a.Network[1].RxBytes != b.Network[1].RxBytes {
+		t.Error("Expected the wlan0 counters to stand still while down")
+	}
+
+	var prev m.Metric
+	for s := 0; s < 300; s++ {
+		sample := demo.Sample(time.Duration(s) * time.Second)
+		for i, net := range sample.Network {
+			if s > 0 && net.RxBytes < prev.Network[i].RxBytes {
+				t.Fatalf("Expected %s counters to grow, at %ds", net.Interface, s)
+			}
+		}
+		prev = sample
+	}
+}
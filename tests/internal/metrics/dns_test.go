@@ -0,0 +1,70 @@
+package metrics
+
+import (
+	m "github.com/j-raghavan/godash/internal/metrics"
+	"reflect"
+	"testing"
+)
+
+// TestParsePiholeSummary tests parsing of the Pi-hole summary response
+func TestParsePiholeSummary(t *testing.T) {
+	summary := `{"queries": {"total": 12000, "blocked": 1800, "percent_blocked": 15.0, "unique_domains": 900}}`
+	stat, err := m.ParsePiholeSummary([]byte(summary))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if stat.Source != m.DNSPihole || stat.Queries != 12000 || stat.Blocked != 1800 || stat.BlockedPercent != 15.0 {
+		t.Errorf("Unexpected summary %+v", stat)
+	}
+}
+
+// TestParsePiholeTopClients tests parsing of the Pi-hole top clients response
+func TestParsePiholeTopClients(t *testing.T) {
+	top := `{"clients": [
+		{"ip": "192.168.1.10", "name": "laptop.lan", "count": 500},
+		{"ip": "192.168.1.11", "name": "", "count": 200}
+	]}`
+	clients, err := m.ParsePiholeTopClients([]byte(top))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	want := []m.DNSClient{
+		{Name: "laptop.lan", Queries: 500},
+		{Name: "192.168.1.11", Queries: 200},
+	}
+	if !reflect.DeepEqual(clients, want) {
+		t.Errorf("Expected %+v, got %+v", want, clients)
+	}
+}
+
+// TestParseAdGuardStats tests parsing of the AdGuard Home stats response
+func TestParseAdGuardStats(t *testing.T) {
+	stats := `{
+		"num_dns_queries": 4000,
+		"num_blocked_filtering": 1000,
+		"top_clients": [{"192.168.1.20": 300}, {"192.168.1.21": 900}]
+	}`
+	stat, err := m.ParseAdGuardStats([]byte(stats))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if stat.Source != m.DNSAdGuard || stat.Queries != 4000 || stat.Blocked != 1000 {
+		t.Errorf("Unexpected stats %+v", stat)
+	}
+	if stat.BlockedPercent != 25 {
+		t.Errorf("Expected 25%% blocked, got %.1f", stat.BlockedPercent)
+	}
+
+	want := []m.DNSClient{
+		{Name: "192.168.1.21", Queries: 900},
+		{Name: "192.168.1.20", Queries: 300},
+	}
+	if !reflect.DeepEqual(stat.TopClients, want) {
+		t.Errorf("Expected %+v, got %+v", want, stat.TopClients)
+	}
+
+	if _, err := m.ParseAdGuardStats([]byte("{")); err == nil {
+		t.Errorf("Expected an error for invalid JSON")
+	}
+}
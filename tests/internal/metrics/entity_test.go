@@ -0,0 +1,51 @@
+package metrics
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	m "github.com/j-raghavan/godash/internal/metrics"
+)
+
+// TestEntities tests the generic form of disks, interfaces, VMs and
+// processes
+func TestEntities(t *testing.T) {
+	metric := m.Metric{
+		Disk:      []m.DiskStat{{Path: "/", Device: "/dev/sda1", FsType: "ext4", Total: 100, Used: 40, Free: 60, UsedPercentage: 40, FullIn: 2 * time.Hour}},
+		Network:   []m.NetworkStat{{Interface: "eth0", Up: true, RxBytes: 10, TxBytes: 20}},
+		VMs:       []m.VMStat{{Name: "web", State: "running", VCPUs: 2, CPUPercent: 12}},
+		Processes: []m.ProcessStatus{{Name: "nginx", Status: m.ProcessOK, Count: 4}},
+	}
+	entities := m.Entities(metric)
+	if len(entities) != 4 {
+		t.Fatalf("Expected 4 entities, got %d", len(entities))
+	}
+
+	disk := entities[0]
+	if disk.Kind != m.EntityDisk || disk.Name != "/" {
+		t.Errorf("Expected disk /, got %s %s", disk.Kind, disk.Name)
+	}
+	// The empty label is left out
+	if names := disk.LabelNames(); !reflect.DeepEqual(names, []string{"device", "fs_type", "path"}) {
+		t.Errorf("Unexpected disk labels %v", names)
+	}
+	if disk.Values["used_percent"] != 40 || disk.Values["full_in_hours"] != 2 {
+		t.Errorf("Unexpected disk values %v", disk.Values)
+	}
+
+	net := entities[1]
+	if net.Kind != m.EntityInterface || net.Values["up"] != 1 || net.Values["tx_bytes"] != 20 {
+		t.Errorf("Unexpected interface %+v", net)
+	}
+	// Rates are left out until the second sample
+	if _, ok := net.Values["rx_bytes_per_sec"]; ok {
+		t.Error("Expected no rates without HasRates")
+	}
+	if vm := entities[2]; vm.Kind != m.EntityVM || vm.Labels["state"] != "running" || vm.Values["vcpus"] != 2 {
+		t.Errorf("Unexpected VM %+v", vm)
+	}
+	if p := entities[3]; p.Kind != m.EntityProcess || p.Name != "nginx" || p.Values["count"] != 4 {
+		t.Errorf("Unexpected process %+v", p)
+	}
+}
@@ -0,0 +1,39 @@
+package metrics
+
+import (
+	"testing"
+	"time"
+
+	m "github.com/j-raghavan/godash/internal/metrics"
+)
+
+// TestTimeUntilFull tests the linear disk-full projection
+func TestTimeUntilFull(t *testing.T) {
+	const gib = 1 << 30
+	hours := []float64{0, 3600, 7200, 10800}
+
+	tests := []struct {
+		name string
+		used []float64
+		free float64
+		want time.Duration
+	}{
+		{name: "growing 1GiB/h", used: []float64{10 * gib, 11 * gib, 12 * gib, 13 * gib}, free: 72 * gib, want: 72 * time.Hour},
+		{name: "noisy growth", used: []float64{10.5 * gib, 10.5 * gib, 11.5 * gib, 13.5 * gib}, free: 72 * gib, want: 72 * time.Hour},
+		{name: "flat", used: []float64{10 * gib, 10 * gib, 10 * gib, 10 * gib}, free: gib, want: 0},
+		{name: "shrinking", used: []float64{13 * gib, 12 * gib, 11 * gib, 10 * gib}, free: gib, want: 0},
+		{name: "more than a year away", used: []float64{10 * gib, 10 * gib, 10 * gib, 10*gib + 1}, free: 500 * gib, want: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := m.TimeUntilFull(hours, tt.used, tt.free)
+			if diff := got - tt.want; diff < -time.Second || diff > time.Second {
+				t.Errorf("Expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+
+	if got := m.TimeUntilFull([]float64{0}, []float64{1}, 1); got != 0 {
+		t.Errorf("Expected no forecast from one point, got %v", got)
+	}
+}
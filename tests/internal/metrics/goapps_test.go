@@ -0,0 +1,91 @@
+package metrics
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	m "github.com/j-raghavan/godash/internal/metrics"
+)
+
+// TestParseExpvar tests parsing of /debug/vars memstats
+func TestParseExpvar(t *testing.T) {
+	vars := `{
+"cmdline": ["/usr/local/bin/api"],
+"memstats": {"Alloc": 4096, "HeapAlloc": 2048, "HeapObjects": 17, "Sys": 8192, "NumGC": 3, "PauseTotalNs": 1500}
+}`
+	stat, err := m.ParseExpvar([]byte(vars))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	want := m.GoAppStat{Goroutines: -1, HasMemStats: true, HeapAlloc: 2048, HeapObjects: 17, Sys: 8192, NumGC: 3, PauseTotalNs: 1500}
+	if stat != want {
+		t.Errorf("Expected %+v, got %+v", want, stat)
+	}
+
+	if _, err := m.ParseExpvar([]byte(`{"cmdline": []}`)); err == nil {
+		t.Errorf("Expected an error when memstats are missing")
+	}
+}
+
+// TestParseGoroutineProfile tests reading the goroutine count from pprof
+func TestParseGoroutineProfile(t *testing.T) {
+	profile := `goroutine profile: total 42
+12 @ 0x43a1b6 0x4065cc 0x406138
+#	0x4065cb	runtime.chanrecv1+0x2b
+`
+	n, err := m.ParseGoroutineProfile(strings.NewReader(profile))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if n != 42 {
+		t.Errorf("Expected 42 goroutines, got %d", n)
+	}
+
+	if _, err := m.ParseGoroutineProfile(strings.NewReader("not a profile\n")); err == nil {
+		t.Errorf("Expected an error for an unexpected header")
+	}
+}
+
+// TestGoAppPprofOnly tests that an application exposing only pprof reports
+// its goroutine count without an error
+func TestGoAppPprofOnly(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/debug/pprof/goroutine" {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprintln(w, "goroutine profile: total 7")
+		for i := 0; i < 10000; i++ {
+			fmt.Fprintln(w, "1 @ 0x43a1b6 0x4065cc 0x406138")
+		}
+	}))
+	defer server.Close()
+
+	collector := m.NewSystemCollector()
+	collector.SetGoApps([]m.GoApp{{Name: "api", URL: server.URL}})
+
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		metric, err := collector.Collect()
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if len(metric.GoApps) == 1 {
+			stat := metric.GoApps[0]
+			if stat.Error != "" {
+				t.Errorf("Expected no error, got %s", stat.Error)
+			}
+			if stat.Goroutines != 7 || stat.HasMemStats {
+				t.Errorf("Expected 7 goroutines and no memstats, got %+v", stat)
+			}
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Error("Expected the application to be reported")
+}
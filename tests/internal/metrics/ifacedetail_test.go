@@ -0,0 +1,47 @@
+package metrics
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	m "github.com/j-raghavan/godash/internal/metrics"
+)
+
+// TestReadLinkSettings tests reading the link speed and duplex from sysfs
+func TestReadLinkSettings(t *testing.T) {
+	tests := []struct {
+		name           string
+		speed, duplex  string
+		expectedSpeed  int
+		expectedDuplex string
+	}{
+		{"gigabit", "1000\n", "full\n", 1000, "full"},
+		{"link down", "-1\n", "unknown\n", 0, ""},
+		{"missing files", "", "", 0, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			if tt.speed != "" {
+				if err := os.WriteFile(filepath.Join(dir, "speed"), []byte(tt.speed), 0o644); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(filepath.Join(dir, "duplex"), []byte(tt.duplex), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			speed, duplex := m.ReadLinkSettings(dir)
+			if speed != tt.expectedSpeed || duplex != tt.expectedDuplex {
+				t.Errorf("Expected %d %q, got %d %q", tt.expectedSpeed, tt.expectedDuplex, speed, duplex)
+			}
+		})
+	}
+}
+
+// TestInterfaceDetailsUnknown tests that an unknown interface is an error
+func TestInterfaceDetailsUnknown(t *testing.T) {
+	if _, err := m.InterfaceDetails("godash-no-such-if0"); err == nil {
+		t.Error("Expected an error for an unknown interface")
+	}
+}
@@ -0,0 +1,41 @@
+package metrics
+
+import (
+	"strings"
+	"testing"
+
+	m "github.com/j-raghavan/godash/internal/metrics"
+)
+
+// TestParseProcStat tests reading the kernel counters from /proc/stat
+func TestParseProcStat(t *testing.T) {
+	data := `cpu  45780 0 7798 206076 471 0 5 166 0 0
+cpu0 45780 0 7798 206076 471 0 5 166 0 0
+intr 765077 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 1 1 2 0 520 61
+ctxt 1784611
+btime 1792153509
+processes 25934
+procs_running 2
+procs_blocked 1
+softirq 191116 0 62740 3 7942 0 0 1 0 40 120390
+`
+	stat, err := m.ParseProcStat(strings.NewReader(data))
+	if err != nil {
+		t.Fatalf("ParseProcStat returned error: %v", err)
+	}
+	if stat.ContextSwitches != 1784611 {
+		t.Errorf("Expected 1784611 context switches, got %d", stat.ContextSwitches)
+	}
+	if stat.Interrupts != 765077 {
+		t.Errorf("Expected 765077 interrupts, got %d", stat.Interrupts)
+	}
+	if stat.Forks != 25934 {
+		t.Errorf("Expected 25934 forks, got %d", stat.Forks)
+	}
+	if stat.ProcsRunning != 2 || stat.ProcsBlocked != 1 {
+		t.Errorf("Expected 2 running and 1 blocked, got %d and %d", stat.ProcsRunning, stat.ProcsBlocked)
+	}
+	if stat.EntropyAvail != -1 || stat.HasRates {
+		t.Errorf("Expected no entropy or rates from /proc/stat alone, got %+v", stat)
+	}
+}
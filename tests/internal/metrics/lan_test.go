@@ -0,0 +1,86 @@
+package metrics
+
+import (
+	m "github.com/j-raghavan/godash/internal/metrics"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestParseARPTable tests parsing of /proc/net/arp
+func TestParseARPTable(t *testing.T) {
+	table := `IP address       HW type     Flags       HW address            Mask     Device
+192.168.1.1      0x1         0x2         AA:BB:CC:DD:EE:01     *        eth0
+192.168.1.50     0x1         0x2         aa:bb:cc:dd:ee:32     *        br0
+192.168.1.99     0x1         0x0         00:00:00:00:00:00     *        br0
+`
+	neighbors, err := m.ParseARPTable(strings.NewReader(table))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	want := []m.Neighbor{
+		{IP: "192.168.1.1", MAC: "aa:bb:cc:dd:ee:01", Device: "eth0"},
+		{IP: "192.168.1.50", MAC: "aa:bb:cc:dd:ee:32", Device: "br0"},
+	}
+	if !reflect.DeepEqual(neighbors, want) {
+		t.Errorf("Expected %+v, got %+v", want, neighbors)
+	}
+}
+
+// TestParseDnsmasqLeases tests parsing of dnsmasq lease files
+func TestParseDnsmasqLeases(t *testing.T) {
+	leasesFile := `1700000000 aa:bb:cc:dd:ee:32 192.168.1.50 laptop 01:aa:bb:cc:dd:ee:32
+0 aa:bb:cc:dd:ee:33 192.168.1.51 * *
+`
+	leases, err := m.ParseDnsmasqLeases(strings.NewReader(leasesFile))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	want := []m.Lease{
+		{Expiry: time.Unix(1700000000, 0), MAC: "aa:bb:cc:dd:ee:32", IP: "192.168.1.50", Hostname: "laptop"},
+		{MAC: "aa:bb:cc:dd:ee:33", IP: "192.168.1.51"},
+	}
+	if !reflect.DeepEqual(leases, want) {
+		t.Errorf("Expected %+v, got %+v", want, leases)
+	}
+
+	if _, err := m.ParseDnsmasqLeases(strings.NewReader("never aa:bb 1.2.3.4 host *\n")); err == nil {
+		t.Error("Expected an error for an invalid expiry")
+	}
+}
+
+// TestDeviceTracker tests that unknown devices are listed until they leave
+// the neighbor table or the listing expires
+func TestDeviceTracker(t *testing.T) {
+	router := m.Neighbor{IP: "192.168.1.1", MAC: "aa:bb:cc:dd:ee:01"}
+	phone := m.Neighbor{IP: "192.168.1.60", MAC: "aa:bb:cc:dd:ee:3c"}
+	start := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	tracker := m.NewDeviceTracker(nil)
+	if got := tracker.Update([]m.Neighbor{router}, start); len(got) != 0 {
+		t.Errorf("Expected the first update to be the baseline, got %v", got)
+	}
+	if got := tracker.Update([]m.Neighbor{router, phone}, start.Add(time.Minute)); !reflect.DeepEqual(got, []m.Neighbor{phone}) {
+		t.Errorf("Expected the phone to be unknown, got %v", got)
+	}
+	if got := tracker.Update([]m.Neighbor{router, phone}, start.Add(2*time.Hour)); len(got) != 0 {
+		t.Errorf("Expected the listing to expire, got %v", got)
+	}
+	if got := tracker.Update([]m.Neighbor{router}, start.Add(3*time.Hour)); len(got) != 0 {
+		t.Errorf("Expected no unknown devices, got %v", got)
+	}
+	if got := tracker.Update([]m.Neighbor{router, phone}, start.Add(4*time.Hour)); len(got) != 0 {
+		t.Errorf("Expected a returning device not to be new, got %v", got)
+	}
+
+	tracker = m.NewDeviceTracker([]string{"AA:BB:CC:DD:EE:01"})
+	if got := tracker.Update([]m.Neighbor{router, phone}, start); !reflect.DeepEqual(got, []m.Neighbor{phone}) {
+		t.Errorf("Expected the phone to be unknown, got %v", got)
+	}
+	if got := tracker.Update([]m.Neighbor{router}, start.Add(time.Minute)); len(got) != 0 {
+		t.Errorf("Expected a device that left to be dropped, got %v", got)
+	}
+}
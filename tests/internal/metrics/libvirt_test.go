@@ -0,0 +1,104 @@
+package metrics
+
+import (
+	m "github.com/j-raghavan/godash/internal/metrics"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const sampleDomstats = `Domain: 'nas'
+  state.state=1
+  state.reason=1
+  cpu.time=123456789000
+  balloon.current=2097152
+  balloon.maximum=4194304
+  vcpu.current=2
+  vcpu.maximum=4
+  net.count=2
+  net.0.name=vnet0
+  net.0.rx.bytes=1000
+  net.0.tx.bytes=2000
+  net.1.name=vnet1
+  net.1.rx.bytes=10
+  net.1.tx.bytes=20
+  block.count=1
+  block.0.name=vda
+  block.0.rd.bytes=4096
+  block.0.wr.bytes=8192
+
+Domain: 'backup'
+  state.state=5
+  state.reason=1
+  balloon.current=1048576
+  vcpu.current=1
+
+`
+
+// TestParseDomstats tests parsing of virsh domstats output
+func TestParseDomstats(t *testing.T) {
+	vms, err := m.ParseDomstats(strings.NewReader(sampleDomstats))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	want := []m.VMStat{
+		{
+			Name:            "nas",
+			State:           "running",
+			VCPUs:           2,
+			CPUTime:         123456789000,
+			Memory:          2 * 1024 * 1024 * 1024,
+			NetRxBytes:      1010,
+			NetTxBytes:      2020,
+			BlockReadBytes:  4096,
+			BlockWriteBytes: 8192,
+		},
+		{
+			Name:   "backup",
+			State:  "shutoff",
+			VCPUs:  1,
+			Memory: 1024 * 1024 * 1024,
+		},
+	}
+	if !reflect.DeepEqual(vms, want) {
+		t.Errorf("Expected %+v, got %+v", want, vms)
+	}
+}
+
+// TestParseDomstatsInvalid tests that malformed counters are reported
+func TestParseDomstatsInvalid(t *testing.T) {
+	_, err := m.ParseDomstats(strings.NewReader("Domain: 'vm'\n  cpu.time=abc\n"))
+	if err == nil {
+		t.Error("Expected an error for an invalid counter")
+	}
+}
+
+// TestLibvirtDisabledByDefault tests that no VMs are reported unless enabled
+func TestLibvirtDisabledByDefault(t *testing.T) {
+	collector := m.NewSystemCollector()
+	metric, err := collector.Collect()
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if metric.VMs != nil {
+		t.Errorf("Expected no VMs, got %v", metric.VMs)
+	}
+}
+
+// TestLibvirtFailureCached tests that a failing virsh is reported without
+// being rerun on every sample
+func TestLibvirtFailureCached(t *testing.T) {
+	t.Setenv("PATH", "")
+	collector := m.NewSystemCollector()
+	collector.SetLibvirt(true)
+	for i := 0; i < 2; i++ {
+		metric, err := collector.Collect()
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if msg := metric.Errors["libvirt"]; !strings.Contains(msg, "libvirt") {
+			t.Errorf("Sample %d: expected a libvirt error, got %q", i, msg)
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package metrics
+
+import (
+	m "github.com/j-raghavan/godash/internal/metrics"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const sampleMdstat = `Personalities : [raid1] [raid6] [raid5] [raid4]
+md0 : active raid1 sdb1[1] sda1[0]
+      1953382464 blocks super 1.2 [2/2] [UU]
+      bitmap: 0/15 pages [0KB], 65536KB chunk
+
+md1 : active raid5 sdc1[2] sdd1[1] sde1[0](F)
+      3906764800 blocks super 1.2 level 5, 512k chunk, algorithm 2 [3/2] [UU_]
+      [===>.................]  recovery = 17.6% (344064000/1953382400) finish=150.2min speed=178560K/sec
+
+md127 : inactive sdf[0](S)
+      976630488 blocks super 1.2
+
+unused devices: <none>
+`
+
+// TestParseMdstat tests parsing of /proc/mdstat
+func TestParseMdstat(t *testing.T) {
+	arrays, err := m.ParseMdstat(strings.NewReader(sampleMdstat))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	want := []m.RaidStat{
+		{
+			Name:        "md0",
+			Level:       "raid1",
+			State:       "active",
+			Devices:     []string{"sdb1", "sda1"},
+			DisksTotal:  2,
+			DisksActive: 2,
+		},
+		{
+			Name:         "md1",
+			Level:        "raid5",
+			State:        "active",
+			Devices:      []string{"sdc1", "sdd1", "sde1"},
+			DisksTotal:   3,
+			DisksActive:  2,
+			Degraded:     true,
+			SyncAction:   "recovery",
+			SyncProgress: 17.6,
+		},
+		{
+			Name:    "md127",
+			State:   "inactive",
+			Devices: []string{"sdf"},
+		},
+	}
+
+	if !reflect.DeepEqual(arrays, want) {
+		t.Errorf("Expected %+v, got %+v", want, arrays)
+	}
+}
+
+// TestParseMdstatEmpty tests parsing when no arrays are configured
+func TestParseMdstatEmpty(t *testing.T) {
+	arrays, err := m.ParseMdstat(strings.NewReader("Personalities : \nunused devices: <none>\n"))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(arrays) != 0 {
+		t.Errorf("Expected no arrays, got %d", len(arrays))
+	}
+}
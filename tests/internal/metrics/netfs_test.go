@@ -0,0 +1,45 @@
+package metrics
+
+import (
+	m "github.com/j-raghavan/godash/internal/metrics"
+	"testing"
+)
+
+// TestIsNetworkFilesystem tests detection of network-backed filesystems
+func TestIsNetworkFilesystem(t *testing.T) {
+	tests := []struct {
+		fstype string
+		want   bool
+	}{
+		{"nfs", true},
+		{"nfs4", true},
+		{"cifs", true},
+		{"smb3", true},
+		{"fuse.sshfs", true},
+		{"ext4", false},
+		{"tmpfs", false},
+		{"fuse.gvfsd-fuse", false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.fstype, func(t *testing.T) {
+			if got := m.IsNetworkFilesystem(tc.fstype); got != tc.want {
+				t.Errorf("Expected %v for %s, got %v", tc.want, tc.fstype, got)
+			}
+		})
+	}
+}
+
+// TestLocalDisksHaveNoHealth tests that local disks are not probed as network mounts
+func TestLocalDisksHaveNoHealth(t *testing.T) {
+	collector := m.NewSystemCollector()
+	metric, err := collector.Collect()
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	for _, disk := range metric.Disk {
+		if !m.IsNetworkFilesystem(disk.FsType) && disk.Health != "" {
+			t.Errorf("Expected no health for local disk %s, got %q", disk.Path, disk.Health)
+		}
+	}
+}
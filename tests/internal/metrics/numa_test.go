@@ -0,0 +1,73 @@
+package metrics
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	m "github.com/j-raghavan/godash/internal/metrics"
+)
+
+// TestParseMeminfoHugePages tests reading the huge page pool
+func TestParseMeminfoHugePages(t *testing.T) {
+	data := `MemTotal:        5603064 kB
+AnonHugePages:         0 kB
+HugePages_Total:     512
+HugePages_Free:      128
+HugePages_Rsvd:       64
+HugePages_Surp:        0
+Hugepagesize:       2048 kB
+`
+	stat, err := m.ParseMeminfoHugePages(strings.NewReader(data))
+	if err != nil {
+		t.Fatalf("ParseMeminfoHugePages returned error: %v", err)
+	}
+	expected := m.HugePageStat{Total: 512, Free: 128, Reserved: 64, PageSize: 2 << 20}
+	if stat != expected {
+		t.Errorf("Expected %+v, got %+v", expected, stat)
+	}
+	if stat.UsedPercent() != 87.5 {
+		t.Errorf("Expected 87.5%% used, got %.1f", stat.UsedPercent())
+	}
+}
+
+// TestParseNodeMeminfo tests reading a NUMA node's memory
+func TestParseNodeMeminfo(t *testing.T) {
+	data := `Node 1 MemTotal:        8388608 kB
+Node 1 MemFree:         2097152 kB
+Node 1 MemUsed:         6291456 kB
+Node 1 Active:           830596 kB
+Node 1 HugePages_Total:     16
+Node 1 HugePages_Free:       4
+`
+	node, err := m.ParseNodeMeminfo(strings.NewReader(data))
+	if err != nil {
+		t.Fatalf("ParseNodeMeminfo returned error: %v", err)
+	}
+	if node.ID != 1 || node.MemTotal != 8<<30 || node.MemUsed != 6<<30 || node.MemFree != 2<<30 {
+		t.Errorf("Unexpected node: %+v", node)
+	}
+	if node.HugePagesTotal != 16 || node.HugePagesFree != 4 {
+		t.Errorf("Expected 4 of 16 huge pages free, got %d of %d", node.HugePagesFree, node.HugePagesTotal)
+	}
+	if node.MemUsedPercent() != 75 {
+		t.Errorf("Expected 75%% used, got %.1f", node.MemUsedPercent())
+	}
+}
+
+// TestParseCPUList tests kernel CPU list parsing
+func TestParseCPUList(t *testing.T) {
+	cpus, err := m.ParseCPUList("0-3,8,10-11\n")
+	if err != nil {
+		t.Fatalf("ParseCPUList returned error: %v", err)
+	}
+	if expected := []int{0, 1, 2, 3, 8, 10, 11}; !reflect.DeepEqual(cpus, expected) {
+		t.Errorf("Expected %v, got %v", expected, cpus)
+	}
+	if cpus, err := m.ParseCPUList(""); err != nil || cpus != nil {
+		t.Errorf("Expected no CPUs for an empty list, got %v, %v", cpus, err)
+	}
+	if _, err := m.ParseCPUList("3-1"); err == nil {
+		t.Error("Expected an error for a reversed range")
+	}
+}
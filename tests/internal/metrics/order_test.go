@@ -0,0 +1,75 @@
+package metrics
+
+import (
+	m "github.com/j-raghavan/godash/internal/metrics"
+	"reflect"
+	"testing"
+)
+
+// TestOrderDisks tests that pinned disks come first and the rest are sorted
+func TestOrderDisks(t *testing.T) {
+	disks := []m.DiskStat{
+		{Path: "/var"},
+		{Path: "/mnt/backup", Device: "/dev/sdb1"},
+		{Path: "/"},
+		{Path: "/home", Label: "Home"},
+	}
+
+	tests := []struct {
+		name   string
+		pinned []string
+		want   []string
+	}{
+		{name: "no pins", pinned: nil, want: []string{"/", "/home", "/mnt/backup", "/var"}},
+		{name: "pin by path", pinned: []string{"/var"}, want: []string{"/var", "/", "/home", "/mnt/backup"}},
+		{name: "pin by device and label", pinned: []string{"/dev/sdb1", "Home"}, want: []string{"/mnt/backup", "/home", "/", "/var"}},
+		{name: "unknown pin", pinned: []string{"/nope"}, want: []string{"/", "/home", "/mnt/backup", "/var"}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var got []string
+			for _, disk := range m.OrderDisks(disks, tc.pinned) {
+				got = append(got, disk.Path)
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("Expected %v, got %v", tc.want, got)
+			}
+		})
+	}
+
+	if disks[0].Path != "/var" {
+		t.Error("Expected input slice to be left untouched")
+	}
+}
+
+// TestOrderInterfaces tests that pinned interfaces come first, then by traffic
+func TestOrderInterfaces(t *testing.T) {
+	stats := []m.NetworkStat{
+		{Interface: "lo", RxBytesPerSec: 5, TxBytesPerSec: 5, RxBytes: 1 << 30},
+		{Interface: "eth0", RxBytesPerSec: 500, TxBytesPerSec: 100},
+		{Interface: "wlan0", RxBytesPerSec: 20, RxBytes: 10},
+		{Interface: "docker0", RxBytesPerSec: 20, RxBytes: 20, Label: "Docker"},
+	}
+
+	tests := []struct {
+		name   string
+		pinned []string
+		want   []string
+	}{
+		{name: "by traffic", pinned: nil, want: []string{"eth0", "docker0", "wlan0", "lo"}},
+		{name: "pinned first", pinned: []string{"lo", "Docker"}, want: []string{"lo", "docker0", "eth0", "wlan0"}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var got []string
+			for _, net := range m.OrderInterfaces(stats, tc.pinned) {
+				got = append(got, net.Interface)
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("Expected %v, got %v", tc.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,77 @@
+package metrics
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	m "github.com/j-raghavan/godash/internal/metrics"
+)
+
+// TestParseProcNetListeners tests reading listening sockets from /proc/net
+func TestParseProcNetListeners(t *testing.T) {
+	tcp := `  sl  local_address rem_address   st tx_queue rx_queue tr tm->when retrnsmt   uid  timeout inode
+   0: 00000000:0016 00000000:0000 0A 00000000:00000000 00:00000000 00000000     0        0 1234 1 0000000000000000 100 0 0 10 0
+   1: 0100007F:1F90 00000000:0000 0A 00000000:00000000 00:00000000 00000000  1000        0 1235 1 0000000000000000 100 0 0 10 0
+   2: 0A00000F:0016 0A000001:D431 01 00000000:00000000 02:00091A2E 00000000     0        0 1236 4 0000000000000000 20 4 29 10 -1
+`
+	listeners, err := m.ParseProcNetListeners(strings.NewReader(tcp), "tcp")
+	if err != nil {
+		t.Fatalf("ParseProcNetListeners returned error: %v", err)
+	}
+	expected := []m.Listener{{Port: 22, Protocol: "tcp"}, {Port: 8080, Protocol: "tcp"}}
+	if !reflect.DeepEqual(listeners, expected) {
+		t.Errorf("Expected %v, got %v", expected, listeners)
+	}
+
+	udp6 := `  sl  local_address                         remote_address                        st tx_queue rx_queue tr tm->when retrnsmt   uid  timeout inode ref pointer drops
+  100: 00000000000000000000000000000000:0035 00000000000000000000000000000000:0000 07 00000000:00000000 00:00000000 00000000     0        0 2001 2 0000000000000000 0
+  101: 00000000000000000000000001000000:A1B2 00000000000000000000000001000000:0035 01 00000000:00000000 00:00000000 00000000     0        0 2002 2 0000000000000000 0
+`
+	listeners, err = m.ParseProcNetListeners(strings.NewReader(udp6), "udp")
+	if err != nil {
+		t.Fatalf("ParseProcNetListeners returned error: %v", err)
+	}
+	expected = []m.Listener{{Port: 53, Protocol: "udp"}}
+	if !reflect.DeepEqual(listeners, expected) {
+		t.Errorf("Expected %v, got %v", expected, listeners)
+	}
+}
+
+// TestWatchPorts tests port expectations and unexpected listeners
+func TestWatchPorts(t *testing.T) {
+	watches := []m.PortWatch{
+		{Port: 22, Protocol: "tcp", Expect: m.PortListening},
+		{Port: 5432, Protocol: "tcp", Expect: m.PortListening},
+		{Port: 23, Protocol: "tcp", Expect: m.PortClosed},
+	}
+	listeners := []m.Listener{
+		{Port: 22, Protocol: "tcp"},
+		{Port: 23, Protocol: "tcp"},
+		{Port: 631, Protocol: "tcp"},
+		{Port: 9000, Protocol: "tcp"},
+		{Port: 5353, Protocol: "udp"},
+	}
+	baseline := map[m.Listener]bool{{Port: 631, Protocol: "tcp"}: true}
+
+	statuses := m.WatchPorts(watches, listeners, baseline)
+	if len(statuses) != 4 {
+		t.Fatalf("Expected 4 statuses, got %d: %+v", len(statuses), statuses)
+	}
+	if !statuses[0].OK || !statuses[0].Listening {
+		t.Errorf("Expected tcp/22 listening and OK, got %+v", statuses[0])
+	}
+	if statuses[1].OK || statuses[1].Listening {
+		t.Errorf("Expected tcp/5432 down, got %+v", statuses[1])
+	}
+	if statuses[2].OK || !statuses[2].Listening {
+		t.Errorf("Expected tcp/23 open against expectation, got %+v", statuses[2])
+	}
+	if statuses[3].Name() != "tcp/9000" || !statuses[3].Unexpected {
+		t.Errorf("Expected tcp/9000 reported as unexpected, got %+v", statuses[3])
+	}
+
+	if statuses := m.WatchPorts(watches, listeners, nil); len(statuses) != 3 {
+		t.Errorf("Expected no unexpected listeners without a baseline, got %d statuses", len(statuses))
+	}
+}
@@ -0,0 +1,162 @@
+package metrics
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	m "github.com/j-raghavan/godash/internal/metrics"
+)
+
+// TestWatchProcesses tests process watch states and restart detection
+func TestWatchProcesses(t *testing.T) {
+	watches := []m.ProcessWatch{
+		{Name: "postgres", MinCount: 2},
+		{Name: "nginx", MaxMemory: 100},
+		{Name: "redis"},
+	}
+	now := time.Now()
+	procs := []m.ProcessInfo{
+		{PID: 12, Name: "postgres", CPUPercent: 1.5, Memory: 40},
+		{PID: 10, Name: "postgres", CPUPercent: 0.5, Memory: 60},
+		{PID: 20, Name: "nginx", Memory: 150},
+	}
+
+	statuses := m.WatchProcesses(watches, procs, nil, now)
+	if len(statuses) != 3 {
+		t.Fatalf("Expected 3 statuses, got %d", len(statuses))
+	}
+	pg := statuses[0]
+	if pg.Status != m.ProcessOK || pg.Count != 2 || pg.CPUPercent != 2 || pg.Memory != 100 {
+		t.Errorf("Unexpected postgres status: %+v", pg)
+	}
+	if !reflect.DeepEqual(pg.PIDs, []int32{10, 12}) {
+		t.Errorf("Expected sorted PIDs, got %v", pg.PIDs)
+	}
+	if statuses[1].Status != m.ProcessOverLimit {
+		t.Errorf("Expected nginx over its memory limit, got %s", statuses[1].Status)
+	}
+	if statuses[2].Status != m.ProcessDown {
+		t.Errorf("Expected redis down, got %s", statuses[2].Status)
+	}
+
+	prev := map[string]m.ProcessStatus{}
+	for _, s := range statuses {
+		prev[s.Name] = s
+	}
+	// postgres worker 12 was replaced by 13
+	procs[0].PID = 13
+	later := now.Add(time.Minute)
+	statuses = m.WatchProcesses(watches, procs, prev, later)
+	if statuses[0].Status != m.ProcessRestarted || statuses[0].Restarts != 1 || !statuses[0].LastRestart.Equal(later) {
+		t.Errorf("Expected postgres restarted, got %+v", statuses[0])
+	}
+	if statuses[1].Restarts != 0 {
+		t.Errorf("Expected nginx not restarted, got %d", statuses[1].Restarts)
+	}
+
+	// The badge clears after a while
+	prev = map[string]m.ProcessStatus{"postgres": statuses[0]}
+	statuses = m.WatchProcesses(watches[:1], procs, prev, later.Add(time.Hour))
+	if statuses[0].Status != m.ProcessOK || statuses[0].Restarts != 1 {
+		t.Errorf("Expected postgres ok with one restart, got %+v", statuses[0])
+	}
+}
+
+// TestParseSmapsRollup tests reading PSS and USS from smaps_rollup
+func TestParseSmapsRollup(t *testing.T) {
+	data := `55d6b6eff000-7ffce3943000 ---p 00000000 00:00 0                          [rollup]
+Rss:                1412 kB
+Pss:                 487 kB
+Pss_Anon:            104 kB
+Shared_Clean:       1268 kB
+Shared_Dirty:          0 kB
+Private_Clean:        40 kB
+Private_Dirty:       104 kB
+`
+	pss, uss, err := m.ParseSmapsRollup(strings.NewReader(data))
+	if err != nil {
+		t.Fatalf("ParseSmapsRollup returned error: %v", err)
+	}
+	if pss != 487*1024 {
+		t.Errorf("Expected PSS of 487 KiB, got %d", pss)
+	}
+	if uss != 144*1024 {
+		t.Errorf("Expected USS of 144 KiB, got %d", uss)
+	}
+	if _, _, err := m.ParseSmapsRollup(strings.NewReader("Rss: 1 kB\n")); err == nil {
+		t.Error("Expected an error without a Pss field")
+	}
+}
+
+// TestWatchProcessesPSSLimit tests that memory limits use PSS when measured
+func TestWatchProcessesPSSLimit(t *testing.T) {
+	watches := []m.ProcessWatch{{Name: "postgres", MaxMemory: 100}}
+	procs := []m.ProcessInfo{
+		{PID: 10, Name: "postgres", Memory: 90, PSS: 40, USS: 10},
+		{PID: 11, Name: "postgres", Memory: 90, PSS: 40, USS: 10},
+	}
+	statuses := m.WatchProcesses(watches, procs, nil, time.Now())
+	if statuses[0].Status != m.ProcessOK {
+		t.Errorf("Expected postgres within its limit by PSS, got %s", statuses[0].Status)
+	}
+	if statuses[0].PSS != 80 || statuses[0].USS != 20 || statuses[0].Memory != 180 {
+		t.Errorf("Unexpected memory totals: %+v", statuses[0])
+	}
+}
+
+// TestCollectProcessWatches tests that watched processes are found by a
+// background scan, including one whose Linux name is truncated and whose
+// command line does not restore it
+func TestCollectProcessWatches(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("Process names are only truncated on Linux")
+	}
+	sleep, err := exec.LookPath("sleep")
+	if err != nil {
+		t.Skip("sleep not available")
+	}
+	data, err := os.ReadFile(sleep)
+	if err != nil {
+		t.Fatalf("Failed to read %s: %v", sleep, err)
+	}
+	const name = "godash-watched-sleeper"
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, data, 0o755); err != nil {
+		t.Fatalf("Failed to copy %s: %v", sleep, err)
+	}
+	cmd := exec.Command(path, "30")
+	cmd.Args[0] = "sleeper"
+	if err := cmd.Start(); err != nil {
+		t.Skipf("Cannot run a copy of sleep: %v", err)
+	}
+	defer func() {
+		_ = cmd.Process.Kill()
+		_ = cmd.Wait()
+	}()
+
+	collector := m.NewSystemCollector()
+	collector.SetProcessWatches([]m.ProcessWatch{{Name: name}}, false)
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		metric, err := collector.Collect()
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if len(metric.Processes) == 1 && metric.Processes[0].Count == 1 {
+			if metric.Processes[0].PIDs[0] != int32(cmd.Process.Pid) {
+				t.Errorf("Expected PID %d, got %v", cmd.Process.Pid, metric.Processes[0].PIDs)
+			}
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected %s to be found, got %+v", name, metric.Processes)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
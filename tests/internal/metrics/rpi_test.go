@@ -0,0 +1,68 @@
+package metrics
+
+import (
+	m "github.com/j-raghavan/godash/internal/metrics"
+	"reflect"
+	"testing"
+)
+
+// TestParseThrottled tests parsing of the firmware throttling flags
+func TestParseThrottled(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    uint64
+		wantErr bool
+	}{
+		{name: "vcgencmd output", input: "throttled=0x50005\n", want: 0x50005},
+		{name: "sysfs output", input: "50000\n", want: 0x50000},
+		{name: "no throttling", input: "throttled=0x0", want: 0},
+		{name: "invalid", input: "throttled=zz", wantErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := m.ParseThrottled(tc.input)
+			if tc.wantErr {
+				if err == nil {
+					t.Error("Expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if got != tc.want {
+				t.Errorf("Expected %#x, got %#x", tc.want, got)
+			}
+		})
+	}
+}
+
+// TestParseGPUMemory tests parsing of the GPU memory split
+func TestParseGPUMemory(t *testing.T) {
+	got, err := m.ParseGPUMemory("gpu=76M\n")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if got != 76*1024*1024 {
+		t.Errorf("Expected %d, got %d", 76*1024*1024, got)
+	}
+
+	if _, err := m.ParseGPUMemory("gpu=76"); err == nil {
+		t.Error("Expected an error for missing unit")
+	}
+}
+
+// TestPiWarnings tests that throttling flags map to warnings
+func TestPiWarnings(t *testing.T) {
+	stat := m.PiStat{ThrottledFlags: m.PiUnderVoltage | m.PiThrottled | m.PiUnderVoltageOccurred}
+	want := []string{"Under-voltage detected", "CPU throttled", "Under-voltage has occurred"}
+	if got := stat.Warnings(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+
+	if got := (m.PiStat{}).Warnings(); len(got) != 0 {
+		t.Errorf("Expected no warnings, got %v", got)
+	}
+}
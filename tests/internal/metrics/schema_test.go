@@ -0,0 +1,171 @@
+package metrics
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/j-raghavan/godash/internal/bandwidth"
+	"github.com/j-raghavan/godash/internal/jobs"
+	m "github.com/j-raghavan/godash/internal/metrics"
+)
+
+var updateSchema = flag.Bool("update-schema", false, "rewrite testdata/metric_v1.json")
+
+// schemaFixture returns a Metric with every field set, so that each JSON
+// field name appears in the golden file
+func schemaFixture() m.Metric {
+	at := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	return m.Metric{
+		SchemaVersion: m.SchemaVersion,
+		Timestamp:     at,
+		Tags:          map[string]string{"env": "prod"},
+		CPU:           []float64{12.5, 37.5},
+		CPUTotal:      25,
+		CPUStates:     m.CPUStateStat{User: 15, Nice: 1, System: 5, IOWait: 2, Steal: 1, IRQ: 0.5, SoftIRQ: 0.5, Idle: 75},
+		CPUFreq:       []m.CPUFreqStat{{CPU: 0, Governor: "powersave", CurrentMHz: 1200, MaxMHz: 3600}},
+		Memory:        m.MemoryStat{Total: 8 << 30, Free: 2 << 30, Used: 6 << 30, UsedPercentage: 75},
+		Disk: []m.DiskStat{{
+			Path: "/", Device: "/dev/sda1", Label: "root", FsType: "ext4", Health: "ok",
+			Latency: 2 * time.Millisecond, Total: 100 << 30, Used: 40 << 30, Free: 60 << 30,
+			UsedPercentage: 40, FullIn: 48 * time.Hour,
+		}},
+		RAID: []m.RaidStat{{
+			Name: "md0", Level: "raid1", State: "active", Devices: []string{"sda1", "sdb1"},
+			DisksTotal: 2, DisksActive: 1, Degraded: true, SyncAction: "recovery", SyncProgress: 12.5,
+		}},
+		Network: []m.NetworkStat{{
+			Interface: "eth0", Label: "uplink", Up: true, RxBytes: 1000, TxBytes: 2000,
+			RxPackets: 10, TxPackets: 20, HasRates: true, RxBytesPerSec: 100, TxBytesPerSec: 200,
+			RxPacketsPerSec: 1, TxPacketsPerSec: 2,
+		}},
+		GoRuntime: m.GoRuntimeStat{
+			NumGoroutine: 8, MemAlloc: 1 << 20, MemSys: 8 << 20, NumGC: 3, PauseTotalNs: 1500,
+			HeapObjects: 4000, GOMAXPROCS: 4, NumCgoCall: 1, RecentPauses: []time.Duration{500, 1000},
+		},
+		Pi: &m.PiStat{Model: "Raspberry Pi 4 Model B", Temperature: 48.5, ThrottledFlags: 0x50000, GPUMemory: 76 << 20},
+		VMs: []m.VMStat{{
+			Name: "web", State: "running", VCPUs: 2, CPUTime: 5e9, CPUPercent: 12, Memory: 2 << 30,
+			NetRxBytes: 10, NetTxBytes: 20, BlockReadBytes: 30, BlockWriteBytes: 40,
+			NetRxBytesPerSec: 1, NetTxBytesPerSec: 2, BlockReadBytesPerSec: 3, BlockWriteBytesPerSec: 4,
+		}},
+		Tunnels: []m.TunnelPeer{{
+			Source: "wireguard", Interface: "wg0", Peer: "peer", Endpoint: "203.0.113.1:51820",
+			LatestHandshake: at, ConnectedSince: at, RxBytes: 1, TxBytes: 2,
+		}},
+		WAN: &m.WANStat{
+			PublicIP: "203.0.113.7", PreviousIP: "203.0.113.6", ChangedAt: at, Provider: "https://ifconfig.me",
+			GatewayLatency: 15 * time.Millisecond, LastCheck: at, Error: "timeout",
+		},
+		Jobs: []jobs.Status{{Name: "backup", LastRun: at, NextDue: at, Overdue: true, Late: time.Hour}},
+		LAN: &m.LANStat{
+			Neighbors:      []m.Neighbor{{IP: "192.168.1.10", MAC: "aa:bb:cc:dd:ee:ff", Device: "br0", Hostname: "nas"}},
+			ActiveLeases:   5,
+			UnknownDevices: []m.Neighbor{{IP: "192.168.1.66", MAC: "11:22:33:44:55:66", Device: "br0"}},
+		},
+		Hardware: []m.HardwareSensor{{Host: "bmc", Name: "Fan1", Kind: "fan", Value: 3000, Unit: "RPM", Status: "OK"}},
+		DNS: &m.DNSStat{
+			Source: "pihole", Queries: 1000, Blocked: 100, BlockedPercent: 10, BlockingEnabled: true,
+			TopClients: []m.DNSClient{{Name: "laptop", Queries: 500}}, History: []float64{9, 10},
+			LastCheck: at, Error: "unauthorized",
+		},
+		GoApps: []m.GoAppStat{{
+			Name: "api", Goroutines: 12, HeapAlloc: 1 << 20, HeapObjects: 300, Sys: 8 << 20,
+			NumGC: 4, PauseTotalNs: 2000, HasMemStats: true, Error: "refused",
+		}},
+		Processes: []m.ProcessStatus{{
+			Name: "postgres", Status: m.ProcessRestarted, Count: 2, PIDs: []int32{100, 101}, CPUPercent: 3,
+			Memory: 64 << 20, PSS: 48 << 20, USS: 32 << 20, Restarts: 1, LastRestart: at,
+		}},
+		Ports:     []m.PortStatus{{Port: 22, Protocol: "tcp", Expect: m.PortListening, Listening: true, OK: true, Unexpected: true}},
+		Certs:     []m.CertStat{{Name: "web", Subject: "example.com", Issuer: "R3", NotAfter: at, DaysLeft: 10.5, Expiring: true, Error: "x"}},
+		Kernel:    &m.KernelStat{ContextSwitches: 1, Interrupts: 2, Forks: 3, ProcsRunning: 4, ProcsBlocked: 5, EntropyAvail: 256, HasRates: true, ContextSwitchesPerSec: 6, InterruptsPerSec: 7, ForksPerSec: 8},
+		HugePages: &m.HugePageStat{Total: 512, Free: 256, Reserved: 8, Surplus: 1, PageSize: 2 << 20},
+		NUMA:      []m.NUMANode{{ID: 0, CPUs: []int{0, 1}, MemTotal: 8 << 30, MemFree: 2 << 30, MemUsed: 6 << 30, HugePagesTotal: 512, HugePagesFree: 256, CPUPercent: 25}},
+		Bandwidth: &bandwidth.Report{
+			Interfaces:  []bandwidth.Usage{{Interface: "eth0", TodayRx: 1 << 30, TodayTx: 1 << 20, MonthRx: 80 << 30, MonthTx: 5 << 30}},
+			PeriodStart: at, Quota: 100 << 30, QuotaUsed: 85 << 30, QuotaPercent: 85, QuotaLevel: 80,
+		},
+		Interval: time.Second,
+		Elapsed:  90 * time.Second,
+		Errors:   map[string]string{"dns": "unauthorized"},
+	}
+}
+
+// TestMetricSchemaV1 guards the JSON wire format: encoding a fully
+// populated Metric must produce exactly the names in the golden file, and
+// the golden file must decode back into the same Metric. A failure here
+// means a field was renamed or removed, which needs a new SchemaVersion.
+func TestMetricSchemaV1(t *testing.T) {
+	golden := filepath.Join("testdata", "metric_v1.json")
+	got, err := json.MarshalIndent(schemaFixture(), "", "  ")
+	if err != nil {
+		t.Fatalf("Marshal returned error: %v", err)
+	}
+	got = append(got, '\n')
+	if *updateSchema {
+		if err := os.WriteFile(golden, got, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatalf("Failed to read golden file: %v", err)
+	}
+
+	var gotFields, wantFields map[string]any
+	if err := json.Unmarshal(got, &gotFields); err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(want, &wantFields); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(gotFields, wantFields) {
+		t.Errorf("Expected JSON encoding to match %s, got\n%s", golden, got)
+	}
+
+	var decoded m.Metric
+	decoder := json.NewDecoder(bytes.NewReader(want))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&decoded); err != nil {
+		t.Fatalf("Failed to decode golden file: %v", err)
+	}
+	if !reflect.DeepEqual(decoded, schemaFixture()) {
+		t.Errorf("Expected golden file to decode into the fixture, got %+v", decoded)
+	}
+}
+
+// TestCollectElapsed tests that samples carry a monotonic time since the
+// collector started that increases between samples
+func TestCollectElapsed(t *testing.T) {
+	collector := m.NewSystemCollector()
+	first, err := collector.Collect()
+	if err != nil {
+		t.Fatalf("Collect returned error: %v", err)
+	}
+	time.Sleep(10 * time.Millisecond)
+	second, err := collector.Collect()
+	if err != nil {
+		t.Fatalf("Collect returned error: %v", err)
+	}
+	if first.Elapsed <= 0 || second.Elapsed-first.Elapsed < 10*time.Millisecond {
+		t.Errorf("Expected increasing elapsed times, got %v then %v", first.Elapsed, second.Elapsed)
+	}
+}
+
+// TestCollectSetsSchemaVersion tests that collected samples carry the
+// schema version
+func TestCollectSetsSchemaVersion(t *testing.T) {
+	metric, err := m.NewSystemCollector().Collect()
+	if err != nil {
+		t.Fatalf("Collect returned error: %v", err)
+	}
+	if metric.SchemaVersion != m.SchemaVersion {
+		t.Errorf("Expected schema version %d, got %d", m.SchemaVersion, metric.SchemaVersion)
+	}
+}
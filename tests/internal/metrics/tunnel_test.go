@@ -0,0 +1,93 @@
+package metrics
+
+import (
+	m "github.com/j-raghavan/godash/internal/metrics"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestParseWireGuardDump tests parsing of `wg show all dump`
+func TestParseWireGuardDump(t *testing.T) {
+	dump := "wg0\tprivkey=\tpubkey=\t51820\toff\n" +
+		"wg0\tpeerA=\t(none)\t203.0.113.1:51820\t10.0.0.2/32\t1700000000\t1024\t2048\t25\n" +
+		"wg0\tpeerB=\t(none)\t(none)\t10.0.0.3/32\t0\t0\t0\toff\n"
+
+	peers, err := m.ParseWireGuardDump(strings.NewReader(dump))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(peers) != 2 {
+		t.Fatalf("Expected 2 peers, got %d", len(peers))
+	}
+
+	if peers[0].Interface != "wg0" || peers[0].Peer != "peerA=" || peers[0].Endpoint != "203.0.113.1:51820" {
+		t.Errorf("Unexpected peer: %+v", peers[0])
+	}
+	if !peers[0].LatestHandshake.Equal(time.Unix(1700000000, 0)) {
+		t.Errorf("Expected handshake at 1700000000, got %v", peers[0].LatestHandshake)
+	}
+	if peers[0].RxBytes != 1024 || peers[0].TxBytes != 2048 {
+		t.Errorf("Expected 1024/2048 bytes, got %d/%d", peers[0].RxBytes, peers[0].TxBytes)
+	}
+
+	if peers[1].Endpoint != "" || !peers[1].LatestHandshake.IsZero() {
+		t.Errorf("Expected peer without endpoint or handshake, got %+v", peers[1])
+	}
+}
+
+// TestParseOpenVPNStatus tests parsing of the OpenVPN status file versions
+func TestParseOpenVPNStatus(t *testing.T) {
+	tests := []struct {
+		name   string
+		status string
+	}{
+		{
+			name: "version 1",
+			status: `OpenVPN CLIENT LIST
+Updated,Thu Jun 18 08:12:15 2015
+Common Name,Real Address,Bytes Received,Bytes Sent,Connected Since
+laptop,198.51.100.7:1194,12345,67890,Thu Jun 18 08:00:00 2015
+ROUTING TABLE
+Virtual Address,Common Name,Real Address,Last Ref
+10.8.0.6,laptop,198.51.100.7:1194,Thu Jun 18 08:12:09 2015
+GLOBAL STATS
+Max bcast/mcast queue length,0
+END
+`,
+		},
+		{
+			name: "version 2",
+			status: `TITLE,OpenVPN 2.4.7
+TIME,Thu Jun 18 08:12:15 2015,1434615135
+HEADER,CLIENT_LIST,Common Name,Real Address,Virtual Address,Virtual IPv6 Address,Bytes Received,Bytes Sent,Connected Since,Connected Since (time_t),Username,Client ID,Peer ID
+CLIENT_LIST,laptop,198.51.100.7:1194,10.8.0.6,,12345,67890,Thu Jun 18 08:00:00 2015,1434614400,UNDEF,0,0
+HEADER,ROUTING_TABLE,Virtual Address,Common Name,Real Address,Last Ref,Last Ref (time_t)
+ROUTING_TABLE,10.8.0.6,laptop,198.51.100.7:1194,Thu Jun 18 08:12:09 2015,1434615129
+END
+`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			peers, err := m.ParseOpenVPNStatus(strings.NewReader(tc.status))
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if len(peers) != 1 {
+				t.Fatalf("Expected 1 peer, got %d", len(peers))
+			}
+			peer := peers[0]
+			if peer.Source != "openvpn" || peer.Peer != "laptop" || peer.Endpoint != "198.51.100.7:1194" {
+				t.Errorf("Unexpected peer: %+v", peer)
+			}
+			if peer.RxBytes != 12345 || peer.TxBytes != 67890 {
+				t.Errorf("Expected 12345/67890 bytes, got %d/%d", peer.RxBytes, peer.TxBytes)
+			}
+			if peer.ConnectedSince.IsZero() {
+				t.Error("Expected connection time to be set")
+			}
+		})
+	}
+}
@@ -0,0 +1,98 @@
+package metrics
+
+import (
+	"context"
+	m "github.com/j-raghavan/godash/internal/metrics"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// TestResolvePublicIP tests provider fallback and address validation
+func TestResolvePublicIP(t *testing.T) {
+	broken := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+	defer broken.Close()
+
+	garbage := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("<html>not an ip</html>"))
+	}))
+	defer garbage.Close()
+
+	working := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("203.0.113.7\n"))
+	}))
+	defer working.Close()
+
+	client := &http.Client{Timeout: time.Second}
+
+	ip, provider, err := m.ResolvePublicIP(context.Background(), client,
+		[]string{broken.URL, garbage.URL, working.URL})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if ip != "203.0.113.7" {
+		t.Errorf("Expected 203.0.113.7, got %s", ip)
+	}
+	if provider != working.URL {
+		t.Errorf("Expected provider %s, got %s", working.URL, provider)
+	}
+
+	if _, _, err := m.ResolvePublicIP(context.Background(), client, []string{broken.URL, garbage.URL}); err == nil {
+		t.Error("Expected an error when no provider returns an address")
+	}
+}
+
+// TestWANWidget tests that the collector reports the public IP once resolved
+func TestWANWidget(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("198.51.100.1"))
+	}))
+	defer server.Close()
+
+	collector := m.NewSystemCollector()
+	collector.SetWAN([]string{server.URL}, time.Hour, "")
+
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		metric, err := collector.Collect()
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if metric.WAN != nil {
+			if metric.WAN.PublicIP != "198.51.100.1" {
+				t.Errorf("Expected 198.51.100.1, got %s", metric.WAN.PublicIP)
+			}
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Error("Expected WAN status to be reported")
+}
+
+// TestLowMemoryPolling tests that background monitors poll less often in
+// low-memory mode
+func TestLowMemoryPolling(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		_, _ = w.Write([]byte("198.51.100.1"))
+	}))
+	defer server.Close()
+
+	collector := m.NewSystemCollector()
+	collector.SetLowMemory(true)
+	collector.SetWAN([]string{server.URL}, 100*time.Millisecond, "")
+	for start := time.Now(); time.Since(start) < 300*time.Millisecond; {
+		if _, err := collector.Collect(); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if n := calls.Load(); n != 1 {
+		t.Errorf("Expected one lookup within four intervals, got %d", n)
+	}
+}
@@ -0,0 +1,309 @@
+package output_test
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/j-raghavan/godash/internal/metrics"
+	"github.com/j-raghavan/godash/internal/output"
+)
+
+// sample is a metric with known values
+var sample = metrics.Metric{
+	Timestamp: time.Unix(1700000000, 0),
+	CPUTotal:  23.4,
+	Memory:    metrics.MemoryStat{UsedPercentage: 61},
+	Disk:      []metrics.DiskStat{{Path: "/", UsedPercentage: 71.2}},
+	Network: []metrics.NetworkStat{
+		{Interface: "eth0", HasRates: true, RxBytesPerSec: 1.2 * 1024 * 1024, TxBytesPerSec: 200 * 1024},
+		{Interface: "wlan0", HasRates: true, TxBytesPerSec: 100 * 1024},
+	},
+}
+
+func TestRenderTime(t *testing.T) {
+	output.SetLocation(time.UTC)
+	defer output.SetLocation(time.Local)
+
+	tmpl, err := output.Parse(`{{.Time}} {{rfc3339 .Metric.Timestamp}}`)
+	require.NoError(t, err)
+	text, err := output.Render(tmpl, sample)
+	require.NoError(t, err)
+	assert.Equal(t, "2023-11-14T22:13:20Z 2023-11-14T22:13:20Z", text)
+}
+
+func TestRenderBuiltin(t *testing.T) {
+	tmpl, err := output.Parse("oneline")
+	require.NoError(t, err)
+	text, err := output.Render(tmpl, sample)
+	require.NoError(t, err)
+	assert.Equal(t, "cpu 23% | mem 61% | / 71% | ↓1.2MB/s ↑300KB/s", text)
+
+	tmpl, err = output.Parse("nagios")
+	require.NoError(t, err)
+	text, err = output.Render(tmpl, sample)
+	require.NoError(t, err)
+	assert.Equal(t, "OK - cpu 23%, memory 61% | cpu=23.4%;;;0;100 memory=61.0%;;;0;100", text)
+
+	tmpl, err = output.Parse("csv")
+	require.NoError(t, err)
+	header, ok, err := output.Header(tmpl)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "time,cpu_percent,memory_percent,rx_bytes_per_sec,tx_bytes_per_sec", header)
+	text, err = output.Render(tmpl, sample)
+	require.NoError(t, err)
+	assert.Equal(t, "1700000000,23.4,61.0,1258291,307200", text)
+
+	for _, name := range output.BuiltinNames() {
+		tmpl, err := output.Parse(name)
+		require.NoError(t, err, name)
+		_, err = output.Render(tmpl, sample)
+		assert.NoError(t, err, name)
+	}
+}
+
+func TestRenderCustom(t *testing.T) {
+	tmpl, err := output.Parse(`{{value "disk./.used_percent" | printf "%.1f"}} {{disk "/nope"}} {{bytes .Metric.Memory.Total}}`)
+	require.NoError(t, err)
+	text, err := output.Render(tmpl, sample)
+	require.NoError(t, err)
+	assert.Equal(t, "71.2 0 0B", text)
+
+	_, ok, err := output.Header(tmpl)
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	path := filepath.Join(t.TempDir(), "cpu.tmpl")
+	require.NoError(t, os.WriteFile(path, []byte("{{pct .CPU}}\n"), 0o644))
+	tmpl, err = output.Parse("@" + path)
+	require.NoError(t, err)
+	text, err = output.Render(tmpl, sample)
+	require.NoError(t, err)
+	assert.Equal(t, "23%", text)
+
+	_, err = output.Parse("{{.CPU")
+	assert.Error(t, err)
+	_, err = output.Parse("@/does/not/exist")
+	assert.Error(t, err)
+}
+
+func TestRenderInfluxTags(t *testing.T) {
+	tagged := sample
+	tagged.Tags = map[string]string{"rack": "2", "env": "prod"}
+	tmpl, err := output.Parse("influx")
+	require.NoError(t, err)
+	text, err := output.Render(tmpl, tagged)
+	require.NoError(t, err)
+	assert.Contains(t, text, ",env=prod,rack=2 cpu=23.4,")
+}
+
+func TestNewEncoder(t *testing.T) {
+	encoder, err := output.NewEncoder("", "")
+	require.NoError(t, err)
+	data, err := encoder.Encode(sample)
+	require.NoError(t, err)
+	var decoded metrics.Metric
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	assert.Equal(t, sample.CPUTotal, decoded.CPUTotal)
+
+	encoder, err = output.NewEncoder("json", "oneline")
+	require.NoError(t, err)
+	data, err = encoder.Encode(sample)
+	require.NoError(t, err)
+	assert.Equal(t, "cpu 23% | mem 61% | / 71% | ↓1.2MB/s ↑300KB/s", string(data))
+
+	_, err = output.NewEncoder("xml", "")
+	assert.Error(t, err)
+}
+
+func TestLineProtocolEncoder(t *testing.T) {
+	tagged := sample
+	tagged.Tags = map[string]string{"site": "home office", "env": "prod"}
+	tagged.CPU = []float64{10, 36.8}
+	tagged.Disk = []metrics.DiskStat{{Path: "/mnt/a,b", Device: "/dev/sdb1", Total: 100, Used: 25, Free: 75, UsedPercentage: 25}}
+	data, err := output.NewLineProtocolEncoder().Encode(tagged)
+	require.NoError(t, err)
+
+	lines := strings.Split(string(data), "\n")
+	require.Len(t, lines, 7)
+	// Every line is tagged with the host, then the host tags sorted by key
+	for _, line := range lines {
+		assert.Contains(t, line, ",env=prod,site=home\\ office")
+		assert.True(t, strings.HasSuffix(line, " 1700000000000000000"), line)
+	}
+	assert.True(t, strings.HasPrefix(lines[0], "godash_cpu,"))
+	assert.Contains(t, lines[0], " usage_percent=23.4,user=0,")
+	assert.Contains(t, lines[2], ",cpu=1 usage_percent=36.8 ")
+	assert.Contains(t, lines[4], `,device=/dev/sdb1,path=/mnt/a\,b free=75,total=100,used=25,used_percent=25 `)
+	assert.Contains(t, lines[5], ",interface=eth0 rx_bytes=0,rx_bytes_per_sec=1258291.2,rx_packets=0,rx_packets_per_sec=0,"+
+		"tx_bytes=0,tx_bytes_per_sec=204800,tx_packets=0,tx_packets_per_sec=0,up=0 ")
+}
+
+func TestCompactBytes(t *testing.T) {
+	assert.Equal(t, "512B", output.CompactBytes(512))
+	assert.Equal(t, "1.5KB", output.CompactBytes(1536))
+	assert.Equal(t, "300KB", output.CompactBytes(300*1024))
+	assert.Equal(t, "2.0GB", output.CompactBytes(2<<30))
+}
+
+// decodeMsgpack decodes the MessagePack forms MsgpackEncoder writes into the
+// values json.Unmarshal produces, returning the rest of data
+func decodeMsgpack(t *testing.T, data []byte) (any, []byte) {
+	t.Helper()
+	require.NotEmpty(t, data)
+	c, data := data[0], data[1:]
+	length := func(n int) (int, []byte) {
+		var v uint64
+		for _, b := range data[:n] {
+			v = v<<8 | uint64(b)
+		}
+		return int(v), data[n:]
+	}
+	var n int
+	switch {
+	case c <= 0x7f:
+		return float64(c), data
+	case c >= 0xe0:
+		return float64(int8(c)), data
+	case c == 0xc0:
+		return nil, data
+	case c == 0xc2 || c == 0xc3:
+		return c == 0xc3, data
+	case c >= 0xcc && c <= 0xcf:
+		v, rest := length(1 << (c - 0xcc))
+		return float64(v), rest
+	case c == 0xcb:
+		return math.Float64frombits(binary.BigEndian.Uint64(data)), data[8:]
+	case c&0xe0 == 0xa0, c >= 0xd9 && c <= 0xdb:
+		if c&0xe0 == 0xa0 {
+			n = int(c & 0x1f)
+		} else {
+			n, data = length(1 << (c - 0xd9))
+		}
+		return string(data[:n]), data[n:]
+	case c&0xf0 == 0x90, c == 0xdc, c == 0xdd:
+		if c&0xf0 == 0x90 {
+			n = int(c & 0x0f)
+		} else {
+			n, data = length(2 << (c - 0xdc))
+		}
+		items := make([]any, n)
+		for i := range items {
+			items[i], data = decodeMsgpack(t, data)
+		}
+		return items, data
+	case c&0xf0 == 0x80, c == 0xde, c == 0xdf:
+		if c&0xf0 == 0x80 {
+			n = int(c & 0x0f)
+		} else {
+			n, data = length(2 << (c - 0xde))
+		}
+		m := make(map[string]any, n)
+		for i := 0; i < n; i++ {
+			var k, v any
+			k, data = decodeMsgpack(t, data)
+			v, data = decodeMsgpack(t, data)
+			m[k.(string)] = v
+		}
+		return m, data
+	}
+	t.Fatalf("unexpected msgpack byte %#x", c)
+	return nil, nil
+}
+
+func TestMsgpackEncoder(t *testing.T) {
+	tagged := sample
+	tagged.Tags = map[string]string{"site": "home"}
+	tagged.CPU = []float64{10, 36.8}
+	encoder, err := output.NewEncoder("msgpack", "")
+	require.NoError(t, err)
+	data, err := encoder.Encode(tagged)
+	require.NoError(t, err)
+
+	got, rest := decodeMsgpack(t, data)
+	assert.Empty(t, rest)
+	jsonData, err := json.Marshal(tagged)
+	require.NoError(t, err)
+	var want any
+	require.NoError(t, json.Unmarshal(jsonData, &want))
+	assert.Equal(t, want, got, "the same document as JSON")
+	assert.Equal(t, data, output.Frame(encoder, data), "msgpack records need no separator")
+}
+
+// protoFields splits a protobuf message into its fields' varint, fixed64 and
+// length-delimited values by field number
+func protoFields(t *testing.T, data []byte) map[int][]any {
+	t.Helper()
+	fields := make(map[int][]any)
+	for len(data) > 0 {
+		key, n := binary.Uvarint(data)
+		require.Positive(t, n)
+		data = data[n:]
+		field := int(key >> 3)
+		switch key & 7 {
+		case 0:
+			v, n := binary.Uvarint(data)
+			require.Positive(t, n)
+			fields[field], data = append(fields[field], v), data[n:]
+		case 1:
+			v := math.Float64frombits(binary.LittleEndian.Uint64(data))
+			fields[field], data = append(fields[field], v), data[8:]
+		case 2:
+			size, n := binary.Uvarint(data)
+			require.Positive(t, n)
+			data = data[n:]
+			fields[field], data = append(fields[field], data[:size]), data[size:]
+		default:
+			t.Fatalf("unexpected wire type %d", key&7)
+		}
+	}
+	return fields
+}
+
+func TestProtobufEncoder(t *testing.T) {
+	tagged := sample
+	tagged.Tags = map[string]string{"site": "home"}
+	tagged.CPU = []float64{10, 36.8}
+	encoder, err := output.NewEncoder("protobuf", "")
+	require.NoError(t, err)
+	data, err := encoder.Encode(tagged)
+	require.NoError(t, err)
+
+	msg := protoFields(t, data)
+	assert.Equal(t, []any{uint64(1700000000000000000)}, msg[1])
+	tag := protoFields(t, msg[3][0].([]byte))
+	assert.Equal(t, []any{[]byte("site")}, tag[1])
+	assert.Equal(t, []any{[]byte("home")}, tag[2])
+	assert.Equal(t, []any{23.4}, msg[4])
+	cores := msg[5][0].([]byte)
+	require.Len(t, cores, 16, "cores are packed")
+	assert.Equal(t, 36.8, math.Float64frombits(binary.LittleEndian.Uint64(cores[8:])))
+	assert.Equal(t, []any{61.0}, protoFields(t, msg[6][0].([]byte))[4])
+
+	require.Len(t, msg[7], 3, "one entity per disk and interface")
+	disk := protoFields(t, msg[7][0].([]byte))
+	assert.Equal(t, []any{[]byte("disk")}, disk[1])
+	assert.Equal(t, []any{[]byte("/")}, disk[2])
+	var usedPercent any
+	for _, entry := range disk[4] {
+		value := protoFields(t, entry.([]byte))
+		if string(value[1][0].([]byte)) == "used_percent" {
+			usedPercent = value[2][0]
+		}
+	}
+	assert.Equal(t, 71.2, usedPercent)
+
+	framed := output.Frame(encoder, data)
+	size, n := binary.Uvarint(framed)
+	assert.Equal(t, len(data), int(size), "protobuf records are length-prefixed")
+	assert.Equal(t, data, framed[n:])
+}
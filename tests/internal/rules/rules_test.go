@@ -0,0 +1,856 @@
+package rules
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/j-raghavan/godash/internal/bandwidth"
+	"github.com/j-raghavan/godash/internal/config"
+	"github.com/j-raghavan/godash/internal/metrics"
+	"github.com/j-raghavan/godash/internal/rules"
+)
+
+func TestParseCondition(t *testing.T) {
+	cond, err := rules.ParseCondition("cpu >= 90.5")
+	require.NoError(t, err)
+	assert.Equal(t, rules.Condition{Metric: "cpu", Op: ">=", Threshold: 90.5}, cond)
+
+	cond, err = rules.ParseCondition("disk./home.used_percent>80")
+	require.NoError(t, err)
+	assert.Equal(t, rules.Condition{Metric: "disk./home.used_percent", Op: ">", Threshold: 80}, cond)
+
+	for _, expr := range []string{"cpu", "> 90", "cpu > hot", "cpu usage > 90"} {
+		_, err := rules.ParseCondition(expr)
+		assert.Error(t, err, expr)
+	}
+}
+
+func TestConditionMatches(t *testing.T) {
+	values := map[string]float64{
+		"disk./.used_percent":     95,
+		"disk./home.used_percent": 50,
+		"disk./.free":             10,
+	}
+	cond, err := rules.ParseCondition("disk.*.used_percent > 90")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]float64{"disk./.used_percent": 95}, cond.Matches(values))
+
+	cond, err = rules.ParseCondition("disk./home.used_percent != 50")
+	require.NoError(t, err)
+	assert.Empty(t, cond.Matches(values))
+}
+
+func TestSelect(t *testing.T) {
+	values := map[string]float64{"cpu": 40, "cpu.0": 30, "cpu.1": 50, "memory.used_percent": 70}
+
+	selected, err := rules.Select(values, "cpu.*")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]float64{"cpu.0": 30, "cpu.1": 50}, selected)
+
+	selected, err = rules.Select(values, " cpu.* > 40 ")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]float64{"cpu.1": 50}, selected)
+
+	_, err = rules.Select(values, "cpu usage")
+	assert.Error(t, err)
+	assert.Equal(t, []string{"cpu", "cpu.0", "cpu.1", "memory.used_percent"}, rules.Names(values))
+}
+
+func TestParseRules(t *testing.T) {
+	parsed, err := rules.ParseRules([]config.RuleConfig{{
+		Name: "hot",
+		When: "cpu > 90",
+		For:  "1m",
+		Actions: []config.ActionConfig{
+			{Type: "http", URL: "http://example.invalid/hook"},
+			{Type: "script", Command: "true", Payload: "{{.Rule}}"},
+		},
+	}, {
+		Name:  "uplink",
+		Event: rules.EventInterfaceDown,
+		Match: "eth*",
+	}})
+	require.NoError(t, err)
+	require.Len(t, parsed, 2)
+	assert.Equal(t, time.Minute, parsed[0].For)
+	assert.Equal(t, http.MethodPost, parsed[0].Actions[0].Method)
+	assert.Nil(t, parsed[1].Expr)
+
+	tests := []struct {
+		name        string
+		cfg         config.RuleConfig
+		errContains string
+	}{
+		{"missing name", config.RuleConfig{When: "cpu > 1"}, "missing a name"},
+		{"no trigger", config.RuleConfig{Name: "r"}, "needs a when expression"},
+		{"unknown event", config.RuleConfig{Name: "r", Event: "reboot"}, "needs a when expression"},
+		{"both", config.RuleConfig{Name: "r", When: "cpu > 1", Event: rules.EventJobOverdue}, "both"},
+		{"bad for", config.RuleConfig{Name: "r", When: "cpu > 1", For: "soon"}, "invalid for"},
+		{"bad action", config.RuleConfig{Name: "r", When: "cpu > 1", Actions: []config.ActionConfig{{Type: "email"}}}, "unknown action type"},
+		{"bad template", config.RuleConfig{Name: "r", When: "cpu > 1", Actions: []config.ActionConfig{{Type: "script", Command: "true", Payload: "{{"}}}, "invalid payload template"},
+		{"command without program", config.RuleConfig{Name: "r", When: "cpu > 1", Actions: []config.ActionConfig{{Type: "command"}}}, "missing a command"},
+		{"bad timeout", config.RuleConfig{Name: "r", When: "cpu > 1", Actions: []config.ActionConfig{{Type: "command", Command: "true", Timeout: "-1s"}}}, "invalid timeout"},
+		{"max_concurrent on script", config.RuleConfig{Name: "r", When: "cpu > 1", Actions: []config.ActionConfig{{Type: "script", Command: "true", MaxConcurrent: 2}}}, "only applies to command"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := rules.ParseRules([]config.RuleConfig{tt.cfg})
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.errContains)
+		})
+	}
+}
+
+func TestEngineThreshold(t *testing.T) {
+	parsed, err := rules.ParseRules([]config.RuleConfig{{Name: "mem", When: "memory.used_percent > 80", For: "10s"}})
+	require.NoError(t, err)
+	engine := rules.NewEngine(parsed, io.Discard)
+
+	start := time.Now()
+	sample := func(offset time.Duration, used float64) []rules.Event {
+		return engine.Evaluate(metrics.Metric{Timestamp: start.Add(offset), Memory: metrics.MemoryStat{UsedPercentage: used}})
+	}
+
+	assert.Empty(t, sample(0, 85), "condition must hold for 10s")
+	events := sample(10*time.Second, 90)
+	require.Len(t, events, 1)
+	assert.Equal(t, "mem", events[0].Rule)
+	assert.Equal(t, rules.EventThreshold, events[0].Kind)
+	assert.Equal(t, "memory.used_percent", events[0].Subject)
+	assert.Equal(t, 90.0, events[0].Value)
+	assert.Empty(t, sample(20*time.Second, 90), "fires once per crossing")
+
+	assert.Empty(t, sample(30*time.Second, 50))
+	assert.Empty(t, sample(40*time.Second, 90))
+	assert.Len(t, sample(50*time.Second, 90), 1, "fires again after recovering")
+}
+
+func TestEngineEvents(t *testing.T) {
+	parsed, err := rules.ParseRules([]config.RuleConfig{
+		{Name: "uplink", Event: rules.EventInterfaceDown, Match: "eth*"},
+		{Name: "raid", Event: rules.EventRAIDDegraded},
+	})
+	require.NoError(t, err)
+	engine := rules.NewEngine(parsed, io.Discard)
+
+	assert.Empty(t, engine.Evaluate(metrics.Metric{
+		Network: []metrics.NetworkStat{{Interface: "eth0", Up: true}, {Interface: "wlan0", Up: true}},
+		RAID:    []metrics.RaidStat{{Name: "md0"}},
+	}), "the first sample is the baseline")
+
+	events := engine.Evaluate(metrics.Metric{
+		Network: []metrics.NetworkStat{{Interface: "eth0"}, {Interface: "wlan0"}},
+		RAID:    []metrics.RaidStat{{Name: "md0", Degraded: true, DisksTotal: 2, DisksActive: 1}},
+	})
+	require.Len(t, events, 2)
+	assert.Equal(t, "uplink", events[0].Rule)
+	assert.Equal(t, "eth0", events[0].Subject)
+	assert.Equal(t, rules.EventInterfaceDown, events[0].Kind)
+	assert.Equal(t, "raid", events[1].Rule)
+	assert.Equal(t, "md0", events[1].Subject)
+}
+
+func TestHTTPAction(t *testing.T) {
+	var body []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPut, r.Method)
+		assert.Equal(t, "secret", r.Header.Get("X-Token"))
+		body, _ = io.ReadAll(r.Body)
+	}))
+	defer server.Close()
+
+	parsed, err := rules.ParseRules([]config.RuleConfig{{
+		Name: "hot",
+		When: "cpu > 90",
+		Actions: []config.ActionConfig{{
+			Type:    "http",
+			URL:     server.URL,
+			Method:  http.MethodPut,
+			Headers: map[string]string{"X-Token": "secret"},
+			Payload: `{"text": "{{.Rule}}: {{.Message}}"}`,
+		}},
+	}})
+	require.NoError(t, err)
+	engine := rules.NewEngine(parsed, io.Discard)
+	require.Len(t, engine.Evaluate(metrics.Metric{CPU: []float64{95, 97}, CPUTotal: 96}), 1)
+	engine.Wait()
+
+	assert.JSONEq(t, `{"text": "hot: cpu is 96 (> 90)"}`, string(body))
+}
+
+func TestScriptAction(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "out")
+	action := rules.Action{Type: "script", Command: `cat > "$OUT"; echo " $GODASH_ALERT_NAME $GODASH_SUBJECT $GODASH_HOST" >> "$OUT"`}
+	t.Setenv("OUT", out)
+
+	err := action.Run(context.Background(), rules.Event{Rule: "uplink", Kind: rules.EventInterfaceDown, Subject: "eth0", Host: "nas"})
+	require.NoError(t, err)
+
+	data, err := os.ReadFile(out)
+	require.NoError(t, err)
+	var event rules.Event
+	lines := string(data)
+	require.NoError(t, json.Unmarshal([]byte(lines[:len(lines)-len(" uplink eth0 nas\n")]), &event))
+	assert.Equal(t, "uplink", event.Rule)
+	assert.Contains(t, lines, " uplink eth0 nas\n")
+
+	failing := rules.Action{Type: "script", Command: "echo boom >&2; exit 3"}
+	err = failing.Run(context.Background(), rules.Event{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "boom")
+}
+
+func TestCommandAction(t *testing.T) {
+	parsed, err := rules.ParseRules([]config.RuleConfig{{
+		Name: "disk-full",
+		When: "disk.*.used_percent > 90",
+		Actions: []config.ActionConfig{{
+			Type:    "command",
+			Command: "sh",
+			Args:    []string{"-c", `sleep 0.3; echo "$GODASH_ALERT_NAME $GODASH_SUBJECT $GODASH_VALUE"`},
+		}},
+	}, {
+		Name: "hot",
+		When: "cpu > 90",
+		Actions: []config.ActionConfig{{
+			Type:    "command",
+			Command: "sleep",
+			Args:    []string{"5"},
+			Timeout: "100ms",
+		}},
+	}})
+	require.NoError(t, err)
+	path := filepath.Join(t.TempDir(), "commands.jsonl")
+	log := &lockedBuffer{}
+	engine := rules.NewEngine(parsed, log)
+	engine.SetAudit(rules.NewAudit(path))
+
+	// Both disks fire at once, but only one run of the action may be in
+	// progress
+	events := engine.Evaluate(metrics.Metric{
+		CPU: []float64{95}, CPUTotal: 95,
+		Disk: []metrics.DiskStat{{Path: "/", UsedPercentage: 95}, {Path: "/data", UsedPercentage: 97}},
+	})
+	require.Len(t, events, 3)
+	engine.Wait()
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	var runs []rules.CommandRun
+	for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		var run rules.CommandRun
+		require.NoError(t, json.Unmarshal([]byte(line), &run))
+		runs = append(runs, run)
+	}
+	require.Len(t, runs, 3)
+	var ran, skipped, timedOut int
+	for _, run := range runs {
+		switch {
+		case run.Skipped:
+			skipped++
+			assert.Equal(t, "disk-full", run.Rule)
+		case run.TimedOut:
+			timedOut++
+			assert.Equal(t, "hot", run.Rule)
+			assert.Equal(t, -1, run.ExitCode)
+		default:
+			ran++
+			assert.Equal(t, 0, run.ExitCode)
+			assert.Equal(t, fmt.Sprintf("disk-full %s %v", run.Subject, run.Value), run.Output)
+			assert.GreaterOrEqual(t, run.Duration, 300*time.Millisecond)
+		}
+	}
+	assert.Equal(t, []int{1, 1, 1}, []int{ran, skipped, timedOut})
+	assert.Contains(t, log.String(), "rule hot: command action failed: command failed: timed out after 100ms")
+	assert.Contains(t, log.String(), "rule disk-full: command action failed: skipped")
+}
+
+// TestCommandActionChildren tests that children left running by a command
+// or script action neither hold it past its timeout nor fail it when it
+// succeeded
+func TestCommandActionChildren(t *testing.T) {
+	parsed, err := rules.ParseRules([]config.RuleConfig{{
+		Name: "hung",
+		When: "cpu > 90",
+		Actions: []config.ActionConfig{
+			{Type: "command", Command: "sh", Args: []string{"-c", "sleep 30 & sleep 30"}, Timeout: "100ms"},
+			{Type: "script", Command: "sleep 30 & sleep 30", Timeout: "100ms"},
+		},
+	}, {
+		Name:    "restart",
+		When:    "memory.used_percent > 90",
+		Actions: []config.ActionConfig{{Type: "command", Command: "sh", Args: []string{"-c", "sleep 30 & echo started"}}},
+	}})
+	require.NoError(t, err)
+	path := filepath.Join(t.TempDir(), "commands.jsonl")
+	log := &lockedBuffer{}
+	engine := rules.NewEngine(parsed, log)
+	engine.SetAudit(rules.NewAudit(path))
+
+	start := time.Now()
+	engine.Evaluate(metrics.Metric{CPU: []float64{95}, CPUTotal: 95,
+		Memory: metrics.MemoryStat{UsedPercentage: 95}})
+	engine.Wait()
+	assert.Less(t, time.Since(start), 5*time.Second, "the timeout is enforced")
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	runs := map[string]rules.CommandRun{}
+	for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		var run rules.CommandRun
+		require.NoError(t, json.Unmarshal([]byte(line), &run))
+		runs[run.Rule] = run
+	}
+	assert.True(t, runs["hung"].TimedOut)
+	assert.Equal(t, 0, runs["restart"].ExitCode)
+	assert.Equal(t, "started", runs["restart"].Output)
+	assert.Contains(t, log.String(), "rule hung: script action failed")
+	assert.NotContains(t, log.String(), "rule restart")
+}
+
+// lockedBuffer collects the log lines of concurrently running actions
+type lockedBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *lockedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *lockedBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestMQTTAction(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+
+	received := make(chan []byte, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		buf := make([]byte, 256)
+		n, _ := conn.Read(buf) // CONNECT
+		if n == 0 || buf[0] != 0x10 {
+			return
+		}
+		_, _ = conn.Write([]byte{0x20, 0x02, 0x00, 0x00})
+		data, _ := io.ReadAll(conn) // PUBLISH and DISCONNECT
+		received <- data
+	}()
+
+	action := rules.Action{Type: "mqtt", Broker: listener.Addr().String(), Topic: "godash/alerts"}
+	require.NoError(t, action.Run(context.Background(), rules.Event{Rule: "hot"}))
+
+	data := <-received
+	require.NotEmpty(t, data)
+	assert.Equal(t, byte(0x30), data[0], "publish packet")
+	assert.Contains(t, string(data), "godash/alerts")
+	assert.Contains(t, string(data), `"rule":"hot"`)
+	assert.Equal(t, []byte{0xE0, 0x00}, data[len(data)-2:], "disconnect packet")
+}
+
+func TestEngineHistory(t *testing.T) {
+	parsed, err := rules.ParseRules([]config.RuleConfig{
+		{Name: "hot", When: "cpu > 90"},
+		{Name: "raid", Event: rules.EventRAIDDegraded},
+	})
+	require.NoError(t, err)
+	history := rules.NewHistory(filepath.Join(t.TempDir(), "alerts.jsonl"))
+	engine := rules.NewEngine(parsed, io.Discard)
+	engine.SetHistory(history)
+
+	start := time.Now().Truncate(time.Second)
+	sample := func(offset time.Duration, cpu float64, degraded bool) {
+		engine.Evaluate(metrics.Metric{
+			Timestamp: start.Add(offset),
+			CPU:       []float64{cpu},
+			CPUTotal:  cpu,
+			RAID:      []metrics.RaidStat{{Name: "md0", Degraded: degraded}},
+		})
+	}
+	sample(0, 95, false)
+	sample(time.Minute, 99, true)
+	sample(2*time.Minute, 50, true)
+
+	recent := history.Recent(10)
+	require.Len(t, recent, 2)
+	assert.Equal(t, "raid", recent[0].Rule, "newest first")
+	assert.Equal(t, "hot", recent[1].Rule)
+	assert.False(t, recent[1].Active(), "resolution updates the recent entry")
+
+	alerts, err := history.Load(time.Time{}, time.Time{})
+	require.NoError(t, err)
+	require.Len(t, alerts, 2)
+	hot := alerts[0]
+	assert.Equal(t, "hot", hot.Rule)
+	assert.True(t, hot.FiredAt.Equal(start))
+	assert.True(t, hot.ResolvedAt.Equal(start.Add(2*time.Minute)))
+	assert.Equal(t, 99.0, hot.Peak)
+	assert.False(t, alerts[1].Active(), "event alerts resolve immediately")
+
+	alerts, err = history.Load(start.Add(90*time.Second), time.Time{})
+	require.NoError(t, err)
+	require.Len(t, alerts, 1, "the raid event was over by then")
+	assert.Equal(t, "hot", alerts[0].Rule)
+
+	alerts, err = history.Load(time.Time{}, start.Add(30*time.Second))
+	require.NoError(t, err)
+	require.Len(t, alerts, 1)
+	assert.Equal(t, "hot", alerts[0].Rule)
+}
+
+func TestHistoryClose(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "alerts.jsonl")
+	history := rules.NewHistory(path)
+	fired := time.Now().Add(-time.Minute)
+	require.NoError(t, history.Record(rules.Alert{Rule: "hot", Kind: rules.EventThreshold, FiredAt: fired}))
+	require.NoError(t, history.Record(rules.Alert{Rule: "cold", Kind: rules.EventThreshold, FiredAt: fired,
+		ResolvedAt: fired.Add(time.Second)}))
+	require.NoError(t, history.Close())
+
+	alerts, err := history.Load(time.Time{}, time.Time{})
+	require.NoError(t, err)
+	require.Len(t, alerts, 2)
+	for _, alert := range alerts {
+		assert.False(t, alert.Active(), alert.Rule)
+		assert.Equal(t, os.Getpid(), alert.PID)
+		assert.Equal(t, alert.Rule == "hot", alert.Stopped, "only alerts active on exit are stopped")
+	}
+}
+
+func TestHistoryReconcile(t *testing.T) {
+	// A process that has exited
+	cmd := exec.Command("true")
+	require.NoError(t, cmd.Run())
+	dead := cmd.Process.Pid
+
+	path := filepath.Join(t.TempDir(), "alerts.jsonl")
+	fired := time.Now().Add(-time.Hour)
+	var lines []string
+	for i, pid := range []int{dead, 0, os.Getppid()} {
+		data, err := json.Marshal(rules.Alert{Rule: fmt.Sprint("rule", i), Kind: rules.EventThreshold,
+			FiredAt: fired, PID: pid})
+		require.NoError(t, err)
+		lines = append(lines, string(data))
+	}
+	require.NoError(t, os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0o644))
+
+	history := rules.NewHistory(path)
+	require.NoError(t, history.Reconcile())
+	alerts, err := history.Load(time.Time{}, time.Time{})
+	require.NoError(t, err)
+	require.Len(t, alerts, 3)
+	assert.True(t, alerts[0].Stopped, "the process that recorded it is gone")
+	assert.True(t, alerts[1].Stopped, "records without a PID are resolved")
+	assert.True(t, alerts[2].Active(), "the process that recorded it is still running")
+	assert.Empty(t, history.Recent(10), "reconciled alerts were not recorded by this process")
+}
+
+func TestParseNotifiers(t *testing.T) {
+	parsed, err := rules.ParseNotifiers([]config.NotifierConfig{
+		{Type: rules.NotifierNtfy, Topic: "alerts"},
+		{Name: "ops", Type: rules.NotifierSlack, URL: "https://hooks.slack.invalid/x", Rules: []string{"disk-*"}},
+	})
+	require.NoError(t, err)
+	require.Len(t, parsed, 2)
+	assert.Equal(t, "ntfy", parsed[0].Name)
+	assert.Equal(t, "https://ntfy.sh", parsed[0].URL)
+	assert.True(t, parsed[0].Routes("anything"))
+	assert.True(t, parsed[1].Routes("disk-full"))
+	assert.False(t, parsed[1].Routes("hot"))
+	assert.True(t, parsed[1].RoutesTags(nil), "no tag filter routes every host")
+}
+
+func TestEngineTags(t *testing.T) {
+	parsed, err := rules.ParseRules([]config.RuleConfig{{Name: "hot", When: "cpu > 90"}})
+	require.NoError(t, err)
+	engine := rules.NewEngine(parsed, io.Discard)
+	engine.SetTags(map[string]string{"env": "prod", "rack": "2"})
+
+	events := engine.Evaluate(metrics.Metric{Timestamp: time.Now(), CPU: []float64{95}, CPUTotal: 95})
+	require.Len(t, events, 1)
+	assert.Equal(t, map[string]string{"env": "prod", "rack": "2"}, events[0].Tags)
+
+	notifiers, err := rules.ParseNotifiers([]config.NotifierConfig{
+		{Type: rules.NotifierNtfy, Topic: "prod", Tags: map[string]string{"env": "prod"}},
+		{Type: rules.NotifierNtfy, Topic: "staging", Tags: map[string]string{"env": "stag*"}},
+		{Type: rules.NotifierNtfy, Topic: "racks", Tags: map[string]string{"rack": "*", "env": "prod"}},
+	})
+	require.NoError(t, err)
+	tags := events[0].Tags
+	assert.True(t, notifiers[0].RoutesTags(tags))
+	assert.False(t, notifiers[1].RoutesTags(tags))
+	assert.True(t, notifiers[2].RoutesTags(tags))
+	assert.False(t, notifiers[2].RoutesTags(map[string]string{"env": "prod"}), "missing tags do not match")
+
+	for _, cfg := range []config.NotifierConfig{
+		{Type: rules.NotifierNtfy},
+		{Type: rules.NotifierTelegram, Token: "t"},
+		{Type: rules.NotifierDiscord},
+		{Type: "pager"},
+	} {
+		_, err := rules.ParseNotifiers([]config.NotifierConfig{cfg})
+		assert.Error(t, err, cfg.Type)
+	}
+}
+
+func TestNotifierSend(t *testing.T) {
+	type request struct {
+		path, title, body string
+	}
+	requests := make(chan request, 4)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		requests <- request{r.URL.Path, r.Header.Get("Title"), string(body)}
+	}))
+	defer server.Close()
+
+	parsed, err := rules.ParseNotifiers([]config.NotifierConfig{
+		{Type: rules.NotifierNtfy, URL: server.URL, Topic: "alerts"},
+		{Type: rules.NotifierTelegram, URL: server.URL, Token: "123:abc", ChatID: "42"},
+		{Type: rules.NotifierSlack, URL: server.URL + "/slack"},
+		{Type: rules.NotifierDiscord, URL: server.URL + "/discord"},
+	})
+	require.NoError(t, err)
+
+	event := rules.Event{Rule: "hot", Host: "pi", Message: "cpu is 95 (> 90)"}
+	for _, n := range parsed {
+		require.NoError(t, n.Send(context.Background(), event), n.Type)
+	}
+
+	ntfy := <-requests
+	assert.Equal(t, "/alerts", ntfy.path)
+	assert.Equal(t, "godash: hot", ntfy.title)
+	assert.Equal(t, "pi: cpu is 95 (> 90)", ntfy.body)
+
+	telegram := <-requests
+	assert.Equal(t, "/bot123:abc/sendMessage", telegram.path)
+	assert.JSONEq(t, `{"chat_id": "42", "text": "hot\npi: cpu is 95 (> 90)"}`, telegram.body)
+
+	slack := <-requests
+	assert.JSONEq(t, `{"text": "*hot* pi: cpu is 95 (> 90)"}`, slack.body)
+
+	discord := <-requests
+	assert.JSONEq(t, `{"content": "**hot** pi: cpu is 95 (> 90)"}`, discord.body)
+}
+
+func TestNotifierErrorHidesURL(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer server.Close()
+
+	n := rules.Notifier{Type: rules.NotifierTelegram, URL: server.URL, Token: "secret-token", ChatID: "1"}
+	err := n.Send(context.Background(), rules.Event{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "403")
+	assert.NotContains(t, err.Error(), "secret-token")
+
+	server.Close()
+	err = n.Send(context.Background(), rules.Event{})
+	require.Error(t, err)
+	assert.NotContains(t, err.Error(), "secret-token")
+}
+
+func TestParseRateCondition(t *testing.T) {
+	cond, err := rules.ParseCondition("rate(disk./.free, 1h) < -1G/h")
+	require.NoError(t, err)
+	assert.Equal(t, rules.Condition{Metric: "disk./.free", Op: "<", Threshold: -float64(1<<30) / 3600, Rate: true, Window: time.Hour}, cond)
+
+	cond, err = rules.ParseCondition("rate(net.eth0.rx_bytes) > 10MiB")
+	require.NoError(t, err)
+	assert.Equal(t, 5*time.Minute, cond.Window)
+	assert.Equal(t, float64(10<<20), cond.Threshold)
+
+	for _, expr := range []string{"rate(cpu > 1", "rate(cpu, soon) > 1", "cpu > 1/h", "rate(cpu) > 1/week"} {
+		_, err := rules.ParseCondition(expr)
+		assert.Error(t, err, expr)
+	}
+}
+
+func TestParseExpr(t *testing.T) {
+	expr, err := rules.ParseExpr("memory.used_percent > 90 && cpu > 80 || raid.degraded > 0")
+	require.NoError(t, err)
+	require.Len(t, expr.Any, 2)
+	assert.Len(t, expr.Any[0], 2)
+	assert.Len(t, expr.Any[1], 1)
+	_, single := expr.Single()
+	assert.False(t, single)
+
+	_, err = rules.ParseExpr("cpu > 80 && ")
+	assert.Error(t, err)
+}
+
+func TestEngineComposite(t *testing.T) {
+	parsed, err := rules.ParseRules([]config.RuleConfig{{Name: "pressure", When: "memory.used_percent > 90 && cpu > 80"}})
+	require.NoError(t, err)
+	engine := rules.NewEngine(parsed, io.Discard)
+
+	sample := func(mem, cpu float64) []rules.Event {
+		return engine.Evaluate(metrics.Metric{Timestamp: time.Now(), CPUTotal: cpu, CPU: []float64{cpu},
+			Memory: metrics.MemoryStat{UsedPercentage: mem}})
+	}
+	assert.Empty(t, sample(95, 50))
+	events := sample(95, 85)
+	require.Len(t, events, 1)
+	assert.Equal(t, "memory.used_percent > 90 && cpu > 80", events[0].Subject)
+	assert.Equal(t, 95.0, events[0].Value)
+	assert.Contains(t, events[0].Message, "cpu = 85")
+	assert.Empty(t, sample(95, 85), "fires once while holding")
+}
+
+func TestEngineRate(t *testing.T) {
+	parsed, err := rules.ParseRules([]config.RuleConfig{{Name: "filling", When: "rate(disk.*.free, 1h) < -1G/h"}})
+	require.NoError(t, err)
+	engine := rules.NewEngine(parsed, io.Discard)
+
+	start := time.Now()
+	sample := func(offset time.Duration, free uint64) []rules.Event {
+		return engine.Evaluate(metrics.Metric{
+			Timestamp: start.Add(offset),
+			Disk:      []metrics.DiskStat{{Path: "/", Free: free}},
+		})
+	}
+	const gib = 1 << 30
+	assert.Empty(t, sample(0, 100*gib))
+	assert.Empty(t, sample(20*time.Minute, 99*gib), "needs half a window of history")
+	events := sample(40*time.Minute, 97*gib)
+	require.Len(t, events, 1)
+	assert.Equal(t, "disk./.free", events[0].Subject)
+	assert.InDelta(t, -3.0*gib/2400, events[0].Value, 1)
+}
+
+func TestEngineDisplayNames(t *testing.T) {
+	parsed, err := rules.ParseRules([]config.RuleConfig{
+		{Name: "full", When: "disk./srv.used_percent > 90"},
+		{Name: "uplink", Event: rules.EventInterfaceDown},
+	})
+	require.NoError(t, err)
+	engine := rules.NewEngine(parsed, io.Discard)
+
+	sample := func(used float64, up bool) metrics.Metric {
+		return metrics.Metric{
+			Disk:    []metrics.DiskStat{{Path: "/srv", Label: "media", UsedPercentage: used}},
+			Network: []metrics.NetworkStat{{Interface: "eth0", Label: "uplink", Up: up}},
+		}
+	}
+	assert.Empty(t, engine.Evaluate(sample(50, true)))
+	events := engine.Evaluate(sample(95, false))
+	require.Len(t, events, 2)
+	assert.Equal(t, "disk./srv.used_percent (media) is 95 (> 90)", events[0].Message)
+	assert.Equal(t, "disk./srv.used_percent", events[0].Subject)
+	assert.Equal(t, "interface eth0 (uplink) is down", events[1].Message)
+	assert.Equal(t, "eth0", events[1].Subject)
+}
+
+func TestEngineProcessEvents(t *testing.T) {
+	parsed, err := rules.ParseRules([]config.RuleConfig{
+		{Name: "db", Event: rules.EventProcessDown, Match: "postgres"},
+		{Name: "flapping", Event: rules.EventProcessRestart},
+	})
+	require.NoError(t, err)
+	engine := rules.NewEngine(parsed, io.Discard)
+
+	assert.Empty(t, engine.Evaluate(metrics.Metric{Processes: []metrics.ProcessStatus{
+		{Name: "postgres", Status: metrics.ProcessOK, Count: 1},
+		{Name: "nginx", Status: metrics.ProcessOK, Count: 1},
+	}}))
+	events := engine.Evaluate(metrics.Metric{Processes: []metrics.ProcessStatus{
+		{Name: "postgres", Status: metrics.ProcessDown},
+		{Name: "nginx", Status: metrics.ProcessRestarted, Count: 1, Restarts: 1},
+	}})
+	require.Len(t, events, 2)
+	assert.Equal(t, "db", events[0].Rule)
+	assert.Equal(t, rules.EventProcessDown, events[0].Kind)
+	assert.Equal(t, "flapping", events[1].Rule)
+	assert.Equal(t, "nginx", events[1].Subject)
+}
+
+func TestEnginePortEvents(t *testing.T) {
+	parsed, err := rules.ParseRules([]config.RuleConfig{
+		{Name: "ssh", Event: rules.EventPortDown, Match: "tcp/22"},
+		{Name: "listeners", Event: rules.EventPortOpened},
+	})
+	require.NoError(t, err)
+	engine := rules.NewEngine(parsed, io.Discard)
+
+	assert.Empty(t, engine.Evaluate(metrics.Metric{Ports: []metrics.PortStatus{
+		{Port: 22, Protocol: "tcp", Expect: metrics.PortListening, Listening: true, OK: true},
+		{Port: 23, Protocol: "tcp", Expect: metrics.PortClosed, OK: true},
+	}}))
+	events := engine.Evaluate(metrics.Metric{Ports: []metrics.PortStatus{
+		{Port: 22, Protocol: "tcp", Expect: metrics.PortListening},
+		{Port: 23, Protocol: "tcp", Expect: metrics.PortClosed, Listening: true},
+		{Port: 9000, Protocol: "tcp", Expect: metrics.PortClosed, Listening: true, Unexpected: true},
+	}})
+	require.Len(t, events, 3)
+	assert.Equal(t, "ssh", events[0].Rule)
+	assert.Equal(t, "tcp/22", events[0].Subject)
+	assert.Equal(t, rules.EventPortOpened, events[1].Kind)
+	assert.Equal(t, "tcp/23", events[1].Subject)
+	assert.Equal(t, "tcp/9000", events[2].Subject)
+
+	values := rules.Values(metrics.Metric{Ports: []metrics.PortStatus{
+		{Port: 22, Protocol: "tcp", Listening: true},
+	}})
+	assert.Equal(t, 1.0, values["port.tcp.22.listening"])
+}
+
+func TestEngineDownAtStart(t *testing.T) {
+	parsed, err := rules.ParseRules([]config.RuleConfig{
+		{Name: "db", Event: rules.EventProcessDown},
+		{Name: "ssh", Event: rules.EventPortDown},
+		{Name: "raid", Event: rules.EventRAIDDegraded},
+	})
+	require.NoError(t, err)
+	engine := rules.NewEngine(parsed, io.Discard)
+
+	sample := metrics.Metric{
+		Processes: []metrics.ProcessStatus{
+			{Name: "postgres", Status: metrics.ProcessDown},
+			{Name: "nginx", Status: metrics.ProcessOK, Count: 1, Restarts: 2},
+		},
+		Ports: []metrics.PortStatus{
+			{Port: 22, Protocol: "tcp", Expect: metrics.PortListening},
+			{Port: 80, Protocol: "tcp", Expect: metrics.PortListening, Listening: true, OK: true},
+		},
+		RAID: []metrics.RaidStat{{Name: "md0", Degraded: true}},
+	}
+	events := engine.Evaluate(sample)
+	require.Len(t, events, 2, "watched processes and ports already down are reported")
+	assert.Equal(t, rules.EventProcessDown, events[0].Kind)
+	assert.Equal(t, "postgres", events[0].Subject)
+	assert.Equal(t, rules.EventPortDown, events[1].Kind)
+	assert.Equal(t, "tcp/22", events[1].Subject)
+
+	assert.Empty(t, engine.Evaluate(sample), "the state is reported once")
+
+	// Processes are missing from samples until the first scan completes
+	engine = rules.NewEngine(parsed, io.Discard)
+	assert.Empty(t, engine.Evaluate(metrics.Metric{}))
+	events = engine.Evaluate(metrics.Metric{Processes: sample.Processes})
+	require.Len(t, events, 1, "processes down in the first scan are reported")
+	assert.Equal(t, "postgres", events[0].Subject)
+}
+
+func TestEngineCertEvents(t *testing.T) {
+	parsed, err := rules.ParseRules([]config.RuleConfig{
+		{Name: "renew", Event: rules.EventCertExpiring},
+	})
+	require.NoError(t, err)
+	engine := rules.NewEngine(parsed, io.Discard)
+
+	assert.Empty(t, engine.Evaluate(metrics.Metric{Certs: []metrics.CertStat{
+		{Name: "proxy", DaysLeft: 15},
+	}}))
+	events := engine.Evaluate(metrics.Metric{Certs: []metrics.CertStat{
+		{Name: "proxy", DaysLeft: 13, Expiring: true},
+	}})
+	require.Len(t, events, 1)
+	assert.Equal(t, "proxy", events[0].Subject)
+	assert.Equal(t, 13.0, events[0].Value)
+	assert.Empty(t, engine.Evaluate(metrics.Metric{Certs: []metrics.CertStat{
+		{Name: "proxy", DaysLeft: 12, Expiring: true},
+	}}))
+
+	values := rules.Values(metrics.Metric{Certs: []metrics.CertStat{{Name: "proxy", DaysLeft: 12}}})
+	assert.Equal(t, 12.0, values["cert.proxy.days_left"])
+}
+
+func TestEngineBandwidthQuotaEvents(t *testing.T) {
+	parsed, err := rules.ParseRules([]config.RuleConfig{
+		{Name: "metered", Event: rules.EventBandwidthQuota},
+	})
+	require.NoError(t, err)
+	engine := rules.NewEngine(parsed, io.Discard)
+
+	quota := func(percent, level float64) metrics.Metric {
+		return metrics.Metric{Bandwidth: &bandwidth.Report{
+			Interfaces: []bandwidth.Usage{{Interface: "wwan0", TodayRx: 1 << 30, MonthRx: 50 << 30}},
+			Quota:      100 << 30, QuotaUsed: uint64(percent) << 30, QuotaPercent: percent, QuotaLevel: level,
+		}}
+	}
+	assert.Empty(t, engine.Evaluate(quota(79, 0)))
+	events := engine.Evaluate(quota(81, 80))
+	require.Len(t, events, 1)
+	assert.Equal(t, "80%", events[0].Subject)
+	assert.Contains(t, events[0].Message, "81% of the monthly bandwidth quota used")
+	assert.Empty(t, engine.Evaluate(quota(90, 80)), "a level is reported once")
+	events = engine.Evaluate(quota(100, 100))
+	require.Len(t, events, 1)
+	assert.Equal(t, "100%", events[0].Subject)
+
+	values := rules.Values(quota(81, 80))
+	assert.Equal(t, 81.0, values["bandwidth.quota_percent"])
+	assert.Equal(t, float64(1<<30), values["bandwidth.wwan0.today_bytes"])
+	assert.Equal(t, float64(50<<30), values["bandwidth.wwan0.month_bytes"])
+}
+
+func TestHistoryCompact(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "alerts.jsonl")
+	history := rules.NewHistory(path)
+	now := time.Now()
+	old := now.Add(-100 * 24 * time.Hour)
+	require.NoError(t, history.Record(rules.Alert{Rule: "old", Kind: rules.EventThreshold, FiredAt: old}))
+	require.NoError(t, history.Record(rules.Alert{Rule: "old", Kind: rules.EventThreshold, FiredAt: old, ResolvedAt: old.Add(time.Hour)}))
+	require.NoError(t, history.Record(rules.Alert{Rule: "recent", Kind: rules.EventThreshold, FiredAt: now}))
+	require.NoError(t, history.Record(rules.Alert{Rule: "recent", Kind: rules.EventThreshold, FiredAt: now, Peak: 97}))
+	require.NoError(t, history.Record(rules.Alert{Rule: "stuck", Kind: rules.EventThreshold, FiredAt: old}))
+
+	require.NoError(t, history.Compact(now.Add(-90*24*time.Hour)))
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, 2, strings.Count(string(data), "\n"), "one record per kept alert")
+
+	alerts, err := history.Load(time.Time{}, time.Time{})
+	require.NoError(t, err)
+	require.Len(t, alerts, 2)
+	assert.Equal(t, "stuck", alerts[0].Rule, "active alerts are kept however old")
+	assert.Equal(t, "recent", alerts[1].Rule)
+	assert.Equal(t, 97.0, alerts[1].Peak)
+}
+
+func TestAuditPrune(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "commands.jsonl")
+	audit := rules.NewAudit(path)
+	now := time.Now()
+	require.NoError(t, audit.Record(rules.CommandRun{Rule: "old", Started: now.Add(-100 * 24 * time.Hour)}))
+	require.NoError(t, audit.Record(rules.CommandRun{Rule: "recent", Started: now}))
+
+	require.NoError(t, audit.Prune(now.Add(-90*24*time.Hour)))
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	var run rules.CommandRun
+	require.NoError(t, json.Unmarshal(data, &run))
+	assert.Equal(t, "recent", run.Rule)
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+}
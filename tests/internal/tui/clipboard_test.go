@@ -0,0 +1,92 @@
+package tui_test
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/j-raghavan/godash/internal/metrics"
+	"github.com/j-raghavan/godash/internal/tui"
+)
+
+// clipboardScreen is a simulation screen passing OSC 52 clipboard requests
+// to copied
+type clipboardScreen struct {
+	tcell.SimulationScreen
+	copied chan string
+}
+
+func (s clipboardScreen) SetClipboard(data []byte) {
+	s.copied <- string(data)
+}
+
+// TestCopySelection tests that 'y' and 'Y' copy the sample or the selected
+// interface over OSC 52 in SSH sessions
+func TestCopySelection(t *testing.T) {
+	t.Setenv("SSH_TTY", "/dev/pts/0")
+	collector := &MockCollector{}
+	collector.On("Start", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		ch := args.Get(1).(chan<- metrics.Metric)
+		go func() {
+			ch <- metrics.Metric{
+				Timestamp: time.Now(),
+				CPU:       []float64{12.5},
+				CPUTotal:  12.5,
+				Memory:    metrics.MemoryStat{Total: 8 << 30, Used: 2 << 30, UsedPercentage: 25},
+				Disk:      []metrics.DiskStat{{Path: "/", Total: 100 << 30, Used: 40 << 30, UsedPercentage: 40}},
+				Network: []metrics.NetworkStat{{Interface: "eth0", Up: true, HasRates: true,
+					RxBytes: 4096, RxBytesPerSec: 2048, RxPacketsPerSec: 3}},
+			}
+		}()
+	})
+
+	screen := clipboardScreen{SimulationScreen: tcell.NewSimulationScreen(""), copied: make(chan string, 1)}
+	app := tview.NewApplication()
+	app.SetScreen(screen)
+	ui := tui.NewUI(collector, false)
+	ui.SetApp(app)
+
+	done := make(chan error)
+	go func() { done <- ui.Start(time.Second) }()
+	defer func() {
+		app.Stop()
+		assert.NoError(t, <-done)
+	}()
+	require.Eventually(t, func() bool {
+		text := make(chan string, 1)
+		app.QueueUpdate(func() { text <- ui.CPUView().GetText(true) })
+		return <-text != ""
+	}, 2*time.Second, 20*time.Millisecond)
+
+	copied := func() string {
+		select {
+		case text := <-screen.copied:
+			return text
+		case <-time.After(2 * time.Second):
+			t.Fatal("nothing copied")
+			return ""
+		}
+	}
+
+	screen.InjectKey(tcell.KeyRune, 'y', tcell.ModNone)
+	sample := copied()
+	assert.Contains(t, sample, "cpu: 12.5%\nmemory: 25.0% (2.0 GiB of 8.0 GiB)\ndisk /: 40.0% (40.0 GiB of 100.0 GiB)\n")
+	assert.Contains(t, sample, "net eth0: ↓ 2.0 KiB/s")
+
+	screen.InjectKey(tcell.KeyDown, 0, tcell.ModNone)
+	screen.InjectKey(tcell.KeyRune, 'y', tcell.ModNone)
+	assert.Contains(t, copied(), "net eth0\n  interface: eth0\n  rx_bytes: 4.0 KiB\n  rx_bytes_per_sec: 2.0 KiB/s\n  rx_packets: 0\n  rx_packets_per_sec: 3.0/s\n")
+
+	screen.InjectKey(tcell.KeyRune, 'Y', tcell.ModNone)
+	var entity metrics.Entity
+	require.NoError(t, json.Unmarshal([]byte(copied()), &entity))
+	assert.Equal(t, metrics.EntityInterface, entity.Kind)
+	assert.Equal(t, "eth0", entity.Name)
+	assert.Equal(t, 2048.0, entity.Values["rx_bytes_per_sec"])
+}
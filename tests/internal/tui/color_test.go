@@ -0,0 +1,23 @@
+package tui_test
+
+import (
+	"testing"
+
+	"github.com/j-raghavan/godash/internal/tui"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseColorMode(t *testing.T) {
+	for input, want := range map[string]string{
+		"":              tui.ColorNormal,
+		"normal":        tui.ColorNormal,
+		"none":          tui.ColorNone,
+		"high-contrast": tui.ColorHighContrast,
+	} {
+		mode, err := tui.ParseColorMode(input)
+		assert.NoError(t, err)
+		assert.Equal(t, want, mode)
+	}
+	_, err := tui.ParseColorMode("rainbow")
+	assert.Error(t, err)
+}
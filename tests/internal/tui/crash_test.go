@@ -0,0 +1,89 @@
+package tui_test
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/j-raghavan/godash/internal/metrics"
+	"github.com/j-raghavan/godash/internal/tui"
+	"github.com/rivo/tview"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteCrashDump(t *testing.T) {
+	dir := t.TempDir()
+	at := time.Date(2026, 10, 16, 14, 0, 0, 0, time.UTC)
+	dump := tui.CrashDump{
+		Time:    at,
+		Panic:   "index out of range",
+		Stack:   "goroutine 1 [running]:",
+		Metrics: []metrics.Metric{{Timestamp: at, CPUTotal: 42}},
+	}
+
+	path, err := tui.WriteCrashDump(dir, dump)
+	require.NoError(t, err)
+	assert.Equal(t, "godash-crash-20261016-140000.json", strings.TrimPrefix(path, dir+string(os.PathSeparator)))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	var decoded tui.CrashDump
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	assert.Equal(t, dump.Panic, decoded.Panic)
+	assert.Equal(t, dump.Stack, decoded.Stack)
+	require.Len(t, decoded.Metrics, 1)
+	assert.Equal(t, 42.0, decoded.Metrics[0].CPUTotal)
+}
+
+func TestStartRecoversPanic(t *testing.T) {
+	dir := t.TempDir()
+	collector := &MockCollector{}
+	collector.On("Start", mock.Anything, mock.Anything).Run(func(mock.Arguments) {
+		panic("collector exploded")
+	})
+	ui := tui.NewUI(collector, false)
+	ui.SetCrashDir(dir)
+
+	err := ui.Start(time.Second)
+	var crash *tui.CrashError
+	require.True(t, errors.As(err, &crash), "expected a CrashError, got %v", err)
+	assert.Equal(t, "collector exploded", crash.Panic)
+	assert.Contains(t, err.Error(), crash.Path)
+
+	data, err := os.ReadFile(crash.Path)
+	require.NoError(t, err)
+	var dump tui.CrashDump
+	require.NoError(t, json.Unmarshal(data, &dump))
+	assert.Equal(t, "collector exploded", dump.Panic)
+	assert.Contains(t, dump.Stack, "TestStartRecoversPanic")
+}
+
+func TestStartRecoversCollectorPanic(t *testing.T) {
+	dir := t.TempDir()
+	collector := metrics.NewSystemCollector()
+	collector.AddObserver(func(metrics.Metric) {
+		panic("parser exploded")
+	})
+	app := tview.NewApplication()
+	app.SetScreen(tcell.NewSimulationScreen(""))
+	ui := tui.NewUI(collector, false)
+	ui.SetApp(app)
+	ui.SetCrashDir(dir)
+
+	err := ui.Start(time.Second)
+	var crash *tui.CrashError
+	require.True(t, errors.As(err, &crash), "expected a CrashError, got %v", err)
+	assert.Equal(t, "parser exploded", crash.Panic)
+
+	data, err := os.ReadFile(crash.Path)
+	require.NoError(t, err)
+	var dump tui.CrashDump
+	require.NoError(t, json.Unmarshal(data, &dump))
+	assert.Contains(t, dump.Stack, "TestStartRecoversCollectorPanic")
+}
@@ -0,0 +1,64 @@
+package tui_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/j-raghavan/godash/internal/metrics"
+	"github.com/j-raghavan/godash/internal/tui"
+)
+
+// TestInterfaceDetailKeys tests that the arrow keys and Enter open the
+// details of the chosen interface
+func TestInterfaceDetailKeys(t *testing.T) {
+	collector := &MockCollector{}
+	collector.On("Start", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		ch := args.Get(1).(chan<- metrics.Metric)
+		go func() {
+			ch <- metrics.Metric{Timestamp: time.Now(), Network: []metrics.NetworkStat{
+				{Interface: "eth0", Label: "eth0"},
+				{Interface: "wlan0", Label: "wlan0"},
+			}}
+		}()
+	})
+
+	app := tview.NewApplication()
+	app.SetScreen(tcell.NewSimulationScreen(""))
+	ui := tui.NewUI(collector, false)
+	ui.SetApp(app)
+
+	done := make(chan error)
+	go func() { done <- ui.Start(time.Second) }()
+	defer func() {
+		app.Stop()
+		assert.NoError(t, <-done)
+	}()
+
+	read := func(fn func() string) string {
+		text := make(chan string, 1)
+		app.QueueUpdate(func() { text <- fn() })
+		return <-text
+	}
+	networkText := func() string { return read(func() string { return ui.NetworkView().GetText(true) }) }
+	detailTitle := func() string { return read(ui.InterfaceView().GetTitle) }
+	key := func(k tcell.Key) { app.QueueEvent(tcell.NewEventKey(k, 0, tcell.ModNone)) }
+
+	assert.Eventually(t, func() bool { return strings.Contains(networkText(), "eth0") },
+		2*time.Second, 20*time.Millisecond)
+
+	key(tcell.KeyDown)
+	key(tcell.KeyDown)
+	key(tcell.KeyEnter)
+	assert.Eventually(t, func() bool { return detailTitle() == "Interface wlan0" },
+		2*time.Second, 20*time.Millisecond)
+
+	key(tcell.KeyUp)
+	assert.Eventually(t, func() bool { return detailTitle() == "Interface eth0" },
+		2*time.Second, 20*time.Millisecond)
+}
@@ -253,3 +253,32 @@ func ImportTcellForLintOnly() {
 // 		},
 // 	}
 // }
+
+func TestSparkline(t *testing.T) {
+	assert.Equal(t, "▁▄█", tui.Sparkline([]float64{0, 50, 100}, 100))
+	assert.Equal(t, "▂█", tui.Sparkline([]float64{1, 4}, 0), "zero max scales to the largest value")
+	assert.Equal(t, "█", tui.Sparkline([]float64{150}, 100), "values above max are clamped")
+	assert.Equal(t, "", tui.Sparkline(nil, 100))
+}
+
+func TestPageRange(t *testing.T) {
+	tests := []struct {
+		name                  string
+		total, perPage, page  int
+		start, end, wantPages int
+	}{
+		{"first page", 7, 3, 0, 0, 3, 3},
+		{"last page is short", 7, 3, 2, 6, 7, 3},
+		{"wraps around", 7, 3, 3, 0, 3, 3},
+		{"fits one page", 2, 3, 1, 0, 2, 1},
+		{"empty", 0, 3, 0, 0, 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start, end, pages := tui.PageRange(tt.total, tt.perPage, tt.page)
+			assert.Equal(t, tt.start, start)
+			assert.Equal(t, tt.end, end)
+			assert.Equal(t, tt.wantPages, pages)
+		})
+	}
+}
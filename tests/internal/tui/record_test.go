@@ -0,0 +1,95 @@
+package tui_test
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/j-raghavan/godash/internal/metrics"
+	"github.com/j-raghavan/godash/internal/tui"
+)
+
+// TestRecordSession tests that a recorded session replays the screen as
+// drawn and keeps the samples drawn
+func TestRecordSession(t *testing.T) {
+	collector := &MockCollector{}
+	collector.On("Start", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		ch := args.Get(1).(chan<- metrics.Metric)
+		go func() {
+			ch <- metrics.Metric{Timestamp: time.Now(), CPU: []float64{42}, CPUTotal: 42}
+		}()
+	})
+
+	path := filepath.Join(t.TempDir(), "session.cast")
+	recorder, err := tui.NewRecorder(path, "test")
+	require.NoError(t, err)
+
+	app := tview.NewApplication()
+	app.SetScreen(tcell.NewSimulationScreen(""))
+	ui := tui.NewUI(collector, false)
+	ui.SetApp(app)
+	ui.SetRecorder(recorder)
+
+	done := make(chan error)
+	go func() { done <- ui.Start(time.Second) }()
+	cpuText := func() string {
+		text := make(chan string, 1)
+		app.QueueUpdate(func() { text <- ui.CPUView().GetText(true) })
+		return <-text
+	}
+	assert.Eventually(t, func() bool {
+		return strings.Contains(cpuText(), "Overall: 42.0%")
+	}, 2*time.Second, 20*time.Millisecond)
+	app.Stop()
+	require.NoError(t, <-done, "Start closes the recorder")
+
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(nil, 1<<20)
+	require.True(t, scanner.Scan())
+	var header tui.CastHeader
+	require.NoError(t, json.Unmarshal(scanner.Bytes(), &header))
+	// The simulation screen is 80x25
+	assert.Equal(t, tui.CastHeader{Version: 2, Width: 80, Height: 25, Timestamp: header.Timestamp,
+		Title: "test", Env: header.Env}, header)
+
+	var output strings.Builder
+	last := -1.0
+	for scanner.Scan() {
+		var event []any
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &event))
+		require.Len(t, event, 3)
+		at := event[0].(float64)
+		assert.GreaterOrEqual(t, at, last, "events are in time order")
+		last = at
+		assert.Equal(t, "o", event[1])
+		output.WriteString(event[2].(string))
+	}
+	assert.Contains(t, output.String(), "Overall: 42.0%")
+	assert.Contains(t, output.String(), "\x1b[1;1H", "rows are written at their position")
+
+	data, err := os.ReadFile(tui.MetricsRecordingPath(path))
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	require.Len(t, lines, 1)
+	var sample metrics.Metric
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &sample))
+	assert.Equal(t, 42.0, sample.CPUTotal)
+}
+
+func TestMetricsRecordingPath(t *testing.T) {
+	assert.Equal(t, "incident.jsonl", tui.MetricsRecordingPath("incident.cast"))
+	assert.Equal(t, "/tmp/incident.jsonl", tui.MetricsRecordingPath("/tmp/incident"))
+}
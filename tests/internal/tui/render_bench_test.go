@@ -0,0 +1,102 @@
+package tui_test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/j-raghavan/godash/internal/metrics"
+	"github.com/j-raghavan/godash/internal/tui"
+)
+
+// renderAllocBudget is the performance target of one frame, see
+// CONTRIBUTING.md
+const renderAllocBudget = 8000
+
+// benchMetric returns a sample of a typical server: 16 cores, 4 disks and
+// 4 interfaces
+func benchMetric(at time.Time) metrics.Metric {
+	metric := metrics.Metric{
+		Timestamp: at,
+		CPUTotal:  42,
+		CPUStates: metrics.CPUStateStat{User: 30, System: 8, IOWait: 3, SoftIRQ: 1, Idle: 58},
+		Memory:    metrics.MemoryStat{Total: 64 << 30, Free: 24 << 30, Used: 40 << 30, UsedPercentage: 62.5},
+	}
+	for i := 0; i < 16; i++ {
+		metric.CPU = append(metric.CPU, float64(i*6))
+	}
+	for i := 0; i < 4; i++ {
+		metric.Disk = append(metric.Disk, metrics.DiskStat{
+			Path: fmt.Sprintf("/data%d", i), Device: fmt.Sprintf("/dev/sd%c1", 'a'+i), FsType: "ext4",
+			Total: 1 << 40, Used: 512 << 30, Free: 512 << 30, UsedPercentage: 50,
+		})
+		metric.Network = append(metric.Network, metrics.NetworkStat{
+			Interface: fmt.Sprintf("eth%d", i), Label: fmt.Sprintf("eth%d", i), Up: true,
+			RxBytes: 1 << 30, TxBytes: 1 << 29, HasRates: true,
+			RxBytesPerSec: float64(i+1) * 1e6, TxBytesPerSec: float64(i+1) * 5e5,
+			RxPacketsPerSec: 1000, TxPacketsPerSec: 500,
+		})
+	}
+	return metric
+}
+
+// startBenchUI runs a UI on a simulated 160x50 terminal that refreshes
+// every pane on every frame. stop shuts it down.
+func startBenchUI(tb testing.TB) (ui *tui.UI, stop func()) {
+	collector := &MockCollector{}
+	collector.On("Start", mock.Anything, mock.Anything)
+
+	screen := tcell.NewSimulationScreen("")
+	screen.SetSize(160, 50)
+	app := tview.NewApplication()
+	app.SetScreen(screen)
+	ui = tui.NewUI(collector, false)
+	ui.SetApp(app)
+	ui.SetCadence(tui.Cadence{Memory: time.Nanosecond, Network: time.Nanosecond, Interfaces: time.Nanosecond})
+
+	done := make(chan error)
+	go func() { done <- ui.Start(time.Second) }()
+	// Wait for the event loop, so that the first frame is drawn by the caller
+	app.QueueUpdate(func() {})
+	return ui, func() {
+		app.Stop()
+		if err := <-done; err != nil {
+			tb.Error(err)
+		}
+	}
+}
+
+// BenchmarkRenderMetrics measures one frame: formatting a sample into the
+// panes and drawing the screen
+func BenchmarkRenderMetrics(b *testing.B) {
+	ui, stop := startBenchUI(b)
+	defer stop()
+	at := time.Now()
+	ui.RenderMetrics(benchMetric(at))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		at = at.Add(100 * time.Millisecond)
+		ui.RenderMetrics(benchMetric(at))
+	}
+}
+
+// TestRenderAllocBudget tests that a frame stays within its allocation
+// budget
+func TestRenderAllocBudget(t *testing.T) {
+	ui, stop := startBenchUI(t)
+	defer stop()
+	at := time.Now()
+	ui.RenderMetrics(benchMetric(at))
+	allocs := testing.AllocsPerRun(20, func() {
+		at = at.Add(100 * time.Millisecond)
+		ui.RenderMetrics(benchMetric(at))
+	})
+	if allocs > renderAllocBudget {
+		t.Errorf("Expected at most %d allocs per frame, got %.0f", renderAllocBudget, allocs)
+	}
+}
@@ -0,0 +1,88 @@
+package tui_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/j-raghavan/godash/internal/metrics"
+	"github.com/j-raghavan/godash/internal/tui"
+)
+
+// TestRenderCoalescesBursts tests that a burst of samples faster than the
+// frame rate ends with the latest sample drawn
+func TestRenderCoalescesBursts(t *testing.T) {
+	collector := &MockCollector{}
+	collector.On("Start", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		ch := args.Get(1).(chan<- metrics.Metric)
+		go func() {
+			for i := 0; i < 50; i++ {
+				ch <- metrics.Metric{Timestamp: time.Now(), CPU: []float64{float64(i)}, CPUTotal: float64(i)}
+			}
+		}()
+	})
+
+	app := tview.NewApplication()
+	app.SetScreen(tcell.NewSimulationScreen(""))
+	ui := tui.NewUI(collector, false)
+	ui.SetApp(app)
+	ui.SetCadence(tui.Cadence{MaxFPS: 5})
+
+	done := make(chan error)
+	go func() { done <- ui.Start(time.Second) }()
+	defer func() {
+		app.Stop()
+		assert.NoError(t, <-done)
+	}()
+
+	cpuText := func() string {
+		text := make(chan string, 1)
+		app.QueueUpdate(func() { text <- ui.CPUView().GetText(true) })
+		return <-text
+	}
+	assert.Eventually(t, func() bool {
+		return strings.Contains(cpuText(), "Overall: 49.0%")
+	}, 2*time.Second, 50*time.Millisecond)
+}
+
+// TestRenderTunnelPeers tests that VPN peers are listed with their endpoint
+func TestRenderTunnelPeers(t *testing.T) {
+	collector := &MockCollector{}
+	collector.On("Start", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		ch := args.Get(1).(chan<- metrics.Metric)
+		go func() {
+			ch <- metrics.Metric{Timestamp: time.Now(), Tunnels: []metrics.TunnelPeer{
+				{Source: "wireguard", Peer: "xTIBA5rboUvnH4htodjb6e697QjLERt1NAB4mZqp8Dg=", Endpoint: "203.0.113.7:51820", LatestHandshake: time.Now()},
+				{Source: "wireguard", Peer: "TrMvSoP4jYQlY6RIzBgbssQqY3vxI2Pi+y71lOWWXX0="},
+			}}
+		}()
+	})
+
+	app := tview.NewApplication()
+	app.SetScreen(tcell.NewSimulationScreen(""))
+	ui := tui.NewUI(collector, false)
+	ui.SetApp(app)
+
+	done := make(chan error)
+	go func() { done <- ui.Start(time.Second) }()
+	defer func() {
+		app.Stop()
+		assert.NoError(t, <-done)
+	}()
+
+	networkText := func() string {
+		text := make(chan string, 1)
+		app.QueueUpdate(func() { text <- ui.NetworkView().GetText(true) })
+		return <-text
+	}
+	assert.Eventually(t, func() bool {
+		text := networkText()
+		return strings.Contains(text, "wireguard xTIBA5rboUvn 203.0.113.7:51820 handshake") &&
+			strings.Contains(text, "wireguard TrMvSoP4jYQl - no handshake")
+	}, 2*time.Second, 50*time.Millisecond)
+}
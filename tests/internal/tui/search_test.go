@@ -0,0 +1,98 @@
+package tui_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/j-raghavan/godash/internal/metrics"
+	"github.com/j-raghavan/godash/internal/tui"
+)
+
+// TestSearch tests that '/' filters the disk and network panes, highlights
+// the matches and that 'n' steps through them
+func TestSearch(t *testing.T) {
+	stop := make(chan struct{})
+	defer close(stop)
+	collector := &MockCollector{}
+	collector.On("Start", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		ch := args.Get(1).(chan<- metrics.Metric)
+		go func() {
+			ticker := time.NewTicker(20 * time.Millisecond)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-ticker.C:
+					ch <- metrics.Metric{
+						Timestamp: time.Now(),
+						CPU:       []float64{10},
+						Disk:      []metrics.DiskStat{{Path: "/boot", Total: 1}, {Path: "/data", Total: 1}},
+						Network: []metrics.NetworkStat{
+							{Interface: "eth0", RxBytesPerSec: 200}, {Interface: "wlan0", RxBytesPerSec: 100},
+						},
+					}
+				case <-stop:
+					return
+				}
+			}
+		}()
+	})
+
+	screen := tcell.NewSimulationScreen("")
+	app := tview.NewApplication()
+	app.SetScreen(screen)
+	ui := tui.NewUI(collector, false)
+	ui.SetApp(app)
+	ui.SetCadence(tui.Cadence{Network: time.Millisecond})
+
+	done := make(chan error)
+	go func() { done <- ui.Start(time.Second) }()
+	defer func() {
+		app.Stop()
+		assert.NoError(t, <-done)
+	}()
+
+	text := func(view func() *tview.TextView, stripTags bool) string {
+		text := make(chan string, 1)
+		app.QueueUpdate(func() { text <- view().GetText(stripTags) })
+		return <-text
+	}
+	shows := func(view func() *tview.TextView, want, notWant string) func() bool {
+		return func() bool {
+			got := text(view, true)
+			return strings.Contains(got, want) && (notWant == "" || !strings.Contains(got, notWant))
+		}
+	}
+	keys := func(s string) {
+		for _, r := range s {
+			screen.InjectKey(tcell.KeyRune, r, tcell.ModNone)
+		}
+	}
+
+	assert.Eventually(t, shows(ui.DiskView, "/boot", ""), 2*time.Second, 20*time.Millisecond)
+
+	keys("/a")
+	screen.InjectKey(tcell.KeyEnter, 0, tcell.ModNone)
+	assert.Eventually(t, shows(ui.DiskView, "▸ /data", "/boot"), 2*time.Second, 20*time.Millisecond,
+		"the first match is current")
+	assert.Contains(t, text(ui.DiskView, false), "/d[::r]a[::-]t[::r]a[::-]")
+	assert.Eventually(t, shows(ui.NetworkView, "wlan0", "eth0"), 2*time.Second, 20*time.Millisecond)
+
+	keys("n")
+	assert.Eventually(t, shows(ui.NetworkView, "▸ wl", ""), 2*time.Second, 20*time.Millisecond,
+		"'n' moves to the interface")
+	assert.Eventually(t, shows(ui.DiskView, "/data", "▸"), 2*time.Second, 20*time.Millisecond)
+	keys("n")
+	assert.Eventually(t, shows(ui.DiskView, "▸ /data", ""), 2*time.Second, 20*time.Millisecond,
+		"'n' wraps around")
+
+	screen.InjectKey(tcell.KeyEscape, 0, tcell.ModNone)
+	assert.Eventually(t, shows(ui.DiskView, "/boot", "▸"), 2*time.Second, 20*time.Millisecond,
+		"Esc clears the filter")
+	assert.Eventually(t, shows(ui.NetworkView, "eth0", ""), 2*time.Second, 20*time.Millisecond)
+}
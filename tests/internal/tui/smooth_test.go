@@ -0,0 +1,75 @@
+package tui_test
+
+import (
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/j-raghavan/godash/internal/metrics"
+	"github.com/j-raghavan/godash/internal/tui"
+)
+
+func TestEWMA(t *testing.T) {
+	start := time.Now()
+	avg := tui.EWMA{Window: time.Second}
+	assert.Equal(t, 0.0, avg.Update(0, start), "the first sample is taken as is")
+	assert.InDelta(t, 100*(1-math.Exp(-1)), avg.Update(100, start.Add(time.Second)), 1e-9,
+		"a step reaches 1-1/e of its height after one window")
+
+	// Ten samples 100ms apart weigh the same as one sample a second later
+	fast, slow := tui.EWMA{Window: time.Second}, tui.EWMA{Window: time.Second}
+	fast.Update(0, start)
+	slow.Update(0, start)
+	var got float64
+	for i := 1; i <= 10; i++ {
+		got = fast.Update(100, start.Add(time.Duration(i)*100*time.Millisecond))
+	}
+	assert.InDelta(t, slow.Update(100, start.Add(time.Second)), got, 1e-9)
+
+	off := tui.EWMA{}
+	off.Update(10, start)
+	assert.Equal(t, 90.0, off.Update(90, start.Add(time.Millisecond)), "no window disables smoothing")
+}
+
+// TestRenderSmoothed tests that the CPU usage shown is averaged over the
+// smoothing window
+func TestRenderSmoothed(t *testing.T) {
+	start := time.Now()
+	collector := &MockCollector{}
+	collector.On("Start", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		ch := args.Get(1).(chan<- metrics.Metric)
+		go func() {
+			ch <- metrics.Metric{Timestamp: start, CPU: []float64{0}, CPUTotal: 0}
+			ch <- metrics.Metric{Timestamp: start.Add(100 * time.Millisecond), CPU: []float64{100}, CPUTotal: 100}
+		}()
+	})
+
+	app := tview.NewApplication()
+	app.SetScreen(tcell.NewSimulationScreen(""))
+	ui := tui.NewUI(collector, false)
+	ui.SetApp(app)
+	ui.SetCadence(tui.Cadence{Smoothing: time.Second})
+
+	done := make(chan error)
+	go func() { done <- ui.Start(time.Second) }()
+	defer func() {
+		app.Stop()
+		assert.NoError(t, <-done)
+	}()
+
+	cpuText := func() string {
+		text := make(chan string, 1)
+		app.QueueUpdate(func() { text <- ui.CPUView().GetText(true) })
+		return <-text
+	}
+	// 100 * (1 - e^-0.1)
+	assert.Eventually(t, func() bool {
+		return strings.Contains(cpuText(), "Overall: 9.5%")
+	}, 2*time.Second, 20*time.Millisecond)
+}
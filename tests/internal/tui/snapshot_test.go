@@ -0,0 +1,52 @@
+package tui_test
+
+import (
+	"encoding/json"
+	"image/png"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/j-raghavan/godash/internal/metrics"
+	"github.com/j-raghavan/godash/internal/tui"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSaveSnapshot(t *testing.T) {
+	dir := t.TempDir()
+	start := time.Date(2026, 10, 16, 14, 0, 0, 0, time.UTC)
+	snapshot := tui.Snapshot{Metric: metrics.Metric{Timestamp: start, CPUTotal: 42}}
+	for i := 0; i < 60; i++ {
+		snapshot.History = append(snapshot.History, tui.HistoryPoint{
+			Time:          start.Add(time.Duration(i) * time.Second),
+			CPUPercent:    float64(i),
+			MemoryPercent: 50,
+			RxBytesPerSec: float64(i * 1000),
+		})
+	}
+
+	pngPath, jsonPath, err := tui.SaveSnapshot(dir, snapshot, start)
+	require.NoError(t, err)
+	assert.Equal(t, "godash-20261016-140000.png", pngPath[len(dir)+1:])
+
+	data, err := os.ReadFile(jsonPath)
+	require.NoError(t, err)
+	var saved tui.Snapshot
+	require.NoError(t, json.Unmarshal(data, &saved))
+	assert.Equal(t, 42.0, saved.Metric.CPUTotal)
+	assert.Len(t, saved.History, 60)
+
+	f, err := os.Open(pngPath)
+	require.NoError(t, err)
+	defer f.Close()
+	img, err := png.Decode(f)
+	require.NoError(t, err)
+	assert.Equal(t, 1200, img.Bounds().Dx())
+	assert.Equal(t, 600, img.Bounds().Dy())
+}
+
+func TestRenderChartEmptyHistory(t *testing.T) {
+	img := tui.RenderChart(nil)
+	assert.Equal(t, 1200, img.Bounds().Dx())
+}
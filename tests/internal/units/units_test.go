@@ -0,0 +1,92 @@
+package units_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/j-raghavan/godash/internal/units"
+)
+
+func TestParse(t *testing.T) {
+	f, err := units.Parse("", false)
+	assert.NoError(t, err)
+	assert.Equal(t, units.Format{}, f)
+
+	f, err = units.Parse("SI", true)
+	assert.NoError(t, err)
+	assert.Equal(t, units.Format{SI: true, Bits: true}, f)
+	assert.Equal(t, "si+bits", f.String())
+
+	_, err = units.Parse("metric", false)
+	assert.Error(t, err)
+}
+
+func TestBytes(t *testing.T) {
+	iec, si := units.Format{}, units.Format{SI: true}
+	tests := []struct {
+		bytes   float64
+		iec, si string
+	}{
+		{500, "500 B", "500 B"},
+		{1000, "1000 B", "1.0 kB"},
+		{1536, "1.5 KiB", "1.5 kB"},
+		{1 << 20, "1.0 MiB", "1.0 MB"},
+		{1e9, "953.7 MiB", "1.0 GB"},
+		{1 << 40, "1.0 TiB", "1.1 TB"},
+	}
+	for _, tc := range tests {
+		assert.Equal(t, tc.iec, iec.Bytes(tc.bytes))
+		assert.Equal(t, tc.si, si.Bytes(tc.bytes))
+	}
+}
+
+func TestCompact(t *testing.T) {
+	assert.Equal(t, "512B", units.Format{}.Compact(512))
+	assert.Equal(t, "1.5KB", units.Format{}.Compact(1536))
+	assert.Equal(t, "300KB", units.Format{}.Compact(300*1024))
+	assert.Equal(t, "1.5kB", units.Format{SI: true}.Compact(1500))
+	assert.Equal(t, "2.0GB", units.Format{SI: true}.Compact(2e9))
+}
+
+func TestRate(t *testing.T) {
+	assert.Equal(t, "1.5 MiB/s", units.Format{}.Rate(1.5*(1<<20)))
+	assert.Equal(t, "1.5 MB/s", units.Format{SI: true}.Rate(1.5e6))
+	// Bit rates use SI prefixes in either system
+	assert.Equal(t, "12.0 Mbit/s", units.Format{Bits: true}.Rate(1.5e6))
+	assert.Equal(t, "800 bit/s", units.Format{Bits: true}.Rate(100))
+	assert.Equal(t, "12Mb/s", units.Format{Bits: true}.CompactRate(1.5e6))
+	assert.Equal(t, "1.5KB/s", units.Format{}.CompactRate(1536))
+}
+
+func TestParseSize(t *testing.T) {
+	cases := map[string]float64{
+		"1024":  1024,
+		"512M":  512 << 20,
+		"1G":    1 << 30,
+		"1GiB":  1 << 30,
+		"1.5k":  1536,
+		"2tb":   2 << 40,
+		" 64K ": 64 << 10,
+		"-1G":   -(1 << 30),
+	}
+	for input, want := range cases {
+		got, err := units.ParseSize(input)
+		assert.NoError(t, err, input)
+		assert.Equal(t, want, got, input)
+	}
+
+	for _, input := range []string{"lots", "", "NaN", "infG"} {
+		_, err := units.ParseSize(input)
+		assert.Error(t, err, input)
+	}
+}
+
+func TestParseBytes(t *testing.T) {
+	got, err := units.ParseBytes("512M")
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(512<<20), got)
+
+	_, err = units.ParseBytes("-1G")
+	assert.Error(t, err, "negative sizes are rejected")
+}